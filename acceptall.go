@@ -0,0 +1,185 @@
+package mailify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AcceptAllBehavior is how a provider's mail servers answer RCPT TO for
+// mailboxes that may not exist.
+type AcceptAllBehavior string
+
+const (
+	// AcceptsAllRecipients means the servers accept every recipient and
+	// bounce later, if at all, so a mailbox check proves nothing. Addresses
+	// get VerdictRisky with SubStatusAcceptAllProvider.
+	AcceptsAllRecipients AcceptAllBehavior = "accept_all"
+	// BlocksVerification means the servers refuse or mislead verification,
+	// e.g. with policy errors for senders they don't know. Addresses get
+	// VerdictUnknown with SubStatusVerificationBlocked.
+	BlocksVerification AcceptAllBehavior = "block"
+	// VerifiesRecipients means the servers answer truthfully, so mailboxes
+	// are checked as usual. Use it to override a rule of the registry.
+	VerifiesRecipients AcceptAllBehavior = "verify"
+)
+
+// AcceptAllRule says how the mail servers of a domain or provider answer
+// mailbox checks, so addresses there are judged without an SMTP
+// conversation that would mislead. See DefaultAcceptAllRules.
+type AcceptAllRule struct {
+	// Match is a domain or mail server name, such as "pphosted.com", that
+	// matches itself and its subdomains.
+	Match string `json:"match"`
+	// Provider names who runs the mail servers, such as Proofpoint.
+	Provider string `json:"provider,omitempty"`
+	// Behavior is how the mail servers answer mailbox checks.
+	Behavior AcceptAllBehavior `json:"behavior"`
+	// Reason explains the behavior, in the results of addresses it applies to.
+	Reason string `json:"reason,omitempty"`
+}
+
+// defaultAcceptAllRules are the providers known to accept every recipient
+// or to block verification, by the names of their mail servers or domains.
+// Keep them to behavior seen across most of a provider's customers; single
+// domains that differ are for users to override.
+var defaultAcceptAllRules = []AcceptAllRule{
+	{Match: "pphosted.com", Provider: "Proofpoint", Behavior: AcceptsAllRecipients, Reason: "Proofpoint gateways usually accept every recipient and bounce later"},
+	{Match: "ppe-hosted.com", Provider: "Proofpoint", Behavior: AcceptsAllRecipients, Reason: "Proofpoint Essentials gateways usually accept every recipient and bounce later"},
+	{Match: "messagelabs.com", Provider: "Broadcom", Behavior: AcceptsAllRecipients, Reason: "Symantec Email Security.cloud gateways accept every recipient"},
+	{Match: "iphmx.com", Provider: "Cisco", Behavior: AcceptsAllRecipients, Reason: "Cisco Secure Email gateways usually accept every recipient"},
+	{Match: "mailcontrol.com", Provider: "Forcepoint", Behavior: AcceptsAllRecipients, Reason: "Forcepoint gateways accept every recipient"},
+	{Match: "yahoodns.net", Provider: "Yahoo", Behavior: AcceptsAllRecipients, Reason: "Yahoo accepts every recipient at RCPT TO and bounces later"},
+	{Match: "mimecast.com", Provider: "Mimecast", Behavior: BlocksVerification, Reason: "Mimecast gateways refuse or defer mailbox checks from senders they don't know"},
+	{Match: "barracudanetworks.com", Provider: "Barracuda", Behavior: BlocksVerification, Reason: "Barracuda gateways refuse or defer mailbox checks from senders they don't know"},
+}
+
+// DefaultAcceptAllRules returns the rules clients use unless
+// WithoutAcceptAllRules is given: Proofpoint, Broadcom, Cisco and Forcepoint
+// gateways and Yahoo accept every recipient, and Mimecast and Barracuda
+// gateways block verification.
+//
+// Returns:
+//   - []AcceptAllRule: A copy of the rules.
+func DefaultAcceptAllRules() []AcceptAllRule {
+	return append([]AcceptAllRule(nil), defaultAcceptAllRules...)
+}
+
+// WithAcceptAllRules adds rules to the client's accept-all registry. A rule
+// whose Match is the same as a shipped rule's replaces it, so a domain known
+// to verify truthfully behind a gateway can be given VerifiesRecipients.
+// Where several rules match, the longest Match wins.
+func WithAcceptAllRules(rules ...AcceptAllRule) Option {
+	return func(c *Client) {
+		for _, rule := range rules {
+			c.acceptAll[strings.ToLower(strings.TrimSuffix(rule.Match, "."))] = rule
+		}
+	}
+}
+
+// WithoutAcceptAllRules empties the client's accept-all registry, including
+// the shipped rules, so every mailbox is checked over SMTP. Rules added
+// after it with WithAcceptAllRules still apply.
+func WithoutAcceptAllRules() Option {
+	return func(c *Client) {
+		c.acceptAll = make(map[string]AcceptAllRule)
+	}
+}
+
+// checkAcceptAllRules checks that rules have a Match and a known Behavior.
+func checkAcceptAllRules(rules []AcceptAllRule) error {
+	for _, rule := range rules {
+		if strings.TrimSuffix(rule.Match, ".") == "" {
+			return errors.New("accept-all rule without a match")
+		}
+		switch rule.Behavior {
+		case AcceptsAllRecipients, BlocksVerification, VerifiesRecipients:
+		default:
+			return fmt.Errorf("accept-all rule for %s: unknown behavior %q, want accept_all, block or verify", rule.Match, rule.Behavior)
+		}
+	}
+	return nil
+}
+
+// newAcceptAllRegistry returns the registry clients start with, the
+// default rules by Match.
+func newAcceptAllRegistry() map[string]AcceptAllRule {
+	registry := make(map[string]AcceptAllRule)
+	for _, rule := range defaultAcceptAllRules {
+		registry[rule.Match] = rule
+	}
+	return registry
+}
+
+// AcceptAllRuleFor returns the rule of the client's accept-all registry that
+// applies to a domain: the one matching the domain itself, or else its
+// preferred mail server.
+//
+// Parameters:
+//   - domain: The domain.
+//   - mailServers: The domain's mail servers, in order of preference.
+//
+// Returns:
+//   - AcceptAllRule: The rule.
+//   - bool: False if no rule applies, or the one that does says the
+//     mail servers verify recipients.
+func (c *Client) AcceptAllRuleFor(domain string, mailServers []string) (AcceptAllRule, bool) {
+	rule, ok := matchSuffix(c.acceptAll, domain)
+	if !ok && len(mailServers) > 0 {
+		rule, ok = matchSuffix(c.acceptAll, mailServers[0])
+	}
+	if !ok || rule.Behavior == VerifiesRecipients {
+		return AcceptAllRule{}, false
+	}
+	return rule, true
+}
+
+// matchSuffix returns the entry of a registry keyed by name whose key is
+// the longest that name is or is a subdomain of.
+func matchSuffix[T any](registry map[string]T, name string) (T, bool) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for {
+		if entry, ok := registry[name]; ok {
+			return entry, true
+		}
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		name = parent
+	}
+}
+
+// acceptAllResult returns the result of validating an address at a domain
+// the accept-all registry has a rule for, nil if it has none.
+func (c *Client) acceptAllResult(domain string, mailServers []string) *ValidationResult {
+	rule, ok := c.AcceptAllRuleFor(domain, mailServers)
+	if !ok {
+		return nil
+	}
+	reason := rule.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("the accept-all registry lists %s", rule.Match)
+	}
+	if rule.Behavior == BlocksVerification {
+		return &ValidationResult{
+			Verdict:      VerdictUnknown,
+			SubStatus:    SubStatusVerificationBlocked,
+			IsValid:      false,
+			HasMX:        true,
+			ErrorMessage: "Provider blocks mailbox verification: " + reason,
+			Provenance:   &Provenance{Source: ProvenanceRules, Detail: rule.Match},
+		}
+	}
+	return &ValidationResult{
+		Verdict:            VerdictRisky,
+		SubStatus:          SubStatusAcceptAllProvider,
+		IsValid:            true,
+		IsCatchAll:         true,
+		CatchAllConfidence: ConfidenceMedium,
+		HasMX:              true,
+		ErrorMessage:       "Provider accepts every recipient, the mailbox can't be verified: " + reason,
+		Provenance:         &Provenance{Source: ProvenanceRules, Detail: rule.Match},
+	}
+}
@@ -0,0 +1,106 @@
+package mailify
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// Address is an email address along with the display name it was given with.
+type Address struct {
+	// Name is the display name, e.g. "Jane Doe", empty if there was none.
+	Name string
+	// Email is the address, normalized as by NormalizeEmail.
+	Email string
+}
+
+// String formats the address as an RFC 5322 name-addr, e.g.
+// "Jane Doe" <jane@example.com>, or as the bare address if it has no
+// display name.
+func (a Address) String() string {
+	if a.Name == "" {
+		return a.Email
+	}
+	return (&mail.Address{Name: a.Name, Address: a.Email}).String()
+}
+
+// ParseAddress parses an address that may carry a display name, as found in
+// mail headers and exported contact lists: a bare address such as
+// jane@example.com or <jane@example.com>, a name-addr such as
+// "Jane Doe" <jane@example.com> or Jane Doe <jane@example.com>, or an
+// address followed by a comment such as jane@example.com (Jane Doe).
+// RFC 2047 encoded display names are decoded.
+//
+// Parameters:
+//   - s: The address to parse.
+//
+// Returns:
+//   - Address: The display name and the normalized address.
+//   - error: An error describing why the address is invalid.
+func ParseAddress(s string) (Address, error) {
+	email, err := NormalizeEmail(s)
+	if err == nil {
+		return Address{Email: email}, nil
+	}
+
+	// Not a bare address, but it may be one with a display name. If it isn't
+	// that either, the bare address error says more about what's wrong
+	parsed, parseErr := mail.ParseAddress(s)
+	if parseErr != nil {
+		return Address{}, err
+	}
+	email, err = NormalizeEmail(parsed.Address)
+	if err != nil {
+		return Address{}, err
+	}
+	return Address{Name: parsed.Name, Email: email}, nil
+}
+
+// SplitAddresses splits a list of addresses separated by commas, semicolons
+// or line breaks, as often found in a single spreadsheet cell. Separators
+// inside quoted display names, comments or angle brackets, as in
+// "Doe, Jane" <jane@example.com>, don't split. Surrounding whitespace is
+// trimmed and empty entries are dropped. The addresses are not validated.
+//
+// Parameters:
+//   - list: The addresses to split.
+//
+// Returns:
+//   - []string: The addresses, nil if there are none.
+func SplitAddresses(list string) []string {
+	var addresses []string
+	var current strings.Builder
+	flush := func() {
+		if address := strings.TrimSpace(current.String()); address != "" {
+			addresses = append(addresses, address)
+		}
+		current.Reset()
+	}
+
+	quoted, escaped := false, false
+	comments, brackets := 0, 0
+	for _, r := range list {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			comments++
+		case r == ')' && comments > 0:
+			comments--
+		case r == '<':
+			brackets++
+		case r == '>' && brackets > 0:
+			brackets--
+		case comments == 0 && brackets == 0 && (r == ',' || r == ';' || r == '\n' || r == '\r'):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return addresses
+}
@@ -0,0 +1,285 @@
+package mailify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ListAnalysis describes a list of addresses before it is validated, as
+// AnalyzeList and AnalyzeFile gather it without any SMTP traffic or DNS
+// lookups, to gauge how long a run will take and how risky the list is.
+type ListAnalysis struct {
+	// Total is the number of addresses in the list, duplicates and invalid ones included.
+	Total int `json:"total"`
+	// SyntaxErrors is the number of addresses that aren't well formed.
+	SyntaxErrors int `json:"syntax_errors"`
+	// Duplicates is the number of well-formed addresses that repeat an earlier one.
+	Duplicates int `json:"duplicates"`
+	// Unique is the number of distinct well-formed addresses, the ones a run validates.
+	Unique int `json:"unique"`
+	// UniqueDomains is the number of distinct domains among them.
+	UniqueDomains int `json:"unique_domains"`
+	// Disposable, Free and Role count the distinct addresses at disposable
+	// domains, at free mailbox providers and of role accounts.
+	Disposable int `json:"disposable"`
+	Free       int `json:"free"`
+	Role       int `json:"role"`
+	// ExpectedProbes is the most RCPT TO commands a run sends: one per
+	// distinct address and, for each domain, the catch-all probes.
+	ExpectedProbes int `json:"expected_probes"`
+	// Domains break the distinct addresses down by domain, most first.
+	Domains []DomainAnalysis `json:"domains"`
+}
+
+// DomainAnalysis describes the addresses of one domain in a ListAnalysis.
+type DomainAnalysis struct {
+	// Domain is the lowercased domain.
+	Domain string `json:"domain"`
+	// Addresses is the number of distinct addresses at the domain.
+	Addresses int `json:"addresses"`
+	// Disposable indicates whether the domain is a disposable mailbox provider's.
+	Disposable bool `json:"disposable,omitempty"`
+	// Free indicates whether the domain is a free mailbox provider's.
+	Free bool `json:"free,omitempty"`
+	// ExpectedProbes is the most RCPT TO commands a run sends the domain's mail servers.
+	ExpectedProbes int `json:"expected_probes"`
+}
+
+// AnalyzeList describes a list of addresses without validating them: how
+// many are malformed or repeated, how many distinct domains they span, how
+// many are disposable, at free providers or role accounts, and how many
+// SMTP probes validating them takes, per domain. Nothing is sent over the
+// network, so it is safe to run before deciding whether to validate a list.
+//
+// Parameters:
+//   - emails: The addresses, which may carry display names.
+//
+// Returns:
+//   - *ListAnalysis: The description of the list. Probes are 0 unless the
+//     client is in ModeFull.
+func (c *Client) AnalyzeList(emails []string) *ListAnalysis {
+	analysis := &ListAnalysis{Total: len(emails)}
+	seen := make(map[string]bool)
+	domains := make(map[string]*DomainAnalysis)
+	for _, email := range emails {
+		if address, err := ParseAddress(email); err == nil {
+			email = address.Email
+		}
+		normalized, err := NormalizeEmail(email)
+		if err != nil {
+			analysis.SyntaxErrors++
+			continue
+		}
+		key := strings.ToLower(normalized)
+		if seen[key] {
+			analysis.Duplicates++
+			continue
+		}
+		seen[key] = true
+		analysis.Unique++
+
+		domain := emailDomain(normalized)
+		d, ok := domains[domain]
+		if !ok {
+			d = &DomainAnalysis{Domain: domain, Disposable: IsDisposableDomain(domain), Free: IsFreeProvider(domain)}
+			domains[domain] = d
+		}
+		d.Addresses++
+		switch {
+		case d.Disposable:
+			analysis.Disposable++
+		case d.Free:
+			analysis.Free++
+		}
+		if IsRoleAccount(normalized) {
+			analysis.Role++
+		}
+	}
+
+	for _, d := range domains {
+		if c.mode == ModeFull {
+			d.ExpectedProbes = d.Addresses + c.catchAllProbes
+		}
+		analysis.ExpectedProbes += d.ExpectedProbes
+		analysis.Domains = append(analysis.Domains, *d)
+	}
+	analysis.UniqueDomains = len(domains)
+	sort.Slice(analysis.Domains, func(i, j int) bool {
+		a, b := analysis.Domains[i], analysis.Domains[j]
+		if a.Addresses != b.Addresses {
+			return a.Addresses > b.Addresses
+		}
+		return a.Domain < b.Domain
+	})
+	return analysis
+}
+
+// AnalyzeFile describes the addresses in an Excel or CSV file like
+// AnalyzeList, reading them from the same column, in every sheet, that file
+// processing would validate. Other files are read as one address per line.
+//
+// Parameters:
+//   - path: The file to analyze.
+//   - opts: EmailColumn or Mapping, Encoding and MultiAddress say where the addresses
+//     are and how they are read, and SkipValidated and MaxAge which rows are
+//     left out, as for ProcessAndValidateEmailsViaFiles.
+//
+// Returns:
+//   - *ListAnalysis: The description of the list.
+//   - error: An error if the file can't be read or has no email column.
+func (c *Client) AnalyzeFile(path string, opts BulkOptions) (*ListAnalysis, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".xlsx", ".xlsm":
+		emails, _, err := listFileEmails(path, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return c.AnalyzeList(emails), nil
+	default:
+		lines, err := readTextLines(path, opts.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return c.AnalyzeList(lines), nil
+	}
+}
+
+// listFileEmails reads the addresses file processing would validate in an
+// Excel or CSV file, from every sheet, and counts the rows it would keep as
+// they are, see BulkOptions.SkipValidated.
+func listFileEmails(path string, opts BulkOptions) (emails []string, kept int, err error) {
+	var sheets [][][]string
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		records, _, err := readCSV(path, opts.Encoding)
+		if err != nil {
+			return nil, 0, err
+		}
+		sheets = append(sheets, records)
+	} else {
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		for _, sheet := range f.GetSheetList() {
+			if sheet == summarySheet {
+				continue
+			}
+			rows, err := f.GetRows(sheet)
+			if err != nil {
+				return nil, 0, fmt.Errorf("sheet %q: %w", sheet, err)
+			}
+			sheets = append(sheets, rows)
+		}
+	}
+
+	now := time.Now()
+	for _, rows := range sheets {
+		if len(rows) < 2 {
+			continue
+		}
+		headers := make(map[string]int)
+		for i, cell := range rows[0] {
+			headers[headerKey(cell)] = i
+		}
+		col, err := resultEmailColumn(rows, headers, opts.emailColumnName())
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, row := range rows[1:] {
+			if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			if opts.SkipValidated && alreadyValidated(row, headers, opts.MaxAge, now) {
+				kept++
+				continue
+			}
+			if opts.MultiAddress == MultiAddressOff {
+				emails = append(emails, strings.TrimSpace(row[col]))
+			} else {
+				emails = append(emails, SplitAddresses(row[col])...)
+			}
+		}
+	}
+	return emails, kept, nil
+}
+
+// readTextLines reads the non-empty lines of a text file, trimmed.
+func readTextLines(path string, enc TextEncoding) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	r, err := NewTextReader(f, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return lines, nil
+}
+
+// EstimateDuration estimates how long validating the list takes with the
+// given bulk options, if validating an address takes perAddress: the longer
+// of working through every address at opts.Concurrency, and working through
+// the largest domain within opts.DomainConcurrency and opts.DomainInterval.
+//
+// Parameters:
+//   - opts: The options the list will be validated with.
+//   - perAddress: How long validating one address takes, e.g. from an earlier run.
+//
+// Returns:
+//   - time.Duration: The estimate, 0 if nothing is to be probed.
+func (a *ListAnalysis) EstimateDuration(opts BulkOptions, perAddress time.Duration) time.Duration {
+	if a.ExpectedProbes == 0 {
+		return 0
+	}
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	batch := opts.BatchSize
+	if batch < 2 {
+		batch = 1
+	}
+
+	// Batched addresses take one validation between them
+	validations := 0
+	var longest time.Duration
+	for _, d := range a.Domains {
+		n := (d.Addresses + batch - 1) / batch
+		validations += n
+		perDomain := time.Duration(n) * perAddress
+		if opts.DomainConcurrency > 0 {
+			perDomain = time.Duration((n+opts.DomainConcurrency-1)/opts.DomainConcurrency) * perAddress
+		}
+		if paced := time.Duration(n-1)*opts.DomainInterval + perAddress; opts.DomainInterval > 0 && paced > perDomain {
+			perDomain = paced
+		}
+		if perDomain > longest && (opts.DomainConcurrency > 0 || opts.DomainInterval > 0) {
+			longest = perDomain
+		}
+	}
+	overall := time.Duration((validations+workers-1)/workers) * perAddress
+	if longest > overall {
+		return longest
+	}
+	return overall
+}
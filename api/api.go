@@ -0,0 +1,332 @@
+// Package api describes mailify's REST API, as the server package serves
+// it: the requests and responses of each route as Go types, and the OpenAPI
+// document generated from them, to generate clients in other languages
+// with. Go clients can decode responses into the types directly:
+//
+//	resp, err := http.Get("http://localhost:8080/domains/example.com")
+//	...
+//	var report api.DomainReport
+//	err = json.NewDecoder(resp.Body).Decode(&report)
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// Error is what the API answers with when a request fails.
+type Error struct {
+	// Error says what went wrong.
+	Error string `json:"error"`
+}
+
+// ValidationResult is what GET /validate answers with: the result of
+// validating an address.
+type ValidationResult = mailify.ValidationResult
+
+// DomainReport is what GET /domains/{domain} answers with: the health
+// report of the domain's mail setup.
+type DomainReport = mailify.DomainInfo
+
+// Health is what GET /healthz answers with while the server is up.
+type Health struct {
+	// Status is "ok".
+	Status string `json:"status"`
+}
+
+// Readiness is what GET /readyz answers with: whether the server can
+// validate addresses, with the checks of what validation depends on.
+type Readiness = mailify.Readiness
+
+// JobUpload is the multipart/form-data request POST /jobs takes.
+type JobUpload struct {
+	// File is the CSV (.csv) or Excel (.xlsx) file to validate.
+	File []byte `json:"file"`
+	// EmailColumn is the header of the column holding the addresses. If
+	// empty, the column is detected.
+	EmailColumn string `json:"email_column,omitempty"`
+}
+
+// Job states, as JobStatus.Status reports them.
+const (
+	// JobRunning means the job is still validating the file.
+	JobRunning = "running"
+	// JobPaused means the job is paused, see POST /jobs/{id}/pause.
+	JobPaused = "paused"
+	// JobDone means the job has validated the file.
+	JobDone = "done"
+	// JobFailed means the job has stopped on an error, see JobStatus.Error.
+	JobFailed = "failed"
+	// JobCancelled means the job was cancelled before it validated the whole
+	// file, see POST /jobs/{id}/cancel. The file keeps the results it has.
+	JobCancelled = "cancelled"
+)
+
+// JobStatus is what POST /jobs, GET /jobs/{id} and the routes controlling a
+// job answer with: the state of a bulk job and, once it has finished, its
+// counts.
+type JobStatus struct {
+	// ID is the job's ID.
+	ID string `json:"id"`
+	// Status is JobRunning, JobPaused, JobDone, JobCancelled or JobFailed.
+	Status string `json:"status"`
+	// File is the name of the uploaded file.
+	File string `json:"file"`
+	// Validated is the number of addresses validated so far.
+	Validated int `json:"validated"`
+	// Summary holds the counts of the job once it has finished.
+	Summary *mailify.BulkSummary `json:"summary,omitempty"`
+	// Error is why the job failed, if it did.
+	Error string `json:"error,omitempty"`
+	// Created is when the file was uploaded.
+	Created time.Time `json:"created"`
+	// Finished is when the job finished, if it has.
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// JobResult is a line of the JSON lines GET /jobs/{id}/results answers
+// with: the outcome of validating one address of the file.
+type JobResult struct {
+	// Email is the address, as it was in the file.
+	Email string `json:"email"`
+	// Result is the validation result, if validation completed.
+	Result *mailify.ValidationResult `json:"result,omitempty"`
+	// Error is why validation failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Route describes one of the API's routes.
+type Route struct {
+	// Method is the HTTP method, such as GET.
+	Method string
+	// Path is the path, with parameters in braces like /domains/{domain}.
+	Path string
+	// OperationID names the route, for generated clients.
+	OperationID string
+	// Summary says what the route does.
+	Summary string
+	// Params describe the parameters in Path and the query.
+	Params []Param
+	// Request describes the request body, if the route takes one.
+	Request *Body
+	// Responses describe the responses, by status.
+	Responses []Response
+	// Public indicates whether the route is served without an API key when
+	// the server has tenants.
+	Public bool
+}
+
+// Param describes a parameter of a route.
+type Param struct {
+	// Name is the parameter's name.
+	Name string
+	// In is where the parameter is: "path", the default, or "query".
+	In string
+	// Description says what it is.
+	Description string
+	// Optional indicates whether the parameter may be left out. Path
+	// parameters never may.
+	Optional bool
+}
+
+// Body describes a request body.
+type Body struct {
+	// ContentType is the media type of the body.
+	ContentType string
+	// Type is a value of the Go type the body encodes, such as JobUpload{}.
+	Type any
+}
+
+// Response describes a response of a route.
+type Response struct {
+	// Status is the HTTP status.
+	Status int
+	// Description says when the route answers with it.
+	Description string
+	// ContentType is the media type of the body, empty if it has none.
+	ContentType string
+	// Type is a value of the Go type the body encodes, nil for any JSON.
+	Type any
+	// Headers name the headers of note the response carries, with what they say.
+	Headers map[string]string
+}
+
+// Routes are the API's routes. The server serves exactly these, and the
+// OpenAPI document describes them.
+var Routes = []Route{
+	{
+		Method:      http.MethodGet,
+		Path:        "/validate",
+		OperationID: "validateEmail",
+		Summary:     "Validate an address: its syntax, its domain's mail servers and, unless the server is in a mode without SMTP, its mailbox",
+		Params: []Param{
+			{Name: "email", In: "query", Description: "The address, such as jane@example.com"},
+			{
+				Name: "max_age", In: "query", Optional: true,
+				Description: "The oldest cached result to accept, as a duration such as 24h or in seconds; 0, the default, accepts any result still in the cache, and -1 always validates again",
+			},
+			{
+				Name: "timeout", In: "query", Optional: true,
+				Description: "The most time the validation may take, as a duration such as 10s or in seconds, in place of the server's; once it is up, the result is unknown with the timeout sub-status",
+			},
+		},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The result, whatever the verdict", ContentType: "application/json", Type: ValidationResult{}},
+			{Status: http.StatusBadRequest, Description: "No address was given", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusInternalServerError, Description: "The address couldn't be validated", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusForbidden, Description: "The tenant may not validate addresses at the domain", ContentType: "application/json", Type: Error{}},
+			{
+				Status: http.StatusTooManyRequests, Description: "Too many validations are in flight, overall or for the client", ContentType: "application/json", Type: Error{},
+				Headers: map[string]string{"Retry-After": "Seconds to wait before trying again"},
+			},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/domains/{domain}",
+		OperationID: "getDomain",
+		Summary:     "Report on a domain's mail setup: its mail servers, provider, SPF, DMARC, catch-all status if known and blocklist status",
+		Params:      []Param{{Name: "domain", Description: "The domain, such as example.com"}},
+		Responses: []Response{
+			{
+				Status: http.StatusOK, Description: "The domain's report", ContentType: "application/json", Type: DomainReport{},
+				Headers: map[string]string{
+					"Cache-Control": "How long the report may be cached",
+					"ETag":          "The report's version, for If-None-Match",
+					"Last-Modified": "When the report was made",
+				},
+			},
+			{Status: http.StatusNotModified, Description: "The report named by If-None-Match is current"},
+			{Status: http.StatusBadRequest, Description: "The domain is invalid", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusForbidden, Description: "The tenant may not validate addresses at the domain", ContentType: "application/json", Type: Error{}},
+			{
+				Status: http.StatusTooManyRequests, Description: "Too many validations are in flight, overall or for the client", ContentType: "application/json", Type: Error{},
+				Headers: map[string]string{"Retry-After": "Seconds to wait before trying again"},
+			},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/jobs",
+		OperationID: "createJob",
+		Summary:     "Start a bulk job validating the addresses of a CSV or Excel file",
+		Request:     &Body{ContentType: "multipart/form-data", Type: JobUpload{}},
+		Responses: []Response{
+			{
+				Status: http.StatusAccepted, Description: "The job has started", ContentType: "application/json", Type: JobStatus{},
+				Headers: map[string]string{"Location": "The job's URL"},
+			},
+			{Status: http.StatusBadRequest, Description: "No file was uploaded, or not a CSV or Excel file", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusRequestEntityTooLarge, Description: "The file is larger than the server accepts", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusForbidden, Description: "The file has addresses at domains the tenant may not validate addresses at", ContentType: "application/json", Type: Error{}},
+			{
+				Status: http.StatusTooManyRequests, Description: "Too many validations are in flight, overall or for the client", ContentType: "application/json", Type: Error{},
+				Headers: map[string]string{"Retry-After": "Seconds to wait before trying again"},
+			},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/jobs/{id}",
+		OperationID: "getJob",
+		Summary:     "Report the state of a bulk job",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The job's state", ContentType: "application/json", Type: JobStatus{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/jobs/{id}/file",
+		OperationID: "getJobFile",
+		Summary:     "Download the file of a finished bulk job, with the result columns added",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The file", ContentType: "application/octet-stream", Type: []byte{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusConflict, Description: "The job is still running", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/jobs/{id}/results",
+		OperationID: "getJobResults",
+		Summary:     "Download the results of a finished bulk job as JSON lines, one JobResult per address",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The results", ContentType: "application/x-ndjson", Type: JobResult{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusConflict, Description: "The job is still running", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/jobs/{id}/pause",
+		OperationID: "pauseJob",
+		Summary:     "Pause a bulk job: validations in progress finish, and no new ones start until it is resumed",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The job's state, paused", ContentType: "application/json", Type: JobStatus{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusConflict, Description: "The job has finished", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/jobs/{id}/resume",
+		OperationID: "resumeJob",
+		Summary:     "Resume a paused bulk job",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The job's state, running", ContentType: "application/json", Type: JobStatus{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusConflict, Description: "The job has finished", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Path:        "/jobs/{id}/cancel",
+		OperationID: "cancelJob",
+		Summary:     "Cancel a bulk job, paused or not: validations in progress finish, and the file is saved with the results it has",
+		Params:      []Param{{Name: "id", Description: "The job's ID"}},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The job's state, still running until the validations in progress finish", ContentType: "application/json", Type: JobStatus{}},
+			{Status: http.StatusNotFound, Description: "There is no such job", ContentType: "application/json", Type: Error{}},
+			{Status: http.StatusConflict, Description: "The job has finished", ContentType: "application/json", Type: Error{}},
+		},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/healthz",
+		OperationID: "getHealth",
+		Summary:     "Report that the server is up, for liveness probes",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The server is up", ContentType: "application/json", Type: Health{}},
+		},
+		Public: true,
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		OperationID: "getReadiness",
+		Summary:     "Check that the server can validate: that its DNS resolver answers, its cache works and outbound SMTP isn't blocked, for readiness probes",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Every check passed", ContentType: "application/json", Type: Readiness{}},
+			{Status: http.StatusServiceUnavailable, Description: "A check failed", ContentType: "application/json", Type: Readiness{}},
+		},
+		Public: true,
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/openapi.json",
+		OperationID: "getOpenAPI",
+		Summary:     "The OpenAPI document of the API",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The document", ContentType: "application/json"},
+		},
+		Public: true,
+	},
+}
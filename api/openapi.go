@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// schema is a JSON schema, as OpenAPI 3.0 documents hold them.
+type schema = map[string]any
+
+// knownSchemas are the schemas of types that don't encode as reflection
+// suggests, or whose values are worth listing.
+var knownSchemas = map[reflect.Type]schema{
+	reflect.TypeOf(time.Time{}):          {"type": "string", "format": "date-time"},
+	reflect.TypeOf(time.Duration(0)):     {"type": "integer", "format": "int64", "description": "Nanoseconds"},
+	reflect.TypeOf([]byte(nil)):          {"type": "string", "format": "binary"},
+	reflect.TypeOf(mailify.StatusCode{}): {"type": "string", "description": "Enhanced status code, such as 5.1.1"},
+	reflect.TypeOf(mailify.Verdict("")): {"type": "string", "enum": []string{
+		string(mailify.VerdictDeliverable), string(mailify.VerdictUndeliverable),
+		string(mailify.VerdictRisky), string(mailify.VerdictUnknown),
+	}},
+	reflect.TypeOf(mailify.Confidence("")): {"type": "string", "enum": []string{
+		string(mailify.ConfidenceHigh), string(mailify.ConfidenceMedium), string(mailify.ConfidenceLow),
+	}},
+	reflect.TypeOf(mailify.Timings{}): {
+		"type":        "object",
+		"description": "Time spent in each stage of validation, in milliseconds",
+		"properties": schema{
+			"dns_ms": integerSchema, "connect_ms": integerSchema, "tls_ms": integerSchema,
+			"helo_ms": integerSchema, "mail_ms": integerSchema, "rcpt_ms": integerSchema,
+			"total_ms": integerSchema,
+		},
+	},
+}
+
+// integerSchema is the schema of integers.
+var integerSchema = schema{"type": "integer"}
+
+// OpenAPI generates the OpenAPI 3.0 document of the API from Routes and the
+// Go types of their requests and responses.
+//
+// Parameters:
+//   - version: The version of the API to put in the document, such as mailify.Version().
+//
+// Returns:
+//   - []byte: The document, as JSON.
+//   - error: An error if the document can't be encoded.
+func OpenAPI(version string) ([]byte, error) {
+	components := make(schema)
+	paths := make(schema)
+	for _, route := range Routes {
+		responses := route.Responses
+		operation := schema{
+			"operationId": route.OperationID,
+			"summary":     route.Summary,
+		}
+		if !route.Public {
+			// Servers with tenants ask for an API key, others don't
+			operation["security"] = []schema{{"apiKey": []string{}}, {"bearerAuth": []string{}}, {}}
+			responses = append(responses[:len(responses):len(responses)], Response{
+				Status: http.StatusUnauthorized, Description: "The server has tenants, and no valid API key was given",
+				ContentType: "application/json", Type: Error{},
+			})
+		}
+		operation["responses"] = responsesOf(responses, components)
+		var params []schema
+		for _, param := range route.Params {
+			in := param.In
+			if in == "" {
+				in = "path"
+			}
+			params = append(params, schema{
+				"name":        param.Name,
+				"in":          in,
+				"required":    !param.Optional,
+				"description": param.Description,
+				"schema":      schema{"type": "string"},
+			})
+		}
+		if params != nil {
+			operation["parameters"] = params
+		}
+		if route.Request != nil {
+			operation["requestBody"] = schema{
+				"required": true,
+				"content":  schema{route.Request.ContentType: schema{"schema": schemaOf(reflect.TypeOf(route.Request.Type), components)}},
+			}
+		}
+		path, ok := paths[route.Path].(schema)
+		if !ok {
+			path = make(schema)
+			paths[route.Path] = path
+		}
+		path[strings.ToLower(route.Method)] = operation
+	}
+
+	doc := schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":       "mailify",
+			"description": "Email validation and domain checks over HTTP, as mailify serve serves them",
+			"version":     version,
+		},
+		"paths": paths,
+		"components": schema{
+			"schemas": components,
+			"securitySchemes": schema{
+				"apiKey":     schema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearerAuth": schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return out, nil
+}
+
+// responsesOf returns the responses object of a route.
+func responsesOf(responses []Response, components schema) schema {
+	out := make(schema)
+	for _, response := range responses {
+		description := response.Description
+		if description == "" {
+			description = http.StatusText(response.Status)
+		}
+		r := schema{"description": description}
+		if response.ContentType != "" {
+			s := schema{}
+			if response.Type != nil {
+				s = schemaOf(reflect.TypeOf(response.Type), components)
+			}
+			r["content"] = schema{response.ContentType: schema{"schema": s}}
+		}
+		if len(response.Headers) > 0 {
+			headers := make(schema)
+			for name, description := range response.Headers {
+				headers[name] = schema{"description": description, "schema": schema{"type": "string"}}
+			}
+			r["headers"] = headers
+		}
+		out[strconv.Itoa(response.Status)] = r
+	}
+	return out
+}
+
+// schemaOf returns the schema of the JSON encoding of a Go type. Named
+// structs are added to components and referred to.
+func schemaOf(t reflect.Type, components schema) schema {
+	if s, ok := knownSchemas[t]; ok {
+		return s
+	}
+	if t.Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()) {
+		return schema{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), components)
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return integerSchema
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": schemaOf(t.Elem(), components)}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": schemaOf(t.Elem(), components)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, components)
+		}
+		if _, ok := components[t.Name()]; !ok {
+			// Set first, for types that refer to themselves
+			components[t.Name()] = schema{}
+			components[t.Name()] = structSchema(t, components)
+		}
+		return schema{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return schema{}
+	}
+}
+
+// structSchema returns the schema of a struct, with the fields encoding/json
+// encodes. Fields that aren't omitted when empty are required.
+func structSchema(t reflect.Type, components schema) schema {
+	properties := make(schema)
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, components)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	s := schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
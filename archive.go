@@ -0,0 +1,172 @@
+package mailify
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxArchiveMemberSize is the largest file extracted from a ZIP archive,
+// guarding against archives that decompress to far more than they hold.
+const maxArchiveMemberSize = 1 << 30
+
+// listExtensions are the file types bulk file processing reads, by extension.
+var listExtensions = []string{".xlsx", ".csv", ".zip"}
+
+// isListFile reports whether a file is of a type bulk file processing reads,
+// leaving out the lock files Excel keeps next to open workbooks.
+func isListFile(name string) bool {
+	base := path.Base(filepath.ToSlash(name))
+	return !strings.HasPrefix(base, "~$") && containsString(listExtensions, strings.ToLower(path.Ext(base)))
+}
+
+// processFile validates the addresses in an Excel, CSV or ZIP file.
+func (c *Client) processFile(filename string, opts BulkOptions) (BulkSummary, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return c.processCSVFile(filename, opts)
+	case ".zip":
+		return c.processZipFile(filename, opts)
+	default:
+		return c.processExcelFile(filename, opts)
+	}
+}
+
+// processZipFile validates the addresses in every Excel and CSV file of a ZIP
+// archive and writes the archive, with the results in those files, next to
+// it: lists.zip becomes lists.validated.zip. Other files are copied as they
+// are, as are the files that fail to process. If the run is interrupted, the
+// archive is written with the results so far, the files not yet processed
+// copied as they are, and ErrInterrupted returned.
+func (c *Client) processZipFile(filename string, opts BulkOptions) (BulkSummary, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	fmt.Printf("Successfully opened ZIP archive: %s\n", filename)
+
+	tmpDir, err := os.MkdirTemp("", "mailify-zip-*")
+	if err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The archive is written under a temporary name first, so an archive
+	// under the final name is always complete
+	outName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".validated.zip"
+	out, err := os.CreateTemp(filepath.Dir(outName), ".mailify-*.zip")
+	if err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	out.Chmod(0o644)
+	zw := zip.NewWriter(out)
+
+	var summary BulkSummary
+	var errs []error
+	interrupted := false
+	for i, member := range r.File {
+		// Nested archives aren't opened, nor are the resource forks macOS adds
+		ext := strings.ToLower(path.Ext(member.Name))
+		if interrupted || member.FileInfo().IsDir() || ext == ".zip" || !isListFile(member.Name) || strings.HasPrefix(member.Name, "__MACOSX/") {
+			if err := zw.Copy(member); err != nil {
+				return BulkSummary{}, fmt.Errorf("failed to copy %s: %w", member.Name, err)
+			}
+			continue
+		}
+
+		// Members are extracted under a name of our own, as theirs may
+		// try to escape the directory
+		local := filepath.Join(tmpDir, fmt.Sprintf("%d%s", i, ext))
+		memberSummary, err := c.processZipMember(member, local, opts)
+		if errors.Is(err, ErrInterrupted) {
+			interrupted = true
+			errs = append(errs, err)
+		} else if err != nil {
+			fmt.Printf("ERROR: %s: %v\n", member.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", member.Name, err))
+			if err := zw.Copy(member); err != nil {
+				return BulkSummary{}, fmt.Errorf("failed to copy %s: %w", member.Name, err)
+			}
+			continue
+		}
+		if err := addZipMember(zw, member, local); err != nil {
+			return BulkSummary{}, err
+		}
+		memberSummary.print("Summary for " + member.Name)
+		summary.add(memberSummary)
+	}
+
+	if err := zw.Close(); err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(out.Name(), outName); err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to write archive: %w", err)
+	}
+	fmt.Printf("Results have been written to: %s\n", outName)
+	return summary, errors.Join(errs...)
+}
+
+// processZipMember extracts a member of an archive to local and validates
+// the addresses in it.
+func (c *Client) processZipMember(member *zip.File, local string, opts BulkOptions) (BulkSummary, error) {
+	if err := extractZipMember(member, local); err != nil {
+		return BulkSummary{}, err
+	}
+	return c.processFile(local, opts)
+}
+
+// extractZipMember extracts a member of an archive to local.
+func extractZipMember(member *zip.File, local string) error {
+	rc, err := member.Open()
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	defer rc.Close()
+
+	f, err := os.Create(local)
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	n, err := io.Copy(f, io.LimitReader(rc, maxArchiveMemberSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	if n > maxArchiveMemberSize {
+		return fmt.Errorf("file is larger than %d bytes", maxArchiveMemberSize)
+	}
+	return nil
+}
+
+// addZipMember adds a processed file to an archive under the name and
+// modification time of the member it came from.
+func addZipMember(zw *zip.Writer, member *zip.File, local string) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", member.Name, err)
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: member.Name, Method: zip.Deflate, Modified: member.Modified})
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", member.Name, err)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("failed to add %s: %w", member.Name, err)
+	}
+	return nil
+}
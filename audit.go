@@ -0,0 +1,212 @@
+package mailify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditRecord records a single validation for later dispute resolution, e.g.
+// with an ESP questioning why an address was suppressed: what was asked,
+// the verdict, the SMTP evidence behind it and the configuration it was
+// reached with.
+type AuditRecord struct {
+	// Time is when the validation finished, in UTC.
+	Time time.Time `json:"time"`
+	// Email is the address as it was given, redacted if WithPIIMode is given.
+	Email string `json:"email"`
+	// Verdict, SubStatus and Confidence repeat the result's, for sinks that index them.
+	Verdict    Verdict    `json:"verdict"`
+	SubStatus  SubStatus  `json:"sub_status,omitempty"`
+	Confidence Confidence `json:"confidence,omitempty"`
+	// Result is the full result, including the SMTP server, its banner,
+	// extensions and the reply's message, and the timings.
+	Result *ValidationResult `json:"result,omitempty"`
+	// Error is the error validation returned, if any.
+	Error string `json:"error,omitempty"`
+	// Version is the mailify version that ran.
+	Version string `json:"version"`
+	// Config is the client's configuration. Bulk settings are not recorded
+	// per validation, so they are left zero.
+	Config ManifestConfig `json:"config"`
+}
+
+// AuditSink stores AuditRecords. Implementations must be safe for
+// concurrent use, as bulk runs record from several goroutines.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// WithAuditSink records every validation, including those a hook ended
+// early, in sink. A record that can't be stored prints a warning but doesn't
+// fail the validation. If sink implements io.Closer, Close closes it.
+func WithAuditSink(sink AuditSink) Option {
+	return func(c *Client) {
+		c.auditSink = sink
+	}
+}
+
+// audit records a finished validation in the audit sink, if there is one.
+func (c *Client) audit(email string, result *ValidationResult, err error) {
+	if c.auditSink == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:    time.Now().UTC(),
+		Email:   c.RedactEmail(email),
+		Version: Version(),
+		Config:  c.manifestConfig(BulkOptions{}),
+	}
+	if result != nil {
+		record.Result = c.redactResult(result)
+		record.Verdict = result.Verdict
+		record.SubStatus = result.SubStatus
+		record.Confidence = result.Confidence
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if err := c.auditSink.Record(context.Background(), record); err != nil {
+		fmt.Printf("Warning: audit record failed: %v\n", err)
+	}
+}
+
+// closeAuditSink closes the audit sink if it can be closed.
+func (c *Client) closeAuditSink() {
+	if closer, ok := c.auditSink.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			fmt.Printf("Warning: closing audit sink failed: %v\n", err)
+		}
+	}
+}
+
+// FileAuditSink appends AuditRecords to a file as JSON lines.
+type FileAuditSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileAuditSink opens path for appending records, creating it if needed.
+//
+// Parameters:
+//   - path: The file to append to.
+//
+// Returns:
+//   - *FileAuditSink: The sink, which must be closed when done.
+//   - error: An error if the file can't be opened.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditSink{f: f}, nil
+}
+
+// Record implements AuditSink. Each record is written with a single write,
+// so lines from concurrent processes appending to the same file don't mix.
+func (s *FileAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// SQLAuditSink inserts AuditRecords into a database table through
+// database/sql, with whichever driver the application imports. The table
+// needs the columns CreateTable creates.
+type SQLAuditSink struct {
+	// DB is the database to insert into.
+	DB *sql.DB
+	// Table is the table's name, "mailify_audit" if empty.
+	Table string
+	// Numbered uses $1, $2… placeholders, as PostgreSQL wants, instead of ?.
+	Numbered bool
+}
+
+// tableName matches the table names SQLAuditSink accepts, as the name can't
+// be passed as a query parameter.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// table returns the table's name, checked so it can go in a statement.
+func (s *SQLAuditSink) table() (string, error) {
+	if s.Table == "" {
+		return "mailify_audit", nil
+	}
+	if !tableName.MatchString(s.Table) {
+		return "", fmt.Errorf("invalid audit table name %q", s.Table)
+	}
+	return s.Table, nil
+}
+
+// CreateTable creates the table if it doesn't exist, with a column for each
+// field sinks are likely to be queried by and the whole record as JSON.
+//
+// Parameters:
+//   - ctx: Cancels the statement.
+//
+// Returns:
+//   - error: An error if the table can't be created.
+func (s *SQLAuditSink) CreateTable(ctx context.Context) error {
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+	recorded_at TIMESTAMP NOT NULL,
+	email TEXT NOT NULL,
+	verdict TEXT NOT NULL,
+	sub_status TEXT NOT NULL,
+	confidence TEXT NOT NULL,
+	error TEXT NOT NULL,
+	record TEXT NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return nil
+}
+
+// Record implements AuditSink.
+func (s *SQLAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	placeholders := make([]string, 7)
+	for i := range placeholders {
+		placeholders[i] = "?"
+		if s.Numbered {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		}
+	}
+	query := `INSERT INTO ` + table + ` (recorded_at, email, verdict, sub_status, confidence, error, record) VALUES (` + strings.Join(placeholders, ", ") + `)`
+	_, err = s.DB.ExecContext(ctx, query, record.Time, record.Email, string(record.Verdict),
+		string(record.SubStatus), string(record.Confidence), record.Error, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to insert audit record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package mailify
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rcptMax returns the most recipients the server accepts per transaction, as
+// advertised with LIMITS RCPTMAX (RFC 9422), or 0 if it doesn't say.
+func (s *smtpSession) rcptMax() int {
+	ok, params := s.extension("LIMITS")
+	if !ok {
+		return 0
+	}
+	for _, param := range strings.Fields(params) {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.EqualFold(name, "RCPTMAX") {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// isTooManyRecipients reports whether a reply to RCPT TO means the transaction
+// has hit the server's recipient limit rather than anything about the recipient.
+func isTooManyRecipients(code int, msg string) bool {
+	return code == 452 && (strings.Contains(msg, "4.5.3") || strings.Contains(strings.ToLower(msg), "too many recipients"))
+}
+
+// tryConnectingSMTPBatch checks several recipients on one server using as few
+// MAIL transactions as the server's recipient limit allows, instead of one
+// per recipient, pipelining each transaction if the server supports it. A recipient's result is nil if it couldn't be probed, either
+// because the connection failed or the transaction ended early; the caller is
+// expected to check those recipients one by one. Errors are returned per
+// recipient alongside deferrals, as tryConnectingSMTP does. The conversation
+// is cut short at deadline, unless it is zero. MAIL FROM is sent with sender.
+func (c *Client) tryConnectingSMTPBatch(smtpDetails *SMTPDetails, sender string, recipients []string, localName string, useTLS, reuse bool, deadline time.Time) ([]*ValidationResult, []error) {
+	results := make([]*ValidationResult, len(recipients))
+	errs := make([]error, len(recipients))
+
+	key := sessionKey(smtpDetails, useTLS)
+
+	var session *smtpSession
+	if reuse {
+		session = c.sessions.get(key)
+	}
+	var sessionTimings Timings
+	if session != nil {
+		session.setDeadline(deadline)
+	} else {
+		var err error
+		session, err = c.openSMTPSession(smtpDetails, localName, useTLS, &sessionTimings, deadline)
+		if err != nil {
+			return results, errs
+		}
+	}
+
+	smtpDetails.Banner = session.banner
+	smtpDetails.UsedTLS = session.tls
+	smtpDetails.Extensions = session.ext
+
+	limit := session.rcptMax()
+	healthy := true
+	for next := 0; next < len(recipients) && healthy; {
+		// One transaction per batch of recipients the server accepts at once
+		end := len(recipients)
+		if limit > 0 && next+limit < end {
+			end = next + limit
+		}
+		mailReply, rcptReplies, err := session.transaction(sender, recipients[next:end])
+		if mailReply.err != nil {
+			healthy = false
+			break
+		}
+
+		probed := 0
+		for _, r := range rcptReplies {
+			if isTooManyRecipients(r.code, r.msg) {
+				break
+			}
+			result := &ValidationResult{IsValid: false, HasMX: true, Timings: sessionTimings}
+			result.Timings.Mail = mailReply.took
+			result.Timings.Rcpt = r.took
+			results[next], errs[next] = interpretRcpt(result, r.code, r.msg, r.err)
+			next++
+			probed++
+		}
+
+		if err != nil || probed == 0 || session.reset() != nil {
+			healthy = false
+		}
+	}
+
+	// Probe for catch-all once for the whole batch, in a transaction of its own
+	// so the probes aren't cut off by the recipient limit
+	var accepted bool
+	for _, result := range results {
+		accepted = accepted || (result != nil && result.Verdict == VerdictDeliverable)
+	}
+	if healthy && accepted && c.catchAllProbes > 0 {
+		domain := emailDomain(recipients[0])
+		record, known := c.CatchAllRecord(domain)
+		confidence := record.Confidence
+		var shortfall *budgetShortfall
+		if !known {
+			probes := c.probeAddresses(domain)
+			if shortfall = c.reserveProbes(domain, len(probes)); shortfall == nil {
+				mailReply, replies, err := session.transaction(sender, probes)
+				if err == nil && mailReply.err == nil {
+					confidence = catchAllConfidence(replies)
+					c.rememberCatchAll(domain, confidence)
+				}
+				if err != nil || session.reset() != nil {
+					healthy = false
+				}
+			}
+		}
+		for _, result := range results {
+			if result != nil {
+				applyCatchAll(result, confidence)
+				applyBudget(result, shortfall)
+			}
+		}
+	}
+
+	if healthy && reuse {
+		session.setDeadline(time.Time{})
+		c.sessions.put(key, smtpDetails, session)
+	} else {
+		session.quit()
+		session.close()
+	}
+	return results, errs
+}
+
+// validateBatch validates addresses that share a domain. Every address goes
+// through the stages up to the SMTP check on its own, then the ones that get
+// that far are checked together in shared MAIL transactions. Recipients the
+// shared transactions give no definite answer for are checked one by one.
+//
+// Parameters:
+//   - emails: The addresses to validate, usually of one domain.
+//   - sender: The MAIL FROM address, the client's if empty.
+//   - maxAge: The oldest cached result to accept, see ValidateEmailMaxAge.
+//   - timeout: The most time each validation may take, the client's if 0.
+//
+// Returns:
+//   - []*ValidationResult: The result for each address, nil if it failed.
+//   - []error: The error for each address, if any.
+func (c *Client) validateBatch(emails []string, sender string, maxAge, timeout time.Duration) ([]*ValidationResult, []error) {
+	results := make([]*ValidationResult, len(emails))
+	errs := make([]error, len(emails))
+	vs := make([]*validation, len(emails))
+	starts := make([]time.Time, len(emails))
+
+	// Run the stages before SMTP, grouping what's left by mail servers
+	groups := make(map[string][]int)
+	var order []string
+	servers := make(map[string][]string)
+	for i, email := range emails {
+		if c.closed.Load() {
+			errs[i] = ErrClientClosed
+			continue
+		}
+		vs[i] = &validation{reuse: true, sender: sender, maxAge: maxAge, timeout: timeout}
+		starts[i] = time.Now()
+		c.startClock(vs[i], starts[i])
+
+		result := c.startHooks(email)
+		if result == nil {
+			result = c.cachedResult(email, vs[i])
+		}
+		var mailServers []string
+		if result == nil {
+			mailServers, result = c.prepare(email, vs[i])
+			result = c.fallback(vs[i], result)
+		}
+		if result != nil {
+			results[i] = result
+			c.finish(email, vs[i], starts[i], result)
+			c.audit(email, result, nil)
+			continue
+		}
+
+		group := strings.Join(mailServers, ",")
+		if _, ok := groups[group]; !ok {
+			order = append(order, group)
+			servers[group] = mailServers
+		}
+		groups[group] = append(groups[group], i)
+	}
+
+	for _, group := range order {
+		indexes := groups[group]
+		mailServers := servers[group]
+		stageStart := time.Now()
+
+		recipients := make([]string, len(indexes))
+		for n, i := range indexes {
+			recipients[n] = vs[i].email
+		}
+		batchResults := c.checkMailboxes(recipients, mailServers, vs[indexes[0]])
+
+		for n, i := range indexes {
+			result, err := batchResults[n], error(nil)
+			if result == nil {
+				// No definite answer from the shared transaction, check on its own
+				result, err = c.checkMailbox(vs[i].email, mailServers, vs[i])
+			}
+			result.Provenance = smtpProvenance(result)
+			results[i] = c.fallback(vs[i], c.smtpStageDone(vs[i].email, mailServers, stageStart, result, err))
+			c.finish(emails[i], vs[i], starts[i], results[i])
+			c.audit(emails[i], results[i], nil)
+		}
+	}
+
+	return results, errs
+}
+
+// checkMailboxes checks several recipients against the first mail server that
+// can be reached, in shared MAIL transactions. Port discovery timings are
+// added to v. Recipients without a definite answer get a nil result.
+func (c *Client) checkMailboxes(recipients []string, mailServers []string, v *validation) []*ValidationResult {
+	results := make([]*ValidationResult, len(recipients))
+
+	localName, err := c.GetHostname()
+	if err != nil {
+		return results
+	}
+
+	for _, mailServer := range c.orderMailServers(mailServers) {
+		if v.expired() {
+			return results
+		}
+		if c.tarpitting(mailServer) {
+			continue
+		}
+		serverStart := time.Now()
+		smtpServer := c.sessions.server(mailServer, false)
+		if smtpServer == nil {
+			err = c.withRetry(v.deadline, func(attempt int) error {
+				var err error
+				smtpServer, err = c.getSMTPServer(mailServer, &v.timings, v.deadline)
+				return err
+			})
+			if err != nil {
+				if !errors.Is(err, ErrBogonMX) {
+					c.recordMX(mailServer, false, time.Since(serverStart))
+				}
+				continue
+			}
+		}
+
+		release, err := c.politely(mailServer, v.deadline)
+		if err != nil {
+			return results
+		}
+		batchResults, batchErrs := c.tryConnectingSMTPBatch(smtpServer, c.mailFrom(v, mailServer), recipients, localName, false, true, v.deadline)
+		release()
+		answered := false
+		for _, result := range batchResults {
+			answered = answered || result != nil
+		}
+		c.recordMX(mailServer, answered, time.Since(serverStart))
+		for n, result := range batchResults {
+			// Deferrals are left to the single recipient path, which retries them
+			if result != nil && batchErrs[n] == nil {
+				details := *smtpServer
+				result.SMTPDetails = &details
+				results[n] = result
+			}
+		}
+		return results
+	}
+	return results
+}
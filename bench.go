@@ -0,0 +1,298 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBenchmarkDomains are the domains Benchmark samples if none are
+// given, covering the largest mailbox providers.
+var DefaultBenchmarkDomains = []string{
+	"gmail.com",
+	"outlook.com",
+	"yahoo.com",
+	"icloud.com",
+	"aol.com",
+	"gmx.com",
+	"proton.me",
+	"zoho.com",
+}
+
+// BenchmarkOptions configures Benchmark.
+type BenchmarkOptions struct {
+	// Samples is how many times each domain's DNS lookup and SMTP connection
+	// are timed, 3 if 0.
+	Samples int
+	// Concurrency is how many SMTP sessions are opened at once in the
+	// throughput test, 8 if 0.
+	Concurrency int
+	// Sessions is how many SMTP sessions the throughput test opens in all,
+	// spread over the domains that could be reached, 5 per worker if 0.
+	Sessions int
+}
+
+// LatencyStats summarizes the timings of repeated attempts.
+type LatencyStats struct {
+	// Samples is how many attempts succeeded.
+	Samples int `json:"samples"`
+	// Min, Median and Max are the fastest, middle and slowest of them.
+	Min    time.Duration `json:"min"`
+	Median time.Duration `json:"median"`
+	Max    time.Duration `json:"max"`
+}
+
+// newLatencyStats summarizes the given timings.
+func newLatencyStats(samples []time.Duration) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyStats{
+		Samples: len(sorted),
+		Min:     sorted[0],
+		Median:  sorted[len(sorted)/2],
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+// DomainBenchmark is how fast a domain's mail could be reached from this host.
+type DomainBenchmark struct {
+	// Domain is the domain that was sampled.
+	Domain string `json:"domain"`
+	// Provider names who runs the domain's mail, guessed from its mail server.
+	Provider string `json:"provider,omitempty"`
+	// MailServer is the mail server connected to, the domain's first MX.
+	MailServer string `json:"mail_server,omitempty"`
+	// Port is the SMTP port that accepted connections.
+	Port string `json:"port,omitempty"`
+	// DNS times the MX lookups.
+	DNS LatencyStats `json:"dns"`
+	// Connect times the TCP connections.
+	Connect LatencyStats `json:"connect"`
+	// Session times the whole session setup: connecting, the greeting and EHLO.
+	Session LatencyStats `json:"session"`
+	// Error is why the domain couldn't be sampled in full, if it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+// BenchmarkReport is the outcome of Benchmark.
+type BenchmarkReport struct {
+	// Domains holds a benchmark per sampled domain, in the order given.
+	Domains []DomainBenchmark `json:"domains"`
+	// Concurrency is how many sessions the throughput test opened at once.
+	Concurrency int `json:"concurrency"`
+	// Sessions is how many sessions the throughput test opened, and Failed
+	// how many of them failed.
+	Sessions int `json:"sessions"`
+	Failed   int `json:"failed"`
+	// Duration is how long the throughput test took.
+	Duration time.Duration `json:"duration"`
+	// SessionsPerSecond is how many sessions were set up each second at
+	// Concurrency, a ceiling on validations per second from this host.
+	SessionsPerSecond float64 `json:"sessions_per_second"`
+}
+
+// Benchmark measures how fast mail servers can be reached from this host, to
+// help size Concurrency before a large run: the latency of MX lookups, of
+// connecting to each domain's mail server and of setting up an SMTP session,
+// and how many sessions can be set up per second with several at once.
+// Sessions are closed after EHLO, so no mailbox is ever asked about.
+//
+// Parameters:
+//   - ctx: Stops the benchmark early; what was measured so far is reported.
+//   - domains: The domains to sample, DefaultBenchmarkDomains if empty.
+//   - opts: Options controlling how much is sampled.
+//
+// Returns:
+//   - BenchmarkReport: The measurements.
+//   - error: An error if no domain's mail server could be reached.
+func (c *Client) Benchmark(ctx context.Context, domains []string, opts BenchmarkOptions) (BenchmarkReport, error) {
+	if len(domains) == 0 {
+		domains = DefaultBenchmarkDomains
+	}
+	samples := opts.Samples
+	if samples < 1 {
+		samples = 3
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 8
+	}
+	sessions := opts.Sessions
+	if sessions < 1 {
+		sessions = 5 * concurrency
+	}
+	localName, _ := c.GetHostname()
+
+	// Latencies are measured one domain at a time, so they don't skew each other
+	report := BenchmarkReport{Concurrency: concurrency}
+	var servers []*SMTPDetails
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			break
+		}
+		bench, server := c.benchmarkDomain(ctx, domain, samples, localName)
+		report.Domains = append(report.Domains, bench)
+		if server != nil {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return report, errors.New("no mail server could be reached")
+	}
+
+	// Throughput, with the sessions spread over the servers in turn
+	jobs := make(chan *SMTPDetails)
+	var failed int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for server := range jobs {
+				if _, err := c.benchmarkSession(server, localName); err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	sent := 0
+	for ; sent < sessions && ctx.Err() == nil; sent++ {
+		details := *servers[sent%len(servers)]
+		jobs <- &details
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Sessions = sent
+	report.Failed = failed
+	report.Duration = time.Since(start)
+	if report.Duration > 0 {
+		report.SessionsPerSecond = float64(sent-failed) / report.Duration.Seconds()
+	}
+	return report, nil
+}
+
+// benchmarkDomain times a domain's MX lookups and SMTP connections. It also
+// returns the server it connected to, or nil if it couldn't.
+func (c *Client) benchmarkDomain(ctx context.Context, domain string, samples int, localName string) (DomainBenchmark, *SMTPDetails) {
+	bench := DomainBenchmark{Domain: domain}
+
+	// The MX cache is bypassed, it's the resolver being measured
+	var dnsTimes []time.Duration
+	var mailServers []string
+	for i := 0; i < samples && ctx.Err() == nil; i++ {
+		start := time.Now()
+		mx, err := c.resolver.LookupMX(ctx, domain)
+		if err != nil {
+			bench.Error = fmt.Sprintf("MX lookup failed: %v", err)
+			continue
+		}
+		dnsTimes = append(dnsTimes, time.Since(start))
+		if len(mailServers) == 0 {
+			for _, record := range mx {
+				mailServers = append(mailServers, strings.TrimSuffix(record.Host, "."))
+			}
+		}
+	}
+	bench.DNS = newLatencyStats(dnsTimes)
+	if len(mailServers) == 0 {
+		if bench.Error == "" {
+			bench.Error = "no MX records found"
+		}
+		return bench, nil
+	}
+	bench.Error = ""
+	bench.MailServer = mailServers[0]
+	bench.Provider = mailProvider(bench.MailServer)
+
+	server, err := c.GetSMTPServer(bench.MailServer)
+	if err != nil {
+		bench.Error = err.Error()
+		return bench, nil
+	}
+	bench.Port = server.Port
+
+	var connectTimes, sessionTimes []time.Duration
+	for i := 0; i < samples && ctx.Err() == nil; i++ {
+		details := *server
+		timings, err := c.benchmarkSession(&details, localName)
+		if err != nil {
+			bench.Error = err.Error()
+			continue
+		}
+		connectTimes = append(connectTimes, timings.Connect)
+		sessionTimes = append(sessionTimes, timings.Connect+timings.TLS+timings.HELO)
+	}
+	bench.Connect = newLatencyStats(connectTimes)
+	bench.Session = newLatencyStats(sessionTimes)
+	if len(sessionTimes) == 0 {
+		return bench, nil
+	}
+	return bench, server
+}
+
+// benchmarkSession sets up an SMTP session up to EHLO and closes it with
+// QUIT, returning how long each step took.
+func (c *Client) benchmarkSession(server *SMTPDetails, localName string) (Timings, error) {
+	var timings Timings
+	session, err := c.openSMTPSession(server, localName, false, &timings, time.Time{})
+	if err != nil {
+		return timings, err
+	}
+	session.quit()
+	session.close()
+	return timings, nil
+}
+
+// mailProviders maps the domains of well-known providers' mail servers to
+// the providers' names.
+var mailProviders = []struct{ suffix, name string }{
+	{"google.com", "Google"},
+	{"googlemail.com", "Google"},
+	{"outlook.com", "Microsoft"},
+	{"hotmail.com", "Microsoft"},
+	{"yahoodns.net", "Yahoo"},
+	{"icloud.com", "Apple"},
+	{"me.com", "Apple"},
+	{"aol.com", "Yahoo"},
+	{"gmx.net", "GMX"},
+	{"gmx.com", "GMX"},
+	{"protonmail.ch", "Proton"},
+	{"zoho.com", "Zoho"},
+	{"zoho.eu", "Zoho"},
+	{"mimecast.com", "Mimecast"},
+	{"pphosted.com", "Proofpoint"},
+	{"messagelabs.com", "Broadcom"},
+	{"barracudanetworks.com", "Barracuda"},
+	{"yandex.net", "Yandex"},
+	{"mail.ru", "Mail.ru"},
+	{"fastmail.com", "Fastmail"},
+	{"messagingengine.com", "Fastmail"},
+}
+
+// mailProvider guesses who runs a mail server from its name, falling back
+// to the last two labels of the name.
+func mailProvider(mailServer string) string {
+	host := strings.ToLower(strings.TrimSuffix(mailServer, "."))
+	for _, p := range mailProviders {
+		if host == p.suffix || strings.HasSuffix(host, "."+p.suffix) {
+			return p.name
+		}
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) > 2 {
+		labels = labels[len(labels)-2:]
+	}
+	return strings.Join(labels, ".")
+}
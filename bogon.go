@@ -0,0 +1,67 @@
+package mailify
+
+import (
+	"errors"
+	"net"
+)
+
+// ErrBogonMX is returned when a mail server only resolves to addresses that
+// can't be reached over the internet, such as loopback or RFC 1918 addresses.
+var ErrBogonMX = errors.New("mail server has no public address")
+
+// bogonNetworks are the reserved networks net.IP has no method for.
+var bogonNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",       // "this" network
+		"100.64.0.0/10",   // carrier-grade NAT
+		"192.0.0.0/24",    // IETF protocol assignments
+		"192.0.2.0/24",    // documentation
+		"198.18.0.0/15",   // benchmarking
+		"198.51.100.0/24", // documentation
+		"203.0.113.0/24",  // documentation
+		"240.0.0.0/4",     // reserved, including broadcast
+		"100::/64",        // discard
+		"2001:db8::/32",   // documentation
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// WithAllowBogonMX lets validation connect to mail servers that resolve to
+// loopback, private or otherwise reserved addresses. By default such servers
+// are skipped, and a domain whose mail servers all resolve to them gets
+// SubStatusBogonMX instead of timing out, as they are a common spam-trap and
+// misconfiguration pattern. Allow them when validating addresses of internal
+// mail servers, or in tests.
+func WithAllowBogonMX() Option {
+	return func(c *Client) {
+		c.allowBogonMX = true
+	}
+}
+
+// isBogon reports whether ip can't be reached over the internet.
+func isBogon(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range bogonNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIPs returns the addresses in ips that can be reached over the internet.
+func publicIPs(ips []net.IP) []net.IP {
+	var public []net.IP
+	for _, ip := range ips {
+		if !isBogon(ip) {
+			public = append(public, ip)
+		}
+	}
+	return public
+}
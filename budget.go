@@ -0,0 +1,163 @@
+package mailify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeBudgetTTL is how long a domain's count of a day's probes is kept in
+// the cache, long enough to outlast the day.
+const probeBudgetTTL = 48 * time.Hour
+
+// ProbeBudget caps the SMTP probes a client sends, so a runaway job can't
+// damage the sending IP's reputation. Every address checked over SMTP is a
+// probe, and so is every made-up mailbox of a catch-all probe. Past a cap,
+// addresses aren't checked and come back VerdictUnknown with
+// SubStatusBudgetExhausted, and so do accepted addresses whose catch-all
+// probes don't fit in what is left.
+type ProbeBudget struct {
+	// PerDomainPerDay is the most probes of one domain's mail servers in a
+	// UTC day, 0 for no cap. With WithCache, the day's counts are kept in the
+	// cache, so they hold across runs, and across processes sharing the
+	// cache but for probes sent at the same moment.
+	PerDomainPerDay int `json:"per_domain_per_day,omitempty"`
+	// PerRun is the most probes the client sends in its lifetime, 0 for no
+	// cap. It suits a client made for one run, such as a CLI invocation; a
+	// long-lived client, such as a server's, stops probing for good once it
+	// is spent.
+	PerRun int `json:"per_run,omitempty"`
+}
+
+// WithProbeBudget caps the SMTP probes the client sends per domain per day
+// and in all. Addresses past the daily cap come back deferred, with
+// RetryAfter set to when the next day starts.
+func WithProbeBudget(budget ProbeBudget) Option {
+	return func(c *Client) {
+		c.budget = &probeBudget{limits: budget, days: make(map[string]*domainDay)}
+	}
+}
+
+// probeBudget counts the probes a client sends against its ProbeBudget.
+type probeBudget struct {
+	limits ProbeBudget
+
+	mu sync.Mutex
+	// spent is how many probes the client has sent in its lifetime.
+	spent int
+	// days holds how many probes each domain's mail servers got today, by domain.
+	days map[string]*domainDay
+}
+
+// domainDay is how many probes a domain's mail servers got in a day.
+type domainDay struct {
+	day   string
+	spent int
+}
+
+// probeBudgetKey is the cache key of how many probes a domain's mail servers
+// got in a day.
+func probeBudgetKey(domain, day string) string {
+	return "probebudget:" + strings.ToLower(domain) + ":" + day
+}
+
+// dayOf returns a domain's count of the probes of day, loading it from
+// cache the first time. It must be called with b.mu held.
+func (b *probeBudget) dayOf(cache Cache, domain, day string) *domainDay {
+	d, ok := b.days[domain]
+	if ok && d.day == day {
+		return d
+	}
+	d = &domainDay{day: day}
+	if cache != nil {
+		if data, ok := cache.Get(probeBudgetKey(domain, day)); ok {
+			d.spent, _ = strconv.Atoi(string(data))
+		}
+	}
+	b.days[domain] = d
+	return d
+}
+
+// spend counts n probes of a domain's mail servers, keeping the day's count
+// in cache. It must be called with b.mu held.
+func (b *probeBudget) spend(cache Cache, domain string, n int) {
+	b.spent += n
+	if b.limits.PerDomainPerDay <= 0 {
+		return
+	}
+	day := time.Now().UTC().Format(time.DateOnly)
+	d := b.dayOf(cache, domain, day)
+	d.spent += n
+	if cache != nil {
+		cache.Set(probeBudgetKey(domain, day), []byte(strconv.Itoa(d.spent)), probeBudgetTTL)
+	}
+}
+
+// budgetShortfall says why n more probes of a domain's mail servers don't fit
+// in the client's budget, and after how long they might, 0 if not before the
+// next run.
+type budgetShortfall struct {
+	reason     string
+	retryAfter time.Duration
+}
+
+// reserveProbes spends n probes of a domain's mail servers if the client's
+// budget allows them, and otherwise returns why it doesn't.
+func (c *Client) reserveProbes(domain string, n int) *budgetShortfall {
+	if c.budget == nil || n <= 0 {
+		return nil
+	}
+	domain = strings.ToLower(domain)
+	b := c.budget
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limits.PerRun > 0 && b.spent+n > b.limits.PerRun {
+		return &budgetShortfall{reason: fmt.Sprintf("the run's budget of %d probes is spent", b.limits.PerRun)}
+	}
+	if b.limits.PerDomainPerDay > 0 {
+		now := time.Now().UTC()
+		if b.dayOf(c.cache, domain, now.Format(time.DateOnly)).spent+n > b.limits.PerDomainPerDay {
+			tomorrow := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			return &budgetShortfall{
+				reason:     fmt.Sprintf("today's budget of %d probes of %s is spent", b.limits.PerDomainPerDay, domain),
+				retryAfter: tomorrow.Sub(now),
+			}
+		}
+	}
+	b.spend(c.cache, domain, n)
+	return nil
+}
+
+// budgetResult spends a probe of a domain's mail servers on checking an
+// address, and returns nil if the client's budget allows it, or else the
+// result to end validation with.
+func (c *Client) budgetResult(domain string) *ValidationResult {
+	shortfall := c.reserveProbes(domain, 1)
+	if shortfall == nil {
+		return nil
+	}
+	return &ValidationResult{
+		Verdict:      VerdictUnknown,
+		SubStatus:    SubStatusBudgetExhausted,
+		IsValid:      false,
+		HasMX:        true,
+		ErrorMessage: "SMTP check skipped, " + shortfall.reason,
+		RetryAfter:   shortfall.retryAfter,
+		Provenance:   &Provenance{Source: ProvenanceRules, Detail: "probe budget"},
+	}
+}
+
+// applyBudget turns the result of an accepted address unknown if the
+// catch-all probes that would tell whether that means anything didn't fit
+// in the budget.
+func applyBudget(result *ValidationResult, shortfall *budgetShortfall) {
+	if shortfall == nil || result.Verdict != VerdictDeliverable {
+		return
+	}
+	result.Verdict = VerdictUnknown
+	result.SubStatus = SubStatusBudgetExhausted
+	result.ErrorMessage = "Catch-all check skipped, " + shortfall.reason
+	result.RetryAfter = shortfall.retryAfter
+}
@@ -0,0 +1,83 @@
+package mailify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/mailifytest"
+)
+
+func TestProbeBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget mailify.ProbeBudget
+		emails []string
+		// exhausted are the indexes of the addresses the budget stops.
+		exhausted []int
+		// retry is whether stopped addresses say when to retry.
+		retry bool
+	}{
+		{
+			name:      "per run",
+			budget:    mailify.ProbeBudget{PerRun: 2},
+			emails:    []string{"a@example.com", "b@example.org", "c@example.com", "d@example.org"},
+			exhausted: []int{2, 3},
+		},
+		{
+			name:      "per domain per day",
+			budget:    mailify.ProbeBudget{PerDomainPerDay: 2},
+			emails:    []string{"a@example.com", "b@example.com", "c@example.com", "d@example.org"},
+			exhausted: []int{2},
+			retry:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mailifytest.NewServer()
+			defer srv.Close()
+			c := newTestClient(t, srv, mailify.WithCatchAllProbes(0), mailify.WithProbeBudget(tt.budget))
+
+			exhausted := make(map[int]bool)
+			for _, i := range tt.exhausted {
+				exhausted[i] = true
+			}
+			for i, email := range tt.emails {
+				result, err := c.ValidateEmail(email)
+				if err != nil {
+					t.Fatalf("ValidateEmail(%q): %v", email, err)
+				}
+				if got := result.SubStatus == mailify.SubStatusBudgetExhausted; got != exhausted[i] {
+					t.Errorf("%s: budget exhausted = %v, want %v", email, got, exhausted[i])
+				}
+				if exhausted[i] && (result.RetryAfter > 0) != tt.retry {
+					t.Errorf("%s: retry after %v, want a retry delay: %v", email, result.RetryAfter, tt.retry)
+				}
+			}
+			if got, want := len(rcpts(srv)), len(tt.emails)-len(tt.exhausted); got != want {
+				t.Errorf("server got %d RCPT TO commands, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestProbeBudgetSharedThroughCache(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	cache := mailify.NewMemoryCache()
+	budget := mailify.WithProbeBudget(mailify.ProbeBudget{PerDomainPerDay: 1})
+
+	first := newTestClient(t, srv, mailify.WithCatchAllProbes(0), mailify.WithCache(cache), budget)
+	if result, _ := first.ValidateEmail("a@example.com"); result.SubStatus == mailify.SubStatusBudgetExhausted {
+		t.Fatal("first probe of the day was stopped")
+	}
+
+	second := newTestClient(t, srv, mailify.WithCatchAllProbes(0), mailify.WithCache(cache), budget)
+	result, _ := second.ValidateEmail("b@example.com")
+	if result.SubStatus != mailify.SubStatusBudgetExhausted {
+		t.Errorf("second client's probe = %s, want it stopped by the day's count in the cache", result.SubStatus)
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > 24*time.Hour {
+		t.Errorf("retry after %v, want it before the next day starts", result.RetryAfter)
+	}
+}
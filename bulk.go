@@ -0,0 +1,326 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BulkOptions configures a bulk validation run.
+type BulkOptions struct {
+	// Concurrency is the number of addresses validated at once. Values below 1 mean 1.
+	// It is ignored when Adaptive is set.
+	Concurrency int
+	// Adaptive, if set, lets an AIMD controller pick the concurrency instead,
+	// backing off when servers start deferring or dropping connections.
+	Adaptive *AdaptiveConcurrency
+	// DomainConcurrency caps how many addresses of the same domain are validated
+	// at once. 0 means no cap beyond the overall concurrency.
+	DomainConcurrency int
+	// DomainInterval is the minimum time between starting validations for
+	// addresses of the same domain. 0 means no rate limit.
+	DomainInterval time.Duration
+	// BatchSize is the most addresses of one domain checked in a single SMTP
+	// MAIL transaction, with one RCPT TO each. Values below 2 check every
+	// address in its own transaction. A batch counts as one validation
+	// towards DomainConcurrency and DomainInterval.
+	BatchSize int
+	// Priority, if set, is called once for each address as the run starts,
+	// and the addresses it reports true for, e.g. the newest signups, are
+	// validated first. The rest are a background lane that workers only take
+	// from while no priority address may start, such as when the domains of
+	// those left are at DomainConcurrency.
+	Priority func(email string) bool
+	// Sender, if set, is the MAIL FROM address of the run in place of the
+	// client's, see ValidateEmailFrom. If it is unusable, every address comes
+	// back with the *SenderError.
+	Sender string
+	// Dedupe, if set, skips addresses it has seen before, in this run or in
+	// earlier ones given the same Deduper, e.g. the chunks of a list too large
+	// to validate at once. Skipped addresses come back with ErrDuplicate.
+	// Addresses are compared without display names and case-insensitively.
+	Dedupe Deduper
+	// Prefetch, if above 0, is how many DNS lookups are made at once in a
+	// pass before validating, which looks up the MX records of every domain
+	// in the run and the addresses of their mail servers. Validations then
+	// find them in the cache instead of stalling on DNS. It takes WithMXCache,
+	// which keeps what the pass looks up, and is skipped in ModeOffline.
+	Prefetch int
+	// Pause, if set, pauses and resumes the run. Validations already
+	// started finish while it is paused.
+	Pause *PauseSwitch
+	// Context, if set, stops the run once it is done, e.g. on Ctrl+C: no new
+	// validations start, those already started finish, and the addresses
+	// left come back with ErrInterrupted. File processing still saves the
+	// results it has, leaving the rows of the addresses left blank.
+	Context context.Context
+	// Stats, if set, tracks the run's progress, rate and ETA as it goes.
+	Stats *RunStats
+	// OnResult, if set, is called as each address finishes validating. Calls
+	// are made one at a time, in completion order.
+	OnResult func(BulkResult)
+	// EmailColumn is the header of the column holding the addresses in file
+	// processing, such as ProcessAndValidateEmailsViaExcelWithOptions. If
+	// empty, the column is detected, see DetectEmailColumn. ValidateBulk
+	// ignores it.
+	EmailColumn string
+	// Mapping, if set, describes the columns of the files in file processing:
+	// where the address and the contact's name are, and which result columns
+	// to write, see ColumnMapping. ValidateBulk ignores it.
+	Mapping *ColumnMapping
+	// Encoding is the character encoding of CSV files in file processing,
+	// detected if empty, see NewTextReader. ValidateBulk ignores it.
+	Encoding TextEncoding
+	// MultiAddress says how file processing, such as
+	// ProcessAndValidateEmailsViaExcelWithOptions, treats cells holding several
+	// addresses. ValidateBulk ignores it.
+	MultiAddress MultiAddressMode
+	// SkipValidated, if set, makes file processing validate only the rows
+	// without a verdict from an earlier run, so a huge sheet can be topped up
+	// with the rows added since. The rest are left as they are and counted as
+	// BulkSummary.Kept. ValidateBulk ignores it.
+	SkipValidated bool
+	// MaxAge, with SkipValidated, validates rows again whose results are
+	// older than it, by their validated_at column. Rows without a date count
+	// as too old. It is also the oldest result taken from the cache of
+	// WithResultCache, see ValidateEmailMaxAge. 0 keeps results however old
+	// they are.
+	MaxAge time.Duration
+	// Timeout, if set, is the most time each validation may take, in place of
+	// the client's, see ValidateEmailWithTimeout.
+	Timeout time.Duration
+	// Notifiers are sent a JobReport when a job finishes: file processing,
+	// each file WatchDirectory processes, and SyncList. ValidateBulk ignores them.
+	Notifiers []Notifier
+}
+
+// emailColumnName returns the header of the column holding the addresses in
+// file processing, EmailColumn or else the mapping's, empty if the column is
+// to be detected.
+func (o BulkOptions) emailColumnName() string {
+	if o.EmailColumn == "" && o.Mapping != nil {
+		return o.Mapping.Email
+	}
+	return o.EmailColumn
+}
+
+// interrupted reports whether the run's Context is done.
+func (o BulkOptions) interrupted() bool {
+	return o.Context != nil && o.Context.Err() != nil
+}
+
+// MultiAddressMode says how cells holding several addresses, separated by
+// commas, semicolons or line breaks (see SplitAddresses), are processed.
+type MultiAddressMode int
+
+const (
+	// MultiAddressOff validates each cell as a single address, so cells with
+	// several addresses come out as invalid. This is the default.
+	MultiAddressOff MultiAddressMode = iota
+	// MultiAddressAggregate validates each address in a cell and writes the
+	// results to the cell's row, one value per address separated by "; ".
+	MultiAddressAggregate
+	// MultiAddressExplode gives each address in a cell a row of its own, a copy
+	// of the original row, and writes each address's result to its row.
+	MultiAddressExplode
+)
+
+// ParseMultiAddressMode parses "off", "aggregate" or "explode".
+//
+// Parameters:
+//   - name: The name of the mode.
+//
+// Returns:
+//   - MultiAddressMode: The mode.
+//   - bool: Whether the name is known.
+func ParseMultiAddressMode(name string) (MultiAddressMode, bool) {
+	switch name {
+	case "off", "":
+		return MultiAddressOff, true
+	case "aggregate":
+		return MultiAddressAggregate, true
+	case "explode":
+		return MultiAddressExplode, true
+	}
+	return MultiAddressOff, false
+}
+
+// ErrInterrupted is returned for the addresses a bulk run didn't get to
+// because BulkOptions.Context was done, and by file processing for files it
+// saved only in part.
+var ErrInterrupted = errors.New("bulk run interrupted")
+
+// BulkResult holds the outcome of validating one address in a bulk run.
+type BulkResult struct {
+	// Index is the position of the address in the input.
+	Index int
+	// Email is the address that was validated.
+	Email string
+	// Result is the validation result, if validation completed.
+	Result *ValidationResult
+	// Err is the error returned by ValidateEmail, if any.
+	Err error
+}
+
+// ValidateBulk validates a list of email addresses concurrently.
+//
+// Work is partitioned by recipient domain into per-domain queues, each with its own
+// concurrency cap and rate limit. Workers stick to a domain while it has addresses
+// left, reusing the same SMTP session for them instead of reconnecting for every
+// address. Idle sessions are closed once the run finishes. With BatchSize set,
+// several addresses of a domain are checked in one MAIL transaction, cutting
+// the cost per address further. Options left zero come from the client's
+// profile, if WithProfile gave it one.
+//
+// Parameters:
+//   - emails: The addresses to validate.
+//   - opts: Options controlling concurrency and progress reporting.
+//
+// Returns:
+//   - []BulkResult: One result per address, in the same order as emails.
+//     Duplicates skipped by opts.Dedupe have Err set to ErrDuplicate. If
+//     opts.Context is done first, those not validated have Err set to
+//     ErrInterrupted and aren't passed to OnResult.
+func (c *Client) ValidateBulk(emails []string, opts BulkOptions) []BulkResult {
+	opts = c.bulkDefaults(opts)
+	results := make([]BulkResult, len(emails))
+	sender := ""
+	if opts.Sender != "" {
+		var err error
+		if sender, err = c.normalizeSender(opts.Sender); err != nil {
+			for i, email := range emails {
+				results[i] = BulkResult{Index: i, Email: email, Err: err}
+			}
+			return results
+		}
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var duplicates []bool
+	if opts.Dedupe != nil {
+		duplicates = make([]bool, len(emails))
+		for i, email := range emails {
+			duplicates[i] = opts.Dedupe.Seen(dedupeKey(email))
+		}
+	}
+	var priority []bool
+	if opts.Priority != nil {
+		priority = make([]bool, len(emails))
+		for i, email := range emails {
+			priority[i] = opts.Priority(email)
+		}
+	}
+	opts.Stats.add(emails)
+	c.prefetchDNS(ctx, emails, duplicates, opts.Prefetch)
+
+	workers := opts.Concurrency
+	if opts.Adaptive != nil {
+		workers = opts.Adaptive.Max
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	sched := newDomainScheduler(emails, duplicates, priority, opts.DomainConcurrency, opts.DomainInterval)
+	stop := context.AfterFunc(ctx, sched.stop)
+	defer stop()
+	done := make(chan BulkResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prev := ""
+			for {
+				opts.Pause.wait(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				// Wait for the concurrency limit before taking addresses, so
+				// a blocked worker doesn't hold a domain's slot.
+				if opts.Adaptive != nil {
+					opts.Adaptive.Acquire()
+				}
+				indexes, domain, ok := sched.take(prev, opts.BatchSize)
+				if !ok {
+					if opts.Adaptive != nil {
+						opts.Adaptive.cancel()
+					}
+					return
+				}
+
+				batch := make([]BulkResult, len(indexes))
+				if len(indexes) == 1 {
+					i := indexes[0]
+					result, err := c.validate(emails[i], &validation{reuse: true, sender: sender, maxAge: opts.MaxAge, timeout: opts.Timeout})
+					batch[0] = BulkResult{Index: i, Email: emails[i], Result: result, Err: err}
+				} else {
+					batchEmails := make([]string, len(indexes))
+					for n, i := range indexes {
+						batchEmails[n] = emails[i]
+					}
+					results, errs := c.validateBatch(batchEmails, sender, opts.MaxAge, opts.Timeout)
+					for n, i := range indexes {
+						batch[n] = BulkResult{Index: i, Email: emails[i], Result: results[n], Err: errs[n]}
+					}
+				}
+
+				if opts.Adaptive != nil {
+					congested := false
+					for _, res := range batch {
+						congested = congested || isCongestionSignal(res.Result)
+					}
+					opts.Adaptive.Release(congested)
+				}
+				sched.done(domain)
+				prev = domain
+
+				for _, res := range batch {
+					done <- res
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	finished := make([]bool, len(emails))
+	for i, duplicate := range duplicates {
+		if duplicate {
+			res := BulkResult{Index: i, Email: emails[i], Err: ErrDuplicate}
+			results[i] = res
+			finished[i] = true
+			opts.Stats.record(res)
+			if opts.OnResult != nil {
+				opts.OnResult(res)
+			}
+		}
+	}
+	for res := range done {
+		results[res.Index] = res
+		finished[res.Index] = true
+		opts.Stats.record(res)
+		if opts.OnResult != nil {
+			opts.OnResult(res)
+		}
+	}
+	c.sessions.closeAll()
+
+	if ctx.Err() != nil {
+		for i, ok := range finished {
+			if !ok {
+				results[i] = BulkResult{Index: i, Email: emails[i], Err: ErrInterrupted}
+			}
+		}
+	}
+
+	return results
+}
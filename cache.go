@@ -0,0 +1,263 @@
+package mailify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mxCache remembers MX lookups, and the addresses of mail servers, for a
+// while. Names that don't exist are cached too, other failures are not. A nil
+// cache caches nothing.
+type mxCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]mxEntry
+	// addrs holds the address lookups of mail servers.
+	addrs map[string]addrEntry
+}
+
+// mxEntry is the cached outcome of one MX lookup.
+type mxEntry struct {
+	servers []string
+	err     error
+	expires time.Time
+}
+
+// addrEntry is the cached outcome of looking up a mail server's addresses.
+type addrEntry struct {
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+// newMXCache creates a cache whose entries live for ttl.
+func newMXCache(ttl time.Duration) *mxCache {
+	return &mxCache{ttl: ttl, entries: make(map[string]mxEntry), addrs: make(map[string]addrEntry)}
+}
+
+// WithMXCache makes the client remember MX lookups, and the addresses of
+// mail servers, for ttl, so addresses of the same domain don't each cost a
+// DNS round trip. In ModeOffline the cache is the only source of MX records.
+func WithMXCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.mxCache = newMXCache(ttl)
+	}
+}
+
+// get returns the cached outcome of looking up domain, with ok false if there is none.
+func (m *mxCache) get(domain string) (servers []string, err error, ok bool) {
+	if m == nil {
+		return nil, nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToLower(domain)
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return nil, nil, false
+	}
+	return entry.servers, entry.err, true
+}
+
+// set records the outcome of looking up domain, unless it was a failure that
+// says nothing about the domain.
+func (m *mxCache) set(domain string, servers []string, err error) {
+	if m == nil {
+		return
+	}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[strings.ToLower(domain)] = mxEntry{servers: servers, err: err, expires: time.Now().Add(m.ttl)}
+}
+
+// getAddrs returns the cached outcome of looking up the addresses of host,
+// with ok false if there is none.
+func (m *mxCache) getAddrs(host string) (ips []net.IP, err error, ok bool) {
+	if m == nil {
+		return nil, nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToLower(host)
+	entry, ok := m.addrs[key]
+	if !ok {
+		return nil, nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.addrs, key)
+		return nil, nil, false
+	}
+	return entry.ips, entry.err, true
+}
+
+// setAddrs records the outcome of looking up the addresses of host, unless it
+// was a failure that says nothing about the host.
+func (m *mxCache) setAddrs(host string, ips []net.IP, err error) {
+	if m == nil {
+		return
+	}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addrs[strings.ToLower(host)] = addrEntry{ips: ips, err: err, expires: time.Now().Add(m.ttl)}
+}
+
+// flush drops every cached entry.
+func (m *mxCache) flush() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]mxEntry)
+	m.addrs = make(map[string]addrEntry)
+}
+
+// Cache stores what validations learn about domains so later validations, and
+// with a persistent implementation later runs, can skip work. Implementations
+// must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, with ok false if there is none or it expired.
+	Get(key string) (value []byte, ok bool)
+	// Set stores value under key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// WithCache sets where knowledge about domains, such as whether they are
+// catch-all, is kept between validations. Use NewMemoryCache to share it
+// within a process or NewFileCache to keep it across runs.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// MemoryCache is a Cache kept in memory.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// memoryEntry is a value in a MemoryCache.
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+// Get implements Cache.
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{value: append([]byte(nil), value...), expires: time.Now().Add(ttl)}
+}
+
+// FileCache is a Cache kept as files in a directory, one per key, so it
+// survives restarts and can be shared by processes on the same machine.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache creates a cache in dir, creating the directory if needed.
+//
+// Parameters:
+//   - dir: The directory to keep the cache in.
+//
+// Returns:
+//   - *FileCache: The cache.
+//   - error: An error if the directory can't be created.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// path returns the file a key is stored in.
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get implements Cache. The file starts with the expiry time as Unix seconds
+// on a line of its own, followed by the value.
+func (f *FileCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return nil, false
+	}
+	header, value, found := bytes.Cut(data, []byte("\n"))
+	if !found {
+		return nil, false
+	}
+	expires, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		os.Remove(f.path(key))
+		return nil, false
+	}
+	return value, true
+}
+
+// Set implements Cache. The file is written to a temporary name first and
+// renamed into place, so readers never see half of it. Failures are ignored,
+// as a cache miss only costs a repeated check.
+func (f *FileCache) Set(key string, value []byte, ttl time.Duration) {
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(tmp, "%d\n", time.Now().Add(ttl).Unix())
+	tmp.Write(value)
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,198 @@
+package mailify
+
+// catalogs holds the translations of human-readable messages, keyed by
+// locale and then by the English message. English needs no catalog.
+var catalogs = map[Locale]map[string]string{
+	LocaleSpanish: {
+		// Error messages
+		"Invalid email format":                                             "Formato de correo electrónico no válido",
+		"No MX records found":                                              "No se encontraron registros MX",
+		"MX records not checked in offline mode":                           "Registros MX no comprobados en modo sin conexión",
+		"SMTP check skipped in dns mode":                                   "Comprobación SMTP omitida en modo DNS",
+		"SMTP check skipped in offline mode":                               "Comprobación SMTP omitida en modo sin conexión",
+		"User doesn't exist":                                               "El usuario no existe",
+		"Mailbox is full":                                                  "El buzón está lleno",
+		"Mailbox is disabled":                                              "El buzón está deshabilitado",
+		"Mailbox not checked, the server refused on policy grounds":        "Buzón no comprobado, el servidor lo rechazó por motivos de política",
+		"Mailbox is temporarily over quota, try again later":               "El buzón ha superado temporalmente su cuota, inténtelo más tarde",
+		"Server has insufficient storage, try again later":                 "El servidor no tiene espacio suficiente, inténtelo más tarde",
+		"Server had a local error processing the request, try again later": "El servidor tuvo un error local al procesar la solicitud, inténtelo más tarde",
+		"Mailbox temporarily unavailable, the server may be greylisting":   "Buzón no disponible temporalmente, el servidor puede estar aplicando listas grises",
+		"Reverse DNS lookup required but email might be valid":             "Se requiere DNS inverso, pero el correo podría ser válido",
+		"Server cannot verify the mailbox but will attempt delivery":       "El servidor no puede verificar el buzón, pero intentará la entrega",
+		"Mailbox not found in directory":                                   "Buzón no encontrado en el directorio",
+		"Mailbox is disabled in directory":                                 "El buzón está desactivado en el directorio",
+		"No mail server answered in time":                                  "Ningún servidor de correo respondió a tiempo",
+		"Mail server is tarpitting, check aborted":                         "El servidor de correo está ralentizando las respuestas (tarpit), comprobación abortada",
+		"Provider accepts every recipient, the mailbox can't be verified":  "El proveedor acepta a todos los destinatarios, el buzón no se puede verificar",
+		"Provider blocks mailbox verification":                             "El proveedor bloquea la verificación de buzones",
+		"Verification API says the mailbox doesn't exist":                  "La API de verificación indica que el buzón no existe",
+		"Verification API flags the address":                               "La API de verificación marca la dirección",
+
+		// Warnings
+		"HELO name has no matching forward and reverse DNS, mail servers may reject or distrust the check": "El nombre HELO no tiene DNS directo e inverso coincidentes, los servidores de correo pueden rechazar la comprobación o desconfiar de ella",
+		"SMTP check blocked from this host, verdict from a verification API":                               "Comprobación SMTP bloqueada desde este host, veredicto de una API de verificación",
+
+		// Verdict descriptions
+		"The mailbox exists and accepts mail":                                     "El buzón existe y acepta correo",
+		"Mail to this address will bounce":                                        "El correo a esta dirección será rechazado",
+		"The address may be deliverable, but delivery could fail or go unnoticed": "La dirección puede ser entregable, pero la entrega podría fallar o pasar desapercibida",
+		"The mail server didn't say whether the mailbox exists":                   "El servidor de correo no indicó si el buzón existe",
+
+		// Report labels
+		"Email Validation Results for": "Resultados de validación de correo para",
+		"Verdict":                      "Veredicto",
+		"Confidence":                   "Confianza",
+		"Has MX Records":               "Tiene registros MX",
+		"Catch-All":                    "Acepta todo",
+		"Disposable":                   "Desechable",
+		"Role Account":                 "Cuenta de rol",
+		"Details":                      "Detalles",
+		"Warning":                      "Advertencia",
+		"Retry After":                  "Reintentar en",
+	},
+	LocaleFrench: {
+		// Error messages
+		"Invalid email format":                                             "Format d'adresse e-mail invalide",
+		"No MX records found":                                              "Aucun enregistrement MX trouvé",
+		"MX records not checked in offline mode":                           "Enregistrements MX non vérifiés en mode hors ligne",
+		"SMTP check skipped in dns mode":                                   "Vérification SMTP ignorée en mode DNS",
+		"SMTP check skipped in offline mode":                               "Vérification SMTP ignorée en mode hors ligne",
+		"User doesn't exist":                                               "L'utilisateur n'existe pas",
+		"Mailbox is full":                                                  "La boîte aux lettres est pleine",
+		"Mailbox is disabled":                                              "La boîte aux lettres est désactivée",
+		"Mailbox not checked, the server refused on policy grounds":        "Boîte aux lettres non vérifiée, le serveur a refusé pour des raisons de politique",
+		"Mailbox is temporarily over quota, try again later":               "La boîte aux lettres a temporairement dépassé son quota, réessayez plus tard",
+		"Server has insufficient storage, try again later":                 "Le serveur manque d'espace de stockage, réessayez plus tard",
+		"Server had a local error processing the request, try again later": "Le serveur a rencontré une erreur locale en traitant la demande, réessayez plus tard",
+		"Mailbox temporarily unavailable, the server may be greylisting":   "Boîte aux lettres temporairement indisponible, le serveur applique peut-être une liste grise",
+		"Reverse DNS lookup required but email might be valid":             "DNS inverse requis, mais l'adresse est peut-être valide",
+		"Server cannot verify the mailbox but will attempt delivery":       "Le serveur ne peut pas vérifier la boîte aux lettres mais tentera la livraison",
+		"Mailbox not found in directory":                                   "Boîte aux lettres introuvable dans l'annuaire",
+		"Mailbox is disabled in directory":                                 "La boîte aux lettres est désactivée dans l'annuaire",
+		"No mail server answered in time":                                  "Aucun serveur de messagerie n'a répondu à temps",
+		"Mail server is tarpitting, check aborted":                         "Le serveur de messagerie ralentit ses réponses (tarpit), vérification abandonnée",
+		"Provider accepts every recipient, the mailbox can't be verified":  "Le fournisseur accepte tous les destinataires, la boîte aux lettres ne peut pas être vérifiée",
+		"Provider blocks mailbox verification":                             "Le fournisseur bloque la vérification des boîtes aux lettres",
+		"Verification API says the mailbox doesn't exist":                  "L'API de vérification indique que la boîte aux lettres n'existe pas",
+		"Verification API flags the address":                               "L'API de vérification signale l'adresse",
+
+		// Warnings
+		"HELO name has no matching forward and reverse DNS, mail servers may reject or distrust the check": "Le nom HELO n'a pas de DNS direct et inverse concordants, les serveurs de messagerie peuvent refuser la vérification ou s'en méfier",
+		"SMTP check blocked from this host, verdict from a verification API":                               "Vérification SMTP bloquée depuis cet hôte, verdict d'une API de vérification",
+
+		// Verdict descriptions
+		"The mailbox exists and accepts mail":                                     "La boîte aux lettres existe et accepte le courrier",
+		"Mail to this address will bounce":                                        "Le courrier envoyé à cette adresse sera rejeté",
+		"The address may be deliverable, but delivery could fail or go unnoticed": "L'adresse est peut-être valide, mais la livraison pourrait échouer ou passer inaperçue",
+		"The mail server didn't say whether the mailbox exists":                   "Le serveur de messagerie n'a pas indiqué si la boîte aux lettres existe",
+
+		// Report labels
+		"Email Validation Results for": "Résultats de validation de l'adresse",
+		"Verdict":                      "Verdict",
+		"Confidence":                   "Confiance",
+		"Has MX Records":               "Enregistrements MX",
+		"Catch-All":                    "Accepte tout",
+		"Disposable":                   "Jetable",
+		"Role Account":                 "Compte de rôle",
+		"Details":                      "Détails",
+		"Warning":                      "Avertissement",
+		"Retry After":                  "Réessayer dans",
+	},
+	LocaleGerman: {
+		// Error messages
+		"Invalid email format":                                             "Ungültiges E-Mail-Format",
+		"No MX records found":                                              "Keine MX-Einträge gefunden",
+		"MX records not checked in offline mode":                           "MX-Einträge im Offline-Modus nicht geprüft",
+		"SMTP check skipped in dns mode":                                   "SMTP-Prüfung im DNS-Modus übersprungen",
+		"SMTP check skipped in offline mode":                               "SMTP-Prüfung im Offline-Modus übersprungen",
+		"User doesn't exist":                                               "Der Benutzer existiert nicht",
+		"Mailbox is full":                                                  "Das Postfach ist voll",
+		"Mailbox is disabled":                                              "Das Postfach ist deaktiviert",
+		"Mailbox not checked, the server refused on policy grounds":        "Postfach nicht geprüft, der Server hat aus Richtliniengründen abgelehnt",
+		"Mailbox is temporarily over quota, try again later":               "Das Postfach hat sein Kontingent vorübergehend überschritten, versuchen Sie es später erneut",
+		"Server has insufficient storage, try again later":                 "Der Server hat nicht genügend Speicherplatz, versuchen Sie es später erneut",
+		"Server had a local error processing the request, try again later": "Beim Verarbeiten der Anfrage trat auf dem Server ein lokaler Fehler auf, versuchen Sie es später erneut",
+		"Mailbox temporarily unavailable, the server may be greylisting":   "Postfach vorübergehend nicht verfügbar, der Server nutzt möglicherweise Greylisting",
+		"Reverse DNS lookup required but email might be valid":             "Reverse-DNS erforderlich, die Adresse ist aber möglicherweise gültig",
+		"Server cannot verify the mailbox but will attempt delivery":       "Der Server kann das Postfach nicht prüfen, versucht aber die Zustellung",
+		"Mailbox not found in directory":                                   "Postfach nicht im Verzeichnis gefunden",
+		"Mailbox is disabled in directory":                                 "Das Postfach ist im Verzeichnis deaktiviert",
+		"No mail server answered in time":                                  "Kein Mailserver hat rechtzeitig geantwortet",
+		"Mail server is tarpitting, check aborted":                         "Der Mailserver verzögert seine Antworten (Tarpit), Prüfung abgebrochen",
+		"Provider accepts every recipient, the mailbox can't be verified":  "Der Anbieter nimmt jeden Empfänger an, das Postfach kann nicht geprüft werden",
+		"Provider blocks mailbox verification":                             "Der Anbieter blockiert die Prüfung von Postfächern",
+		"Verification API says the mailbox doesn't exist":                  "Die Prüf-API meldet, dass das Postfach nicht existiert",
+		"Verification API flags the address":                               "Die Prüf-API markiert die Adresse",
+
+		// Warnings
+		"HELO name has no matching forward and reverse DNS, mail servers may reject or distrust the check": "Der HELO-Name hat kein übereinstimmendes Forward- und Reverse-DNS, Mailserver lehnen die Prüfung möglicherweise ab oder misstrauen ihr",
+		"SMTP check blocked from this host, verdict from a verification API":                               "SMTP-Prüfung von diesem Host blockiert, Ergebnis von einer Prüf-API",
+
+		// Verdict descriptions
+		"The mailbox exists and accepts mail":                                     "Das Postfach existiert und nimmt E-Mails an",
+		"Mail to this address will bounce":                                        "E-Mails an diese Adresse werden abgewiesen",
+		"The address may be deliverable, but delivery could fail or go unnoticed": "Die Adresse ist möglicherweise zustellbar, die Zustellung könnte aber fehlschlagen oder unbemerkt bleiben",
+		"The mail server didn't say whether the mailbox exists":                   "Der Mailserver hat nicht mitgeteilt, ob das Postfach existiert",
+
+		// Report labels
+		"Email Validation Results for": "E-Mail-Prüfergebnisse für",
+		"Verdict":                      "Ergebnis",
+		"Confidence":                   "Sicherheit",
+		"Has MX Records":               "MX-Einträge vorhanden",
+		"Catch-All":                    "Catch-All",
+		"Disposable":                   "Wegwerfadresse",
+		"Role Account":                 "Rollenkonto",
+		"Details":                      "Details",
+		"Warning":                      "Warnung",
+		"Retry After":                  "Erneut versuchen in",
+	},
+	LocaleHindi: {
+		// Error messages
+		"Invalid email format":                                             "अमान्य ईमेल प्रारूप",
+		"No MX records found":                                              "कोई MX रिकॉर्ड नहीं मिला",
+		"MX records not checked in offline mode":                           "ऑफ़लाइन मोड में MX रिकॉर्ड की जाँच नहीं की गई",
+		"SMTP check skipped in dns mode":                                   "DNS मोड में SMTP जाँच छोड़ दी गई",
+		"SMTP check skipped in offline mode":                               "ऑफ़लाइन मोड में SMTP जाँच छोड़ दी गई",
+		"User doesn't exist":                                               "उपयोगकर्ता मौजूद नहीं है",
+		"Mailbox is full":                                                  "मेलबॉक्स भरा हुआ है",
+		"Mailbox is disabled":                                              "मेलबॉक्स अक्षम है",
+		"Mailbox not checked, the server refused on policy grounds":        "मेलबॉक्स की जाँच नहीं हुई, सर्वर ने नीति कारणों से अस्वीकार कर दिया",
+		"Mailbox is temporarily over quota, try again later":               "मेलबॉक्स अस्थायी रूप से अपने कोटे से अधिक है, बाद में पुनः प्रयास करें",
+		"Server has insufficient storage, try again later":                 "सर्वर में पर्याप्त संग्रहण नहीं है, बाद में पुनः प्रयास करें",
+		"Server had a local error processing the request, try again later": "अनुरोध संसाधित करते समय सर्वर में स्थानीय त्रुटि हुई, बाद में पुनः प्रयास करें",
+		"Mailbox temporarily unavailable, the server may be greylisting":   "मेलबॉक्स अस्थायी रूप से अनुपलब्ध है, सर्वर ग्रेलिस्टिंग कर रहा हो सकता है",
+		"Reverse DNS lookup required but email might be valid":             "रिवर्स DNS आवश्यक है, लेकिन ईमेल मान्य हो सकता है",
+		"Server cannot verify the mailbox but will attempt delivery":       "सर्वर मेलबॉक्स की पुष्टि नहीं कर सकता, लेकिन डिलीवरी का प्रयास करेगा",
+		"Mailbox not found in directory":                                   "निर्देशिका में मेलबॉक्स नहीं मिला",
+		"Mailbox is disabled in directory":                                 "निर्देशिका में मेलबॉक्स अक्षम है",
+		"No mail server answered in time":                                  "किसी भी मेल सर्वर ने समय पर उत्तर नहीं दिया",
+		"Mail server is tarpitting, check aborted":                         "मेल सर्वर जानबूझकर उत्तर धीमे कर रहा है (टारपिट), जाँच रोकी गई",
+		"Provider accepts every recipient, the mailbox can't be verified":  "प्रदाता हर प्राप्तकर्ता को स्वीकार करता है, मेलबॉक्स सत्यापित नहीं किया जा सकता",
+		"Provider blocks mailbox verification":                             "प्रदाता मेलबॉक्स सत्यापन को रोकता है",
+		"Verification API says the mailbox doesn't exist":                  "सत्यापन API के अनुसार मेलबॉक्स मौजूद नहीं है",
+		"Verification API flags the address":                               "सत्यापन API ने पते को चिह्नित किया है",
+
+		// Warnings
+		"HELO name has no matching forward and reverse DNS, mail servers may reject or distrust the check": "HELO नाम का फ़ॉरवर्ड और रिवर्स DNS मेल नहीं खाता, मेल सर्वर जाँच को अस्वीकार कर सकते हैं या उस पर भरोसा नहीं कर सकते",
+		"SMTP check blocked from this host, verdict from a verification API":                               "इस होस्ट से SMTP जाँच अवरुद्ध है, निर्णय सत्यापन API से",
+
+		// Verdict descriptions
+		"The mailbox exists and accepts mail":                                     "मेलबॉक्स मौजूद है और ईमेल स्वीकार करता है",
+		"Mail to this address will bounce":                                        "इस पते पर भेजा गया ईमेल वापस आ जाएगा",
+		"The address may be deliverable, but delivery could fail or go unnoticed": "पते पर डिलीवरी संभव हो सकती है, लेकिन वह विफल हो सकती है या अनदेखी रह सकती है",
+		"The mail server didn't say whether the mailbox exists":                   "मेल सर्वर ने नहीं बताया कि मेलबॉक्स मौजूद है या नहीं",
+
+		// Report labels
+		"Email Validation Results for": "ईमेल सत्यापन परिणाम",
+		"Verdict":                      "निर्णय",
+		"Confidence":                   "विश्वसनीयता",
+		"Has MX Records":               "MX रिकॉर्ड हैं",
+		"Catch-All":                    "कैच-ऑल",
+		"Disposable":                   "डिस्पोज़ेबल",
+		"Role Account":                 "भूमिका खाता",
+		"Details":                      "विवरण",
+		"Warning":                      "चेतावनी",
+		"Retry After":                  "पुनः प्रयास करें",
+	},
+}
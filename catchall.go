@@ -0,0 +1,153 @@
+package mailify
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Confidence says how sure a determination is.
+type Confidence string
+
+const (
+	// ConfidenceHigh means every probe agreed.
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium means most probes agreed.
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow means only a few probes agreed, which may be noise.
+	ConfidenceLow Confidence = "low"
+)
+
+// defaultCatchAllProbes is how many made-up mailboxes are probed when a
+// recipient is accepted, unless WithCatchAllProbes says otherwise.
+const defaultCatchAllProbes = 3
+
+// WithCatchAllProbes sets how many made-up mailboxes are probed after a
+// recipient is accepted, to tell whether the domain accepts every address.
+// All but the last are random, made up as WithProbeGenerator says; the last
+// follows a known-bad pattern no real mailbox uses. The share of probes
+// accepted gives the catch-all determination its confidence. 0 disables
+// catch-all detection. The default is 3. With WithCache, determinations are
+// remembered and domains aren't probed again for a week.
+func WithCatchAllProbes(n int) Option {
+	return func(c *Client) {
+		c.catchAllProbes = n
+	}
+}
+
+// WithProbeSeed sets the seed the made-up mailboxes of catch-all probes are
+// derived from. They depend only on the seed and the domain, so a run given
+// the seed a manifest records probes the same mailboxes again. By default
+// every client picks a random seed.
+func WithProbeSeed(seed int64) Option {
+	return func(c *Client) {
+		c.probeSeed = seed
+	}
+}
+
+// catchAllConfidence turns probe replies into how sure we are that the domain
+// is catch-all, or "" if no probe was accepted. Replies cut off by the
+// server's recipient limit don't count.
+func catchAllConfidence(replies []reply) Confidence {
+	accepted, total := 0, 0
+	for _, r := range replies {
+		if isTooManyRecipients(r.code, r.msg) {
+			continue
+		}
+		total++
+		if r.err == nil && (r.code == 250 || r.code == 251) {
+			accepted++
+		}
+	}
+
+	switch {
+	case accepted == 0:
+		return ""
+	case accepted == total:
+		return ConfidenceHigh
+	case accepted*2 > total:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// applyCatchAll marks an accepted recipient's result as catch-all with the
+// given confidence. Only a high or medium confidence turns the verdict risky,
+// a lone accepted probe is too weak to overrule the server accepting the address.
+func applyCatchAll(result *ValidationResult, confidence Confidence) {
+	if confidence == "" || result.Verdict != VerdictDeliverable {
+		return
+	}
+	result.IsCatchAll = true
+	result.CatchAllConfidence = confidence
+	if confidence == ConfidenceHigh || confidence == ConfidenceMedium {
+		result.Verdict = VerdictRisky
+		result.SubStatus = SubStatusCatchAll
+	}
+}
+
+// catchAllTTL is how long a catch-all determination is trusted before the
+// domain is probed again.
+const catchAllTTL = 7 * 24 * time.Hour
+
+// CatchAllRecord is what has been learned about whether a domain accepts
+// every address. Records are kept in the cache given with WithCache.
+type CatchAllRecord struct {
+	// Domain is the domain the record is about.
+	Domain string `json:"domain"`
+	// IsCatchAll indicates whether any made-up mailbox was accepted.
+	IsCatchAll bool `json:"is_catch_all"`
+	// Confidence says how sure the determination is, empty if IsCatchAll is false.
+	Confidence Confidence `json:"confidence,omitempty"`
+	// CheckedAt is when the domain was probed.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// catchAllKey is the cache key of a domain's CatchAllRecord.
+func catchAllKey(domain string) string {
+	return "catchall:" + strings.ToLower(domain)
+}
+
+// CatchAllRecord returns what the client's cache knows about whether domain
+// is catch-all. While a record is known, validations of the domain skip the
+// catch-all probes and use the record instead.
+//
+// Parameters:
+//   - domain: The domain to look up.
+//
+// Returns:
+//   - CatchAllRecord: The record.
+//   - bool: False if there is no cache or it has no record for the domain.
+func (c *Client) CatchAllRecord(domain string) (CatchAllRecord, bool) {
+	if c.cache == nil {
+		return CatchAllRecord{}, false
+	}
+	data, ok := c.cache.Get(catchAllKey(domain))
+	if !ok {
+		return CatchAllRecord{}, false
+	}
+	var record CatchAllRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return CatchAllRecord{}, false
+	}
+	return record, true
+}
+
+// rememberCatchAll stores the outcome of probing domain in the client's cache.
+func (c *Client) rememberCatchAll(domain string, confidence Confidence) {
+	if c.cache == nil {
+		return
+	}
+	record := CatchAllRecord{
+		Domain:     strings.ToLower(domain),
+		IsCatchAll: confidence != "",
+		Confidence: confidence,
+		CheckedAt:  time.Now().UTC(),
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	c.cache.Set(catchAllKey(domain), data, catchAllTTL)
+}
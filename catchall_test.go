@@ -0,0 +1,137 @@
+package mailify_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/mailifytest"
+)
+
+// knownBadProbe is the pattern of the last, known-bad catch-all probe.
+var knownBadProbe = regexp.MustCompile(`^zq[0-9a-f]{24}qz@example\.com$`)
+
+// probeSeed makes the made-up mailboxes of the tests the same on every run.
+const probeSeed = 42
+
+// newTestClient creates a client whose mail for example.com and example.org
+// is handled by srv.
+func newTestClient(t *testing.T, srv *mailifytest.Server, opts ...mailify.Option) *mailify.Client {
+	t.Helper()
+	r := mailifytest.NewResolver()
+	r.AddMX("example.com", "mx.example.com")
+	r.AddIP("mx.example.com", "192.0.2.1")
+	r.AddMX("example.org", "mx.example.org")
+	r.AddIP("mx.example.org", "192.0.2.2")
+	opts = append(mailifytest.Options(srv, r), append([]mailify.Option{mailify.WithoutSenderCheck(), mailify.WithProbeSeed(probeSeed)}, opts...)...)
+	c, err := mailify.NewClient("sender@sender.example", opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// rcpts returns the addresses of the RCPT TO commands srv received, in order.
+func rcpts(srv *mailifytest.Server) []string {
+	var addresses []string
+	for _, line := range srv.Transcript() {
+		verb, arg, _ := strings.Cut(line, ":")
+		if strings.EqualFold(verb, "RCPT TO") {
+			address, _, _ := strings.Cut(strings.TrimPrefix(arg, "<"), ">")
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// catchAllProbes returns the made-up mailboxes a client with n catch-all
+// probes asks example.com for, by validating an address at a server that
+// rejects them all.
+func catchAllProbes(t *testing.T, n int) []string {
+	t.Helper()
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	srv.SetDefaultRcptReply(550, "5.1.1 No such user")
+	srv.SetRcptReply("user@example.com", 250, "2.1.5 OK")
+	c := newTestClient(t, srv, mailify.WithCatchAllProbes(n))
+	if _, err := c.ValidateEmail("user@example.com"); err != nil {
+		t.Fatalf("ValidateEmail: %v", err)
+	}
+	return rcpts(srv)[1:]
+}
+
+func TestCatchAllProbes(t *testing.T) {
+	probes := catchAllProbes(t, 3)
+	if len(probes) != 3 {
+		t.Fatalf("got %d probes %v, want 3", len(probes), probes)
+	}
+	for i, probe := range probes[:2] {
+		if knownBadProbe.MatchString(probe) || !strings.HasSuffix(probe, "@example.com") {
+			t.Errorf("probe %d = %q, want a random mailbox at example.com", i, probe)
+		}
+	}
+	if !knownBadProbe.MatchString(probes[2]) {
+		t.Errorf("last probe = %q, want the known-bad pattern", probes[2])
+	}
+
+	tests := []struct {
+		name string
+		// probes is how many catch-all probes the client sends.
+		probes int
+		// budget is the client's ProbeBudget.PerRun, 0 for no budget.
+		budget int
+		// accepted are the indexes of the probes the server accepts.
+		accepted      []int
+		wantRcpts     int
+		wantVerdict   mailify.Verdict
+		wantSubStatus mailify.SubStatus
+		wantCatchAll  mailify.Confidence
+	}{
+		{name: "every probe rejected", probes: 3, wantRcpts: 4, wantVerdict: mailify.VerdictDeliverable},
+		{name: "every probe accepted", probes: 3, accepted: []int{0, 1, 2}, wantRcpts: 4, wantVerdict: mailify.VerdictRisky, wantSubStatus: mailify.SubStatusCatchAll, wantCatchAll: mailify.ConfidenceHigh},
+		{name: "most probes accepted", probes: 3, accepted: []int{0, 2}, wantRcpts: 4, wantVerdict: mailify.VerdictRisky, wantSubStatus: mailify.SubStatusCatchAll, wantCatchAll: mailify.ConfidenceMedium},
+		{name: "one probe accepted", probes: 3, accepted: []int{1}, wantRcpts: 4, wantVerdict: mailify.VerdictDeliverable, wantCatchAll: mailify.ConfidenceLow},
+		{name: "only the known-bad probe", probes: 1, accepted: []int{0}, wantRcpts: 2, wantVerdict: mailify.VerdictRisky, wantSubStatus: mailify.SubStatusCatchAll, wantCatchAll: mailify.ConfidenceHigh},
+		{name: "no probes", probes: 0, accepted: []int{0, 1, 2}, wantRcpts: 1, wantVerdict: mailify.VerdictDeliverable},
+		{name: "budget fits every probe", probes: 3, budget: 4, accepted: []int{0, 1, 2}, wantRcpts: 4, wantVerdict: mailify.VerdictRisky, wantSubStatus: mailify.SubStatusCatchAll, wantCatchAll: mailify.ConfidenceHigh},
+		{name: "budget one probe short", probes: 3, budget: 3, accepted: []int{0, 1, 2}, wantRcpts: 1, wantVerdict: mailify.VerdictUnknown, wantSubStatus: mailify.SubStatusBudgetExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probes := catchAllProbes(t, tt.probes)
+
+			srv := mailifytest.NewServer()
+			defer srv.Close()
+			srv.SetDefaultRcptReply(550, "5.1.1 No such user")
+			srv.SetRcptReply("user@example.com", 250, "2.1.5 OK")
+			for _, i := range tt.accepted {
+				if i < len(probes) {
+					srv.SetRcptReply(probes[i], 250, "2.1.5 OK")
+				}
+			}
+			opts := []mailify.Option{mailify.WithCatchAllProbes(tt.probes)}
+			if tt.budget > 0 {
+				opts = append(opts, mailify.WithProbeBudget(mailify.ProbeBudget{PerRun: tt.budget}))
+			}
+			c := newTestClient(t, srv, opts...)
+
+			result, err := c.ValidateEmail("user@example.com")
+			if err != nil {
+				t.Fatalf("ValidateEmail: %v", err)
+			}
+
+			want := append([]string{"user@example.com"}, probes...)[:tt.wantRcpts]
+			if got := rcpts(srv); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("RCPT TO sequence = %v, want %v", got, want)
+			}
+			if result.Verdict != tt.wantVerdict || result.SubStatus != tt.wantSubStatus {
+				t.Errorf("verdict = %s/%s, want %s/%s", result.Verdict, result.SubStatus, tt.wantVerdict, tt.wantSubStatus)
+			}
+			if result.CatchAllConfidence != tt.wantCatchAll {
+				t.Errorf("catch-all confidence = %q, want %q", result.CatchAllConfidence, tt.wantCatchAll)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzeColumn            string
+	analyzeEncoding          string
+	analyzeSplitCells        string
+	analyzeConcurrency       int
+	analyzeDomainConcurrency int
+	analyzeDomainInterval    time.Duration
+	analyzeBatchSize         int
+	analyzePerAddress        time.Duration
+	analyzeTop               int
+	analyzeJSON              bool
+)
+
+// analyzeCmd describes a list before it is validated, without SMTP traffic.
+//
+// Usage:
+//   mailify analyze <file> [flags]
+//
+// Flags:
+//       --column string               Header of the column holding the emails
+//       --encoding string             Encoding of CSV and text files
+//       --split-cells string          Split cells holding several emails: off, aggregate or explode
+//   -c, --concurrency int             Number of emails the run will validate at once
+//       --domain-concurrency int      Max emails of the same domain the run will validate at once
+//       --domain-interval duration    Min time between validations of the same domain in the run
+//       --batch-size int              Max emails of the same domain the run will check in one SMTP transaction
+//       --per-address duration        How long validating one email takes, for the time estimate
+//       --top int                     Number of domains with the most emails to list
+//   -j, --json                        Print the analysis as JSON
+//
+// Examples:
+//   # See how long a list takes to validate 10 at a time, at most 2 per domain
+//   mailify analyze list.xlsx -c 10 --domain-concurrency 2
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <file>",
+	Short: "Describe a list of emails before validating it, without any SMTP traffic",
+	Long: `Analyze reads the emails of an Excel, CSV or text file, from the column -e would validate,
+and reports how many are malformed or repeated, how many domains they span, how many are disposable,
+at free providers or role accounts, and how many SMTP probes validating them takes, in all and for
+the domains with the most emails. With the bulk flags of the run, it estimates how long the run takes.
+Nothing is sent over the network.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc, ok := mailify.ParseTextEncoding(analyzeEncoding)
+		if !ok {
+			return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", analyzeEncoding)
+		}
+		multiAddress, ok := mailify.ParseMultiAddressMode(analyzeSplitCells)
+		if !ok {
+			return fmt.Errorf("unknown --split-cells mode %q, expected off, aggregate or explode", analyzeSplitCells)
+		}
+		client, err := mailify.NewClient("", mailify.WithoutSenderCheck())
+		if err != nil {
+			return err
+		}
+		opts := mailify.BulkOptions{
+			Concurrency:       analyzeConcurrency,
+			DomainConcurrency: analyzeDomainConcurrency,
+			DomainInterval:    analyzeDomainInterval,
+			BatchSize:         analyzeBatchSize,
+			EmailColumn:       analyzeColumn,
+			Encoding:          enc,
+			MultiAddress:      multiAddress,
+		}
+		analysis, err := client.AnalyzeFile(args[0], opts)
+		if err != nil {
+			return err
+		}
+		estimate := analysis.EstimateDuration(opts, analyzePerAddress)
+
+		if analyzeJSON {
+			out, err := json.MarshalIndent(struct {
+				*mailify.ListAnalysis
+				Estimate string `json:"estimate"`
+			}{analysis, estimate.String()}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode analysis: %v", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Println("=== List ===")
+		fmt.Printf("Emails: %d\n", analysis.Total)
+		fmt.Printf("Syntax errors: %d\n", analysis.SyntaxErrors)
+		fmt.Printf("Duplicates: %d\n", analysis.Duplicates)
+		fmt.Printf("Unique emails: %d\n", analysis.Unique)
+		fmt.Printf("Unique domains: %d\n", analysis.UniqueDomains)
+		fmt.Printf("Disposable: %d\n", analysis.Disposable)
+		fmt.Printf("Free providers: %d\n", analysis.Free)
+		fmt.Printf("Role accounts: %d\n", analysis.Role)
+		fmt.Printf("Expected SMTP probes: %d\n", analysis.ExpectedProbes)
+		fmt.Printf("Estimated run time: %s\n", estimate.Round(time.Second))
+
+		if analyzeTop > 0 && len(analysis.Domains) > 0 {
+			fmt.Println("\n=== Top domains ===")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "DOMAIN\tEMAILS\tPROBES\tKIND")
+			for i, d := range analysis.Domains {
+				if i == analyzeTop {
+					break
+				}
+				kind := ""
+				switch {
+				case d.Disposable:
+					kind = "disposable"
+				case d.Free:
+					kind = "free"
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", d.Domain, d.Addresses, d.ExpectedProbes, kind)
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+func init() {
+	analyzeCmd.Flags().StringVar(&analyzeColumn, "column", "", "Header of the column holding the emails (default the \"email\" column, or else the column that looks most like emails)")
+	analyzeCmd.Flags().StringVar(&analyzeEncoding, "encoding", "auto", "Encoding of CSV and text files: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
+	analyzeCmd.Flags().StringVar(&analyzeSplitCells, "split-cells", "off", "Split cells holding several emails separated by commas or semicolons: off, aggregate or explode")
+	analyzeCmd.Flags().IntVarP(&analyzeConcurrency, "concurrency", "c", 1, "Number of emails the run will validate at once")
+	analyzeCmd.Flags().IntVar(&analyzeDomainConcurrency, "domain-concurrency", 0, "Max emails of the same domain the run will validate at once (0 for no limit)")
+	analyzeCmd.Flags().DurationVar(&analyzeDomainInterval, "domain-interval", 0, "Min time between validations of the same domain in the run, e.g. 2s")
+	analyzeCmd.Flags().IntVar(&analyzeBatchSize, "batch-size", 1, "Max emails of the same domain the run will check in one SMTP transaction")
+	analyzeCmd.Flags().DurationVar(&analyzePerAddress, "per-address", 3*time.Second, "How long validating one email takes, for the time estimate")
+	analyzeCmd.Flags().IntVar(&analyzeTop, "top", 10, "Number of domains with the most emails to list (0 for none)")
+	analyzeCmd.Flags().BoolVarP(&analyzeJSON, "json", "j", false, "Print the analysis as JSON")
+	rootCmd.AddCommand(analyzeCmd)
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchSamples     int
+	benchConcurrency int
+	benchSessions    int
+	benchJSON        bool
+)
+
+// benchCmd measures how fast mail servers can be reached from this host.
+//
+// Usage:
+//   mailify bench [domain...] [flags]
+//
+// Flags:
+//       --samples int       Times each domain's DNS lookup and SMTP connection are timed (default 3)
+//   -c, --concurrency int   SMTP sessions opened at once in the throughput test (default 8)
+//       --sessions int      SMTP sessions opened in the throughput test (default 5 per worker)
+//   -j, --json              Print the report as JSON
+//
+// Examples:
+//   # Benchmark against the largest mailbox providers
+//   mailify bench
+//
+//   # See whether 32 workers are worth it for your own customers' domains
+//   mailify bench example.com example.org -c 32
+var benchCmd = &cobra.Command{
+	Use:   "bench [domain...]",
+	Short: "Measure DNS and SMTP latency and throughput from this host",
+	Long: `Bench measures how fast mail servers can be reached from this host, to help size the
+concurrency of bulk runs: the latency of MX lookups, of connecting to each domain's mail server and of
+setting up an SMTP session, per domain and mail provider, and how many sessions can be set up per
+second with several at once. Sessions are closed after EHLO, so no mailbox is ever asked about.
+Without domains, the largest mailbox providers are sampled.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := mailify.NewClient("", append(resolverOptions(), mailify.WithoutSenderCheck())...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		report, err := client.Benchmark(ctx, args, mailify.BenchmarkOptions{
+			Samples:     benchSamples,
+			Concurrency: benchConcurrency,
+			Sessions:    benchSessions,
+		})
+
+		if benchJSON {
+			out, jsonErr := json.MarshalIndent(report, "", "  ")
+			if jsonErr != nil {
+				return fmt.Errorf("failed to encode report: %v", jsonErr)
+			}
+			fmt.Println(string(out))
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tPROVIDER\tMAIL SERVER\tDNS\tCONNECT\tSESSION\tERROR")
+		for _, domain := range report.Domains {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", domain.Domain, orDash(domain.Provider), orDash(domain.MailServer),
+				formatLatency(domain.DNS), formatLatency(domain.Connect), formatLatency(domain.Session), domain.Error)
+		}
+		if flushErr := w.Flush(); flushErr != nil {
+			return flushErr
+		}
+		if err != nil {
+			return fmt.Errorf("benchmark failed: %v", err)
+		}
+
+		fmt.Println("\n=== Throughput ===")
+		fmt.Printf("Concurrency: %d\n", report.Concurrency)
+		fmt.Printf("Sessions: %d (%d failed)\n", report.Sessions, report.Failed)
+		fmt.Printf("Duration: %s\n", report.Duration.Round(time.Millisecond))
+		fmt.Printf("Sessions per second: %.1f\n", report.SessionsPerSecond)
+		if report.Failed > 0 {
+			fmt.Println("Some sessions failed; try a lower --concurrency, mail servers may be throttling this host.")
+		}
+		return nil
+	},
+}
+
+// formatLatency prints the median latency along with its range, or "-" if
+// nothing was measured.
+func formatLatency(stats mailify.LatencyStats) string {
+	if stats.Samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s-%s)", stats.Median.Round(time.Millisecond),
+		stats.Min.Round(time.Millisecond), stats.Max.Round(time.Millisecond))
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchSamples, "samples", 3, "Times each domain's DNS lookup and SMTP connection are timed")
+	benchCmd.Flags().IntVarP(&benchConcurrency, "concurrency", "c", 8, "SMTP sessions opened at once in the throughput test")
+	benchCmd.Flags().IntVar(&benchSessions, "sessions", 0, "SMTP sessions opened in the throughput test (default 5 per worker)")
+	benchCmd.Flags().BoolVarP(&benchJSON, "json", "j", false, "Print the report as JSON")
+	rootCmd.AddCommand(benchCmd)
+}
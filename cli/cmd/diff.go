@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffColumn   string
+	diffEncoding string
+	diffJSON     bool
+	diffSummary  bool
+)
+
+// diffCmd compares the results of two validation runs of a list.
+//
+// Usage:
+//   mailify diff <old results> <new results> [flags]
+//
+// Flags:
+//       --column string     Header of the column holding the emails
+//       --encoding string   Encoding of CSV files
+//       --summary           Only print the summary, not each changed address
+//   -j, --json              Print the comparison as JSON
+//
+// Examples:
+//   # See which addresses changed verdict since last quarter's run
+//   mailify diff q1.csv q2.csv
+var diffCmd = &cobra.Command{
+	Use:   "diff <old results> <new results>",
+	Short: "Compare the results of two validation runs of a list",
+	Long: `Diff compares two Excel or CSV files holding validation results, as written by --excel, and
+reports the addresses whose verdict changed, e.g. from deliverable to undeliverable, followed by a
+summary of the changes, the addresses only in one of the files, and how much of the list has decayed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc, ok := mailify.ParseTextEncoding(diffEncoding)
+		if !ok {
+			return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", diffEncoding)
+		}
+		diff, err := mailify.DiffResultFiles(args[0], args[1], mailify.BulkOptions{EmailColumn: diffColumn, Encoding: enc})
+		if err != nil {
+			return err
+		}
+
+		if diffJSON {
+			out, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode comparison: %v", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		if !diffSummary {
+			for _, change := range diff.Changed {
+				fmt.Printf("%s: %s -> %s\n", change.Email, change.Old, change.New)
+			}
+		}
+
+		fmt.Println("\n=== Changes ===")
+		transitions := make([]string, 0, len(diff.Transitions))
+		for transition := range diff.Transitions {
+			transitions = append(transitions, transition)
+		}
+		sort.Strings(transitions)
+		for _, transition := range transitions {
+			fmt.Printf("%s: %d\n", transition, diff.Transitions[transition])
+		}
+		fmt.Printf("Changed: %d\n", len(diff.Changed))
+		fmt.Printf("Unchanged: %d\n", diff.Unchanged)
+		fmt.Printf("Only in %s: %d\n", args[0], len(diff.Removed))
+		fmt.Printf("Only in %s: %d\n", args[1], len(diff.Added))
+		fmt.Printf("Decayed: %d of %d deliverable (%.1f%%)\n", diff.Decayed, diff.WasDeliverable, diff.DecayRate()*100)
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffColumn, "column", "", "Header of the column holding the emails (default the \"email\" column, or else the column that looks most like emails)")
+	diffCmd.Flags().StringVar(&diffEncoding, "encoding", "auto", "Encoding of CSV files: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
+	diffCmd.Flags().BoolVar(&diffSummary, "summary", false, "Only print the summary, not each changed address")
+	diffCmd.Flags().BoolVarP(&diffJSON, "json", "j", false, "Print the comparison as JSON")
+	rootCmd.AddCommand(diffCmd)
+}
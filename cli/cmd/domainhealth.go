@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// dkimSelectors holds the DKIM selectors to look up besides the common ones.
+	dkimSelectors []string
+	// healthJSON prints the report as JSON instead of tables.
+	healthJSON bool
+	// healthCacheDir is the cache of validation runs to report the domain's catch-all status and reputation from.
+	healthCacheDir string
+)
+
+// domainHealthCmd prints a health report of a domain's mail setup.
+//
+// Usage:
+//   mailify domain-health <domain> [flags]
+//
+// Flags:
+//   -k, --selector strings  DKIM selectors to look up besides the common ones
+//   -j, --json              Print the report as JSON
+//       --cache-dir string  Cache of validation runs to report catch-all status and reputation from
+//
+// Examples:
+//   # Report on a domain
+//   mailify domain-health example.com
+//
+//   # Also check the domain's own DKIM selectors
+//   mailify domain-health example.com --selector mailer --selector 2024a
+//
+//   # Include what validation runs with the same cache have shown about the domain
+//   mailify domain-health example.com --cache-dir ~/.cache/mailify
+var domainHealthCmd = &cobra.Command{
+	Use:   "domain-health <domain>",
+	Short: "Print a health report of a domain's mail setup",
+	Long: `Domain-health checks a domain's MX records and whether each mail server offers TLS, its SPF,
+DMARC, MTA-STS and BIMI records, common DKIM selectors and those given with --selector, and whether the domain
+or its mail servers are on common DNS blocklists. With --cache-dir, it also reports whether the domain is
+catch-all and its reputation, bounce and deferral rates, catch-all flips and MX changes, from validation
+runs that used the same cache.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := append(resolverOptions(), mailify.WithoutSenderCheck())
+		if healthCacheDir != "" {
+			cache, err := mailify.NewFileCache(healthCacheDir)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, mailify.WithCache(cache))
+		}
+		client, err := mailify.NewClient("", opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		info, err := client.GetDomainInfo(args[0], dkimSelectors...)
+		if err != nil {
+			return err
+		}
+
+		if healthJSON {
+			out, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode report: %v", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		return printDomainInfo(info)
+	},
+}
+
+// printDomainInfo prints a domain health report as tables.
+func printDomainInfo(info *mailify.DomainInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Domain health report for", info.Domain+":")
+	fmt.Fprintln(w)
+	if info.MXError != "" {
+		fmt.Fprintln(w, "MX:\t"+info.MXError)
+	} else {
+		fmt.Fprintln(w, "MX HOST\tPREF\tADDRESSES\tREACHABLE\tTLS\tBLOCKLISTS\tERROR")
+		for _, mx := range info.MX {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%v\t%v\t%s\t%s\n", mx.Host, mx.Preference, orDash(strings.Join(mx.IPAddresses, ", ")),
+				mx.Reachable, mx.TLS, orDash(strings.Join(mx.Blocklists, ", ")), orDash(mx.Error))
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+	dnssecState, dnssec := "problem", info.DNSSEC.Error
+	switch {
+	case dnssec != "":
+	case info.DNSSEC.Validated:
+		dnssecState, dnssec = "ok", "MX chain signed and validated by "+info.DNSSEC.Resolver
+	case info.DNSSEC.Signed:
+		dnssec = "MX chain signed but not validated by " + info.DNSSEC.Resolver
+	default:
+		dnssec = "unsigned: " + strings.Join(info.DNSSEC.Unsigned, ", ")
+	}
+	fmt.Fprintf(w, "DNSSEC\t%s\t%s\n", dnssecState, dnssec)
+	if info.Provider != "" {
+		fmt.Fprintf(w, "Provider\tok\t%s\n", info.Provider)
+	}
+	if info.CatchAll != nil {
+		state, catchAll := "ok", "not catch-all"
+		if info.CatchAll.IsCatchAll {
+			state, catchAll = "risky", fmt.Sprintf("catch-all (%s confidence)", info.CatchAll.Confidence)
+		}
+		fmt.Fprintf(w, "Catch-all\t%s\t%s, checked %s\n", state, catchAll, info.CatchAll.CheckedAt.Format("2006-01-02"))
+	}
+	if rep := info.Reputation; rep != nil {
+		state := "ok"
+		if rep.Score < 70 {
+			state = "risky"
+		}
+		detail := fmt.Sprintf("score %d/100 over %d checks, %.1f%% bounced, %.1f%% deferred", rep.Score, rep.Checks, 100*rep.BounceRate, 100*rep.DeferralRate)
+		if rep.CatchAllFlips > 0 {
+			detail += fmt.Sprintf(", catch-all flipped %d times", rep.CatchAllFlips)
+		}
+		if !rep.MXChangedAt.IsZero() {
+			detail += ", MX changed " + rep.MXChangedAt.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "Reputation\t%s\t%s\n", state, detail)
+	}
+	fmt.Fprintf(w, "SPF\t%s\t%s\n", status(info.SPF.Error), orDash(firstNonEmpty(info.SPF.Error, info.SPF.Record)))
+	fmt.Fprintf(w, "DMARC\t%s\t%s\n", status(info.DMARC.Error), orDash(firstNonEmpty(info.DMARC.Error, "policy "+info.DMARC.Policy)))
+	mtaSTS := info.MTASTS.Error
+	if mtaSTS == "" {
+		mtaSTS = fmt.Sprintf("mode %s, mx %s", info.MTASTS.Mode, strings.Join(info.MTASTS.MX, ", "))
+	}
+	fmt.Fprintf(w, "MTA-STS\t%s\t%s\n", status(info.MTASTS.Error), mtaSTS)
+	bimi := info.BIMI.Error
+	if bimi == "" {
+		bimi = "logo " + info.BIMI.LogoURL
+		if info.BIMI.HasVMC {
+			bimi += ", VMC " + info.BIMI.AuthorityURL
+		} else {
+			bimi += ", no VMC"
+		}
+	}
+	fmt.Fprintf(w, "BIMI\t%s\t%s\n", status(info.BIMI.Error), bimi)
+	for _, dkim := range info.DKIM {
+		state, detail := "ok", dkim.KeyType+" key"
+		if dkim.KeyBits > 0 {
+			detail += fmt.Sprintf(", %d bits", dkim.KeyBits)
+		}
+		if dkim.Testing {
+			detail += ", testing"
+		}
+		switch {
+		case !dkim.Found:
+			state, detail = "missing", firstNonEmpty(dkim.Error, "no key record")
+		case !dkim.Valid:
+			state, detail = "problem", dkim.Error
+		}
+		fmt.Fprintf(w, "DKIM %s\t%s\t%s\n", dkim.Selector, state, detail)
+	}
+	if len(info.DKIM) == 0 {
+		fmt.Fprintf(w, "DKIM\tunknown\tno common selector found, name yours with --selector\n")
+	}
+	if len(info.Blocklists) > 0 {
+		fmt.Fprintf(w, "Blocklists\tlisted\t%s\n", strings.Join(info.Blocklists, ", "))
+	} else {
+		fmt.Fprintf(w, "Blocklists\tok\tnot listed\n")
+	}
+	return w.Flush()
+}
+
+// status turns a check's error into a short status.
+func status(errMsg string) string {
+	if errMsg != "" {
+		return "problem"
+	}
+	return "ok"
+}
+
+// firstNonEmpty returns the first of the values that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	domainHealthCmd.Flags().StringSliceVarP(&dkimSelectors, "selector", "k", nil, "DKIM selectors to look up besides common ones such as google and selector1")
+	domainHealthCmd.Flags().BoolVarP(&healthJSON, "json", "j", false, "Print the report as JSON")
+	domainHealthCmd.Flags().StringVar(&healthCacheDir, "cache-dir", "", "Cache directory of validation runs, as their --cache-dir, to report the domain's catch-all status and reputation from")
+	rootCmd.AddCommand(domainHealthCmd)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	filterSender      string
+	filterOutput      string
+	filterKeep        []string
+	filterDrop        []string
+	filterConcurrency int
+	filterEncoding    string
+)
+
+// filterDropKinds are the kinds of address --drop accepts, and how to tell them.
+var filterDropKinds = map[string]func(*mailify.ValidationResult) bool{
+	"role":           func(r *mailify.ValidationResult) bool { return r.IsRoleAccount },
+	"disposable":     func(r *mailify.ValidationResult) bool { return r.IsDisposable },
+	"catch-all":      func(r *mailify.ValidationResult) bool { return r.IsCatchAll },
+	"mailbox-full":   func(r *mailify.ValidationResult) bool { return r.IsMailboxFull },
+	"low-confidence": func(r *mailify.ValidationResult) bool { return r.Confidence == mailify.ConfidenceLow },
+}
+
+// filterCmd validates a list and writes out the addresses worth keeping.
+//
+// Usage:
+//   mailify filter <file> [flags]
+//
+// Flags:
+//   -s, --sender string      Sender email address (required)
+//   -o, --output string      File to write the kept addresses to (default stdout)
+//       --keep strings       Verdicts to keep (default deliverable)
+//       --drop strings       Kinds of address to drop: role, disposable, catch-all, mailbox-full or low-confidence
+//   -c, --concurrency int    Number of emails to validate at once
+//       --encoding string    Encoding of the list
+//       --profile string     Named settings to validate with: aggressive, polite, offline or one from the config
+//       --config string      Config file defining profiles, protected domains and probe budgets
+//       --timeout duration   Most time each validation may take, 0 for no limit (default 30s)
+//       --max-probes int     Most SMTP probes of the run
+//
+// Examples:
+//   # Keep the deliverable and risky addresses that aren't role or disposable ones
+//   mailify filter list.txt -s sender@example.com --keep deliverable,risky --drop role,disposable -o clean.txt
+//
+//   # Filter a list piped in
+//   cat export.csv | mailify filter - -s sender@example.com > clean.txt
+var filterCmd = &cobra.Command{
+	Use:   "filter <file>",
+	Short: "Validate a list and write out only the addresses worth keeping",
+	Long: `Filter reads the email addresses in a file, or stdin if the file is -, validates them, and writes
+the addresses whose verdict is one of --keep, and that aren't any of the --drop kinds, one per line to
+stdout or --output. Addresses that couldn't be validated are left out. The file may be a plain list or
+any text holding addresses, such as a CSV export. A summary is printed to stderr.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keep := make(map[mailify.Verdict]bool)
+		for _, v := range filterKeep {
+			verdict := mailify.Verdict(v)
+			switch verdict {
+			case mailify.VerdictDeliverable, mailify.VerdictUndeliverable, mailify.VerdictRisky, mailify.VerdictUnknown:
+				keep[verdict] = true
+			default:
+				return fmt.Errorf("unknown verdict %q, expected deliverable, undeliverable, risky or unknown", v)
+			}
+		}
+		for _, kind := range filterDrop {
+			if filterDropKinds[kind] == nil {
+				return fmt.Errorf("unknown --drop kind %q, expected role, disposable, catch-all, mailbox-full or low-confidence", kind)
+			}
+		}
+		enc, ok := mailify.ParseTextEncoding(filterEncoding)
+		if !ok {
+			return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", filterEncoding)
+		}
+
+		emails, err := readEmails(args[0], enc)
+		if err != nil {
+			return err
+		}
+
+		opts, err := clientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := mailify.NewClient(filterSender, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		out := os.Stdout
+		if filterOutput != "" {
+			f, err := os.Create(filterOutput)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		w := bufio.NewWriter(out)
+
+		kept, failed := 0, 0
+		ctx, cancel := interruptContext()
+		defer cancel()
+		for _, res := range client.ValidateBulk(emails, mailify.BulkOptions{Concurrency: concurrencyOf(cmd, filterConcurrency), Context: ctx}) {
+			if res.Result == nil {
+				failed++
+				continue
+			}
+			if !keepResult(res.Result, keep) {
+				continue
+			}
+			fmt.Fprintln(w, res.Email)
+			kept++
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write addresses: %v", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Kept %d of %d addresses", kept, len(emails))
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, ", %d couldn't be validated", failed)
+		}
+		fmt.Fprintln(os.Stderr)
+		if ctx.Err() != nil {
+			return mailify.ErrInterrupted
+		}
+		return nil
+	},
+}
+
+// keepResult reports whether an address with the given result passes the
+// --keep verdicts and none of the --drop kinds.
+func keepResult(result *mailify.ValidationResult, keep map[mailify.Verdict]bool) bool {
+	if !keep[result.Verdict] {
+		return false
+	}
+	for _, kind := range filterDrop {
+		if filterDropKinds[kind](result) {
+			return false
+		}
+	}
+	return true
+}
+
+// readEmails returns the email addresses in a file, or stdin if path is "-",
+// decoded as enc says.
+func readEmails(path string, enc mailify.TextEncoding) ([]string, error) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read list: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	r, err := mailify.NewTextReader(in, enc)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list: %v", err)
+	}
+	emails := mailify.ExtractEmails(string(data))
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("no email addresses found in %s", path)
+	}
+	return emails, nil
+}
+
+func init() {
+	filterCmd.Flags().StringVarP(&filterSender, "sender", "s", "", "Sender email address (required)")
+	filterCmd.MarkFlagRequired("sender")
+	filterCmd.Flags().StringVarP(&filterOutput, "output", "o", "", "File to write the kept addresses to (default stdout)")
+	filterCmd.Flags().StringSliceVar(&filterKeep, "keep", []string{"deliverable"}, "Verdicts of the addresses to keep: deliverable, undeliverable, risky or unknown")
+	filterCmd.Flags().StringSliceVar(&filterDrop, "drop", nil, "Kinds of address to drop whatever their verdict: role, disposable, catch-all, mailbox-full or low-confidence")
+	filterCmd.Flags().IntVarP(&filterConcurrency, "concurrency", "c", 1, "Number of emails to validate at once")
+	filterCmd.Flags().StringVar(&filterEncoding, "encoding", "auto", "Encoding of the list: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
+	addClientFlags(filterCmd, true)
+	rootCmd.AddCommand(filterCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+// verifyPublicKey is the public key manifests are verified against.
+var verifyPublicKey string
+
+// keygenCmd creates an ed25519 key pair for signing manifests.
+//
+// Usage:
+//   mailify keygen <name>
+//
+// Examples:
+//   # Write compliance.key and compliance.pub
+//   mailify keygen compliance
+var keygenCmd = &cobra.Command{
+	Use:   "keygen <name>",
+	Short: "Create a key pair for signing run manifests",
+	Long: `Keygen creates an ed25519 key pair in PEM form: <name>.key, the private key to pass to --sign-key,
+and <name>.pub, the public key to give whoever verifies the manifests. Existing files are not overwritten.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		priv, pub := args[0]+".key", args[0]+".pub"
+		if err := mailify.GenerateSigningKey(priv, pub); err != nil {
+			return err
+		}
+		fmt.Printf("Private key written to %s, public key to %s\n", priv, pub)
+		return nil
+	},
+}
+
+// verifyManifestCmd checks a signed manifest and the files it lists.
+//
+// Usage:
+//   mailify verify-manifest <manifest> --public-key <file>
+//
+// Flags:
+//   -k, --public-key string  PEM ed25519 public key of the signer (required)
+//
+// Examples:
+//   # Check a manifest and that the result files haven't changed since
+//   mailify verify-manifest mailify-manifest-20240101T120000.json -k compliance.pub
+var verifyManifestCmd = &cobra.Command{
+	Use:   "verify-manifest <manifest>",
+	Short: "Verify a signed run manifest and the result files it lists",
+	Long: `Verify-manifest checks the signature of a manifest written with --sign-key, prints what it records,
+and checks that each result file it lists is still unchanged.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := mailify.LoadPublicKey(verifyPublicKey)
+		if err != nil {
+			return err
+		}
+		signed, err := mailify.ReadSignedManifest(args[0])
+		if err != nil {
+			return err
+		}
+		manifest, err := mailify.VerifyManifest(signed, key)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Signature: valid")
+		fmt.Printf("Validated by %s %s from %s to %s\n", manifest.Tool, manifest.Version,
+			manifest.StartedAt.Format("2006-01-02 15:04:05 MST"), manifest.FinishedAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Sender: %s, mode: %s, emails: %d\n", manifest.Config.SenderEmail, manifest.Config.Mode, manifest.Summary.Total)
+		for _, file := range manifest.Inputs {
+			fmt.Printf("Input:  %s (sha256 %s)\n", file.Path, file.SHA256)
+		}
+
+		changed := 0
+		for _, file := range manifest.Outputs {
+			if err := file.CheckFile(); err != nil {
+				fmt.Printf("Output: %s: %v\n", file.Path, err)
+				changed++
+				continue
+			}
+			fmt.Printf("Output: %s unchanged\n", file.Path)
+		}
+		if changed > 0 {
+			return fmt.Errorf("%d result file(s) don't match the manifest", changed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyManifestCmd.Flags().StringVarP(&verifyPublicKey, "public-key", "k", "", "PEM ed25519 public key of whoever signed the manifest (required)")
+	verifyManifestCmd.MarkFlagRequired("public-key")
+	rootCmd.AddCommand(keygenCmd)
+	rootCmd.AddCommand(verifyManifestCmd)
+}
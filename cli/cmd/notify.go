@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// Notification flags, shared by every command that runs bulk jobs.
+var (
+	notifySlack     string
+	notifyDiscord   string
+	notifyEmails    []string
+	notifyRelay     string
+	notifyRelayUser string
+	notifyFrom      string
+	notifyMXChange  string
+)
+
+// notifyPasswordEnv is the environment variable holding the relay password,
+// kept out of flags so it doesn't show up in process listings.
+const notifyPasswordEnv = "MAILIFY_RELAY_PASSWORD"
+
+// notifiers returns the notifiers for the notification flags. Emails are
+// sent from --notify-from, or sender if it isn't set.
+func notifiers(sender string) ([]mailify.Notifier, error) {
+	var list []mailify.Notifier
+	if notifySlack != "" {
+		list = append(list, &mailify.SlackNotifier{WebhookURL: notifySlack})
+	}
+	if notifyDiscord != "" {
+		list = append(list, &mailify.DiscordNotifier{WebhookURL: notifyDiscord})
+	}
+	if len(notifyEmails) > 0 {
+		if notifyRelay == "" {
+			return nil, fmt.Errorf("--notify-email needs --notify-relay")
+		}
+		host, port, err := net.SplitHostPort(notifyRelay)
+		if err != nil {
+			host, port = notifyRelay, ""
+		}
+		from := notifyFrom
+		if from == "" {
+			from = sender
+		}
+		list = append(list, &mailify.EmailNotifier{
+			Relay: mailify.Relay{
+				Host:     host,
+				Port:     port,
+				Username: notifyRelayUser,
+				Password: os.Getenv(notifyPasswordEnv),
+			},
+			From: from,
+			To:   notifyEmails,
+		})
+	}
+	return list, nil
+}
+
+// mxChangeOptions returns the client options of the --notify-mx-change flag.
+func mxChangeOptions() []mailify.Option {
+	if notifyMXChange == "" {
+		return nil
+	}
+	return []mailify.Option{mailify.WithMXChangeWebhook(notifyMXChange)}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&notifySlack, "notify-slack", "", "Slack incoming webhook URL to post a summary to when a bulk job finishes")
+	rootCmd.PersistentFlags().StringVar(&notifyDiscord, "notify-discord", "", "Discord webhook URL to post a summary to when a bulk job finishes")
+	rootCmd.PersistentFlags().StringSliceVar(&notifyEmails, "notify-email", nil, "Addresses to email a summary to when a bulk job finishes, through --notify-relay")
+	rootCmd.PersistentFlags().StringVar(&notifyRelay, "notify-relay", "", "SMTP relay for --notify-email, host[:port] (port 587 by default)")
+	rootCmd.PersistentFlags().StringVar(&notifyRelayUser, "notify-relay-user", "", "Username to log in to --notify-relay with; the password is read from $"+notifyPasswordEnv)
+	rootCmd.PersistentFlags().StringVar(&notifyFrom, "notify-from", "", "Sender address of --notify-email summaries (default the --sender address)")
+	rootCmd.PersistentFlags().StringVar(&notifyMXChange, "notify-mx-change", "", "URL to POST a JSON event to when a domain's mail servers changed since it was last validated, noticed with --cache-dir")
+}
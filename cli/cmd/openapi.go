@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/api"
+	"github.com/spf13/cobra"
+)
+
+// openapiOutput is where the OpenAPI document is written, stdout if empty.
+var openapiOutput string
+
+// openapiCmd prints the OpenAPI document of the REST API mailify serve serves.
+//
+// Usage:
+//   mailify openapi [flags]
+//
+// Flags:
+//   -o, --output string  Write the document to a file instead of stdout
+//
+// Examples:
+//   # Generate a TypeScript client
+//   mailify openapi -o mailify.json
+//   npx @openapitools/openapi-generator-cli generate -i mailify.json -g typescript-fetch -o client
+var openapiCmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Print the OpenAPI document of mailify serve's REST API",
+	Long: `OpenAPI prints the OpenAPI 3 document of the REST API mailify serve serves, to generate clients
+in other languages with. A running server serves the same document at /openapi.json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		doc, err := api.OpenAPI(mailify.Version())
+		if err != nil {
+			return err
+		}
+		if openapiOutput == "" {
+			fmt.Println(string(doc))
+			return nil
+		}
+		if err := os.WriteFile(openapiOutput, append(doc, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write OpenAPI document: %v", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	openapiCmd.Flags().StringVarP(&openapiOutput, "output", "o", "", "Write the document to a file instead of stdout")
+	rootCmd.AddCommand(openapiCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+// probePorts holds any extra ports to probe besides 25, 465 and 587.
+var probePorts []string
+
+// probeCmd checks every SMTP port on a mail server and prints a diagnostics table.
+//
+// Usage:
+//   mailify probe <mail-server> [flags]
+//
+// Flags:
+//   -p, --port strings  Additional ports to probe besides 25, 465 and 587
+//
+// Examples:
+//   # Probe the default SMTP ports
+//   mailify probe gmail-smtp-in.l.google.com
+//
+//   # Also probe a custom port
+//   mailify probe mx.example.com --port 2525
+var probeCmd = &cobra.Command{
+	Use:   "probe <mail-server>",
+	Short: "Probe all SMTP ports on a mail server",
+	Long: `Probe connects to ports 25, 465 and 587 (plus any custom ports) on a mail server in parallel
+and reports reachability, greeting banner, TLS support and latency for each one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := mailify.NewClient("", append(resolverOptions(), mailify.WithoutSenderCheck())...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+
+		probes, err := client.ProbePorts(args[0], probePorts...)
+		if err != nil {
+			return fmt.Errorf("failed to probe mail server: %v", err)
+		}
+
+		fmt.Println("Port probe results for", args[0]+":")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PORT\tREACHABLE\tTLS\tLATENCY\tBANNER / ERROR")
+		for _, probe := range probes {
+			detail := probe.Banner
+			if probe.Error != "" {
+				detail = probe.Error
+			}
+			latency := "-"
+			if probe.Reachable {
+				latency = probe.Latency.Round(time.Millisecond).String()
+			}
+			fmt.Fprintf(w, "%s\t%v\t%v\t%s\t%s\n", probe.Port, probe.Reachable, probe.TLS, latency, detail)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	probeCmd.Flags().StringSliceVarP(&probePorts, "port", "p", nil, "Additional ports to probe besides 25, 465 and 587")
+	rootCmd.AddCommand(probeCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayPublicKey string
+	replayManifest  string
+)
+
+// replayCmd repeats a recorded -e run with the same settings.
+//
+// Usage:
+//   mailify replay <manifest> [file, directory or glob] [flags]
+//
+// Flags:
+//   -k, --public-key string  PEM ed25519 public key to verify a signed manifest with
+//       --manifest string    Where to write a manifest of the repeated run
+//
+// Examples:
+//   # Repeat last quarter's run on a copy of its input
+//   mailify replay mailify-manifest-20240101T120000.json original/list.xlsx -k compliance.pub
+var replayCmd = &cobra.Command{
+	Use:   "replay <manifest> [file, directory or glob]",
+	Short: "Repeat a recorded run with the same settings",
+	Long: `Replay reads a manifest written with --manifest or --sign-key and validates the files again with the
+configuration it records: the sender, mode, attempts, timeouts, catch-all probes and their seed, and
+the bulk settings. The files must be the run's inputs as they were before it, checked by their hashes,
+so pass copies kept of files the run wrote its results to. Without files, the manifest's single input
+is used. With --public-key, the manifest's signature is checked first. Domains protected with
+--protected-domains or in the config file, and its probe budget, still apply.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		manifest, err := mailify.ReadManifest(args[0])
+		if err != nil {
+			return err
+		}
+		if replayPublicKey != "" {
+			key, err := mailify.LoadPublicKey(replayPublicKey)
+			if err != nil {
+				return err
+			}
+			signed, err := mailify.ReadSignedManifest(args[0])
+			if err != nil {
+				return err
+			}
+			if _, err := mailify.VerifyManifest(signed, key); err != nil {
+				return err
+			}
+			fmt.Println("Signature: valid")
+		}
+
+		pattern := ""
+		switch {
+		case len(args) == 2:
+			pattern = args[1]
+		case len(manifest.Inputs) == 1:
+			pattern = manifest.Inputs[0].Path
+		default:
+			return fmt.Errorf("the manifest lists %d inputs, name a directory or glob holding them", len(manifest.Inputs))
+		}
+		if err := manifest.CheckInputs(pattern); err != nil {
+			return fmt.Errorf("%v, pass the files as they were before the run", err)
+		}
+
+		opts, err := manifest.Config.Options()
+		if err != nil {
+			return err
+		}
+		// The run's settings are the manifest's, but domains protected since still aren't probed
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, guardOptions(config)...)
+		client, err := mailify.NewClient(manifest.Config.SenderEmail, append(resolverOptions(), opts...)...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+		bulk, err := manifest.Config.BulkOptions()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := interruptContext()
+		defer cancel()
+		bulk.Context = ctx
+		bulk.Stats = mailify.NewRunStats()
+
+		fmt.Printf("Replaying the %s %s run of %s\n", manifest.Tool, manifest.Version, manifest.StartedAt.Format("2006-01-02 15:04:05 MST"))
+		if replayManifest == "" {
+			_, err := client.ProcessAndValidateEmailsViaFiles(pattern, bulk)
+			return err
+		}
+		_, replayed, err := client.ProcessAndRecordFiles(pattern, bulk)
+		if replayed.Tool == "" {
+			return err
+		}
+		if writeErr := replayed.WriteFile(replayManifest); writeErr != nil {
+			return writeErr
+		}
+		fmt.Printf("Manifest has been written to: %s\n", replayManifest)
+		return err
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayPublicKey, "public-key", "k", "", "PEM ed25519 public key to verify a signed manifest with before replaying it")
+	replayCmd.Flags().StringVar(&replayManifest, "manifest", "", "Where to write a manifest of the repeated run")
+	rootCmd.AddCommand(replayCmd)
+}
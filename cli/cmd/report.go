@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// ciOutcome is how an address counts in a CI report.
+type ciOutcome int
+
+const (
+	ciPass ciOutcome = iota
+	ciFail
+	ciError
+	ciSkip
+)
+
+// ciOutcomeOf says how a result counts in a CI report: deliverable addresses
+// pass, undeliverable ones fail, those that couldn't be validated are errors
+// and risky or unknown ones are skipped, as they neither pass nor fail.
+func ciOutcomeOf(res mailify.BulkResult) ciOutcome {
+	switch {
+	case res.Result == nil:
+		return ciError
+	case res.Result.Verdict == mailify.VerdictDeliverable:
+		return ciPass
+	case res.Result.Verdict == mailify.VerdictUndeliverable:
+		return ciFail
+	default:
+		return ciSkip
+	}
+}
+
+// ciMessage describes a result that didn't pass.
+func ciMessage(res mailify.BulkResult) string {
+	if res.Result == nil {
+		return fmt.Sprint(res.Err)
+	}
+	msg := string(res.Result.Verdict)
+	if res.Result.SubStatus != "" {
+		msg += " (" + string(res.Result.SubStatus) + ")"
+	}
+	if res.Result.ErrorMessage != "" {
+		msg += ": " + res.Result.ErrorMessage
+	}
+	return msg
+}
+
+// checkFailures returns an error if any address failed or couldn't be
+// validated, so CI steps fail.
+func checkFailures(results []mailify.BulkResult) error {
+	failed := 0
+	for _, res := range results {
+		if outcome := ciOutcomeOf(res); outcome == ciFail || outcome == ciError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d addresses failed validation", failed, len(results))
+	}
+	return nil
+}
+
+// junitSuite is a JUnit XML test suite, in the form CI systems read.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase is a test case, an address.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+// junitMessage is why a test case didn't pass.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+// writeJUnit writes the results as a JUnit XML report with a test case per
+// address, named after it and classed by its domain.
+func writeJUnit(w io.Writer, suite string, results []mailify.BulkResult) error {
+	report := junitSuite{Name: suite, Tests: len(results)}
+	var total float64
+	for _, res := range results {
+		seconds := 0.0
+		if res.Result != nil {
+			seconds = res.Result.Timings.Total.Seconds()
+		}
+		total += seconds
+
+		tc := junitCase{Name: res.Email, ClassName: suite + "." + emailDomain(res.Email), Time: fmt.Sprintf("%.3f", seconds)}
+		message := &junitMessage{Message: ciMessage(res)}
+		if res.Result != nil {
+			message.Type = string(res.Result.SubStatus)
+		}
+		switch ciOutcomeOf(res) {
+		case ciFail:
+			tc.Failure = message
+			report.Failures++
+		case ciError:
+			tc.Error = message
+			report.Errors++
+		case ciSkip:
+			tc.Skipped = message
+			report.Skipped++
+		}
+		report.Cases = append(report.Cases, tc)
+	}
+	report.Time = fmt.Sprintf("%.3f", total)
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, out)
+	return err
+}
+
+// writeGitHubAnnotations writes the results as GitHub Actions workflow
+// commands: an error for each address that failed or couldn't be validated,
+// a warning for each skipped one, and a notice summing up. Annotations point
+// at file if it is given.
+func writeGitHubAnnotations(w io.Writer, file string, results []mailify.BulkResult) {
+	location := ""
+	if file != "" && file != "-" {
+		location = "file=" + escapeAnnotationProperty(file) + ","
+	}
+	counts := make(map[ciOutcome]int)
+	for _, res := range results {
+		outcome := ciOutcomeOf(res)
+		counts[outcome]++
+		level := ""
+		switch outcome {
+		case ciFail, ciError:
+			level = "error"
+		case ciSkip:
+			level = "warning"
+		default:
+			continue
+		}
+		fmt.Fprintf(w, "::%s %stitle=%s::%s\n", level, location,
+			escapeAnnotationProperty(res.Email), escapeAnnotationData(ciMessage(res)))
+	}
+	fmt.Fprintf(w, "::notice title=mailify::%d passed, %d failed, %d errors, %d skipped\n",
+		counts[ciPass], counts[ciFail], counts[ciError], counts[ciSkip])
+}
+
+// escapeAnnotationData escapes the message of a workflow command.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a property of a workflow command.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// emailDomain returns the domain of an address, or "invalid" if it has none.
+func emailDomain(email string) string {
+	if at := strings.LastIndex(email, "@"); at >= 0 && at < len(email)-1 {
+		return email[at+1:]
+	}
+	return "invalid"
+}
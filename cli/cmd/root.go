@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
 
 	"github.com/adarsh-jaiss/mailify"
 	"github.com/spf13/cobra"
@@ -14,10 +23,61 @@ var (
 	client         *mailify.Client
 	emailToCheck   string
 	excelFile      string
+	textFile       string
 	domain         string
 	receipientEmail string
+	outputJSON      bool
+	concurrency     int
+	adaptive        bool
+	domainLimit     int
+	domainInterval  time.Duration
+	maxAttempts     int
+	timeout         time.Duration
+	mode            string
+	batchSize       int
+	cacheDir        string
+	resultTTL       time.Duration
+	resolverAddr    string
+	compareResolvers []string
+	templateText    string
+	locale          string
+	splitCells      string
+	textEncoding    string
+	emailColumn     string
+	mappingPath     string
+	skipValidated   bool
+	maxAge          time.Duration
+	dryRun          bool
+	sampleSize      string
+	signKey         string
+	manifestPath    string
+	piiMode         string
+	auditLog        string
+	profileName     string
+	heloName        string
+	learnMX         bool
+	tarpitThreshold time.Duration
+	smtpPortList    []string
+	tlsModeName     string
+	fallbackAPIs    []string
+	dedupe          string
+	dedupeFPRate    float64
+	dedupeCapacity  int
+	prefetch        int
+	priorityFile    string
+	configPath      string
+	probeSeed       int64
+	protectedList   []string
+	domainBudget    int
+	runBudget       int
+	// profile is the profile --profile or the config file selected, if any.
+	profile *mailify.Profile
 )
 
+// piiKeyEnv is the environment variable holding the key --pii hashes
+// addresses with, kept out of flags so it doesn't show up in process listings.
+const piiKeyEnv = "MAILIFY_PII_KEY"
+
 // rootCmd represents the base command for the Mailify CLI tool
 // rootCmd represents the base command when called without any subcommands.
 // It provides functionality to validate email addresses and get mail server information.
@@ -27,9 +87,52 @@ var (
 // 
 // Flags:
 //   -e, --email string       Email address to validate
-//   -x, --excel string       Path to Excel file for bulk email validation
+//   -x, --excel string       Path to an Excel, CSV or ZIP file, a directory or a glob for bulk email validation
+//   -t, --text string        Path to a text file to extract and validate email addresses from
+//       --encoding string    Encoding of the --text file and CSV files: auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252
 //   -d, --domain string      Domain to get mail servers for
 //   -r, --receipient string  Email address to get mail servers for
+//   -j, --json               Print validation results as JSON
+//   -c, --concurrency int    Number of emails to validate at once in bulk runs
+//       --adaptive           Adjust bulk concurrency automatically, up to --concurrency
+//       --domain-concurrency Max emails of the same domain validated at once in bulk runs
+//       --domain-interval    Min time between validations of the same domain in bulk runs
+//       --batch-size int     Max emails of the same domain checked in one SMTP transaction
+//       --split-cells string Split cells holding several emails: off, aggregate or explode
+//       --dedupe string      Skip repeated emails: off, exact or bloom (fixed memory, rare false positives)
+//       --prefetch int       DNS lookups at once in a pass over every domain before validating
+//       --priority string    File of emails to validate before the rest, e.g. the newest signups
+//       --column string      Header of the Excel column holding the emails, detected if not given
+//       --mapping string     YAML or JSON file naming a file format's columns and the result columns to write
+//       --skip-validated     Only validate the rows of the -e files without a verdict from an earlier run
+//       --max-age duration   With --skip-validated, validate rows again whose results are older than this;
+//                            with --result-ttl, the oldest cached result to take
+//       --dry-run            Report what a bulk run would validate and check its outputs, without network activity
+//       --sample string      Validate a random sample, e.g. 500 or 2%, and estimate the whole list's verdicts
+//       --sign-key string    ed25519 key to sign a manifest of the -e run with
+//       --manifest string    Where to write the manifest of the -e run, signed with --sign-key if given
+//       --attempts int       Max attempts for DNS lookups, connections and SMTP conversations
+//       --tarpit-threshold   Most time a mail server may take to answer a command (default 15s)
+//       --ports strings      SMTP ports to try, in order (default 25,587,465)
+//       --tls string         How to secure SMTP connections: auto, starttls, implicit or none
+//       --fallback strings   Verification APIs to ask when SMTP checks are blocked: zerobounce, neverbounce, kickbox
+//       --mode string        How much of the network to use: full, dns or offline
+//       --cache-dir string   Directory to remember catch-all domains in between runs
+//       --result-ttl duration  With --cache-dir, answer emails validated within this long from the cache
+//       --learn-mx           Try the mail servers that have been most responsive first
+//       --resolver string    DNS resolver: host:port, tls://host[:port] (DoT) or an https:// DoH URL
+//       --compare-resolvers  More resolvers to compare MX records against, flagging disagreements
+//       --template string    Go text/template to print results with, or @file to read it from
+//       --locale string      Language of result messages: en, es, fr, de or hi
+//       --pii string         How addresses appear in errors and cache keys: plain, hash or redact
+//       --audit-log string   File to append a JSON record of every validation to
+//       --helo-name string   Fully qualified name to introduce this host with in EHLO, guessed if not given
+//       --profile string     Named settings to validate with: aggressive, polite, offline or one from the config
+//       --config string      Config file defining profiles (default $XDG_CONFIG_HOME/mailify/config.json)
+//       --probe-seed int     Seed the made-up mailboxes of catch-all probes are derived from
+//       --protected-domains  Domains never to probe over SMTP, such as your own and partners'
+//       --max-probes-per-domain  Most SMTP probes of one domain a day, across runs with --cache-dir
+//       --max-probes         Most SMTP probes of the run
 // 
 // Examples:
 //   # Validate a single email address
@@ -38,6 +141,21 @@ var (
 //   # Bulk validate emails from an Excel file
 //   mailify --excel emails.xlsx
 // 
+//   # Bulk validate a client's export, laid out as its mapping file says
+//   mailify --excel client_x.xlsx --mapping client_x.yaml
+// 
+//   # Validate only the rows added since last time, and those last validated over 30 days ago
+//   mailify --excel emails.xlsx --max-age 720h
+// 
+//   # Judge a purchased list from 500 of its emails before paying for a full run
+//   mailify --excel purchased.csv --sample 500 -c 10
+// 
+//   # See what a bulk run would do before starting it
+//   mailify --excel 'lists/*.xlsx' --dry-run -c 10
+// 
+//   # Bulk validate gently from a shared IP, with a longer timeout than the profile's
+//   mailify --excel emails.xlsx --profile polite --timeout 2m
+// 
 //   # Get mail servers for a domain
 //   mailify --domain example.com
 // 
@@ -53,27 +171,181 @@ It can process single email addresses or bulk validate emails from Excel files.`
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Initialize client
 		var err error
-		client, err = mailify.NewClient(senderEmail)
+		validationMode, ok := mailify.ParseValidationMode(mode)
+		if !ok {
+			return fmt.Errorf("unknown mode %q, expected full, dns or offline", mode)
+		}
+		opts, err := profileOptions()
+		if err != nil {
+			return err
+		}
+		if !fromProfile(cmd, "attempts") {
+			retry := mailify.DefaultRetryPolicy()
+			retry.MaxAttempts = maxAttempts
+			opts = append(opts, mailify.WithRetryPolicy(retry))
+		}
+		if !fromProfile(cmd, "mode") {
+			opts = append(opts, mailify.WithMode(validationMode))
+		}
+		if !fromProfile(cmd, "timeout") {
+			opts = append(opts, mailify.WithTimeout(timeout))
+		}
+		if dryRun {
+			if emailToCheck != "" || domain != "" || receipientEmail != "" {
+				return fmt.Errorf("--dry-run works with --excel and --text only")
+			}
+			// Nothing is validated, so the sender and HELO name, which take
+			// DNS lookups to check, aren't checked
+			opts = append(opts, mailify.WithoutSenderCheck())
+		} else if heloName != "" {
+			opts = append(opts, mailify.WithHELOName(heloName))
+		}
+		opts = append(opts, mailify.WithTarpitThreshold(tarpitThreshold))
+		transport, err := smtpTransportOptions()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, transport...)
+		opts = append(opts, mxChangeOptions()...)
+		if len(fallbackAPIs) > 0 {
+			option, err := fallbackOption(fallbackAPIs)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, option)
+		}
+		if learnMX {
+			opts = append(opts, mailify.WithMXLearning())
+		}
+		if prefetch > 0 {
+			// The prefetch pass keeps what it looks up in the MX cache
+			opts = append(opts, mailify.WithMXCache(prefetchTTL))
+		}
+		if cacheDir != "" {
+			cache, err := mailify.NewFileCache(cacheDir)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, mailify.WithCache(cache))
+		}
+		if resultTTL > 0 {
+			opts = append(opts, mailify.WithResultCache(resultTTL))
+		}
+		if templateText != "" {
+			tmpl, err := parseTemplate(templateText)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, mailify.WithResultTemplate(tmpl))
+		}
+		if locale != "" {
+			resultLocale, ok := mailify.ParseLocale(locale)
+			if !ok {
+				return fmt.Errorf("unsupported locale %q, expected one of %v", locale, mailify.Locales())
+			}
+			opts = append(opts, mailify.WithLocale(resultLocale))
+		}
+		if piiMode != "" {
+			option, err := piiOption()
+			if err != nil {
+				return err
+			}
+			opts = append(opts, option)
+		}
+		if auditLog != "" {
+			sink, err := mailify.NewFileAuditSink(auditLog)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, mailify.WithAuditSink(sink))
+		}
+		client, err = mailify.NewClient(senderEmail, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to create mailify client: %v", err)
 		}
+		defer client.Close()
 
 		// Handle single email validation
 		if emailToCheck != "" {
-			result, err := client.ValidateEmail(emailToCheck)
+			result, err := client.ValidateEmailMaxAge(emailToCheck, maxAge)
 			if err != nil {
 				return fmt.Errorf("failed to validate email: %v", err)
 			}
-			fmt.Println(client.FormatValidationResult(emailToCheck, result))
+			if outputJSON {
+				out, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to encode result: %v", err)
+				}
+				fmt.Println(string(out))
+			} else {
+				out, err := client.RenderValidationResult(emailToCheck, result)
+				if err != nil {
+					return fmt.Errorf("failed to render result: %v", err)
+				}
+				fmt.Println(out)
+			}
 		}
 
-		// Handle bulk validation from Excel
+		// Handle bulk validation from Excel, CSV and ZIP files
 		if excelFile != "" {
-			err := client.ProcessAndValidateEmailsViaExcel(excelFile, client.SenderEmail)
+			enc, ok := mailify.ParseTextEncoding(textEncoding)
+			if !ok {
+				return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", textEncoding)
+			}
+			multiAddress, ok := mailify.ParseMultiAddressMode(splitCells)
+			if !ok {
+				return fmt.Errorf("unknown --split-cells mode %q, expected off, aggregate or explode", splitCells)
+			}
+			notify, err := notifiers(senderEmail)
 			if err != nil {
-				return fmt.Errorf("failed to process Excel file: %v", err)
+				return err
+			}
+			opts, err := bulkOptions(cmd)
+			if err != nil {
+				return err
+			}
+			opts.Notifiers = notify
+			opts.EmailColumn = emailColumn
+			opts.SkipValidated = skipValidated || maxAge > 0
+			opts.MaxAge = maxAge
+			if mappingPath != "" {
+				if opts.Mapping, err = mailify.LoadColumnMapping(mappingPath); err != nil {
+					return err
+				}
+			}
+			opts.Encoding = enc
+			opts.MultiAddress = multiAddress
+			opts.Stats = mailify.NewRunStats()
+			if dryRun {
+				if err := planFiles(excelFile, opts); err != nil {
+					return err
+				}
+			} else if sampleSize != "" {
+				if err := sampleFiles(excelFile, opts); err != nil {
+					return err
+				}
+			} else {
+				ctx, cancel := interruptContext()
+				defer cancel()
+				opts.Context = ctx
+				var summary mailify.BulkSummary
+				if signKey != "" || manifestPath != "" {
+					summary, err = processAndRecord(excelFile, opts)
+				} else {
+					summary, err = client.ProcessAndValidateEmailsViaFiles(excelFile, opts)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to process Excel files: %v", err)
+				}
+				fmt.Printf("Successfully processed and validated emails in %d file(s)\n", summary.Files)
+			}
+		}
+
+		// Handle bulk validation of addresses found in free text
+		if textFile != "" {
+			if err := validateText(cmd, textFile); err != nil {
+				return err
 			}
-			fmt.Println("Successfully processed and validated emails in", excelFile)
 		}
 
 		// Handle domain mail servers
@@ -101,7 +373,7 @@ It can process single email addresses or bulk validate emails from Excel files.`
 		}
 
 		// Check if no flags were provided
-		if emailToCheck == "" && excelFile == "" && domain == "" && receipientEmail == "" {
+		if emailToCheck == "" && excelFile == "" && textFile == "" && domain == "" && receipientEmail == "" {
 			return fmt.Errorf("no operation specified. Use --help to see available flags")
 		}
 
@@ -109,11 +381,479 @@ It can process single email addresses or bulk validate emails from Excel files.`
 	},
 }
 
+// validateText extracts the email addresses from a text file, or stdin if
+// path is "-", decoding it as --encoding says, and validates them with the
+// bulk flags.
+func validateText(cmd *cobra.Command, path string) error {
+	enc, ok := mailify.ParseTextEncoding(textEncoding)
+	if !ok {
+		return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", textEncoding)
+	}
+	emails, err := readEmails(path, enc)
+	if err != nil {
+		return err
+	}
+	opts, err := bulkOptions(cmd)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		analysis := client.AnalyzeList(emails)
+		return printPlan(&mailify.RunPlan{
+			Files:    []mailify.FilePlan{{Path: path, Output: "stdout", Emails: len(emails)}},
+			Analysis: analysis,
+			Estimate: analysis.EstimateDuration(opts, dryRunPerAddress),
+		})
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	opts.Context = ctx
+	if sampleSize != "" {
+		size, err := parseSampleSize()
+		if err != nil {
+			return err
+		}
+		estimate, err := client.ValidateSample(emails, size, opts)
+		return printSample(estimate, err)
+	}
+
+	results := client.ValidateBulk(emails, opts)
+	if outputJSON {
+		return printResultsJSON(results)
+	}
+	for _, res := range results {
+		switch {
+		case res.Err != nil:
+			fmt.Printf("%s: error: %v\n", res.Email, res.Err)
+		case res.Result.SubStatus != "":
+			fmt.Printf("%s: %s (%s)\n", res.Email, res.Result.Verdict, res.Result.SubStatus)
+		default:
+			fmt.Printf("%s: %s\n", res.Email, res.Result.Verdict)
+		}
+	}
+	return nil
+}
+
+// sampleFiles validates a --sample of the emails in files like -e reads them,
+// writing nothing to them, and prints what it says about the whole list.
+func sampleFiles(pattern string, opts mailify.BulkOptions) error {
+	size, err := parseSampleSize()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := interruptContext()
+	defer cancel()
+	opts.Context = ctx
+	estimate, err := client.SampleFiles(pattern, size, opts)
+	return printSample(estimate, err)
+}
+
+// parseSampleSize parses --sample.
+func parseSampleSize() (mailify.SampleSize, error) {
+	size, ok := mailify.ParseSampleSize(sampleSize)
+	if !ok {
+		return size, fmt.Errorf("invalid --sample %q, expected a number of emails such as 500 or a percentage such as 2%%", sampleSize)
+	}
+	return size, nil
+}
+
+// printSample prints the estimates of a --sample, as JSON with --json, and
+// returns the error the sample ended with. Estimates from an interrupted
+// sample are printed too.
+func printSample(estimate *mailify.SampleEstimate, err error) error {
+	if estimate == nil {
+		return err
+	}
+	if outputJSON {
+		out, jsonErr := json.MarshalIndent(estimate, "", "  ")
+		if jsonErr != nil {
+			return fmt.Errorf("failed to encode estimate: %v", jsonErr)
+		}
+		fmt.Println(string(out))
+		return err
+	}
+
+	fmt.Println("\n=== Sample estimate (95% confidence) ===")
+	fmt.Printf("Sampled %d of %d emails\n", estimate.Sampled, estimate.Population)
+	for _, verdict := range []mailify.Verdict{mailify.VerdictDeliverable, mailify.VerdictUndeliverable, mailify.VerdictRisky, mailify.VerdictUnknown} {
+		fmt.Printf("%s: %s\n", verdict, estimate.Verdicts[verdict])
+	}
+	fmt.Printf("mailbox full: %s\n", estimate.MailboxFull)
+	if estimate.Errors > 0 {
+		fmt.Printf("Errors, left out: %d\n", estimate.Errors)
+	}
+	return err
+}
+
+// dryRunPerAddress is how long --dry-run takes validating one email to take,
+// for its estimate, as mailify analyze does by default.
+const dryRunPerAddress = 3 * time.Second
+
+// planFiles prints what processing files like -e does would do, and fails
+// if the run would fail on any of them.
+func planFiles(pattern string, opts mailify.BulkOptions) error {
+	plan, err := client.PlanFiles(pattern, opts, dryRunPerAddress)
+	if plan == nil {
+		return err
+	}
+	if err := printPlan(plan); err != nil {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("the run would fail: %v", err)
+	}
+	return nil
+}
+
+// printPlan prints what a --dry-run would do, as JSON with --json.
+func printPlan(plan *mailify.RunPlan) error {
+	if outputJSON {
+		out, err := json.MarshalIndent(struct {
+			*mailify.RunPlan
+			Estimate string `json:"estimate"`
+		}{plan, plan.Estimate.String()}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %v", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Println("=== Dry run: nothing was validated or written ===")
+	for _, file := range plan.Files {
+		switch {
+		case file.Error != "":
+			fmt.Printf("%s: would fail: %s\n", file.Path, file.Error)
+		case file.Kept > 0:
+			fmt.Printf("%s: %d emails to validate, %d rows already validated, results to %s\n", file.Path, file.Emails, file.Kept, file.Output)
+		default:
+			fmt.Printf("%s: %d emails to validate, results to %s\n", file.Path, file.Emails, file.Output)
+		}
+	}
+	fmt.Printf("Emails: %d\n", plan.Analysis.Total)
+	fmt.Printf("Syntax errors: %d\n", plan.Analysis.SyntaxErrors)
+	fmt.Printf("Duplicates: %d\n", plan.Analysis.Duplicates)
+	fmt.Printf("Unique domains: %d\n", plan.Analysis.UniqueDomains)
+	fmt.Printf("Expected SMTP probes: %d\n", plan.Analysis.ExpectedProbes)
+	fmt.Printf("Estimated run time: %s\n", plan.Estimate.Round(time.Second))
+	return nil
+}
+
+// processAndRecord processes files like -e does and writes a manifest of the
+// run to --manifest, signed with --sign-key if it is given.
+func processAndRecord(pattern string, opts mailify.BulkOptions) (mailify.BulkSummary, error) {
+	path := manifestPath
+	if path == "" {
+		path = "mailify-manifest-" + time.Now().Format("20060102T150405") + ".json"
+	}
+	if signKey == "" {
+		summary, manifest, err := client.ProcessAndRecordFiles(pattern, opts)
+		if manifest.Tool == "" {
+			return summary, err
+		}
+		if writeErr := manifest.WriteFile(path); writeErr != nil {
+			return summary, writeErr
+		}
+		fmt.Printf("Manifest has been written to: %s\n", path)
+		return summary, err
+	}
+
+	key, err := mailify.LoadSigningKey(signKey)
+	if err != nil {
+		return mailify.BulkSummary{}, err
+	}
+	summary, signed, err := client.ProcessAndSignFiles(pattern, opts, key)
+	if signed.Signature == "" {
+		return summary, err
+	}
+	if writeErr := signed.WriteFile(path); writeErr != nil {
+		return summary, writeErr
+	}
+	fmt.Printf("Signed manifest has been written to: %s\n", path)
+	return summary, err
+}
+
+// defaultTimeout is the default of the --timeout flags, long enough for slow
+// servers while keeping unresponsive domains from holding up bulk runs.
+const defaultTimeout = 30 * time.Second
+
+// interruptContext returns a context that is done on Ctrl+C or SIGTERM, for
+// bulk runs to stop starting validations and save the results they have. A
+// second signal quits at once. Calling cancel stops listening for signals.
+func interruptContext() (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "\nInterrupted: finishing the validations in progress and saving the results, press Ctrl+C again to quit at once")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// bulkOptions returns the bulk options of the bulk flags. Flags not given
+// are left zero when a profile is in use, for the client to take the
+// profile's settings.
+func bulkOptions(cmd *cobra.Command) (mailify.BulkOptions, error) {
+	var opts mailify.BulkOptions
+	switch dedupe {
+	case "off":
+	case "exact":
+		opts.Dedupe = mailify.NewExactDeduper()
+	case "bloom":
+		opts.Dedupe = mailify.NewBloomDeduper(dedupeCapacity, dedupeFPRate)
+	default:
+		return opts, fmt.Errorf("unknown --dedupe mode %q, expected off, exact or bloom", dedupe)
+	}
+	if !fromProfile(cmd, "concurrency") {
+		opts.Concurrency = concurrency
+	}
+	if !fromProfile(cmd, "domain-concurrency") {
+		opts.DomainConcurrency = domainLimit
+	}
+	if !fromProfile(cmd, "domain-interval") {
+		opts.DomainInterval = domainInterval
+	}
+	if !fromProfile(cmd, "batch-size") {
+		opts.BatchSize = batchSize
+	}
+	if adaptive {
+		limit := concurrency
+		if fromProfile(cmd, "concurrency") {
+			limit = profile.Concurrency
+		}
+		opts.Adaptive = mailify.NewAdaptiveConcurrency(1, limit)
+	}
+	opts.Prefetch = prefetch
+	if priorityFile != "" {
+		enc, ok := mailify.ParseTextEncoding(textEncoding)
+		if !ok {
+			return opts, fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", textEncoding)
+		}
+		emails, err := readEmails(priorityFile, enc)
+		if err != nil {
+			return opts, err
+		}
+		urgent := make(map[string]bool, len(emails))
+		for _, email := range emails {
+			urgent[priorityKey(email)] = true
+		}
+		opts.Priority = func(email string) bool { return urgent[priorityKey(email)] }
+	}
+	return opts, nil
+}
+
+// priorityKey returns what --priority matches an email by: the email without
+// its display name, normalized and lowercased.
+func priorityKey(email string) string {
+	if address, err := mailify.ParseAddress(email); err == nil {
+		email = address.Email
+	}
+	if normalized, err := mailify.NormalizeEmail(email); err == nil {
+		email = normalized
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// loadConfig loads the config file, --config or else the default one if it
+// exists. Without either, the config is empty.
+func loadConfig() (mailify.Config, error) {
+	path := configPath
+	if path == "" {
+		if defaultPath, err := mailify.DefaultConfigPath(); err == nil {
+			if _, err := os.Stat(defaultPath); err == nil {
+				path = defaultPath
+			}
+		}
+	}
+	if path == "" {
+		return mailify.Config{}, nil
+	}
+	return mailify.LoadConfig(path)
+}
+
+// guardOptions returns the client options that keep a run from probing what
+// it mustn't, whatever else it is configured with: the protected domains and
+// probe budget of the config, with the --protected-domains and probe budget
+// flags.
+func guardOptions(config mailify.Config) []mailify.Option {
+	var opts []mailify.Option
+	if protected := append(config.ProtectedDomains, protectedList...); len(protected) > 0 {
+		opts = append(opts, mailify.WithProtectedDomains(protected...))
+	}
+	var budget mailify.ProbeBudget
+	if config.ProbeBudget != nil {
+		budget = *config.ProbeBudget
+	}
+	if option, ok := budgetOption(budget); ok {
+		opts = append(opts, option)
+	}
+	return opts
+}
+
+// profileOptions loads the profile --profile names, or else the config
+// file's default one, and returns the client options to start from: the
+// --resolver options, the config's accept-all rules, politeness settings
+// and probe generator, the --probe-seed flag, the guardOptions and, if a
+// profile was selected, the profile.
+func profileOptions() ([]mailify.Option, error) {
+	opts := resolverOptions()
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(config.AcceptAll) > 0 {
+		opts = append(opts, mailify.WithAcceptAllRules(config.AcceptAll...))
+	}
+	if len(config.Politeness) > 0 {
+		opts = append(opts, mailify.WithProviderPoliteness(config.Politeness...))
+	}
+	if config.ProbeGenerator != nil {
+		opts = append(opts, mailify.WithProbeGenerator(*config.ProbeGenerator))
+	}
+	if probeSeed != 0 {
+		opts = append(opts, mailify.WithProbeSeed(probeSeed))
+	}
+	opts = append(opts, guardOptions(config)...)
+	name := profileName
+	if name == "" {
+		name = config.Profile
+	}
+	if name == "" {
+		return opts, nil
+	}
+	selected, err := config.LookupProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	profile = &selected
+	return append(opts, mailify.WithProfile(selected)), nil
+}
+
+// clientOptions returns the client options of a command that validates
+// lists with the --profile, --config and --timeout flags addClientFlags
+// registers: those of profileOptions, and the --timeout flag's unless the
+// profile sets it.
+func clientOptions(cmd *cobra.Command) ([]mailify.Option, error) {
+	opts, err := profileOptions()
+	if err != nil {
+		return nil, err
+	}
+	if !fromProfile(cmd, "timeout") {
+		opts = append(opts, mailify.WithTimeout(timeout))
+	}
+	return opts, nil
+}
+
+// concurrencyOf returns the --concurrency flag's value, or 0 for the
+// client to take the profile's if the profile sets it.
+func concurrencyOf(cmd *cobra.Command, concurrency int) int {
+	if fromProfile(cmd, "concurrency") {
+		return 0
+	}
+	return concurrency
+}
+
+// addClientFlags registers the flags clientOptions reads on a command,
+// --max-probes among them, and --config too if withConfig is set, for
+// commands that don't have a --config of their own.
+func addClientFlags(cmd *cobra.Command, withConfig bool) {
+	cmd.Flags().StringVar(&profileName, "profile", "", profileUsage)
+	if withConfig {
+		cmd.Flags().StringVar(&configPath, "config", "", configUsage)
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultTimeout, timeoutUsage)
+	cmd.Flags().IntVar(&runBudget, "max-probes", 0, maxProbesUsage)
+}
+
+// fromProfile reports whether the setting of a flag comes from the profile
+// in use, which it does unless the flag was given.
+func fromProfile(cmd *cobra.Command, flag string) bool {
+	return profile != nil && !cmd.Flags().Changed(flag)
+}
+
+// piiOption returns the client option for the --pii flag, keyed with the
+// key in $MAILIFY_PII_KEY.
+func piiOption() (mailify.Option, error) {
+	pii, ok := mailify.ParsePIIMode(piiMode)
+	if !ok {
+		return nil, fmt.Errorf("unknown --pii mode %q, expected plain, hash or redact", piiMode)
+	}
+	key := os.Getenv(piiKeyEnv)
+	if pii != mailify.PIIPlain && key == "" {
+		return nil, fmt.Errorf("--pii %s needs a key in $%s", piiMode, piiKeyEnv)
+	}
+	return mailify.WithPIIMode(pii, []byte(key)), nil
+}
+
+// resolverOptions returns the client options for the --resolver and
+// --compare-resolvers flags.
+func resolverOptions() []mailify.Option {
+	var opts []mailify.Option
+	if resolverAddr != "" {
+		opts = append(opts, mailify.WithResolver(newResolver(resolverAddr)))
+	}
+	var compare []mailify.NamedResolver
+	for _, spec := range compareResolvers {
+		compare = append(compare, mailify.NamedResolver{Name: spec, Resolver: newResolver(spec)})
+	}
+	if len(compare) > 0 {
+		opts = append(opts, mailify.WithMXConsistencyCheck(compare...))
+	}
+	return opts
+}
+
+// parseTemplate parses the --template flag, reading the template from a file
+// if it starts with @.
+func parseTemplate(text string) (*template.Template, error) {
+	if path, ok := strings.CutPrefix(text, "@"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %v", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("result").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// newResolver creates a resolver from a plain DNS server given as host:port,
+// a DNS-over-TLS server given as tls://host[:port], or a DNS-over-HTTPS endpoint URL.
+func newResolver(spec string) mailify.Resolver {
+	switch {
+	case strings.HasPrefix(spec, "https://"):
+		return mailify.NewDoHResolver(spec, nil)
+	case strings.HasPrefix(spec, "tls://"):
+		return mailify.NewDoTResolver(strings.TrimPrefix(spec, "tls://"), "")
+	}
+
+	address := spec
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
 // Execute runs the root command and handles any errors that occur during its execution.
-// If an error is encountered, it prints the error message and exits the program with a status code of 1.
+// If an error is encountered, it prints the error message to stderr, keeping
+// stdout for reports, and exits the program with a status code of 1.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -123,8 +863,41 @@ func Execute() {
 // - sender: Required flag for specifying the sender email address.
 // - validate: Optional flag for validating a single email address.
 // - excel: Optional flag for processing and validating emails from an Excel file.
+// - text: Optional flag for validating the emails found in a text file.
+// - encoding: Optional encoding of the text file, detected by default.
 // - domain: Optional flag for getting mail servers for a domain.
 // - receipient: Optional flag for getting mail servers for a recipient email.
+// - json: Optional flag for printing validation results as JSON.
+// - template: Optional Go template for printing validation results.
+// - locale: Optional language for validation result messages.
+// - pii: Optional hashing or redaction of addresses in errors and cache keys.
+// - audit-log: Optional file every validation is recorded in.
+// - concurrency: Optional flag for the number of emails validated at once in bulk runs.
+// - adaptive: Optional flag for adjusting bulk concurrency automatically.
+// - domain-concurrency, domain-interval: Optional per-domain limits for bulk runs.
+// - batch-size: Optional flag for checking several emails of a domain in one SMTP transaction.
+// - split-cells: Optional flag for validating each email of cells that hold several.
+// - dedupe, dedupe-fp-rate, dedupe-capacity: Optional skipping of repeated emails in bulk runs.
+// - prefetch: Optional DNS lookups of every domain before a bulk run starts validating.
+// - priority: Optional list of emails to validate first in bulk runs.
+// - column: Optional header of the Excel column holding the emails.
+// - mapping: Optional file describing the columns of a recurring file format.
+// - skip-validated, max-age: Optional validation of only the rows without recent results.
+// - dry-run: Optional report of what a bulk run would do, without validating.
+// - sample: Optional validation of a random part of a list to estimate its quality.
+// - attempts: Optional flag for the number of attempts before giving up on a server.
+// - timeout: Optional limit on the time each validation may take.
+// - helo-name: Optional name to introduce this host with in EHLO.
+// - tarpit-threshold: Optional limit on how long a mail server may take to answer a command.
+// - ports, tls: Optional SMTP ports to try and how to secure the connections.
+// - fallback: Optional verification APIs to ask about mailboxes SMTP checks are blocked for.
+// - mode: Optional flag for skipping SMTP (dns) or all network checks (offline).
+// - cache-dir: Optional directory where catch-all determinations are kept between runs.
+// - result-ttl: Optional time validation results are kept in the cache-dir for.
+// - learn-mx: Optional reordering of mail servers by how responsive they have been.
+// - resolver: Optional DNS resolver for every command, plain, DNS-over-TLS or DNS-over-HTTPS.
+// - compare-resolvers: Optional resolvers whose MX records are compared with the main resolver's.
+// - profile, config: Optional named settings for validation and the file defining more of them.
 func init() {
 	// Required sender email flag
 	rootCmd.Flags().StringVarP(&senderEmail, "sender", "s", "", "Sender email address (required)")
@@ -132,7 +905,144 @@ func init() {
 
 	// Operation flags
 	rootCmd.Flags().StringVarP(&emailToCheck, "validate", "v", "", "Validate a single email address")
-	rootCmd.Flags().StringVarP(&excelFile, "excel", "e", "", "Process and validate emails from an Excel file")
+	rootCmd.Flags().StringVarP(&excelFile, "excel", "e", "", "Process and validate emails from an Excel, CSV or ZIP file, or from every such file in a directory or matching a glob such as 'lists/*.xlsx'")
+	rootCmd.Flags().StringVarP(&textFile, "text", "t", "", "Extract the email addresses from a text file, or - for stdin, and validate them")
+	rootCmd.Flags().StringVar(&textEncoding, "encoding", "auto", "Encoding of the --text file and CSV files: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
 	rootCmd.Flags().StringVarP(&domain, "domain", "d", "", "Get mail servers for a domain")
 	rootCmd.Flags().StringVarP(&receipientEmail, "receipient", "r", "", "Get mail servers for a receipient email")
-}
\ No newline at end of file
+
+	// Output flags
+	rootCmd.Flags().BoolVarP(&outputJSON, "json", "j", false, "Print validation results as JSON")
+	rootCmd.Flags().StringVar(&templateText, "template", "", "Go text/template to print validation results with, e.g. '{{.Email}}: {{.Verdict}}', or @file to read it from a file")
+	rootCmd.Flags().StringVar(&locale, "locale", "", "Language of validation result messages: en, es, fr, de or hi (default en)")
+	rootCmd.Flags().StringVar(&piiMode, "pii", "", "Keep addresses out of error messages and cache keys: plain, hash (replaced by an HMAC keyed with $"+piiKeyEnv+") or redact (HMAC of the local part, domain kept)")
+
+	// Audit flags
+	rootCmd.Flags().StringVar(&auditLog, "audit-log", "", "File to append a JSON line to for every validation, with the verdict, SMTP evidence and configuration, for resolving disputes later")
+
+	// Bulk flags
+	rootCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 1, "Number of emails to validate at once in bulk runs")
+	rootCmd.Flags().BoolVar(&adaptive, "adaptive", false, "Adjust bulk concurrency automatically between 1 and --concurrency, backing off on deferrals")
+	rootCmd.Flags().IntVar(&domainLimit, "domain-concurrency", 0, "Max emails of the same domain validated at once in bulk runs (0 for no limit)")
+	rootCmd.Flags().DurationVar(&domainInterval, "domain-interval", 0, "Min time between validations of the same domain in bulk runs, e.g. 2s")
+	rootCmd.Flags().IntVar(&batchSize, "batch-size", 1, "Max emails of the same domain checked in one SMTP transaction in bulk runs")
+	rootCmd.Flags().StringVar(&emailColumn, "column", "", "Header of the Excel column holding the emails (default the \"email\" column, or else the column that looks most like emails)")
+	rootCmd.Flags().StringVar(&mappingPath, "mapping", "", "YAML or JSON file naming the columns holding the email, first name, last name and company, and the result columns to write, for a recurring file format")
+	rootCmd.Flags().BoolVar(&skipValidated, "skip-validated", false, "Only validate the rows of the -e files without a verdict from an earlier run, leaving the rest as they are, to top up a huge sheet")
+	rootCmd.Flags().DurationVar(&maxAge, "max-age", 0, "Validate rows again whose results are older than this, e.g. 720h; implies --skip-validated. With --result-ttl, also the oldest cached result taken")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Read the --excel or --text input and report what would be validated (emails, unique domains, expected SMTP probes and run time), checking that results can be written, without any network activity")
+	rootCmd.Flags().StringVar(&sampleSize, "sample", "", "Validate a random sample of the --excel or --text emails, a number such as 500 or a percentage such as 2%, and estimate the share of the whole list with each verdict with 95% confidence intervals, writing nothing to the files")
+	rootCmd.Flags().StringVar(&signKey, "sign-key", "", "PEM ed25519 private key to sign a manifest of the -e run with, recording file hashes, version, configuration and times (see mailify keygen)")
+	rootCmd.Flags().StringVar(&manifestPath, "manifest", "", "Where to write a manifest of the -e run, recording file hashes, version, configuration and the seed of catch-all probes, to repeat it with mailify replay; signed if --sign-key is given (default mailify-manifest-<time>.json)")
+	rootCmd.Flags().StringVar(&dedupe, "dedupe", "off", "Skip emails already seen in bulk runs: off, exact (every email kept in memory) or bloom (a Bloom filter of fixed size, for lists of tens of millions)")
+	rootCmd.Flags().Float64Var(&dedupeFPRate, "dedupe-fp-rate", 0.001, "Share of new emails --dedupe bloom may mistake for repeats and skip")
+	rootCmd.Flags().IntVar(&dedupeCapacity, "dedupe-capacity", 10_000_000, "Number of distinct emails --dedupe bloom is sized for; past it, more are mistaken for repeats")
+	rootCmd.Flags().IntVar(&prefetch, "prefetch", 0, "Look up the MX records and mail server addresses of every domain in bulk runs before validating, this many at once, so validations don't stall on DNS (0 for off)")
+	rootCmd.Flags().StringVar(&priorityFile, "priority", "", "File of emails, e.g. the newest signups, to validate before the rest of a bulk run, which carries on in the background while none of them can start")
+	rootCmd.Flags().StringVar(&splitCells, "split-cells", "off", "Split Excel cells holding several emails separated by commas or semicolons: off, aggregate (results joined in the same row) or explode (a row per email)")
+
+	// Retry flags
+	rootCmd.Flags().IntVar(&maxAttempts, "attempts", mailify.DefaultRetryPolicy().MaxAttempts, "Max attempts for DNS lookups, connections and SMTP conversations, retried with exponential backoff")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", defaultTimeout, timeoutUsage)
+	rootCmd.Flags().StringVar(&heloName, "helo-name", "", heloNameUsage)
+	rootCmd.Flags().DurationVar(&tarpitThreshold, "tarpit-threshold", defaultTarpitThreshold, tarpitUsage)
+	rootCmd.Flags().StringSliceVar(&smtpPortList, "ports", nil, portsUsage)
+	rootCmd.Flags().StringVar(&tlsModeName, "tls", string(mailify.TLSAuto), tlsUsage)
+	rootCmd.Flags().StringSliceVar(&fallbackAPIs, "fallback", nil, fallbackUsage)
+
+	// Mode flags
+	rootCmd.Flags().StringVar(&mode, "mode", "full", "How much of the network to use: full, dns (no SMTP) or offline (syntax, role and disposable checks only)")
+
+	// Cache flags
+	rootCmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Directory to remember catch-all domains in, so later runs skip probing them")
+	rootCmd.Flags().DurationVar(&resultTTL, "result-ttl", 0, "With --cache-dir, keep validation results for this long, e.g. 720h, and answer emails validated again within it from the cache (0 for off)")
+	rootCmd.Flags().BoolVar(&learnMX, "learn-mx", false, "Learn how often and how fast each mail server answers and try the most responsive first, across runs with --cache-dir")
+
+	// DNS flags
+	rootCmd.PersistentFlags().StringSliceVar(&protectedList, "protected-domains", nil, "Domains, and their subdomains, never to probe over SMTP, such as your own and partners'; their addresses are answered from the cache or come back unknown")
+	rootCmd.PersistentFlags().IntVar(&domainBudget, "max-probes-per-domain", 0, "Most SMTP probes of one domain's mail servers a day, counted across runs with --cache-dir; addresses past it come back unknown until the next day (0 for no cap)")
+	rootCmd.Flags().IntVar(&runBudget, "max-probes", 0, maxProbesUsage)
+	rootCmd.PersistentFlags().StringVar(&resolverAddr, "resolver", "", "DNS resolver: host:port, tls://host[:port] for DNS-over-TLS or an https:// URL for DNS-over-HTTPS (default 8.8.8.8)")
+	rootCmd.Flags().StringSliceVar(&compareResolvers, "compare-resolvers", nil, "More resolvers, in the same forms as --resolver, to compare MX records against to spot split-horizon DNS or poisoning")
+
+	// Profile flags
+	rootCmd.Flags().StringVar(&profileName, "profile", "", profileUsage)
+	rootCmd.Flags().StringVar(&configPath, "config", "", configUsage)
+	rootCmd.Flags().Int64Var(&probeSeed, "probe-seed", 0, probeSeedUsage)
+}
+
+// profileUsage, configUsage, timeoutUsage, maxProbesUsage, probeSeedUsage,
+// heloNameUsage, tarpitUsage, portsUsage, tlsUsage and fallbackUsage
+// describe the --profile, --config, --timeout, --max-probes, --probe-seed,
+// --helo-name, --tarpit-threshold, --ports, --tls and --fallback flags of
+// the commands that validate.
+const (
+	heloNameUsage = "Fully qualified name to introduce this host with in EHLO, which its IP address should resolve back to (default guessed from the hostname)"
+	profileUsage = "Named timeouts, concurrency, rate limits and probing to validate with: aggressive, polite, offline or one defined in the config file; flags given override it"
+	configUsage  = "Config file defining profiles and the default one (default mailify/config.json in the user config directory, if it exists)"
+	timeoutUsage = "Most time each validation may take, across all mail servers, ports and retries (0 for no limit)"
+	maxProbesUsage = "Most SMTP probes of the run, catch-all probes included; addresses past it come back unknown (0 for no cap)"
+	probeSeedUsage = "Seed the made-up mailboxes of catch-all probes are derived from, to probe the same ones again in test runs (0 for a random seed)"
+	tarpitUsage  = "Most time a mail server may take to answer a command before it is given up on as tarpitting and skipped for an hour (0 for no limit)"
+	portsUsage   = "SMTP ports to try, in order, e.g. 25 to never fall back to the submission ports (default 25,587,465)"
+	tlsUsage     = "How to secure SMTP connections: auto (STARTTLS on retries, implicit TLS on 465), starttls (required from the first attempt), implicit (TLS on connect on every port) or none"
+	fallbackUsage = "Verification APIs to ask, in order, about mailboxes whose SMTP check is blocked from this host: zerobounce, neverbounce or kickbox, each with its key in $MAILIFY_<NAME>_API_KEY"
+)
+
+// fallbackOption returns the client option of the --fallback flag, reading
+// each verification API's key from $MAILIFY_<NAME>_API_KEY.
+func fallbackOption(names []string) (mailify.Option, error) {
+	var verifiers []mailify.FallbackVerifier
+	for _, name := range names {
+		env := "MAILIFY_" + strings.ToUpper(name) + "_API_KEY"
+		verifier, err := mailify.NewFallbackVerifier(name, os.Getenv(env))
+		if err != nil {
+			return nil, fmt.Errorf("--fallback: %w (set $%s)", err, env)
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return mailify.WithFallbackVerifiers(verifiers...), nil
+}
+
+// budgetOption returns the client option of a probe budget, with the caps
+// the --max-probes-per-domain and --max-probes flags give overriding its
+// own, and false if there are no caps. serve has no --max-probes, as a cap
+// over the server's lifetime would stop it probing for good.
+func budgetOption(budget mailify.ProbeBudget) (mailify.Option, bool) {
+	if domainBudget > 0 {
+		budget.PerDomainPerDay = domainBudget
+	}
+	if runBudget > 0 {
+		budget.PerRun = runBudget
+	}
+	if budget.PerDomainPerDay <= 0 && budget.PerRun <= 0 {
+		return nil, false
+	}
+	return mailify.WithProbeBudget(budget), true
+}
+
+// smtpTransportOptions returns the client options of the --ports and --tls
+// flags.
+func smtpTransportOptions() ([]mailify.Option, error) {
+	mode, err := mailify.ParseTLSMode(tlsModeName)
+	if err != nil {
+		return nil, err
+	}
+	opts := []mailify.Option{mailify.WithTLSMode(mode)}
+	if len(smtpPortList) > 0 {
+		for _, port := range smtpPortList {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return nil, fmt.Errorf("invalid port %q in --ports", port)
+			}
+		}
+		opts = append(opts, mailify.WithPorts(smtpPortList...))
+	}
+	return opts, nil
+}
+
+// defaultTarpitThreshold is the default of --tarpit-threshold, the library's.
+const defaultTarpitThreshold = 15 * time.Second
+
+// prefetchTTL is how long the DNS records --prefetch looks up are kept, long
+// enough to last most runs.
+const prefetchTTL = 6 * time.Hour
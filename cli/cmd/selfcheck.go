@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// selfCheckIP is the public address to check, discovered if empty.
+	selfCheckIP string
+	// selfCheckJSON prints the report as JSON instead of a table.
+	selfCheckJSON bool
+)
+
+// selfCheckCmd checks how this host looks to the mail servers it validates against.
+//
+// Usage:
+//   mailify self-check [flags]
+//
+// Flags:
+//       --ip string         Public address to check (default discovered)
+//       --helo-name string  Name this host introduces itself with in EHLO
+//   -j, --json              Print the report as JSON
+//
+// Examples:
+//   # Check this host before a large run
+//   mailify self-check --helo-name verify.example.com
+var selfCheckCmd = &cobra.Command{
+	Use:   "self-check",
+	Short: "Check whether mail servers will trust this host's checks",
+	Long: `Self-check looks up this host's public address and checks that it has a reverse DNS name, that the
+name resolves back to it and matches the EHLO name, and that it isn't on major DNS blocklists. Mail servers
+often refuse or answer falsely to hosts that fail these checks, which makes verdicts unreliable. The command
+exits with status 1 if any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := append(resolverOptions(), mailify.WithoutSenderCheck())
+		if heloName != "" {
+			opts = append(opts, mailify.WithHELOName(heloName))
+		}
+		client, err := mailify.NewClient("", opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		report, err := client.CheckHostReputation(context.Background(), mailify.HostCheckOptions{IP: selfCheckIP})
+		if err != nil {
+			return err
+		}
+		// A host that looks spammy isn't a usage mistake
+		cmd.SilenceUsage = true
+
+		if selfCheckJSON {
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode report: %v", err)
+			}
+			fmt.Println(string(out))
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "IP:\t"+report.IP)
+			fmt.Fprintln(w, "Reverse DNS:\t"+orDash(strings.Join(report.PTR, ", ")))
+			fmt.Fprintf(w, "Forward-confirmed:\t%v\n", report.ForwardConfirmed)
+			fmt.Fprintf(w, "HELO name:\t%s (matches: %v)\n", report.HELOName, report.HELOMatches)
+			fmt.Fprintln(w, "Blocklists:\t"+firstNonEmpty(strings.Join(report.Blocklists, ", "), "not listed"))
+			for _, warning := range report.Warnings {
+				fmt.Fprintln(w, "Warning:\t"+warning)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+		if !report.Reliable() {
+			return fmt.Errorf("verdicts from this host may be unreliable")
+		}
+		return nil
+	},
+}
+
+func init() {
+	selfCheckCmd.Flags().StringVar(&selfCheckIP, "ip", "", "Public address this host connects to mail servers from (default discovered)")
+	selfCheckCmd.Flags().StringVar(&heloName, "helo-name", "", heloNameUsage)
+	selfCheckCmd.Flags().BoolVarP(&selfCheckJSON, "json", "j", false, "Print the report as JSON")
+	rootCmd.AddCommand(selfCheckCmd)
+}
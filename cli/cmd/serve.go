@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr      string
+	serveDomainTTL time.Duration
+	serveCacheDir  string
+	serveMaxUpload int64
+	serveSender    string
+	serveInFlight  int
+	serveClientCap int
+	serveTenants   string
+	serveResultTTL time.Duration
+	serveTimeout   time.Duration
+)
+
+// serveShutdownTimeout is how long requests in progress are given to finish
+// when the server is stopped.
+const serveShutdownTimeout = 30 * time.Second
+
+// serveCmd serves mailify's REST API over HTTP.
+//
+// Usage:
+//   mailify serve [flags]
+//
+// Flags:
+//       --addr string          Address to listen on (default ":8080")
+//       --domain-ttl duration  How long domain reports are cached (default 1h)
+//       --cache-dir string     Directory of catch-all determinations to report
+//       --result-ttl duration  With --cache-dir, answer addresses validated within this long from the cache
+//       --max-upload int       Largest file accepted for a bulk job, in bytes (default 64 MiB)
+//   -s, --sender string        Sender address for the SMTP checks of validations
+//       --max-in-flight int    Most validations in flight at once, 0 for no limit (default 100)
+//       --client-limit int     Most validations in flight at once for one client IP, 0 for no limit (default 10)
+//       --tenants string       YAML or JSON file of tenants, with their API keys and policies
+//       --fallback strings     Verification APIs to ask when SMTP checks are blocked: zerobounce, neverbounce, kickbox
+//       --timeout duration     Most time each validation may take, 0 for no limit (default 30s)
+//
+// Examples:
+//   # Serve on port 8080, for a signup form's checks
+//   mailify serve --sender verify@example.com
+//   curl 'localhost:8080/validate?email=jane@example.com'
+//   curl localhost:8080/domains/example.com
+//
+//   # Validate a CSV file, then download it with the results once the job is done
+//   curl -F file=@leads.csv localhost:8080/jobs
+//   curl localhost:8080/jobs/<id>
+//   curl -X POST localhost:8080/jobs/<id>/pause
+//   curl -o leads.validated.csv localhost:8080/jobs/<id>/file
+//
+//   # Serve several teams, each with its own API key and policies
+//   mailify serve --tenants tenants.yaml
+//   curl -H 'X-API-Key: growth-7f3a9c' 'localhost:8080/validate?email=jane@example.com'
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve mailify's REST API over HTTP",
+	Long: `Serve answers HTTP requests with JSON, for services written in other languages:
+
+  GET /validate?email=   The result of validating the address, as -v --json prints it, from the
+                         --result-ttl cache if it was validated since max_age=, such as 24h, ago,
+                         given up on after timeout=, such as 10s, in place of --timeout
+  GET /domains/{domain}  The domain's MX records, provider, SPF, DMARC, catch-all status
+                         (if known from --cache-dir) and blocklist status, as domain-health reports them
+  POST /jobs             Start a bulk job on a CSV or Excel file uploaded as the "file" field of a
+                         multipart/form-data request, with the column of the addresses in
+                         "email_column" if it can't be detected; answers with the job's ID
+  GET /jobs/{id}         The job's status: running, paused, done, cancelled or failed, with its
+                         counts once done
+  POST /jobs/{id}/pause  Pause the job; /resume resumes it, and /cancel stops it for good, saving
+                         the file with the results it has
+  GET /jobs/{id}/file    The file with the result columns -e adds, once the job is done
+  GET /jobs/{id}/results The results as JSON lines, one per address, once the job is done
+  GET /healthz           Answers 200 while the server is up, for liveness probes
+  GET /readyz            Checks that the DNS resolver answers, the --cache-dir cache works and
+                         outbound SMTP on port 25 isn't blocked; 503 if not, for readiness probes
+  GET /openapi.json      The OpenAPI document of the API, as mailify openapi prints it
+
+Finished jobs are kept for download for 24 hours. Jobs still running when the server is stopped are
+cancelled, their files saved with the results they have.
+
+Validations, domain lookups and bulk jobs count against --max-in-flight overall and --client-limit
+per client IP until they finish, protecting the reputation of the address they are sent from.
+Requests beyond either are answered with 429 Too Many Requests and a Retry-After header.
+
+With --tenants, every request but those of /healthz, /readyz and /openapi.json must carry the API
+key of a tenant, in the X-API-Key header or as a Bearer token, or is answered with 401. Each
+tenant has its own sender, cap on validations in flight (in place of --client-limit), requests
+per minute, domains it may and may not validate addresses at, and webhooks its job reports are
+posted to, and sees only its own jobs:
+
+  tenants:
+    - name: growth
+      api_keys: [growth-7f3a9c]
+      sender: verify@growth.example.com
+      max_in_flight: 5
+      requests_per_minute: 600
+      deny: ["*.gov"]
+      webhooks: [https://hooks.example.com/mailify]
+
+Domain reports are cached for --domain-ttl, and responses carry Cache-Control, ETag and
+Last-Modified headers so clients can cache them too. Stop with Ctrl+C.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := resolverOptions()
+		opts = append(opts, mailify.WithTimeout(serveTimeout))
+		if serveSender == "" {
+			opts = append(opts, mailify.WithoutSenderCheck())
+		}
+		if serveCacheDir != "" {
+			cache, err := mailify.NewFileCache(serveCacheDir)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, mailify.WithCache(cache))
+		}
+		if serveResultTTL > 0 {
+			opts = append(opts, mailify.WithResultCache(serveResultTTL))
+		}
+		opts = append(opts, mxChangeOptions()...)
+		config, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if config.ProbeBudget != nil {
+			// Only the daily cap, a cap over the server's lifetime would end its probing for good
+			budget := *config.ProbeBudget
+			budget.PerRun = 0
+			config.ProbeBudget = &budget
+		}
+		opts = append(opts, guardOptions(config)...)
+		if len(fallbackAPIs) > 0 {
+			option, err := fallbackOption(fallbackAPIs)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, option)
+		}
+		client, err := mailify.NewClient(serveSender, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		serverOpts := []server.Option{
+			server.WithDomainTTL(serveDomainTTL),
+			server.WithMaxUpload(serveMaxUpload),
+			server.WithMaxInFlight(serveInFlight),
+			server.WithClientLimit(serveClientCap),
+		}
+		if serveTenants != "" {
+			tenants, err := server.LoadTenants(serveTenants)
+			if err != nil {
+				return err
+			}
+			serverOpts = append(serverOpts, server.WithTenants(tenants))
+		}
+
+		handler := server.New(client, serverOpts...)
+		// Jobs still running when the server stops are cancelled, saving what they have
+		defer handler.Close()
+		srv := &http.Server{
+			Addr:              serveAddr,
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Printf("Serving on %s, press Ctrl+C to stop\n", serveAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+	serveCmd.Flags().DurationVar(&serveDomainTTL, "domain-ttl", time.Hour, "How long domain reports are cached, and clients are told they may cache them (0 for no caching)")
+	serveCmd.Flags().StringVar(&serveCacheDir, "cache-dir", "", "Directory of catch-all determinations, as --cache-dir of validation keeps them, to report for domains")
+	serveCmd.Flags().Int64Var(&serveMaxUpload, "max-upload", 64<<20, "Largest file accepted for a bulk job, in bytes")
+	serveCmd.Flags().StringVarP(&serveSender, "sender", "s", "", "Sender address for the SMTP checks of validations")
+	serveCmd.Flags().IntVar(&serveInFlight, "max-in-flight", 100, "Most validations, domain lookups and bulk jobs in flight at once, 0 for no limit")
+	serveCmd.Flags().IntVar(&serveClientCap, "client-limit", 10, "Most validations, domain lookups and bulk jobs in flight at once for one client IP, 0 for no limit")
+	serveCmd.Flags().DurationVar(&serveResultTTL, "result-ttl", 0, "With --cache-dir, keep validation results for this long, e.g. 720h, and answer addresses validated again within it from the cache (0 for off)")
+	serveCmd.Flags().StringVar(&serveTenants, "tenants", "", "YAML or JSON file of tenants, each with its API keys, sender, limits, allowed and denied domains and webhooks")
+	serveCmd.Flags().StringSliceVar(&fallbackAPIs, "fallback", nil, fallbackUsage)
+	serveCmd.Flags().DurationVar(&serveTimeout, "timeout", defaultTimeout, "Most time each validation may take, across all mail servers, ports and retries, unless a request's timeout parameter says otherwise (0 for no limit)")
+	rootCmd.AddCommand(serveCmd)
+}
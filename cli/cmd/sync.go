@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncSender      string
+	syncConfig      string
+	syncAction      string
+	syncTag         string
+	syncVerdicts    []string
+	syncConcurrency int
+	syncJSON        bool
+)
+
+// syncCmd validates a Mailchimp, HubSpot or SendGrid list where it lives.
+//
+// Usage:
+//   mailify sync --config <file> [flags]
+//
+// Flags:
+//   -s, --sender string      Sender email address (required)
+//       --config string      JSON file with the provider, API key and list (required)
+//       --action string      What to do to undeliverable contacts: report, tag or remove (default report)
+//       --tag string         Tag to apply with --action tag
+//       --verdict strings    Verdicts to act on (default undeliverable)
+//   -c, --concurrency int    Number of emails to validate at once
+//   -j, --json               Print the report as JSON
+//       --profile string     Named settings to validate with, from the default config file
+//       --timeout duration   Most time each validation may take, 0 for no limit (default 30s)
+//       --max-probes int     Most SMTP probes of the run
+//
+// Examples:
+//   # Report on a Mailchimp audience
+//   mailify sync --config mailchimp.json -s sender@example.com
+//
+//   # Tag undeliverable and risky contacts
+//   mailify sync --config mailchimp.json -s sender@example.com --action tag --verdict undeliverable --verdict risky
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Validate a Mailchimp, HubSpot or SendGrid list and clean it in place",
+	Long: `Sync pulls a list from Mailchimp, HubSpot or SendGrid through the provider's API, validates every
+contact, and with --action tags or removes the undeliverable ones (or those with the --verdict given).
+The config file is JSON with "provider", "api_key" and "list_id"; the key may be an environment variable
+such as "${MAILCHIMP_API_KEY}". SendGrid needs "tag_list_id" to tag, and HubSpot writes tags to the
+"tag_property" contact property, mailify_status by default.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := mailify.LoadConnectorConfig(syncConfig)
+		if err != nil {
+			return err
+		}
+		conn, err := mailify.NewListConnector(config)
+		if err != nil {
+			return err
+		}
+		verdicts := make([]mailify.Verdict, len(syncVerdicts))
+		for i, v := range syncVerdicts {
+			verdicts[i] = mailify.Verdict(v)
+		}
+
+		notify, err := notifiers(syncSender)
+		if err != nil {
+			return err
+		}
+
+		opts, err := clientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := mailify.NewClient(syncSender, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		summary, err := client.SyncList(ctx, conn, mailify.SyncOptions{
+			Action:   mailify.SyncAction(syncAction),
+			Tag:      syncTag,
+			Verdicts: verdicts,
+			Bulk:     mailify.BulkOptions{Concurrency: concurrencyOf(cmd, syncConcurrency), Notifiers: notify},
+		})
+		if err != nil {
+			return err
+		}
+
+		if syncJSON {
+			out, err := json.MarshalIndent(summary, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode report: %v", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		for _, res := range summary.Results {
+			switch {
+			case res.Error != "":
+				fmt.Printf("%s: ERROR %s\n", res.Contact.Email, res.Error)
+			case res.Action != "":
+				fmt.Printf("%s: %s (%s)\n", res.Contact.Email, res.Result.Verdict, res.Action)
+			}
+		}
+		fmt.Printf("\n=== %s sync summary ===\n", summary.Provider)
+		fmt.Printf("Contacts: %d\n", summary.Contacts)
+		for _, verdict := range []mailify.Verdict{mailify.VerdictDeliverable, mailify.VerdictUndeliverable, mailify.VerdictRisky, mailify.VerdictUnknown} {
+			fmt.Printf("%s: %d\n", verdict, summary.Verdicts[verdict])
+		}
+		fmt.Printf("Tagged: %d\n", summary.Tagged)
+		fmt.Printf("Removed: %d\n", summary.Removed)
+		fmt.Printf("Errors: %d\n", summary.Errors)
+		fmt.Printf("Duration: %s\n", summary.Duration.Round(time.Second))
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().StringVarP(&syncSender, "sender", "s", "", "Sender email address (required)")
+	syncCmd.MarkFlagRequired("sender")
+	syncCmd.Flags().StringVar(&syncConfig, "config", "", "JSON file with the provider, API key and list to sync (required)")
+	syncCmd.MarkFlagRequired("config")
+	syncCmd.Flags().StringVar(&syncAction, "action", "report", "What to do to the contacts found: report (change nothing), tag or remove")
+	syncCmd.Flags().StringVar(&syncTag, "tag", mailify.DefaultSyncTag, "Tag to apply with --action tag")
+	syncCmd.Flags().StringSliceVar(&syncVerdicts, "verdict", []string{"undeliverable"}, "Verdicts of the contacts to act on: deliverable, undeliverable, risky or unknown")
+	syncCmd.Flags().IntVarP(&syncConcurrency, "concurrency", "c", 1, "Number of emails to validate at once")
+	syncCmd.Flags().BoolVarP(&syncJSON, "json", "j", false, "Print the report as JSON")
+	// --config is the list's, so profiles come from the default config file
+	addClientFlags(syncCmd, false)
+	rootCmd.AddCommand(syncCmd)
+}
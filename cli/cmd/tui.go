@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tuiSender      string
+	tuiConcurrency int
+	tuiEncoding    string
+	tuiMode        string
+)
+
+// tuiCmd validates a list in an interactive terminal UI.
+//
+// Usage:
+//   mailify tui <file> [flags]
+//
+// Flags:
+//   -s, --sender string      Sender email address (required)
+//   -c, --concurrency int    Number of emails to validate at once (default 8)
+//       --encoding string    Encoding of the list
+//       --mode string        How much of the network to use: full, dns or offline
+//       --profile string     Named settings to validate with: aggressive, polite, offline or one from the config
+//       --config string      Config file defining profiles, protected domains and probe budgets
+//       --timeout duration   Most time each validation may take, 0 for no limit (default 30s)
+//       --max-probes int     Most SMTP probes of the run
+//
+// Keys:
+//   up/down, pgup/pgdown  Move through the table
+//   s                     Sort by input order, verdict, domain or email
+//   f                     Show every verdict, or only one
+//   d                     Switch between addresses and domains
+//   enter                 On a domain, show only its addresses
+//   p                     Pause or resume the run
+//   x                     Cancel the run, keeping the results so far
+//   q                     Quit
+//
+// Examples:
+//   # Watch a list being validated
+//   mailify tui list.txt -s sender@example.com -c 16
+var tuiCmd = &cobra.Command{
+	Use:   "tui <file>",
+	Short: "Validate a list in an interactive terminal UI",
+	Long: `Tui validates the email addresses in a file, or stdin if the file is -, showing a live table of
+the results that can be sorted and filtered by verdict, a per-domain view to drill down into, and
+controls to pause, resume and cancel long runs. Press q to quit.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc, ok := mailify.ParseTextEncoding(tuiEncoding)
+		if !ok {
+			return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", tuiEncoding)
+		}
+		validationMode, ok := mailify.ParseValidationMode(tuiMode)
+		if !ok {
+			return fmt.Errorf("unknown mode %q, expected full, dns or offline", tuiMode)
+		}
+		emails, err := readEmails(args[0], enc)
+		if err != nil {
+			return err
+		}
+
+		opts, err := clientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if !fromProfile(cmd, "mode") {
+			opts = append(opts, mailify.WithMode(validationMode))
+		}
+		client, err := mailify.NewClient(tuiSender, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		model := newTUIModel(emails)
+		program := tea.NewProgram(model, tea.WithAltScreen())
+		job := client.StartBulk(emails, mailify.BulkOptions{
+			Concurrency: concurrencyOf(cmd, tuiConcurrency),
+			OnResult:    func(res mailify.BulkResult) { program.Send(res) },
+		})
+		model.job = job
+		go func() {
+			job.Wait()
+			program.Send(tuiDoneMsg{})
+		}()
+
+		_, err = program.Run()
+		return err
+	},
+}
+
+// tuiDoneMsg tells the model the run has finished.
+type tuiDoneMsg struct{}
+
+// tuiTickMsg refreshes the elapsed time.
+type tuiTickMsg time.Time
+
+// tuiRow is an address in the table.
+type tuiRow struct {
+	index      int
+	email      string
+	domain     string
+	verdict    string
+	subStatus  string
+	confidence string
+	detail     string
+}
+
+// tuiSorts are the orders the table can be sorted in, cycled with s.
+var tuiSorts = []string{"input", "verdict", "domain", "email"}
+
+// tuiFilters are the verdicts the table can be narrowed to, cycled with f.
+var tuiFilters = []string{"all", "deliverable", "undeliverable", "risky", "unknown", "error", "pending"}
+
+// tuiModel is the state of the terminal UI.
+type tuiModel struct {
+	rows     []tuiRow
+	done     int
+	finished bool
+	start    time.Time
+	elapsed  time.Duration
+	job      *mailify.BulkJob
+	canceled bool
+
+	sort    int
+	filter  int
+	domains bool
+	domain  string
+	cursor  int
+	offset  int
+	height  int
+	width   int
+}
+
+// newTUIModel creates the model for a run over emails. Its job is set once
+// the run starts.
+func newTUIModel(emails []string) *tuiModel {
+	rows := make([]tuiRow, len(emails))
+	for i, email := range emails {
+		domain := ""
+		if at := strings.LastIndex(email, "@"); at >= 0 {
+			domain = strings.ToLower(email[at+1:])
+		}
+		rows[i] = tuiRow{index: i, email: email, domain: domain, verdict: "pending"}
+	}
+	return &tuiModel{rows: rows, start: time.Now(), height: 24, width: 100}
+}
+
+func (m *tuiModel) Init() tea.Cmd { return tuiTick() }
+
+// tuiTick schedules the next refresh of the elapsed time.
+func tuiTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case mailify.BulkResult:
+		row := &m.rows[msg.Index]
+		switch {
+		case msg.Result == nil:
+			row.verdict, row.detail = "error", fmt.Sprint(msg.Err)
+		default:
+			row.verdict = string(msg.Result.Verdict)
+			row.subStatus = string(msg.Result.SubStatus)
+			row.confidence = string(msg.Result.Confidence)
+			row.detail = msg.Result.ErrorMessage
+		}
+		m.done++
+	case tuiDoneMsg:
+		m.finished = true
+		m.elapsed = time.Since(m.start)
+	case tuiTickMsg:
+		if m.finished {
+			return m, nil
+		}
+		m.elapsed = time.Since(m.start)
+		return m, tuiTick()
+	case tea.WindowSizeMsg:
+		m.height, m.width = msg.Height, msg.Width
+	case tea.KeyMsg:
+		return m, m.key(msg.String())
+	}
+	return m, nil
+}
+
+// key handles a key press.
+func (m *tuiModel) key(key string) tea.Cmd {
+	switch key {
+	case "q", "ctrl+c":
+		m.job.Cancel()
+		return tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.pageSize()
+	case "pgdown":
+		m.cursor += m.pageSize()
+	case "s":
+		m.sort = (m.sort + 1) % len(tuiSorts)
+	case "f":
+		m.filter = (m.filter + 1) % len(tuiFilters)
+		m.cursor = 0
+	case "d":
+		m.domains = !m.domains
+		m.domain = ""
+		m.cursor = 0
+	case "enter":
+		if m.domains {
+			if summaries := m.domainSummaries(); m.cursor < len(summaries) {
+				m.domain = summaries[m.cursor].domain
+				m.domains = false
+				m.cursor = 0
+			}
+		}
+	case "esc":
+		m.domain = ""
+		m.cursor = 0
+	case "p":
+		if m.job.Paused() {
+			m.job.Resume()
+		} else {
+			m.job.Pause()
+		}
+	case "x":
+		m.job.Cancel()
+		m.canceled = true
+	}
+	return nil
+}
+
+// pageSize is how many table lines fit on the screen.
+func (m *tuiModel) pageSize() int {
+	if size := m.height - 6; size > 1 {
+		return size
+	}
+	return 1
+}
+
+// visibleRows returns the addresses that pass the filter, in the chosen order.
+func (m *tuiModel) visibleRows() []tuiRow {
+	var rows []tuiRow
+	for _, row := range m.rows {
+		if m.filter > 0 && row.verdict != tuiFilters[m.filter] {
+			continue
+		}
+		if m.domain != "" && row.domain != m.domain {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		switch tuiSorts[m.sort] {
+		case "verdict":
+			return rows[i].verdict < rows[j].verdict
+		case "domain":
+			return rows[i].domain < rows[j].domain
+		case "email":
+			return rows[i].email < rows[j].email
+		}
+		return rows[i].index < rows[j].index
+	})
+	return rows
+}
+
+// tuiDomain counts the verdicts of a domain's addresses.
+type tuiDomain struct {
+	domain   string
+	total    int
+	verdicts map[string]int
+}
+
+// domainSummaries returns the verdict counts of each domain, the largest first.
+func (m *tuiModel) domainSummaries() []tuiDomain {
+	byDomain := make(map[string]*tuiDomain)
+	var summaries []*tuiDomain
+	for _, row := range m.rows {
+		d, ok := byDomain[row.domain]
+		if !ok {
+			d = &tuiDomain{domain: row.domain, verdicts: make(map[string]int)}
+			byDomain[row.domain] = d
+			summaries = append(summaries, d)
+		}
+		d.total++
+		d.verdicts[row.verdict]++
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].total > summaries[j].total })
+	out := make([]tuiDomain, len(summaries))
+	for i, d := range summaries {
+		out[i] = *d
+	}
+	return out
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	status := "running"
+	switch {
+	case m.finished && m.canceled:
+		status = "canceled"
+	case m.finished:
+		status = "finished"
+	case m.canceled:
+		status = "canceling"
+	case m.job.Paused():
+		status = "PAUSED"
+	}
+	counts := make(map[string]int)
+	for _, row := range m.rows {
+		counts[row.verdict]++
+	}
+	snap := m.job.Stats()
+	eta := ""
+	if !m.finished && snap.ETA > 0 {
+		eta = "  ETA " + snap.ETA.Round(time.Second).String()
+	}
+	fmt.Fprintf(&b, "mailify  %d/%d validated  %s  %s  %.1f/s%s\n", m.done, len(m.rows), status, m.elapsed.Round(time.Second), snap.Rate, eta)
+	fmt.Fprintf(&b, "deliverable %d  undeliverable %d  risky %d  unknown %d  error %d\n",
+		counts["deliverable"], counts["undeliverable"], counts["risky"], counts["unknown"], counts["error"])
+
+	var lines []string
+	if m.domains {
+		fmt.Fprintf(&b, "view: domains\n\n")
+		lines = append(lines, fmt.Sprintf("  %-32s %7s %12s %14s %6s %8s %6s %6s", "DOMAIN", "TOTAL", "DELIVERABLE", "UNDELIVERABLE", "RISKY", "UNKNOWN", "ERROR", "LEFT"))
+		for _, d := range m.domainSummaries() {
+			lines = append(lines, fmt.Sprintf("%-32s %7d %12d %14d %6d %8d %6d %6d", truncate(d.domain, 32), d.total,
+				d.verdicts["deliverable"], d.verdicts["undeliverable"], d.verdicts["risky"], d.verdicts["unknown"], d.verdicts["error"], snap.Backlog[d.domain]))
+		}
+	} else {
+		view := "addresses"
+		if m.domain != "" {
+			view = "addresses of " + m.domain + " (esc for all)"
+		}
+		fmt.Fprintf(&b, "view: %s  sort: %s  filter: %s\n\n", view, tuiSorts[m.sort], tuiFilters[m.filter])
+		lines = append(lines, fmt.Sprintf("  %-40s %-14s %-20s %-10s %s", "EMAIL", "VERDICT", "SUB-STATUS", "CONFIDENCE", "DETAILS"))
+		for _, row := range m.visibleRows() {
+			lines = append(lines, fmt.Sprintf("%-40s %-14s %-20s %-10s %s", truncate(row.email, 40), row.verdict, row.subStatus, row.confidence, row.detail))
+		}
+	}
+
+	// The header line stays, the rest scrolls with the cursor
+	b.WriteString(lines[0] + "\n")
+	items := lines[1:]
+	page := m.pageSize()
+	m.cursor = max(0, min(m.cursor, len(items)-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+	for i := m.offset; i < len(items) && i < m.offset+page; i++ {
+		prefix := "  "
+		if i == m.cursor {
+			prefix = "> "
+		}
+		b.WriteString(truncate(prefix+items[i], m.width) + "\n")
+	}
+
+	b.WriteString("\n↑/↓ move  s sort  f filter  d domains  enter drill down  p pause/resume  x cancel  q quit")
+	return b.String()
+}
+
+// truncate shortens s to n characters, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func init() {
+	tuiCmd.Flags().StringVarP(&tuiSender, "sender", "s", "", "Sender email address (required)")
+	tuiCmd.MarkFlagRequired("sender")
+	tuiCmd.Flags().IntVarP(&tuiConcurrency, "concurrency", "c", 8, "Number of emails to validate at once")
+	tuiCmd.Flags().StringVar(&tuiEncoding, "encoding", "auto", "Encoding of the list: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
+	tuiCmd.Flags().StringVar(&tuiMode, "mode", "full", "How much of the network to use: full, dns (no SMTP) or offline (syntax, role and disposable checks only)")
+	addClientFlags(tuiCmd, true)
+	rootCmd.AddCommand(tuiCmd)
+}
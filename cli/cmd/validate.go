@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateSender      string
+	validateConcurrency int
+	validateJSON        bool
+	validateMode        string
+	validateOutput      string
+	validateFile        string
+	validateSuite       string
+	validateTimeout     time.Duration
+)
+
+// validateCmd validates the addresses given as arguments.
+//
+// Usage:
+//   mailify validate <email>... [flags]
+//   mailify validate --file <list> [flags]
+//
+// Flags:
+//   -s, --sender string      Sender email address (required)
+//   -f, --file string        File to read more addresses from, - for stdin
+//   -c, --concurrency int    Number of emails to validate at once (default 8)
+//   -o, --output string      Output format: table, json, junit or github (default table)
+//   -j, --json               Print the results as JSON, like --output json
+//       --suite string       Name of the JUnit test suite (default mailify)
+//       --mode string        How much of the network to use: full, dns or offline
+//       --timeout duration   Most time each validation may take, 0 for no limit (default 30s)
+//       --helo-name string   Fully qualified name to introduce this host with in EHLO
+//       --tarpit-threshold duration  Most time a mail server may take to answer a command (default 15s)
+//       --ports strings      SMTP ports to try, in order (default 25,587,465)
+//       --tls string         How to secure SMTP connections: auto, starttls, implicit or none
+//       --fallback strings   Verification APIs to ask when SMTP checks are blocked: zerobounce, neverbounce, kickbox
+//       --profile string     Named settings to validate with: aggressive, polite, offline or one from the config
+//       --config string      Config file defining profiles
+//       --probe-seed int     Seed the made-up mailboxes of catch-all probes are derived from
+//       --max-probes int     Most SMTP probes of the run
+//
+// Examples:
+//   # Validate three addresses at once
+//   mailify validate a@example.com b@example.org c@example.net -s sender@example.com
+//
+//   # Validate with a profile from ~/.config/mailify/config.json
+//   mailify validate a@example.com -s sender@example.com --profile nightly
+//
+//   # Gate CI on a seed list, with a JUnit report
+//   mailify validate -f seeds.txt -s sender@example.com -o junit > mailify.xml
+var validateCmd = &cobra.Command{
+	Use:   "validate <email>...",
+	Short: "Validate any number of email addresses at once",
+	Long: `Validate checks every address given as an argument or found in --file, several at a time, and
+prints a table with a row per address in the order given, or with --output json an array of results.
+
+For CI, --output junit prints a JUnit XML report with a test case per address, and --output github
+prints GitHub Actions annotations. Deliverable addresses pass, undeliverable ones fail, addresses that
+couldn't be validated are errors and the rest are skipped; in both formats the command exits with
+status 1 if any address failed or errored.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		validationMode, ok := mailify.ParseValidationMode(validateMode)
+		if !ok {
+			return fmt.Errorf("unknown mode %q, expected full, dns or offline", validateMode)
+		}
+		if validateJSON {
+			validateOutput = "json"
+		}
+		switch validateOutput {
+		case "table", "json", "junit", "github":
+		default:
+			return fmt.Errorf("unknown output %q, expected table, json, junit or github", validateOutput)
+		}
+
+		emails := args
+		if validateFile != "" {
+			listed, err := readEmails(validateFile, mailify.EncodingAuto)
+			if err != nil {
+				return err
+			}
+			emails = append(emails, listed...)
+		}
+		if len(emails) == 0 {
+			return fmt.Errorf("no addresses given, pass them as arguments or with --file")
+		}
+		opts, err := profileOptions()
+		if err != nil {
+			return err
+		}
+		if !fromProfile(cmd, "mode") {
+			opts = append(opts, mailify.WithMode(validationMode))
+		}
+		if !fromProfile(cmd, "timeout") {
+			opts = append(opts, mailify.WithTimeout(validateTimeout))
+		}
+		if heloName != "" {
+			opts = append(opts, mailify.WithHELOName(heloName))
+		}
+		opts = append(opts, mailify.WithTarpitThreshold(tarpitThreshold))
+		transport, err := smtpTransportOptions()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, transport...)
+		if len(fallbackAPIs) > 0 {
+			option, err := fallbackOption(fallbackAPIs)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, option)
+		}
+		bulk := mailify.BulkOptions{Concurrency: validateConcurrency}
+		if fromProfile(cmd, "concurrency") {
+			bulk.Concurrency = 0
+		}
+		client, err := mailify.NewClient(validateSender, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		ctx, cancel := interruptContext()
+		defer cancel()
+		bulk.Context = ctx
+		results := client.ValidateBulk(emails, bulk)
+		// Failed addresses aren't a usage mistake
+		cmd.SilenceUsage = true
+		switch validateOutput {
+		case "json":
+			return printResultsJSON(results)
+		case "junit":
+			if err := writeJUnit(os.Stdout, validateSuite, results); err != nil {
+				return err
+			}
+			return checkFailures(results)
+		case "github":
+			writeGitHubAnnotations(os.Stdout, validateFile, results)
+			return checkFailures(results)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "EMAIL\tVERDICT\tSUB-STATUS\tCONFIDENCE\tDETAILS")
+		for _, res := range results {
+			if res.Err != nil && res.Result == nil {
+				fmt.Fprintf(w, "%s\terror\t-\t-\t%v\n", res.Email, res.Err)
+				continue
+			}
+			subStatus := string(res.Result.SubStatus)
+			if subStatus == "" {
+				subStatus = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", res.Email, res.Result.Verdict, subStatus, res.Result.Confidence, res.Result.ErrorMessage)
+		}
+		return w.Flush()
+	},
+}
+
+// printResultsJSON prints the results of a bulk run as a JSON array, with
+// each address's result or error.
+func printResultsJSON(results []mailify.BulkResult) error {
+	type jsonResult struct {
+		Email  string                    `json:"email"`
+		Result *mailify.ValidationResult `json:"result,omitempty"`
+		Error  string                    `json:"error,omitempty"`
+	}
+	out := make([]jsonResult, len(results))
+	for i, res := range results {
+		out[i] = jsonResult{Email: res.Email, Result: res.Result}
+		if res.Err != nil {
+			out[i].Error = res.Err.Error()
+		}
+	}
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %v", err)
+	}
+	fmt.Println(string(encoded))
+	return nil
+}
+
+func init() {
+	validateCmd.Flags().StringVarP(&validateSender, "sender", "s", "", "Sender email address (required)")
+	validateCmd.MarkFlagRequired("sender")
+	validateCmd.Flags().IntVarP(&validateConcurrency, "concurrency", "c", 8, "Number of emails to validate at once")
+	validateCmd.Flags().StringVarP(&validateFile, "file", "f", "", "File to read more addresses from, such as a seed list, or - for stdin")
+	validateCmd.Flags().StringVarP(&validateOutput, "output", "o", "table", "Output format: table, json, junit (a JUnit XML report for CI) or github (GitHub Actions annotations)")
+	validateCmd.Flags().BoolVarP(&validateJSON, "json", "j", false, "Print the results as JSON, like --output json")
+	validateCmd.Flags().StringVar(&validateSuite, "suite", "mailify", "Name of the test suite in --output junit reports")
+	validateCmd.Flags().StringVar(&validateMode, "mode", "full", "How much of the network to use: full, dns (no SMTP) or offline (syntax, role and disposable checks only)")
+	validateCmd.Flags().DurationVar(&validateTimeout, "timeout", defaultTimeout, timeoutUsage)
+	validateCmd.Flags().StringVar(&heloName, "helo-name", "", heloNameUsage)
+	validateCmd.Flags().DurationVar(&tarpitThreshold, "tarpit-threshold", defaultTarpitThreshold, tarpitUsage)
+	validateCmd.Flags().StringSliceVar(&smtpPortList, "ports", nil, portsUsage)
+	validateCmd.Flags().StringVar(&tlsModeName, "tls", string(mailify.TLSAuto), tlsUsage)
+	validateCmd.Flags().StringSliceVar(&fallbackAPIs, "fallback", nil, fallbackUsage)
+	validateCmd.Flags().StringVar(&profileName, "profile", "", profileUsage)
+	validateCmd.Flags().StringVar(&configPath, "config", "", configUsage)
+	validateCmd.Flags().Int64Var(&probeSeed, "probe-seed", 0, probeSeedUsage)
+	validateCmd.Flags().IntVar(&runBudget, "max-probes", 0, maxProbesUsage)
+	rootCmd.AddCommand(validateCmd)
+}
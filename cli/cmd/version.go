@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+// versionJSON prints the build info as JSON.
+var versionJSON bool
+
+// versionCmd prints the version, commit and build date of mailify.
+//
+// Usage:
+//   mailify version [flags]
+//
+// Flags:
+//   -j, --json  Print the build info as JSON
+//
+// Examples:
+//   # Print the version
+//   mailify version
+//
+//   # Record the exact build in a CI log
+//   mailify version --json
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of mailify",
+	Long: `Version prints the mailify version along with the commit and date it was built from and the Go
+version it was built with. Release binaries have them set when built; others read them from the build
+info Go embeds.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		build := mailify.ReadBuildInfo()
+		if versionJSON {
+			out, err := json.MarshalIndent(build, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode build info: %v", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		fmt.Println("mailify", build.Version)
+		if build.Commit != "" {
+			fmt.Println("Commit:", build.Commit)
+		}
+		if build.Date != "" {
+			fmt.Println("Built:", build.Date)
+		}
+		fmt.Println("Go:", build.GoVersion)
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().BoolVarP(&versionJSON, "json", "j", false, "Print the build info as JSON")
+	rootCmd.Version = mailify.Version()
+	rootCmd.AddCommand(versionCmd)
+}
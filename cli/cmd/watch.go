@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchSender      string
+	watchOutput      string
+	watchInterval    time.Duration
+	watchWebhook     string
+	watchConcurrency int
+	watchColumn      string
+	watchSplitCells  string
+	watchEncoding    string
+)
+
+// watchCmd watches a directory and validates the Excel, CSV and ZIP files dropped into it.
+//
+// Usage:
+//   mailify watch <dir> [flags]
+//
+// Flags:
+//   -s, --sender string       Sender email address (required)
+//   -o, --output string       Directory processed files are moved to, with their results (required)
+//       --interval duration   How often to check for new files (default 5s)
+//       --webhook string      URL to POST a JSON report to after each file
+//   -c, --concurrency int     Number of emails to validate at once
+//       --column string       Header of the column holding the emails
+//       --split-cells string  Split cells holding several emails: off, aggregate or explode
+//       --encoding string     Encoding of CSV files
+//       --profile string      Named settings to validate with: aggressive, polite, offline or one from the config
+//       --config string       Config file defining profiles, protected domains and probe budgets
+//       --timeout duration    Most time each validation may take, 0 for no limit (default 30s)
+//       --max-probes int      Most SMTP probes of the run
+//
+// Examples:
+//   # Process lists dropped into ./inbox, moving them with their results to ./done
+//   mailify watch ./inbox -o ./done -s sender@example.com
+//
+//   # Also report on each file to a webhook
+//   mailify watch ./inbox -o ./done -s sender@example.com --webhook https://example.com/hooks/mailify
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Validate the list files dropped into a directory",
+	Long: `Watch checks a directory for Excel, CSV and ZIP files, and validates the emails in each new file
+once it has finished being copied in. Processed files are moved, with their results, to the output
+directory. With --webhook, a JSON report on each file is POSTed to a URL. Stop with Ctrl+C.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc, ok := mailify.ParseTextEncoding(watchEncoding)
+		if !ok {
+			return fmt.Errorf("unknown encoding %q, expected auto, utf-8, utf-16le, utf-16be, latin-1 or windows-1252", watchEncoding)
+		}
+		multiAddress, ok := mailify.ParseMultiAddressMode(watchSplitCells)
+		if !ok {
+			return fmt.Errorf("unknown --split-cells mode %q, expected off, aggregate or explode", watchSplitCells)
+		}
+
+		notify, err := notifiers(watchSender)
+		if err != nil {
+			return err
+		}
+
+		clientOpts, err := clientOptions(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := mailify.NewClient(watchSender, clientOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to create mailify client: %v", err)
+		}
+		defer client.Close()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		opts := mailify.WatchOptions{
+			OutputDir: watchOutput,
+			Interval:  watchInterval,
+			Webhook:   watchWebhook,
+			Bulk: mailify.BulkOptions{
+				EmailColumn:  watchColumn,
+				Encoding:     enc,
+				MultiAddress: multiAddress,
+				Concurrency:  concurrencyOf(cmd, watchConcurrency),
+				Notifiers:    notify,
+			},
+		}
+
+		fmt.Printf("Watching %s for list files, press Ctrl+C to stop\n", args[0])
+		return client.WatchDirectory(ctx, args[0], opts)
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&watchSender, "sender", "s", "", "Sender email address (required)")
+	watchCmd.MarkFlagRequired("sender")
+	watchCmd.Flags().StringVarP(&watchOutput, "output", "o", "", "Directory processed files are moved to, with their results (required)")
+	watchCmd.MarkFlagRequired("output")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Second, "How often to check the directory for new files")
+	watchCmd.Flags().StringVar(&watchWebhook, "webhook", "", "URL to POST a JSON report to after each file is processed")
+	watchCmd.Flags().IntVarP(&watchConcurrency, "concurrency", "c", 1, "Number of emails to validate at once")
+	watchCmd.Flags().StringVar(&watchColumn, "column", "", "Header of the column holding the emails (default the \"email\" column, or else the column that looks most like emails)")
+	watchCmd.Flags().StringVar(&watchSplitCells, "split-cells", "off", "Split cells holding several emails: off, aggregate or explode")
+	watchCmd.Flags().StringVar(&watchEncoding, "encoding", "auto", "Encoding of CSV files: auto (detected), utf-8, utf-16le, utf-16be, latin-1 or windows-1252")
+	addClientFlags(watchCmd, true)
+	rootCmd.AddCommand(watchCmd)
+}
@@ -1,23 +1,215 @@
 package mailify
 
-// 
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// ErrClientClosed is returned by validations started after Close.
+var ErrClientClosed = errors.New("client is closed")
+
 // Client represents an email client with a sender email address.
+//
+// A Client is safe for concurrent use by multiple goroutines, including
+// concurrent bulk runs, which share its pooled SMTP sessions and MX cache.
+// SenderEmail must not be changed while validations are running. Long-lived
+// programs should call Close when they are done with the client.
 type Client struct {
 	SenderEmail string
+
+	// ipPreference controls which address family is dialed first when a mail server has both.
+	ipPreference IPPreference
+	// fallbackDelay is how long to wait for a connection attempt before racing the next address.
+	fallbackDelay time.Duration
+	// sessions holds idle SMTP sessions for reuse during bulk runs.
+	sessions *sessionPool
+	// retry controls how failed lookups, connections and conversations are retried.
+	retry RetryPolicy
+	// timeout is the most time a validation may take, 0 for no limit.
+	timeout time.Duration
+	// connectTimeout is the most time a connection attempt to a mail server may take.
+	connectTimeout time.Duration
+	// tarpitThreshold is the most time a mail server may take to answer a command, 0 for no limit.
+	tarpitThreshold time.Duration
+	// tarpits holds the mail servers caught tarpitting, with when to stop skipping them.
+	tarpits sync.Map
+	// dialer opens connections to mail servers.
+	dialer Dialer
+	// resolver looks up MX, address and TXT records.
+	resolver Resolver
+	// mode controls how much of the network validation may use.
+	mode ValidationMode
+	// mxCache remembers MX lookups, nil unless WithMXCache is given.
+	mxCache *mxCache
+	// hooks run custom logic around the validation stages.
+	hooks []Hooks
+	// stages are custom validation stages run between the built-in ones.
+	stages []customStage
+	// profile supplies defaults for bulk runs, nil unless WithProfile is given.
+	profile *Profile
+	// catchAllProbes is how many made-up mailboxes are probed after a recipient is accepted.
+	catchAllProbes int
+	// probeSeed is what the made-up mailboxes of catch-all probes are derived from.
+	probeSeed int64
+	// probeGenerator makes up the mailboxes of catch-all probes, nil for the default ones.
+	probeGenerator *ProbeGenerator
+	// mxStats learns how mail servers respond, nil unless WithMXLearning is given.
+	mxStats *mxStats
+	// domainReputations gathers what validations show about each domain, kept when a cache is given.
+	domainReputations *domainReputations
+	// cache keeps knowledge about domains between validations, nil unless WithCache is given.
+	cache Cache
+	// resultTTL is how long results are kept in the cache, 0 unless WithResultCache is given.
+	resultTTL time.Duration
+	// acceptAll holds the rules of the accept-all registry by Match, see WithAcceptAllRules.
+	acceptAll map[string]AcceptAllRule
+	// protected holds the domains never probed over SMTP, see WithProtectedDomains.
+	protected map[string]string
+	// budget counts SMTP probes against the caps of WithProbeBudget, nil if there are none.
+	budget *probeBudget
+	// politeness holds the politeness settings by Match, see WithProviderPoliteness.
+	politeness map[string]ProviderPoliteness
+	// fallbacks are the verification APIs asked about mailboxes that couldn't be checked over SMTP.
+	fallbacks []FallbackVerifier
+	// ports are the ports SMTP services are looked for on, DefaultSMTPPorts unless WithPorts is given.
+	ports []string
+	// tlsMode is how connections to mail servers are secured, see WithTLSMode.
+	tlsMode TLSMode
+	// gatesMu guards gates, the per-provider politeness gates.
+	gatesMu sync.Mutex
+	// gates hold back conversations with each provider's mail servers, by the Match of its settings.
+	gates map[string]*providerGate
+	// confirm configures confirmation sends, nil unless WithConfirmation is given.
+	confirm *ConfirmationConfig
+	// confirmStore keeps confirmations when no cache was given with WithCache.
+	confirmStore Cache
+	// mxResolvers are the resolvers MX lookups are compared against, if any.
+	mxResolvers []NamedResolver
+	// allowBogonMX lets validation connect to mail servers with private or reserved addresses.
+	allowBogonMX bool
+	// dnssecResolver is the validating resolver DNSSEC checks query, host:port.
+	dnssecResolver string
+	// resultTemplate renders FormatValidationResult's output, if set.
+	resultTemplate *template.Template
+	// locale is the language human-readable messages are written in.
+	locale Locale
+	// piiMode controls how addresses are recorded outside results, see WithPIIMode.
+	piiMode PIIMode
+	// piiKey keys the hashes addresses are replaced with.
+	piiKey []byte
+	// auditSink records every validation, nil unless WithAuditSink is given.
+	auditSink AuditSink
+	// skipSenderCheck lets NewClient accept any sender, see WithoutSenderCheck.
+	skipSenderCheck bool
+	// checkSenderDomain has NewClient check the sender's MX and SPF records.
+	checkSenderDomain bool
+	// heloName is the name sent in EHLO and HELO, guessed by GetHostname unless WithHELOName is given.
+	heloName string
+	// heloOnce guards the forward and reverse DNS check of the HELO name.
+	heloOnce sync.Once
+	// heloMismatch is the warning results carry if the HELO name fails the check.
+	heloMismatch string
+	// closed is set once Close has been called.
+	closed    atomic.Bool
+	closeOnce sync.Once
 }
 
+// Option configures optional behavior of a Client.
+type Option func(*Client)
+
 // NewClient creates a new Client instance with the provided sender email address.
 // It returns a pointer to the Client and an error, if any.
 //
+// The sender must be a syntactically valid address, see NormalizeEmail, and
+// is used in its normalized form. WithSenderDomainCheck checks its domain's
+// DNS too, and WithoutSenderCheck skips checking it.
+//
 // Parameters:
 //   - SenderEmail: A string representing the sender's email address.
+//   - opts: Optional settings, such as WithIPPreference, applied in order.
 //
 // Returns:
 //   - *Client: A pointer to the newly created Client instance.
-//   - error: An error if there is any issue during the creation of the Client.
-func NewClient(SenderEmail string) (*Client, error) {
-	return &Client{
-		SenderEmail: SenderEmail,
-	}, nil
+//   - error: A *SenderError if the sender address is unusable, or an error
+//     if the name WithHELOName gave is.
+func NewClient(SenderEmail string, opts ...Option) (*Client, error) {
+	c := &Client{
+		SenderEmail:       SenderEmail,
+		ipPreference:      PreferIPv6,
+		fallbackDelay:     250 * time.Millisecond,
+		sessions:          newSessionPool(30 * time.Second),
+		retry:             DefaultRetryPolicy(),
+		connectTimeout:    defaultConnectTimeout,
+		tarpitThreshold:   defaultTarpitThreshold,
+		dialer:            &net.Dialer{},
+		resolver:          defaultResolver(),
+		catchAllProbes:    defaultCatchAllProbes,
+		probeSeed:         rand.Int63(),
+		acceptAll:         newAcceptAllRegistry(),
+		politeness:        newPolitenessRegistry(),
+		gates:             make(map[string]*providerGate),
+		domainReputations: newDomainReputations(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.mode = platformMode(c.mode)
+	if err := c.checkSender(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := c.checkHELOName(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if c.probeGenerator != nil {
+		if err := c.probeGenerator.Check(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
+// WithIPPreference sets which address family is tried first when a mail server
+// resolves to both IPv4 and IPv6 addresses, or restricts dialing to one family.
+// The default is PreferIPv6, as recommended by RFC 8305.
+func WithIPPreference(pref IPPreference) Option {
+	return func(c *Client) {
+		c.ipPreference = pref
+	}
+}
+
+// WithFallbackDelay sets how long a connection attempt is given before the next
+// address is raced against it. The default is 250ms, as recommended by RFC 8305.
+func WithFallbackDelay(delay time.Duration) Option {
+	return func(c *Client) {
+		c.fallbackDelay = delay
+	}
+}
+
+// Close releases the client's resources: pooled SMTP sessions are closed with
+// QUIT, cached MX records are dropped, what WithMXLearning learned is saved
+// to the cache and the audit sink is closed if it can be. Validations
+// already running finish normally, but their sessions are not pooled again,
+// and validations started afterwards fail with ErrClientClosed. Calling
+// Close more than once is harmless.
+//
+// Returns:
+//   - error: Always nil; Close has this signature to satisfy io.Closer.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		c.sessions.close()
+		c.mxCache.flush()
+		c.saveMXStats()
+		c.saveDomainReputations()
+		c.closeAuditSink()
+	})
+	return nil
+}
@@ -0,0 +1,80 @@
+package mailify
+
+import (
+	"strings"
+)
+
+// detectSampleRows is how many data rows DetectEmailColumn looks at.
+const detectSampleRows = 100
+
+// DetectEmailColumn finds the column of a sheet most likely to hold email
+// addresses, for files whose email column isn't named "email". Each column's
+// first 100 data rows are checked with ParseAddress, a cell with several
+// addresses counting if all of them parse, and the column with the most
+// valid cells wins. A header mentioning email or mail, such as "E-mail" or
+// "Contact Mail", breaks ties and wins over a column with only a few valid
+// cells.
+//
+// Parameters:
+//   - rows: The sheet's rows, the header row first.
+//
+// Returns:
+//   - int: The index of the column.
+//   - bool: False if no column holds any valid address or has an email header.
+func DetectEmailColumn(rows [][]string) (int, bool) {
+	if len(rows) == 0 {
+		return 0, false
+	}
+	sample := rows[1:]
+	if len(sample) > detectSampleRows {
+		sample = sample[:detectSampleRows]
+	}
+
+	columns := len(rows[0])
+	for _, row := range sample {
+		columns = max(columns, len(row))
+	}
+
+	best, bestScore := 0, 0.0
+	for col := 0; col < columns; col++ {
+		var valid, filled int
+		for _, row := range sample {
+			if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			filled++
+			if isAddressCell(row[col]) {
+				valid++
+			}
+		}
+
+		var score float64
+		if filled > 0 {
+			score = float64(valid) / float64(filled)
+		}
+		if col < len(rows[0]) && isEmailHeader(rows[0][col]) {
+			score += 0.5
+		}
+		if score > bestScore {
+			best, bestScore = col, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// isAddressCell reports whether every address in a cell is valid.
+func isAddressCell(cell string) bool {
+	addresses := SplitAddresses(cell)
+	for _, address := range addresses {
+		if _, err := ParseAddress(address); err != nil {
+			return false
+		}
+	}
+	return len(addresses) > 0
+}
+
+// isEmailHeader reports whether a column header names an email column.
+func isEmailHeader(header string) bool {
+	header = strings.ToLower(header)
+	return strings.Contains(header, "mail")
+}
@@ -0,0 +1,278 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Evidence is one source's account of an address.
+type Evidence struct {
+	// Source names where the evidence came from, such as "smtp" or "zerobounce".
+	Source string
+	// Result is the source's verdict on the address, in the standard schema.
+	Result *ValidationResult
+	// At is when the source reached its verdict.
+	At time.Time
+}
+
+// EvidenceSource gives evidence about addresses to a CompositeVerifier.
+// Built-in sources check the address live over SMTP (SMTPSource), read the
+// result cache (CacheSource), ask a verification API (APISource) or look it
+// up in a SuppressionList.
+type EvidenceSource interface {
+	// Name identifies the source in provenance.
+	Name() string
+	// Evidence returns what the source knows about an address, nil if nothing.
+	Evidence(email string) (*Evidence, error)
+}
+
+// MergeStrategy is how a CompositeVerifier makes one verdict of the evidence
+// of several sources.
+type MergeStrategy string
+
+const (
+	// MergePriority takes the first definite verdict of the sources in the
+	// order given, asking the next only if the ones before had none, so cheap
+	// sources such as a suppression list or the cache can spare an SMTP check.
+	MergePriority MergeStrategy = "priority"
+	// MergeMostRecent asks every source and takes the most recent definite
+	// verdict.
+	MergeMostRecent MergeStrategy = "most_recent"
+	// MergeQuorum asks every source and takes the definite verdict at least
+	// Quorum of them agree on. If none does, the result is VerdictUnknown
+	// with SubStatusConflictingEvidence.
+	MergeQuorum MergeStrategy = "quorum"
+)
+
+// CompositeVerifier judges addresses on the evidence of several sources,
+// merged into one result by its Strategy. Which source the verdict came from,
+// and what every source said, are kept in the result's Extra under
+// "composite". Unknown verdicts count as no evidence.
+type CompositeVerifier struct {
+	// Sources are asked in order, which is also their priority.
+	Sources []EvidenceSource
+	// Strategy is how the evidence is merged, MergePriority if empty.
+	Strategy MergeStrategy
+	// Quorum is the number of sources that must agree for MergeQuorum, a
+	// majority of those with a definite verdict if 0.
+	Quorum int
+}
+
+// NewCompositeVerifier returns a verifier merging the evidence of sources
+// with strategy.
+//
+// Parameters:
+//   - strategy: How the evidence is merged.
+//   - sources: The sources, in order of priority.
+//
+// Returns:
+//   - *CompositeVerifier: The verifier.
+func NewCompositeVerifier(strategy MergeStrategy, sources ...EvidenceSource) *CompositeVerifier {
+	return &CompositeVerifier{Sources: sources, Strategy: strategy}
+}
+
+// Verify gathers the sources' evidence about an address and merges it.
+//
+// Parameters:
+//   - email: The address to judge.
+//
+// Returns:
+//   - *ValidationResult: The merged result, a copy of the chosen evidence's
+//     with the provenance in Extra["composite"].
+//   - error: An error if no source gave any evidence, joining the sources'
+//     errors, or if the strategy is unknown.
+func (cv *CompositeVerifier) Verify(email string) (*ValidationResult, error) {
+	strategy := cv.Strategy
+	if strategy == "" {
+		strategy = MergePriority
+	}
+	switch strategy {
+	case MergePriority, MergeMostRecent, MergeQuorum:
+	default:
+		return nil, fmt.Errorf("unknown merge strategy %q, want priority, most_recent or quorum", strategy)
+	}
+
+	var evidence []*Evidence
+	var errs []error
+	var chosen *Evidence
+	for _, source := range cv.Sources {
+		e, err := source.Evidence(email)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+			continue
+		}
+		if e == nil || e.Result == nil {
+			continue
+		}
+		if e.Source == "" {
+			e.Source = source.Name()
+		}
+		evidence = append(evidence, e)
+		if strategy == MergePriority && e.Result.Verdict != VerdictUnknown {
+			chosen = e
+			break
+		}
+	}
+	if len(evidence) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("no evidence about %s", email)
+	}
+
+	switch strategy {
+	case MergeMostRecent:
+		for _, e := range evidence {
+			if e.Result.Verdict != VerdictUnknown && (chosen == nil || e.At.After(chosen.At)) {
+				chosen = e
+			}
+		}
+	case MergeQuorum:
+		chosen = cv.quorum(evidence)
+		if chosen == nil {
+			now := time.Now().UTC()
+			return composedResult(strategy, nil, evidence, &ValidationResult{
+				Verdict:         VerdictUnknown,
+				SubStatus:       SubStatusConflictingEvidence,
+				Confidence:      ConfidenceLow,
+				NormalizedEmail: email,
+				ErrorMessage:    "Evidence sources disagree, no verdict reached a quorum",
+				ValidatedAt:     now,
+				Provenance:      &Provenance{Source: ProvenanceRules, Detail: "no quorum", At: now},
+			}), nil
+		}
+	}
+	if chosen == nil {
+		// Nothing definite, the first source's unknown verdict is as good as any
+		chosen = evidence[0]
+	}
+	result := *chosen.Result
+	return composedResult(strategy, chosen, evidence, &result), nil
+}
+
+// quorum returns the highest-priority evidence of the definite verdict at
+// least the verifier's quorum of sources agree on, nil if there is none.
+func (cv *CompositeVerifier) quorum(evidence []*Evidence) *Evidence {
+	votes := make(map[Verdict]int)
+	definite := 0
+	for _, e := range evidence {
+		if e.Result.Verdict != VerdictUnknown {
+			votes[e.Result.Verdict]++
+			definite++
+		}
+	}
+	needed := cv.Quorum
+	if needed <= 0 {
+		needed = definite/2 + 1
+	}
+	for _, e := range evidence {
+		if e.Result.Verdict != VerdictUnknown && votes[e.Result.Verdict] >= needed {
+			return e
+		}
+	}
+	return nil
+}
+
+// composedResult records in result which evidence it was chosen from, by
+// which strategy, and what every source said.
+func composedResult(strategy MergeStrategy, chosen *Evidence, evidence []*Evidence, result *ValidationResult) *ValidationResult {
+	sources := make([]map[string]any, len(evidence))
+	disagree := false
+	for i, e := range evidence {
+		sources[i] = map[string]any{
+			"source":  e.Source,
+			"verdict": string(e.Result.Verdict),
+			"at":      e.At,
+		}
+		if chosen != nil && e.Result.Verdict != VerdictUnknown && e.Result.Verdict != chosen.Result.Verdict {
+			disagree = true
+		}
+	}
+	composite := map[string]any{"strategy": string(strategy), "sources": sources}
+	if chosen != nil {
+		composite["chosen"] = chosen.Source
+	}
+
+	extra := make(map[string]any, len(result.Extra)+1)
+	for key, value := range result.Extra {
+		extra[key] = value
+	}
+	extra["composite"] = composite
+	result.Extra = extra
+	result.Warnings = append([]string(nil), result.Warnings...)
+	if disagree {
+		result.Warnings = append(result.Warnings, "Evidence sources disagree about the address")
+	}
+	return result
+}
+
+// SMTPSource is an EvidenceSource checking addresses live with a client, as
+// ValidateEmail does but never answering from the result cache.
+type SMTPSource struct {
+	// Client validates the addresses.
+	Client *Client
+}
+
+// Name implements EvidenceSource.
+func (s SMTPSource) Name() string {
+	return "smtp"
+}
+
+// Evidence implements EvidenceSource.
+func (s SMTPSource) Evidence(email string) (*Evidence, error) {
+	result, err := s.Client.ValidateEmailMaxAge(email, -1)
+	if result == nil {
+		return nil, err
+	}
+	return &Evidence{Source: s.Name(), Result: result, At: result.ValidatedAt}, nil
+}
+
+// CacheSource is an EvidenceSource reading the results a client keeps with
+// WithResultCache.
+type CacheSource struct {
+	// Client holds the cache.
+	Client *Client
+	// MaxAge is the oldest result taken, any still in the cache if 0.
+	MaxAge time.Duration
+}
+
+// Name implements EvidenceSource.
+func (s CacheSource) Name() string {
+	return "cache"
+}
+
+// Evidence implements EvidenceSource.
+func (s CacheSource) Evidence(email string) (*Evidence, error) {
+	result := s.Client.cachedResult(email, &validation{maxAge: s.MaxAge})
+	if result == nil {
+		return nil, nil
+	}
+	return &Evidence{Source: s.Name(), Result: result, At: result.ValidatedAt}, nil
+}
+
+// APISource is an EvidenceSource asking a verification API.
+type APISource struct {
+	// Verifier asks the API.
+	Verifier FallbackVerifier
+}
+
+// Name implements EvidenceSource.
+func (s APISource) Name() string {
+	return s.Verifier.Name()
+}
+
+// Evidence implements EvidenceSource.
+func (s APISource) Evidence(email string) (*Evidence, error) {
+	result, err := s.Verifier.Verify(context.Background(), email)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	result.ValidatedAt = now
+	result.NormalizedEmail = email
+	result.Confidence = resultConfidence(result)
+	result.Provenance = &Provenance{Source: ProvenanceExternalAPI, Detail: s.Name(), At: now}
+	return &Evidence{Source: s.Name(), Result: result, At: now}, nil
+}
@@ -0,0 +1,171 @@
+package mailify
+
+import "sync"
+
+// AdaptiveConcurrency is an AIMD (additive increase, multiplicative decrease)
+// controller for the number of validations run at once during a bulk run.
+//
+// Outcomes are tallied over a window of completed validations. If the share of
+// congestion signals (421, 451 and 452 replies, greylisting) in a window
+// stays at or below MaxErrorRate the limit is raised by one, otherwise it is
+// multiplied by DecreaseFactor. The limit always stays between Min and Max.
+//
+// An AdaptiveConcurrency is safe for concurrent use and should not be shared
+// between bulk runs.
+type AdaptiveConcurrency struct {
+	// Min is the lowest concurrency the controller will back off to.
+	Min int
+	// Max is the highest concurrency the controller will grow to.
+	Max int
+	// Window is the number of completed validations between adjustments.
+	Window int
+	// MaxErrorRate is the share of congestion signals in a window above which the controller backs off.
+	MaxErrorRate float64
+	// DecreaseFactor is what the limit is multiplied by when backing off.
+	DecreaseFactor float64
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	limit     int
+	inFlight  int
+	completed int
+	congested int
+}
+
+// NewAdaptiveConcurrency creates a controller that starts at min concurrency and
+// grows towards max, using a window of 10 validations, a 10% error threshold,
+// and halving the limit when backing off.
+//
+// Parameters:
+//   - min: The lowest concurrency to back off to (at least 1).
+//   - max: The highest concurrency to grow to.
+//
+// Returns:
+//   - *AdaptiveConcurrency: The controller, to be set on BulkOptions.Adaptive.
+func NewAdaptiveConcurrency(min, max int) *AdaptiveConcurrency {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &AdaptiveConcurrency{
+		Min:            min,
+		Max:            max,
+		Window:         10,
+		MaxErrorRate:   0.1,
+		DecreaseFactor: 0.5,
+	}
+}
+
+// init lazily sets up the condition variable and starting limit. Callers must hold a.mu.
+func (a *AdaptiveConcurrency) init() {
+	if a.cond == nil {
+		a.cond = sync.NewCond(&a.mu)
+		a.limit = a.Min
+		if a.limit < 1 {
+			a.limit = 1
+		}
+	}
+}
+
+// Acquire blocks until a validation may start under the current limit.
+func (a *AdaptiveConcurrency) Acquire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.init()
+
+	for a.inFlight >= a.limit {
+		a.cond.Wait()
+	}
+	a.inFlight++
+}
+
+// Release marks a validation as finished. congested reports whether the
+// receiving servers deferred the validation in a way that suggests they want
+// us to slow down.
+func (a *AdaptiveConcurrency) Release(congested bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.init()
+
+	a.inFlight--
+	a.completed++
+	if congested {
+		a.congested++
+	}
+
+	window := a.Window
+	if window < 1 {
+		window = 1
+	}
+	if a.completed >= window {
+		if float64(a.congested)/float64(a.completed) > a.MaxErrorRate {
+			a.limit = int(float64(a.limit) * a.DecreaseFactor)
+		} else {
+			a.limit++
+		}
+		if a.limit < a.Min {
+			a.limit = a.Min
+		}
+		if a.limit > a.Max {
+			a.limit = a.Max
+		}
+		if a.limit < 1 {
+			a.limit = 1
+		}
+		a.completed, a.congested = 0, 0
+	}
+
+	a.cond.Broadcast()
+}
+
+// Limit returns the current concurrency limit.
+func (a *AdaptiveConcurrency) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.init()
+	return a.limit
+}
+
+// cancel gives back a slot taken by Acquire for a validation that never ran,
+// without counting it towards the window.
+func (a *AdaptiveConcurrency) cancel() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.init()
+
+	a.inFlight--
+	a.cond.Broadcast()
+}
+
+// congestionCodes are the reply codes with which mail servers ask us to come
+// back later: service unavailable (421), local error (451), such as a rate
+// limit, and insufficient storage (452).
+var congestionCodes = map[int]bool{
+	421: true,
+	451: true,
+	452: true,
+}
+
+// congestionSubStatuses are the sub-statuses of results whose mail servers
+// deferred us rather than answering about the mailbox.
+var congestionSubStatuses = map[SubStatus]bool{
+	SubStatusGreylisted:          true,
+	SubStatusTemporaryFailure:    true,
+	SubStatusInsufficientStorage: true,
+}
+
+// isCongestionSignal reports whether a validation outcome suggests we should
+// reduce the load we put on the receiving servers: a 421, 451 or 452 reply,
+// or greylisting. A 452 about a full mailbox is about the mailbox, not the
+// load, and doesn't count.
+func isCongestionSignal(result *ValidationResult) bool {
+	if result == nil || result.SubStatus == SubStatusMailboxFull {
+		return false
+	}
+	if result.SMTPReply != nil && congestionCodes[result.SMTPReply.Code] {
+		return true
+	}
+	return congestionSubStatuses[result.SubStatus]
+}
@@ -0,0 +1,53 @@
+package mailify
+
+// resultConfidence says how sure a result's verdict is, based on how it was
+// reached. A verdict the server stated outright, such as a 550 5.1.1 reply,
+// is high confidence. One we inferred, such as a catch-all domain, is medium,
+// and one we fell back to because the server didn't give an answer, such as
+// a timeout or greylisting, is low. Resolvers disagreeing about the domain's
+// mail servers lowers the confidence by one level.
+func resultConfidence(result *ValidationResult) Confidence {
+	var confidence Confidence
+	switch result.SubStatus {
+	case SubStatusInvalidFormat, SubStatusNoMX, SubStatusMailboxNotFound, SubStatusMailboxFull,
+		SubStatusConfirmed, SubStatusDirectoryVerified, SubStatusMailboxDisabled, SubStatusSuppressed:
+		confidence = ConfidenceHigh
+	case SubStatusCatchAll:
+		// The recipient may or may not exist, how sure we are that the
+		// domain accepts everyone is all we know
+		confidence = ConfidenceMedium
+		if result.CatchAllConfidence != ConfidenceHigh {
+			confidence = ConfidenceLow
+		}
+	case SubStatusBogonMX, SubStatusAcceptAllProvider:
+		confidence = ConfidenceMedium
+	case "":
+		confidence = ConfidenceHigh
+		if result.Verdict != VerdictDeliverable {
+			confidence = ConfidenceMedium
+		} else if result.CatchAllConfidence != "" {
+			// A lone accepted probe left the verdict deliverable, but the
+			// acceptance may mean nothing
+			confidence = ConfidenceMedium
+		}
+	default:
+		// Cannot verify, greylisted, temporary failures, SMTP and DNS errors
+		// and skipped checks are all a lack of an answer
+		confidence = ConfidenceLow
+	}
+
+	if result.MXConsistency != nil && !result.MXConsistency.Consistent {
+		confidence = lowerConfidence(confidence)
+	}
+	return confidence
+}
+
+// lowerConfidence returns the confidence one level below c.
+func lowerConfidence(c Confidence) Confidence {
+	switch c {
+	case ConfidenceHigh:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
@@ -0,0 +1,326 @@
+package mailify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrConfirmationDisabled is returned by the confirmation APIs when the
+	// client was created without WithConfirmation.
+	ErrConfirmationDisabled = errors.New("confirmation sends are not configured")
+	// ErrInvalidToken is returned for a confirmation token that is malformed or
+	// wasn't signed with the configured secret.
+	ErrInvalidToken = errors.New("invalid confirmation token")
+	// ErrTokenExpired is returned for a confirmation token past its expiry.
+	ErrTokenExpired = errors.New("confirmation token has expired")
+)
+
+// defaultConfirmationTTL is how long a confirmation link works unless
+// ConfirmationConfig.TTL says otherwise.
+const defaultConfirmationTTL = 72 * time.Hour
+
+// confirmedTTL is how long a confirmed address is remembered.
+const confirmedTTL = 365 * 24 * time.Hour
+
+// ConfirmationConfig configures confirmation sends: short emails with a
+// signed link that prove an address works once the link is opened. They are
+// meant for addresses RCPT TO can't settle, such as those at catch-all domains.
+type ConfirmationConfig struct {
+	// Relay is the SMTP server the emails are sent through.
+	Relay Relay
+	// Secret signs the tokens in the links. It must be kept private and stay
+	// the same for as long as links should keep working.
+	Secret []byte
+	// BaseURL is the page the link points at, e.g. "https://example.com/confirm".
+	// The token is added as its token query parameter.
+	BaseURL string
+	// Subject is the email's subject, "Please confirm your email address" if empty.
+	Subject string
+	// TTL is how long a link works, 72 hours if zero.
+	TTL time.Duration
+	// AutoSend makes validations send a confirmation email to addresses whose
+	// result is inconclusive: catch-all domains and servers that won't verify
+	// mailboxes. With ModeDNSOnly, addresses whose domain has mail servers are
+	// inconclusive too, which suits environments where only the relay may be
+	// contacted. ValidationResult.ConfirmationSent reports when one was sent.
+	AutoSend bool
+}
+
+// Confirmation records that an address was confirmed by opening its link.
+type Confirmation struct {
+	// Email is the address that was confirmed.
+	Email string `json:"email"`
+	// ConfirmedAt is when the link was opened.
+	ConfirmedAt time.Time `json:"confirmed_at"`
+}
+
+// WithConfirmation enables confirmation sends. Confirmations are recorded in
+// the cache given with WithCache, so they can be shared between processes; an
+// in-memory cache is used otherwise. Once an address is confirmed, its
+// validations come back deliverable with SubStatusConfirmed.
+func WithConfirmation(config ConfirmationConfig) Option {
+	return func(c *Client) {
+		c.confirm = &config
+		c.confirmStore = NewMemoryCache()
+	}
+}
+
+// confirmations returns the cache confirmations are kept in.
+func (c *Client) confirmations() Cache {
+	if c.cache != nil {
+		return c.cache
+	}
+	return c.confirmStore
+}
+
+// confirmedKey is the cache key of an address's Confirmation. With
+// WithPIIMode the key holds the address's hash instead of the address.
+func (c *Client) confirmedKey(email string) string {
+	if c.piiMode != PIIPlain {
+		return "confirmed:" + c.RedactEmail(email)
+	}
+	return "confirmed:" + strings.ToLower(email)
+}
+
+// SendConfirmation emails a confirmation link to the given address through the
+// configured relay.
+//
+// Parameters:
+//   - email: The address to confirm.
+//
+// Returns:
+//   - string: The token in the link, which RecordClick accepts.
+//   - error: ErrConfirmationDisabled, a syntax error for the address, or an
+//     error if the relay couldn't be reached or refused the email.
+func (c *Client) SendConfirmation(email string) (string, error) {
+	if c.confirm == nil {
+		return "", ErrConfirmationDisabled
+	}
+	if c.closed.Load() {
+		return "", ErrClientClosed
+	}
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := c.confirm.TTL
+	if ttl <= 0 {
+		ttl = defaultConfirmationTTL
+	}
+	token := c.signToken(email, time.Now().Add(ttl))
+	link, err := confirmationLink(c.confirm.BaseURL, token)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.sendMail(c.confirm.Relay, email, confirmationMessage(c.SenderEmail, email, c.confirm.Subject, link)); err != nil {
+		return "", fmt.Errorf("confirmation send failed: %w", err)
+	}
+	return token, nil
+}
+
+// RecordClick checks a token from a confirmation link and records its address
+// as confirmed. It is meant to be called by the page BaseURL points at;
+// ConfirmationHandler does that for net/http servers.
+//
+// Parameters:
+//   - token: The token from the link.
+//
+// Returns:
+//   - Confirmation: The recorded confirmation.
+//   - error: ErrConfirmationDisabled, ErrInvalidToken or ErrTokenExpired.
+func (c *Client) RecordClick(token string) (Confirmation, error) {
+	if c.confirm == nil {
+		return Confirmation{}, ErrConfirmationDisabled
+	}
+	email, err := c.checkToken(token, time.Now())
+	if err != nil {
+		return Confirmation{}, err
+	}
+
+	// The cached copy holds the address as WithPIIMode records it
+	confirmation := Confirmation{Email: email, ConfirmedAt: time.Now().UTC()}
+	stored := confirmation
+	stored.Email = c.RedactEmail(email)
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return Confirmation{}, err
+	}
+	c.confirmations().Set(c.confirmedKey(email), data, confirmedTTL)
+	return confirmation, nil
+}
+
+// Confirmation returns the recorded confirmation of an address, if it has been confirmed.
+//
+// Parameters:
+//   - email: The address to look up.
+//
+// Returns:
+//   - Confirmation: The confirmation.
+//   - bool: False if the address hasn't been confirmed or confirmation sends aren't configured.
+func (c *Client) Confirmation(email string) (Confirmation, bool) {
+	if c.confirm == nil {
+		return Confirmation{}, false
+	}
+	if normalized, err := NormalizeEmail(email); err == nil {
+		email = normalized
+	}
+	data, ok := c.confirmations().Get(c.confirmedKey(email))
+	if !ok {
+		return Confirmation{}, false
+	}
+	var confirmation Confirmation
+	if err := json.Unmarshal(data, &confirmation); err != nil {
+		return Confirmation{}, false
+	}
+	confirmation.Email = email
+	return confirmation, true
+}
+
+// ConfirmationHandler returns an http.Handler for the page confirmation links
+// point at. It records the click for the token query parameter and replies
+// with a short plain text page, naming the mailify version in the Server header.
+func (c *Client) ConfirmationHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", userAgent())
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		confirmation, err := c.RecordClick(r.URL.Query().Get("token"))
+		switch {
+		case errors.Is(err, ErrConfirmationDisabled):
+			http.Error(w, "Confirmation is not available.", http.StatusNotFound)
+		case errors.Is(err, ErrTokenExpired):
+			http.Error(w, "This confirmation link has expired.", http.StatusGone)
+		case err != nil:
+			http.Error(w, "This confirmation link is not valid.", http.StatusBadRequest)
+		default:
+			fmt.Fprintf(w, "Thanks, %s is confirmed.\n", confirmation.Email)
+		}
+	})
+}
+
+// signToken returns a token for email that expires at the given time. It is
+// the base64url encoded address and expiry, followed by their HMAC-SHA256.
+func (c *Client) signToken(email string, expires time.Time) string {
+	payload := email + "|" + strconv.FormatInt(expires.Unix(), 10)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(c.tokenMAC(payload))
+}
+
+// checkToken verifies a token's signature and expiry and returns its address.
+func (c *Client) checkToken(token string, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	payload, err := enc.DecodeString(encodedPayload)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	mac, err := enc.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, c.tokenMAC(string(payload))) {
+		return "", ErrInvalidToken
+	}
+
+	email, expiry, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if now.After(time.Unix(unix, 0)) {
+		return "", ErrTokenExpired
+	}
+	return email, nil
+}
+
+// tokenMAC signs a token payload with the configured secret.
+func (c *Client) tokenMAC(payload string) []byte {
+	h := hmac.New(sha256.New, c.confirm.Secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// confirmationLink adds token to baseURL as its token query parameter.
+func confirmationLink(baseURL, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid confirmation URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// confirmationMessage builds the confirmation email, with CRLF line endings.
+func confirmationMessage(from, to, subject, link string) []byte {
+	if subject == "" {
+		subject = "Please confirm your email address"
+	}
+	body := "Please confirm your email address by opening this link:\r\n\r\n" +
+		link + "\r\n\r\n" +
+		"If you didn't ask for this, you can ignore this email.\r\n"
+	return textMessage(from, to, subject, body)
+}
+
+// textMessage builds a plain text email, with CRLF line endings.
+func textMessage(from, to, subject, body string) []byte {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		// Header values come from configuration and addresses, keep them on one line
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", to)
+	header("Subject", subject)
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+emailDomain(from)+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return b.Bytes()
+}
+
+// applyConfirmation marks the result of a confirmed address as deliverable,
+// or sends a confirmation email for an inconclusive one if AutoSend is set.
+func (c *Client) applyConfirmation(email string, result *ValidationResult) {
+	if c.confirm == nil || email == "" {
+		return
+	}
+	if _, ok := c.Confirmation(email); ok {
+		result.Verdict = VerdictDeliverable
+		result.SubStatus = SubStatusConfirmed
+		result.IsValid = true
+		return
+	}
+	inconclusive := result.SubStatus == SubStatusCatchAll || result.SubStatus == SubStatusCannotVerify ||
+		result.SubStatus == SubStatusAcceptAllProvider || result.SubStatus == SubStatusVerificationBlocked ||
+		(result.SubStatus == SubStatusSkipped && c.mode == ModeDNSOnly)
+	if c.confirm.AutoSend && inconclusive && !result.ConfirmationSent {
+		if _, err := c.SendConfirmation(email); err == nil {
+			result.ConfirmationSent = true
+		}
+	}
+}
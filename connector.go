@@ -0,0 +1,310 @@
+package mailify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Contact is a contact in a list held by an email marketing provider.
+type Contact struct {
+	// ID identifies the contact to the provider.
+	ID string `json:"id"`
+	// Email is the contact's address.
+	Email string `json:"email"`
+}
+
+// ListConnector reads a list, audience or segment from an email marketing
+// provider, and updates the contacts in it. MailchimpConnector,
+// HubSpotConnector and SendGridConnector are provided; SyncList works with
+// any implementation.
+type ListConnector interface {
+	// Name is the provider's name, such as "mailchimp".
+	Name() string
+	// Contacts returns every contact in the list.
+	Contacts(ctx context.Context) ([]Contact, error)
+	// Tag marks a contact with a tag, in whatever way the provider supports.
+	Tag(ctx context.Context, contact Contact, tag string) error
+	// Remove takes a contact off the list.
+	Remove(ctx context.Context, contact Contact) error
+}
+
+// SyncAction is what SyncList does to the contacts it finds.
+type SyncAction string
+
+const (
+	// SyncReport only validates the list and reports, changing nothing.
+	SyncReport SyncAction = "report"
+	// SyncTag tags the contacts found with SyncOptions.Tag.
+	SyncTag SyncAction = "tag"
+	// SyncRemove removes the contacts found from the list.
+	SyncRemove SyncAction = "remove"
+)
+
+// DefaultSyncTag is the tag SyncList applies when SyncOptions.Tag is empty.
+const DefaultSyncTag = "mailify-undeliverable"
+
+// SyncOptions configures SyncList.
+type SyncOptions struct {
+	// Action is what to do to the contacts with one of Verdicts, SyncReport if empty.
+	Action SyncAction
+	// Tag is the tag SyncTag applies, DefaultSyncTag if empty.
+	Tag string
+	// Verdicts are the verdicts acted on, only VerdictUndeliverable if empty.
+	// Contacts that fail to validate are never acted on.
+	Verdicts []Verdict
+	// Bulk configures the validation run, as for ValidateBulk.
+	Bulk BulkOptions
+}
+
+// SyncResult is the outcome for one contact of a SyncList run.
+type SyncResult struct {
+	Contact Contact `json:"contact"`
+	// Result is the validation result, if validation completed.
+	Result *ValidationResult `json:"result,omitempty"`
+	// Action is the action taken on the contact, empty if none.
+	Action SyncAction `json:"action,omitempty"`
+	// Error describes why validating or updating the contact failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// SyncSummary summarizes a SyncList run.
+type SyncSummary struct {
+	// Provider is the connector's name.
+	Provider string `json:"provider"`
+	// Contacts is the number of contacts in the list.
+	Contacts int `json:"contacts"`
+	// Verdicts counts the results by verdict.
+	Verdicts map[Verdict]int `json:"verdicts"`
+	// Tagged and Removed count the contacts updated.
+	Tagged  int `json:"tagged"`
+	Removed int `json:"removed"`
+	// Errors counts the contacts that failed to validate or update.
+	Errors int `json:"errors"`
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration"`
+	// Results holds one result per contact, in list order.
+	Results []SyncResult `json:"results"`
+}
+
+// SyncList pulls a list from an email marketing provider, validates every
+// contact in it, and tags or removes the contacts with the given verdicts,
+// so lists can be cleaned where they live instead of being exported,
+// validated and imported again by hand.
+//
+// Parameters:
+//   - ctx: Cancels the provider requests, and stops validation unless
+//     opts.Bulk.Context is set, see BulkOptions.Context. No contact is
+//     tagged or removed once it is done.
+//   - conn: The provider list to sync, see NewListConnector.
+//   - opts: What to do to which contacts, and how to validate them.
+//
+// Returns:
+//   - SyncSummary: The outcome for every contact, with counts.
+//   - error: An error if the list can't be read or the action is unknown.
+//     Contacts that fail to update are reported in their SyncResult instead.
+func (c *Client) SyncList(ctx context.Context, conn ListConnector, opts SyncOptions) (SyncSummary, error) {
+	start := time.Now()
+	if opts.Action == "" {
+		opts.Action = SyncReport
+	}
+	switch opts.Action {
+	case SyncReport, SyncTag, SyncRemove:
+	default:
+		return SyncSummary{}, fmt.Errorf("unknown sync action %q", opts.Action)
+	}
+	if opts.Tag == "" {
+		opts.Tag = DefaultSyncTag
+	}
+	if len(opts.Verdicts) == 0 {
+		opts.Verdicts = []Verdict{VerdictUndeliverable}
+	}
+
+	contacts, err := conn.Contacts(ctx)
+	if err != nil {
+		return SyncSummary{}, fmt.Errorf("failed to read %s list: %w", conn.Name(), err)
+	}
+
+	emails := make([]string, len(contacts))
+	for i, contact := range contacts {
+		emails[i] = contact.Email
+	}
+	if opts.Bulk.Context == nil {
+		opts.Bulk.Context = ctx
+	}
+	results := c.ValidateBulk(emails, opts.Bulk)
+
+	summary := SyncSummary{
+		Provider: conn.Name(),
+		Contacts: len(contacts),
+		Verdicts: make(map[Verdict]int),
+		Results:  make([]SyncResult, len(contacts)),
+	}
+	for i, res := range results {
+		sr := SyncResult{Contact: contacts[i], Result: res.Result}
+		if res.Err != nil {
+			sr.Error = res.Err.Error()
+			summary.Errors++
+			summary.Results[i] = sr
+			continue
+		}
+		summary.Verdicts[res.Result.Verdict]++
+
+		if opts.Action != SyncReport && containsVerdict(opts.Verdicts, res.Result.Verdict) {
+			if err := ctx.Err(); err != nil {
+				return summary, err
+			}
+			if opts.Action == SyncTag {
+				err = conn.Tag(ctx, contacts[i], opts.Tag)
+			} else {
+				err = conn.Remove(ctx, contacts[i])
+			}
+			switch {
+			case err != nil:
+				sr.Error = err.Error()
+				summary.Errors++
+			case opts.Action == SyncTag:
+				sr.Action = SyncTag
+				summary.Tagged++
+			default:
+				sr.Action = SyncRemove
+				summary.Removed++
+			}
+		}
+		summary.Results[i] = sr
+	}
+	summary.Duration = time.Since(start)
+	counts := BulkSummary{Total: summary.Contacts, Verdicts: summary.Verdicts, Errors: summary.Errors}
+	notify(opts.Bulk.Notifiers, newJobReport("Sync of "+conn.Name()+" list", start, counts, "", nil))
+	return summary, nil
+}
+
+// containsVerdict reports whether verdicts holds v.
+func containsVerdict(verdicts []Verdict, v Verdict) bool {
+	for _, verdict := range verdicts {
+		if verdict == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnectorConfig holds the provider, credentials and list for
+// NewListConnector, typically read from a file with LoadConnectorConfig.
+type ConnectorConfig struct {
+	// Provider is "mailchimp", "hubspot" or "sendgrid".
+	Provider string `json:"provider"`
+	// APIKey is the provider's API key, or HubSpot's private app token.
+	APIKey string `json:"api_key"`
+	// ListID is the Mailchimp audience, HubSpot list or SendGrid list to sync.
+	ListID string `json:"list_id"`
+	// TagListID is the SendGrid list tagged contacts are added to, as SendGrid
+	// has no tags.
+	TagListID string `json:"tag_list_id,omitempty"`
+	// TagProperty is the HubSpot contact property tags are written to,
+	// DefaultHubSpotTagProperty if empty, as HubSpot has no tags.
+	TagProperty string `json:"tag_property,omitempty"`
+	// Endpoint overrides the provider's API root, for testing or proxies.
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// LoadConnectorConfig reads a ConnectorConfig from a JSON file. The API key
+// may name an environment variable, as in "${MAILCHIMP_API_KEY}", to keep
+// it out of the file.
+//
+// Parameters:
+//   - path: The JSON file.
+//
+// Returns:
+//   - ConnectorConfig: The configuration, with the API key expanded.
+//   - error: An error if the file can't be read or parsed.
+func LoadConnectorConfig(path string) (ConnectorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ConnectorConfig{}, fmt.Errorf("failed to read connector config: %w", err)
+	}
+	var config ConnectorConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ConnectorConfig{}, fmt.Errorf("invalid connector config: %w", err)
+	}
+	config.APIKey = os.ExpandEnv(config.APIKey)
+	return config, nil
+}
+
+// NewListConnector creates the connector for a configuration's provider.
+//
+// Parameters:
+//   - config: The provider, credentials and list.
+//
+// Returns:
+//   - ListConnector: The connector.
+//   - error: An error if the provider is unknown or the API key or list is missing.
+func NewListConnector(config ConnectorConfig) (ListConnector, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("connector config has no api_key")
+	}
+	if config.ListID == "" {
+		return nil, errors.New("connector config has no list_id")
+	}
+	switch strings.ToLower(config.Provider) {
+	case "mailchimp":
+		return &MailchimpConnector{APIKey: config.APIKey, ListID: config.ListID, Endpoint: config.Endpoint}, nil
+	case "hubspot":
+		return &HubSpotConnector{Token: config.APIKey, ListID: config.ListID, TagProperty: config.TagProperty, Endpoint: config.Endpoint}, nil
+	case "sendgrid":
+		return &SendGridConnector{APIKey: config.APIKey, ListID: config.ListID, TagListID: config.TagListID, Endpoint: config.Endpoint}, nil
+	}
+	return nil, fmt.Errorf("unknown connector provider %q, expected mailchimp, hubspot or sendgrid", config.Provider)
+}
+
+// apiRequest sends a JSON request to a provider API, with the header set
+// by auth, and decodes a JSON response into out unless it is nil.
+func apiRequest(ctx context.Context, client *http.Client, auth func(*http.Request), method, rawURL string, body, out any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		return err
+	}
+	auth(req)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
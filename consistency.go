@@ -0,0 +1,120 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// primaryResolverName is how the client's own resolver is named in MXConsistency.
+const primaryResolverName = "primary"
+
+// NamedResolver is a Resolver along with the name it is reported by.
+type NamedResolver struct {
+	// Name identifies the resolver in reports, e.g. "cloudflare".
+	Name string
+	// Resolver does the lookups.
+	Resolver Resolver
+}
+
+// MXConsistency compares the MX records several resolvers gave for a domain.
+// Differences can mean split-horizon DNS, a resolver serving stale records,
+// or a poisoned cache.
+type MXConsistency struct {
+	// Consistent indicates whether every resolver that answered gave the same mail servers.
+	Consistent bool `json:"consistent"`
+	// Used names the resolver whose answer the SMTP stage used.
+	Used string `json:"used"`
+	// Answers holds each resolver's answer, the client's own resolver first.
+	Answers []ResolverAnswer `json:"answers"`
+}
+
+// ResolverAnswer is the MX records one resolver gave for a domain.
+type ResolverAnswer struct {
+	// Resolver names the resolver, "primary" for the client's own.
+	Resolver string `json:"resolver"`
+	// MX holds the mail servers, sorted.
+	MX []string `json:"mx,omitempty"`
+	// Error describes why the lookup failed, if it did. A domain without MX
+	// records is an answer, not an error.
+	Error string `json:"error,omitempty"`
+}
+
+// WithMXConsistencyCheck looks up every domain's MX records with the given
+// resolvers too, and reports in ValidationResult.MXConsistency whether they
+// agree with the client's own resolver, whose answer is the one validation
+// uses. Resolvers that fail to answer don't count as disagreeing.
+func WithMXConsistencyCheck(resolvers ...NamedResolver) Option {
+	return func(c *Client) {
+		c.mxResolvers = append(c.mxResolvers, resolvers...)
+	}
+}
+
+// CheckMXConsistency looks up domain's MX records with the client's own
+// resolver and those given with WithMXConsistencyCheck, and compares them.
+//
+// Parameters:
+//   - domain: The domain to check.
+//
+// Returns:
+//   - *MXConsistency: The answers and whether they agree.
+func (c *Client) CheckMXConsistency(domain string) *MXConsistency {
+	mailServers, err := c.GetMailServers(domain)
+	return c.mxConsistency(domain, mailServers, err)
+}
+
+// mxConsistency compares the primary answer for domain with the other resolvers' answers.
+func (c *Client) mxConsistency(domain string, primary []string, primaryErr error) *MXConsistency {
+	answers := make([]ResolverAnswer, len(c.mxResolvers)+1)
+	answers[0] = resolverAnswer(primaryResolverName, primary, primaryErr)
+
+	var wg sync.WaitGroup
+	for i, r := range c.mxResolvers {
+		wg.Add(1)
+		go func(answer *ResolverAnswer, r NamedResolver) {
+			defer wg.Done()
+			records, err := r.Resolver.LookupMX(context.Background(), domain)
+			var hosts []string
+			for _, record := range records {
+				hosts = append(hosts, strings.TrimSuffix(record.Host, "."))
+			}
+			*answer = resolverAnswer(r.Name, hosts, err)
+		}(&answers[i+1], r)
+	}
+	wg.Wait()
+
+	consistency := &MXConsistency{Consistent: true, Used: primaryResolverName, Answers: answers}
+	var reference []string
+	compared := false
+	for _, answer := range answers {
+		if answer.Error != "" {
+			continue
+		}
+		if !compared {
+			reference, compared = answer.MX, true
+		} else if !slices.Equal(reference, answer.MX) {
+			consistency.Consistent = false
+		}
+	}
+	return consistency
+}
+
+// resolverAnswer builds a resolver's answer, treating a missing domain or
+// missing MX records as an empty answer.
+func resolverAnswer(name string, hosts []string, err error) ResolverAnswer {
+	answer := ResolverAnswer{Resolver: name}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		answer.Error = err.Error()
+		return answer
+	}
+	for _, host := range hosts {
+		answer.MX = append(answer.MX, strings.ToLower(host))
+	}
+	slices.Sort(answer.MX)
+	answer.MX = slices.Compact(answer.MX)
+	return answer
+}
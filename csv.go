@@ -0,0 +1,111 @@
+package mailify
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// processCSVFile validates the addresses in a CSV file and writes the results
+// back to it as UTF-8, keeping its delimiter. If the run is interrupted, the
+// results so far are saved and ErrInterrupted returned.
+func (c *Client) processCSVFile(filename string, opts BulkOptions) (BulkSummary, error) {
+	records, delimiter, err := readCSV(filename, opts.Encoding)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+
+	fmt.Printf("Successfully opened CSV file: %s\n", filename)
+
+	t := &csvTable{name: filepath.Base(filename), records: records}
+	summary, err := c.processTable(t, opts)
+	if err != nil && !errors.Is(err, ErrInterrupted) {
+		return BulkSummary{}, err
+	}
+	summary.Files = 1
+
+	fmt.Println("\nSaving results to CSV file...")
+	if err := writeCSV(filename, t.records, delimiter); err != nil {
+		return BulkSummary{}, err
+	}
+	return summary, err
+}
+
+// readCSV reads a CSV file in the given encoding, detecting whether its
+// fields are separated by commas, semicolons or tabs.
+func readCSV(filename string, enc TextEncoding) ([][]string, rune, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if enc == "" {
+		enc = EncodingAuto
+	}
+	text, err := NewTextReader(f, enc)
+	if err != nil {
+		return nil, 0, err
+	}
+	br := bufio.NewReader(text)
+	start, _ := br.Peek(br.Size())
+	if len(start) == 0 {
+		return nil, 0, errNoData
+	}
+	delimiter := detectDelimiter(string(start))
+
+	r := csv.NewReader(br)
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return records, delimiter, nil
+}
+
+// detectDelimiter picks the field delimiter used most on the first line of
+// a CSV file: a comma, a semicolon, as written by Excel in locales using a
+// decimal comma, or a tab.
+func detectDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	delimiter, most := ',', 0
+	for _, candidate := range []rune{',', ';', '\t'} {
+		if n := strings.Count(line, string(candidate)); n > most {
+			delimiter, most = candidate, n
+		}
+	}
+	return delimiter
+}
+
+// writeCSV replaces a CSV file with the given records, writing them to a
+// temporary file first so that a failed write leaves the original intact.
+func writeCSV(filename string, records [][]string, delimiter rune) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".mailify-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if info, err := os.Stat(filename); err == nil {
+		tmp.Chmod(info.Mode().Perm())
+	}
+
+	w := csv.NewWriter(tmp)
+	w.Comma = delimiter
+	if err := w.WriteAll(records); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package mailify
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+)
+
+// ErrDuplicate is the error of the addresses a bulk run skipped because
+// BulkOptions.Dedupe had seen them before.
+var ErrDuplicate = errors.New("duplicate address")
+
+// Deduper tells which addresses of bulk runs have been seen before, see
+// BulkOptions.Dedupe. Implementations must be safe for concurrent use.
+type Deduper interface {
+	// Seen reports whether key was seen before, and records it.
+	Seen(key string) bool
+}
+
+// exactDeduper remembers every key it has seen.
+type exactDeduper struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewExactDeduper returns a Deduper that never mistakes a new address for a
+// duplicate, but keeps every address in memory, which for tens of millions of
+// addresses runs into gigabytes. See NewBloomDeduper for those.
+func NewExactDeduper() Deduper {
+	return &exactDeduper{seen: make(map[string]struct{})}
+}
+
+// Seen implements Deduper.
+func (d *exactDeduper) Seen(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	d.seen[key] = struct{}{}
+	return false
+}
+
+// bloomDeduper is a Bloom filter: k bits of a fixed-size bit set are set for
+// each key, and a key whose bits are all set has probably been seen.
+type bloomDeduper struct {
+	mu     sync.Mutex
+	bits   []uint64
+	m      uint64
+	hashes int
+}
+
+// NewBloomDeduper returns a Deduper that uses a fixed amount of memory, about
+// 1.8 MB per million addresses at a 0.1% false-positive rate, but mistakes
+// that share of new addresses for duplicates. Past the expected number of
+// addresses, the rate rises.
+//
+// Parameters:
+//   - expected: How many distinct addresses the runs are expected to hold.
+//   - falsePositiveRate: The share of new addresses that may be skipped as
+//     duplicates, e.g. 0.001. Values outside (0, 1) mean 0.001.
+//
+// Returns:
+//   - Deduper: The Bloom filter.
+func NewBloomDeduper(expected int, falsePositiveRate float64) Deduper {
+	if expected < 1 {
+		expected = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.001
+	}
+	n := float64(expected)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(m / n * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	words := (uint64(m) + 63) / 64
+	return &bloomDeduper{bits: make([]uint64, words), m: words * 64, hashes: hashes}
+}
+
+// Seen implements Deduper.
+func (d *bloomDeduper) Seen(key string) bool {
+	// Kirsch-Mitzenmacher: the k positions are h1 + i*h2
+	h1 := fnv64a(key)
+	h2 := mix64(h1) | 1
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen := true
+	for i := 0; i < d.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % d.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if d.bits[word]&mask == 0 {
+			seen = false
+			d.bits[word] |= mask
+		}
+	}
+	return seen
+}
+
+// fnv64a is the 64-bit FNV-1a hash of s, computed without allocating.
+func fnv64a(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// mix64 scrambles h into a second, independent hash (the SplitMix64 finalizer).
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// dedupeKey returns what an address is deduplicated by: the address without
+// its display name, normalized and lowercased.
+func dedupeKey(email string) string {
+	if address, err := ParseAddress(email); err == nil {
+		email = address.Email
+	}
+	if normalized, err := NormalizeEmail(email); err == nil {
+		email = normalized
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,152 @@
+package mailify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// IPPreference controls the order in which IPv4 and IPv6 addresses are dialed.
+type IPPreference int
+
+const (
+	// PreferIPv6 dials IPv6 addresses first, falling back to IPv4.
+	PreferIPv6 IPPreference = iota
+	// PreferIPv4 dials IPv4 addresses first, falling back to IPv6.
+	PreferIPv4
+	// IPv4Only never dials IPv6 addresses.
+	IPv4Only
+	// IPv6Only never dials IPv4 addresses.
+	IPv6Only
+)
+
+// sortIPs orders the addresses according to the preference, interleaving the two
+// address families so a broken network path for one family can't stall every
+// attempt (RFC 8305, section 4).
+func sortIPs(ips []net.IP, pref IPPreference) []net.IP {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	var first, second []net.IP
+	switch pref {
+	case IPv4Only:
+		return v4
+	case IPv6Only:
+		return v6
+	case PreferIPv4:
+		first, second = v4, v6
+	default:
+		first, second = v6, v4
+	}
+
+	sorted := make([]net.IP, 0, len(ips))
+	for len(first) > 0 || len(second) > 0 {
+		if len(first) > 0 {
+			sorted = append(sorted, first[0])
+			first = first[1:]
+		}
+		if len(second) > 0 {
+			sorted = append(sorted, second[0])
+			second = second[1:]
+		}
+	}
+	return sorted
+}
+
+// dialResult is the outcome of a single connection attempt in dialHappyEyeballs.
+type dialResult struct {
+	conn net.Conn
+	ip   net.IP
+	err  error
+}
+
+// dialHappyEyeballs races TCP connections to the given addresses on port in the
+// style of RFC 8305. Attempts are started in preference order, each one either
+// after the previous attempt fails or after the client's fallback delay, and the
+// first connection to succeed wins. Connections that lose the race are closed.
+//
+// Parameters:
+//   - ips: The candidate addresses of the server.
+//   - port: The port to connect to.
+//   - timeout: The timeout for each individual connection attempt.
+//
+// Returns:
+//   - net.Conn: The winning connection.
+//   - net.IP: The address the winning connection was made to.
+//   - error: The last connection error if no attempt succeeded.
+func (c *Client) dialHappyEyeballs(ips []net.IP, port string, timeout time.Duration) (net.Conn, net.IP, error) {
+	ips = sortIPs(ips, c.ipPreference)
+	if len(ips) == 0 {
+		return nil, nil, fmt.Errorf("no usable IP addresses")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan dialResult, len(ips))
+
+	pending := 0
+	var lastErr error
+	var winner *dialResult
+
+	// handle records the outcome of a finished attempt
+	handle := func(res dialResult) {
+		pending--
+		if res.err != nil {
+			lastErr = res.err
+			return
+		}
+		winner = &res
+	}
+
+	for _, ip := range ips {
+		if winner != nil {
+			break
+		}
+
+		pending++
+		go func(ip net.IP) {
+			attemptCtx, cancelAttempt := context.WithTimeout(ctx, timeout)
+			defer cancelAttempt()
+			conn, err := c.dialer.DialContext(attemptCtx, "tcp", net.JoinHostPort(ip.String(), port))
+			results <- dialResult{conn: conn, ip: ip, err: err}
+		}(ip)
+
+		// Give the attempt a head start before racing the next address,
+		// moving on straight away if it fails.
+		timer := time.NewTimer(c.fallbackDelay)
+		select {
+		case res := <-results:
+			timer.Stop()
+			handle(res)
+		case <-timer.C:
+		}
+	}
+
+	for winner == nil && pending > 0 {
+		handle(<-results)
+	}
+
+	if winner == nil {
+		return nil, nil, lastErr
+	}
+
+	// Close any connections that complete after the winner
+	cancel()
+	go func(pending int) {
+		for ; pending > 0; pending-- {
+			if res := <-results; res.conn != nil {
+				res.conn.Close()
+			}
+		}
+	}(pending)
+
+	return winner.conn, winner.ip, nil
+}
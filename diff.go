@@ -0,0 +1,204 @@
+package mailify
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// VerdictChange is an address whose verdict differs between two runs.
+type VerdictChange struct {
+	// Email is the address, as the newer file has it.
+	Email string `json:"email"`
+	// Old is the verdict in the older file.
+	Old Verdict `json:"old"`
+	// New is the verdict in the newer file.
+	New Verdict `json:"new"`
+}
+
+// ResultsDiff compares the results of two validation runs of a list, e.g. to
+// see how much of it has decayed before deciding whom to re-engage.
+type ResultsDiff struct {
+	// Changed are the addresses in both files whose verdict changed, sorted by address.
+	Changed []VerdictChange `json:"changed"`
+	// Transitions counts the changes by old and new verdict, keyed like "deliverable->undeliverable".
+	Transitions map[string]int `json:"transitions"`
+	// Unchanged counts the addresses in both files whose verdict is the same.
+	Unchanged int `json:"unchanged"`
+	// Added are the addresses only in the newer file.
+	Added []string `json:"added,omitempty"`
+	// Removed are the addresses only in the older file.
+	Removed []string `json:"removed,omitempty"`
+	// WasDeliverable counts the addresses in both files that were deliverable in the older one.
+	WasDeliverable int `json:"was_deliverable"`
+	// Decayed counts those of them that no longer are.
+	Decayed int `json:"decayed"`
+}
+
+// DecayRate returns the share of the addresses that were deliverable in the
+// older run and no longer are, from 0 to 1.
+func (d ResultsDiff) DecayRate() float64 {
+	if d.WasDeliverable == 0 {
+		return 0
+	}
+	return float64(d.Decayed) / float64(d.WasDeliverable)
+}
+
+// DiffResultFiles compares two files holding the results of validation runs,
+// as written by ProcessAndValidateEmailsViaFiles: Excel or CSV files with a
+// verdict column. Addresses are matched ignoring case, and rows without a
+// verdict are skipped.
+//
+// Parameters:
+//   - oldPath: The file with the older results.
+//   - newPath: The file with the newer results.
+//   - opts: EmailColumn, or else Mapping, names the column holding the
+//     addresses, detected as for bulk runs if empty, and Encoding is that of CSV files. Other
+//     fields are ignored.
+//
+// Returns:
+//   - ResultsDiff: The comparison.
+//   - error: An error if either file can't be read or has no verdict column.
+func DiffResultFiles(oldPath, newPath string, opts BulkOptions) (ResultsDiff, error) {
+	oldResults, _, err := readResultFile(oldPath, opts)
+	if err != nil {
+		return ResultsDiff{}, err
+	}
+	newResults, order, err := readResultFile(newPath, opts)
+	if err != nil {
+		return ResultsDiff{}, err
+	}
+
+	diff := ResultsDiff{Transitions: make(map[string]int)}
+	for _, key := range order {
+		current := newResults[key]
+		previous, ok := oldResults[key]
+		if !ok {
+			diff.Added = append(diff.Added, current.email)
+			continue
+		}
+		if previous.verdict == VerdictDeliverable {
+			diff.WasDeliverable++
+			if current.verdict != VerdictDeliverable {
+				diff.Decayed++
+			}
+		}
+		if previous.verdict == current.verdict {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, VerdictChange{Email: current.email, Old: previous.verdict, New: current.verdict})
+		diff.Transitions[string(previous.verdict)+"->"+string(current.verdict)]++
+	}
+	for key, previous := range oldResults {
+		if _, ok := newResults[key]; !ok {
+			diff.Removed = append(diff.Removed, previous.email)
+		}
+	}
+
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Email < diff.Changed[j].Email })
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff, nil
+}
+
+// fileResult is an address's verdict as read from a results file.
+type fileResult struct {
+	email   string
+	verdict Verdict
+}
+
+// readResultFile reads the verdicts in a results file, keyed by lowercased
+// address, along with the keys in the order they first appear.
+func readResultFile(path string, opts BulkOptions) (map[string]fileResult, []string, error) {
+	var sheets [][][]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, _, err := readCSV(path, opts.Encoding)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		sheets = append(sheets, records)
+	case ".xlsx", ".xlsm":
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to open file: %w", path, err)
+		}
+		defer f.Close()
+		for _, sheet := range f.GetSheetList() {
+			if sheet == summarySheet {
+				continue
+			}
+			rows, err := f.GetRows(sheet)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: sheet %q: %w", path, sheet, err)
+			}
+			sheets = append(sheets, rows)
+		}
+	default:
+		return nil, nil, fmt.Errorf("%s: only Excel and CSV result files can be compared", path)
+	}
+
+	results := make(map[string]fileResult)
+	var order []string
+	found := false
+	for _, rows := range sheets {
+		if len(rows) < 2 {
+			continue
+		}
+		headers := make(map[string]int)
+		for i, cell := range rows[0] {
+			headers[headerKey(cell)] = i
+		}
+		verdictCol, ok := headers["verdict"]
+		if !ok {
+			continue
+		}
+		emailCol, err := resultEmailColumn(rows, headers, opts.emailColumnName())
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		found = true
+
+		for _, row := range rows[1:] {
+			if emailCol >= len(row) || verdictCol >= len(row) {
+				continue
+			}
+			email, verdict := strings.TrimSpace(row[emailCol]), strings.TrimSpace(row[verdictCol])
+			if email == "" || verdict == "" {
+				continue
+			}
+			key := strings.ToLower(email)
+			if _, ok := results[key]; !ok {
+				order = append(order, key)
+			}
+			results[key] = fileResult{email: email, verdict: Verdict(verdict)}
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("%s: %w", path, errNoVerdicts)
+	}
+	return results, order, nil
+}
+
+// resultEmailColumn finds the column holding the addresses like emailColumn
+// does, but without reporting a detected column, so output can stay JSON.
+func resultEmailColumn(rows [][]string, headers map[string]int, name string) (int, error) {
+	if name != "" {
+		return emailColumn(rows, headers, name)
+	}
+	if col, ok := headers["email"]; ok {
+		return col, nil
+	}
+	if col, ok := DetectEmailColumn(rows); ok {
+		return col, nil
+	}
+	return 0, errors.New("no column holding email addresses found, name one with EmailColumn")
+}
+
+// errNoVerdicts is returned for files without a verdict column.
+var errNoVerdicts = errors.New("no verdict column found, validate the file first")
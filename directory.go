@@ -0,0 +1,210 @@
+package mailify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TokenSource returns an OAuth 2.0 access token for a directory API. It is
+// called for every request, so it should cache tokens until they expire; an
+// oauth2.TokenSource can be adapted with a one-line function.
+type TokenSource func() (string, error)
+
+// GraphVerifier is a Stage that checks whether mailboxes exist in a Microsoft
+// 365 tenant through Microsoft Graph, instead of probing over SMTP. It is meant
+// for organizations validating their own tenant's addresses, and needs a token
+// for an app granted User.Read.All and GroupMember.Read.All.
+//
+// Register it with WithStage(StageSMTP, verifier). Addresses at the listed
+// domains are answered from the directory, others carry on to the SMTP check,
+// as do addresses the lookup fails for.
+type GraphVerifier struct {
+	// Domains are the tenant's domains the verifier answers for.
+	Domains []string
+	// Token supplies access tokens for Microsoft Graph.
+	Token TokenSource
+	// Endpoint is the Graph API root, "https://graph.microsoft.com/v1.0" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements Stage.
+func (g *GraphVerifier) Name() StageName {
+	return "microsoft_graph"
+}
+
+// Run implements Stage. Users and mail-enabled groups are matched on any of
+// their addresses, including aliases.
+func (g *GraphVerifier) Run(input StageInput) (*ValidationResult, error) {
+	if !hasDomain(g.Domains, input.Domain) {
+		return nil, nil
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://graph.microsoft.com/v1.0"
+	}
+
+	// Filtering on proxyAddresses is an advanced query, which needs the
+	// ConsistencyLevel header and $count
+	filter := fmt.Sprintf("proxyAddresses/any(x:x eq 'smtp:%[1]s') or mail eq '%[1]s'", strings.ReplaceAll(input.Email, "'", "''"))
+	for _, kind := range []string{"users", "groups"} {
+		query := url.Values{"$filter": {filter}, "$count": {"true"}, "$select": {"id,accountEnabled,mail"}}
+		if kind == "groups" {
+			query.Set("$select", "id,mail")
+		}
+
+		var page struct {
+			Value []struct {
+				ID             string `json:"id"`
+				AccountEnabled *bool  `json:"accountEnabled"`
+			} `json:"value"`
+		}
+		header := http.Header{"ConsistencyLevel": {"eventual"}}
+		status, err := directoryGet(g.HTTPClient, g.Token, endpoint+"/"+kind+"?"+query.Encode(), header, &page)
+		if err != nil {
+			return nil, fmt.Errorf("microsoft graph: %w", err)
+		}
+		if status != http.StatusOK || len(page.Value) == 0 {
+			continue
+		}
+
+		entry := page.Value[0]
+		input.Fields["id"] = entry.ID
+		input.Fields["type"] = strings.TrimSuffix(kind, "s")
+		enabled := entry.AccountEnabled == nil || *entry.AccountEnabled
+		return directoryResult(true, enabled), nil
+	}
+	return directoryResult(false, false), nil
+}
+
+// GoogleDirectoryVerifier is a Stage that checks whether mailboxes exist in a
+// Google Workspace domain through the Admin SDK Directory API, instead of
+// probing over SMTP. It is meant for organizations validating their own
+// addresses, and needs a token with the admin.directory.user.readonly and
+// admin.directory.group.readonly scopes, usually from a service account with
+// domain-wide delegation.
+//
+// Register it with WithStage(StageSMTP, verifier). Addresses at the listed
+// domains are answered from the directory, others carry on to the SMTP check,
+// as do addresses the lookup fails for.
+type GoogleDirectoryVerifier struct {
+	// Domains are the Workspace domains the verifier answers for.
+	Domains []string
+	// Token supplies access tokens for the Admin SDK.
+	Token TokenSource
+	// Endpoint is the Directory API root,
+	// "https://admin.googleapis.com/admin/directory/v1" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements Stage.
+func (g *GoogleDirectoryVerifier) Name() StageName {
+	return "google_directory"
+}
+
+// Run implements Stage. Users and groups are matched on their primary address
+// or any alias.
+func (g *GoogleDirectoryVerifier) Run(input StageInput) (*ValidationResult, error) {
+	if !hasDomain(g.Domains, input.Domain) {
+		return nil, nil
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://admin.googleapis.com/admin/directory/v1"
+	}
+
+	for _, kind := range []string{"users", "groups"} {
+		var entry struct {
+			ID        string `json:"id"`
+			Suspended bool   `json:"suspended"`
+			Archived  bool   `json:"archived"`
+		}
+		status, err := directoryGet(g.HTTPClient, g.Token, endpoint+"/"+kind+"/"+url.PathEscape(input.Email), nil, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("google directory: %w", err)
+		}
+		if status == http.StatusNotFound {
+			continue
+		}
+
+		input.Fields["id"] = entry.ID
+		input.Fields["type"] = strings.TrimSuffix(kind, "s")
+		return directoryResult(true, !entry.Suspended && !entry.Archived), nil
+	}
+	return directoryResult(false, false), nil
+}
+
+// hasDomain reports whether domain is one of domains.
+func hasDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimSuffix(d, "."), domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// directoryResult builds the result of a directory lookup.
+func directoryResult(found, enabled bool) *ValidationResult {
+	switch {
+	case !found:
+		return &ValidationResult{Verdict: VerdictUndeliverable, SubStatus: SubStatusMailboxNotFound, HasMX: true, ErrorMessage: "Mailbox not found in directory"}
+	case !enabled:
+		return &ValidationResult{Verdict: VerdictUndeliverable, SubStatus: SubStatusMailboxDisabled, HasMX: true, ErrorMessage: "Mailbox is disabled in directory"}
+	default:
+		return &ValidationResult{Verdict: VerdictDeliverable, SubStatus: SubStatusDirectoryVerified, IsValid: true, HasMX: true}
+	}
+}
+
+// directoryGet fetches a directory API URL with a bearer token and decodes a
+// 200 response into v. A 404 is returned as a status rather than an error.
+func directoryGet(client *http.Client, token TokenSource, rawURL string, header http.Header, v any) (int, error) {
+	if token == nil {
+		return 0, fmt.Errorf("no token source configured")
+	}
+	accessToken, err := token()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get access token: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return 0, fmt.Errorf("invalid response: %w", err)
+		}
+		return resp.StatusCode, nil
+	case http.StatusNotFound:
+		return resp.StatusCode, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
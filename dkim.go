@@ -0,0 +1,168 @@
+package mailify
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// commonDKIMSelectors are the selectors CheckDKIM always looks up: generic
+// ones and those of widely used mail providers.
+var commonDKIMSelectors = []string{
+	"default", "dkim", "mail", "smtp", "s1", "s2", "k1", "k2", "k3",
+	"google", "selector1", "selector2", "sig1", "fm1", "fm2", "fm3",
+	"protonmail", "protonmail2", "protonmail3", "zoho", "mandrill", "mxvault",
+}
+
+// minRSAKeyBits is the smallest RSA key RFC 8301 allows verifiers to accept.
+const minRSAKeyBits = 1024
+
+// DKIMInfo describes a DKIM selector of a domain (RFC 6376).
+type DKIMInfo struct {
+	// Selector is the selector that was looked up.
+	Selector string `json:"selector"`
+	// Found indicates whether the selector has a key record.
+	Found bool `json:"found"`
+	// Record is the key record, if found.
+	Record string `json:"record,omitempty"`
+	// KeyType is the key's algorithm, "rsa" or "ed25519".
+	KeyType string `json:"key_type,omitempty"`
+	// KeyBits is the size of an RSA key.
+	KeyBits int `json:"key_bits,omitempty"`
+	// Valid indicates whether the record holds a usable key.
+	Valid bool `json:"valid"`
+	// Revoked indicates whether the key was revoked with an empty p= tag.
+	Revoked bool `json:"revoked,omitempty"`
+	// Testing indicates whether the domain is testing DKIM (t=y), so
+	// verifiers must not treat failures differently from unsigned mail.
+	Testing bool `json:"testing,omitempty"`
+	// Error describes what is wrong with the record, or why the lookup failed.
+	Error string `json:"error,omitempty"`
+}
+
+// CheckDKIM looks up a domain's DKIM selectors and validates the key records
+// it finds. Common selectors, such as default, google and selector1, are
+// always looked up; DKIM has no way to list a domain's selectors, so any
+// others must be given. The lookups run in parallel.
+//
+// Parameters:
+//   - domain: The domain to check.
+//   - selectors: Selectors to look up besides the common ones.
+//
+// Returns:
+//   - []DKIMInfo: One entry per selector looked up, common selectors first,
+//     whether it was found or not.
+//   - error: An error if the domain is not a valid domain name.
+func (c *Client) CheckDKIM(domain string, selectors ...string) ([]DKIMInfo, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if err := checkDomain(domain); err != nil {
+		return nil, fmt.Errorf("invalid domain %q: %w", domain, err)
+	}
+
+	var all []string
+	for _, selector := range append(append([]string{}, commonDKIMSelectors...), selectors...) {
+		selector = strings.ToLower(strings.TrimSpace(selector))
+		if selector != "" && !containsString(all, selector) {
+			all = append(all, selector)
+		}
+	}
+
+	infos := make([]DKIMInfo, len(all))
+	var wg sync.WaitGroup
+	for i, selector := range all {
+		wg.Add(1)
+		go func(info *DKIMInfo, selector string) {
+			defer wg.Done()
+			*info = c.dkimSelector(domain, selector)
+		}(&infos[i], selector)
+	}
+	wg.Wait()
+	return infos, nil
+}
+
+// dkimSelector looks up and validates a single selector.
+func (c *Client) dkimSelector(domain, selector string) DKIMInfo {
+	info := DKIMInfo{Selector: selector}
+	records, err := c.lookupTXT(selector + "._domainkey." + domain)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	// Some names have unrelated TXT records, or a CNAME to a provider's wildcard
+	for _, record := range records {
+		if strings.Contains(record, "p=") {
+			info.Found = true
+			info.Record = record
+			break
+		}
+	}
+	if !info.Found {
+		return info
+	}
+	validateDKIMRecord(&info)
+	return info
+}
+
+// validateDKIMRecord parses info.Record and fills in what it says about the key.
+func validateDKIMRecord(info *DKIMInfo) {
+	if version := tagValue(info.Record, "v"); version != "" && !strings.HasPrefix(strings.TrimSpace(info.Record), "v=DKIM1") {
+		info.Error = "v= tag must come first and be DKIM1"
+		return
+	}
+	for _, flag := range strings.Split(tagValue(info.Record, "t"), ":") {
+		if strings.TrimSpace(flag) == "y" {
+			info.Testing = true
+		}
+	}
+
+	info.KeyType = strings.ToLower(tagValue(info.Record, "k"))
+	if info.KeyType == "" {
+		info.KeyType = "rsa"
+	}
+
+	// Long keys are split into several strings, which may leave whitespace in the value
+	encoded := strings.Join(strings.Fields(tagValue(info.Record, "p")), "")
+	if encoded == "" {
+		info.Revoked = true
+		info.Error = "key has been revoked"
+		return
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		info.Error = "key is not valid base64"
+		return
+	}
+
+	switch info.KeyType {
+	case "rsa":
+		pub, err := x509.ParsePKIXPublicKey(key)
+		if err != nil {
+			// Some signers publish the bare PKCS #1 key
+			pub, err = x509.ParsePKCS1PublicKey(key)
+		}
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if err != nil || !ok {
+			info.Error = "key is not a valid RSA public key"
+			return
+		}
+		info.KeyBits = rsaKey.N.BitLen()
+		if info.KeyBits < minRSAKeyBits {
+			info.Error = fmt.Sprintf("RSA key is too short (%d bits)", info.KeyBits)
+			return
+		}
+	case "ed25519":
+		if len(key) != ed25519.PublicKeySize {
+			info.Error = "key is not a valid Ed25519 public key"
+			return
+		}
+	default:
+		info.Error = fmt.Sprintf("unknown key type %q", info.KeyType)
+		return
+	}
+	info.Valid = true
+}
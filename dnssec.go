@@ -0,0 +1,206 @@
+package mailify
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultDNSSECResolver is the validating resolver DNSSEC checks query
+// unless WithDNSSECResolver says otherwise.
+const defaultDNSSECResolver = "8.8.8.8:53"
+
+// typeRRSIG is the RRSIG record type, which dnsmessage doesn't name.
+const typeRRSIG dnsmessage.Type = 46
+
+// DNSSECInfo describes whether a domain's MX lookup chain, the MX records and
+// the addresses of the mail servers they name, is protected by DNSSEC.
+type DNSSECInfo struct {
+	// Signed indicates whether every answer in the chain came with signatures.
+	Signed bool `json:"signed"`
+	// Validated indicates whether the resolver validated every answer in the
+	// chain (the AD bit), which is what protects against spoofed MX records.
+	Validated bool `json:"validated"`
+	// Resolver is the validating resolver that was asked.
+	Resolver string `json:"resolver"`
+	// Unsigned holds the names in the chain whose answers weren't signed.
+	Unsigned []string `json:"unsigned,omitempty"`
+	// Error describes why the check couldn't be done, if it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+// WithDNSSECResolver sets the validating resolver DNSSEC checks query, as
+// host:port. It must validate DNSSEC and set the AD bit on validated answers.
+// The default is Google's public DNS server (8.8.8.8:53).
+func WithDNSSECResolver(address string) Option {
+	return func(c *Client) {
+		c.dnssecResolver = address
+	}
+}
+
+// CheckDNSSEC reports whether a domain's MX records, and the address records
+// of the mail servers they name, are DNSSEC-signed and validate. The lookups
+// go to the validating resolver set with WithDNSSECResolver, since the
+// client's Resolver doesn't say whether its answers were validated.
+//
+// Parameters:
+//   - domain: The domain to check.
+//
+// Returns:
+//   - DNSSECInfo: What was found. Its Error is set if a lookup failed.
+//   - error: An error if the domain is not a valid domain name.
+func (c *Client) CheckDNSSEC(domain string) (DNSSECInfo, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if err := checkDomain(domain); err != nil {
+		return DNSSECInfo{}, fmt.Errorf("invalid domain %q: %w", domain, err)
+	}
+	info := DNSSECInfo{Resolver: c.dnssecResolver, Signed: true, Validated: true}
+	if info.Resolver == "" {
+		info.Resolver = defaultDNSSECResolver
+	}
+
+	record := func(name string, answer *dnssecAnswer) {
+		info.Validated = info.Validated && answer.authenticated
+		if !answer.signed {
+			info.Signed = false
+			info.Unsigned = append(info.Unsigned, name)
+		}
+	}
+
+	answer, err := queryDNSSEC(info.Resolver, domain, dnsmessage.TypeMX)
+	if err != nil {
+		return DNSSECInfo{Resolver: info.Resolver, Error: err.Error()}, nil
+	}
+	if len(answer.mx) == 0 {
+		return DNSSECInfo{Resolver: info.Resolver, Error: "no MX records"}, nil
+	}
+	record(domain, answer)
+
+	for _, host := range answer.mx {
+		hostAnswer, err := queryDNSSEC(info.Resolver, host, dnsmessage.TypeA)
+		if err == nil && !hostAnswer.found {
+			// IPv6-only mail servers only have AAAA records
+			hostAnswer, err = queryDNSSEC(info.Resolver, host, dnsmessage.TypeAAAA)
+		}
+		if err != nil {
+			return DNSSECInfo{Resolver: info.Resolver, Error: err.Error()}, nil
+		}
+		record(host, hostAnswer)
+	}
+	return info, nil
+}
+
+// dnssecAnswer is what a DNSSEC query found out.
+type dnssecAnswer struct {
+	// found indicates whether the answer held records of the asked type.
+	found bool
+	// signed indicates whether the answer held RRSIG records.
+	signed bool
+	// authenticated is the AD bit of the response.
+	authenticated bool
+	// mx holds the mail servers of an MX answer.
+	mx []string
+}
+
+// queryDNSSEC asks resolver for name's records of type qtype with the DNSSEC
+// OK bit set, over UDP and again over TCP if the answer was truncated.
+func queryDNSSEC(resolver, name string, qtype dnsmessage.Type) (*dnssecAnswer, error) {
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, AuthenticData: true})
+	builder.EnableCompression()
+	builder.StartQuestions()
+	builder.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET})
+	builder.StartAdditionals()
+	var opt dnsmessage.ResourceHeader
+	opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true)
+	builder.OPTResource(opt, dnsmessage.OPTResource{})
+	query, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := exchangeDNS("udp", resolver, query)
+	if err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		return nil, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	if msg.Truncated {
+		if response, err = exchangeDNS("tcp", resolver, query); err != nil {
+			return nil, err
+		}
+		if err := msg.Unpack(response); err != nil {
+			return nil, fmt.Errorf("invalid DNS response: %w", err)
+		}
+	}
+	if msg.ID != id {
+		return nil, errors.New("DNS response does not match the query")
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess && msg.RCode != dnsmessage.RCodeNameError {
+		return nil, fmt.Errorf("lookup %s failed: %s", name, msg.RCode)
+	}
+
+	answer := &dnssecAnswer{authenticated: msg.AuthenticData}
+	for _, rr := range msg.Answers {
+		switch rr.Header.Type {
+		case typeRRSIG:
+			answer.signed = true
+		case qtype:
+			answer.found = true
+			if mx, ok := rr.Body.(*dnsmessage.MXResource); ok {
+				answer.mx = append(answer.mx, strings.TrimSuffix(mx.MX.String(), "."))
+			}
+		}
+	}
+	return answer, nil
+}
+
+// exchangeDNS sends a DNS query over network and returns the response.
+func exchangeDNS(network, resolver string, query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout(network, resolver, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		response := make([]byte, 4096)
+		n, err := conn.Read(response)
+		if err != nil {
+			return nil, err
+		}
+		return response[:n], nil
+	}
+
+	// Over TCP messages are prefixed with their length
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	response := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,442 @@
+package mailify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultIPBlocklists are the DNS blocklists mail server addresses are checked against.
+var defaultIPBlocklists = []string{"zen.spamhaus.org", "bl.spamcop.net", "b.barracudacentral.org"}
+
+// defaultDomainBlocklists are the DNS blocklists domains are checked against.
+var defaultDomainBlocklists = []string{"dbl.spamhaus.org"}
+
+// DomainInfo is a health report of a domain's mail setup, as gathered by GetDomainInfo.
+type DomainInfo struct {
+	// Domain is the domain the report is about.
+	Domain string `json:"domain"`
+	// MX describes each of the domain's mail servers, in order of preference.
+	MX []MXInfo `json:"mx"`
+	// MXError is why the mail servers couldn't be looked up, if they couldn't.
+	MXError string `json:"mx_error,omitempty"`
+	// Provider is who runs the domain's preferred mail server, such as
+	// Google or Microsoft, guessed from its name.
+	Provider string `json:"provider,omitempty"`
+	// CatchAll is what the client's cache knows about whether the domain
+	// accepts every address, see CatchAllRecord. It is nil if nothing is
+	// known; the domain isn't probed.
+	CatchAll *CatchAllRecord `json:"catch_all,omitempty"`
+	// AcceptAll is the rule of the client's accept-all registry for the
+	// domain, if its provider is known to accept every recipient or to block
+	// verification, see WithAcceptAllRules.
+	AcceptAll *AcceptAllRule `json:"accept_all,omitempty"`
+	// Reputation is what validating the domain's addresses has shown about
+	// it, from the client's cache, nil if nothing is known. See DomainReputation.
+	Reputation *DomainReputation `json:"reputation,omitempty"`
+	// SPF describes the domain's SPF record.
+	SPF SPFInfo `json:"spf"`
+	// DMARC describes the domain's DMARC record.
+	DMARC DMARCInfo `json:"dmarc"`
+	// DNSSEC describes whether the MX lookup chain is DNSSEC-signed and validates.
+	DNSSEC DNSSECInfo `json:"dnssec"`
+	// MTASTS describes the domain's MTA-STS policy.
+	MTASTS MTASTSInfo `json:"mta_sts"`
+	// BIMI describes the domain's BIMI record.
+	BIMI BIMIInfo `json:"bimi"`
+	// DKIM describes the DKIM selectors that were found, and those asked for that weren't.
+	DKIM []DKIMInfo `json:"dkim,omitempty"`
+	// Blocklists holds the domain blocklists that list the domain.
+	Blocklists []string `json:"blocklists,omitempty"`
+}
+
+// MXInfo describes one of a domain's mail servers.
+type MXInfo struct {
+	// Host is the mail server's host name.
+	Host string `json:"host"`
+	// Preference is the MX preference, lower is tried first.
+	Preference uint16 `json:"preference"`
+	// IPAddresses holds the mail server's addresses.
+	IPAddresses []string `json:"ip_addresses,omitempty"`
+	// Reachable indicates whether the server accepted a connection on the first of the
+	// client's SMTP ports, 25 unless WithPorts says otherwise.
+	Reachable bool `json:"reachable"`
+	// TLS indicates whether the server offers TLS on that port.
+	TLS bool `json:"tls"`
+	// Blocklists holds the IP blocklists that list any of the server's addresses.
+	Blocklists []string `json:"blocklists,omitempty"`
+	// Error is why the server couldn't be checked, if it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+// SPFInfo describes a domain's SPF record (RFC 7208).
+type SPFInfo struct {
+	// Record is the SPF record, empty if there is none.
+	Record string `json:"record,omitempty"`
+	// Valid indicates whether the domain has exactly one SPF record.
+	Valid bool `json:"valid"`
+	// Error describes what is wrong with the record, if anything.
+	Error string `json:"error,omitempty"`
+}
+
+// DMARCInfo describes a domain's DMARC record (RFC 7489).
+type DMARCInfo struct {
+	// Record is the DMARC record, empty if there is none.
+	Record string `json:"record,omitempty"`
+	// Policy is the record's p= tag: none, quarantine or reject.
+	Policy string `json:"policy,omitempty"`
+	// Error describes what is wrong with the record, if anything.
+	Error string `json:"error,omitempty"`
+}
+
+// MTASTSInfo describes a domain's MTA-STS policy (RFC 8461).
+type MTASTSInfo struct {
+	// ID is the policy id from the _mta-sts TXT record, empty if there is none.
+	ID string `json:"id,omitempty"`
+	// Mode is the policy mode: enforce, testing or none.
+	Mode string `json:"mode,omitempty"`
+	// MX holds the mail server patterns the policy allows.
+	MX []string `json:"mx,omitempty"`
+	// MaxAge is how long senders may cache the policy, in seconds.
+	MaxAge int `json:"max_age,omitempty"`
+	// Error describes why the policy couldn't be fetched, if it couldn't.
+	Error string `json:"error,omitempty"`
+}
+
+// BIMIInfo describes a domain's BIMI record, which tells mailbox providers
+// which logo to show next to its messages.
+type BIMIInfo struct {
+	// Record is the default._bimi record, empty if there is none.
+	Record string `json:"record,omitempty"`
+	// LogoURL is the SVG logo from the l= tag.
+	LogoURL string `json:"logo_url,omitempty"`
+	// AuthorityURL is the Verified Mark Certificate from the a= tag.
+	AuthorityURL string `json:"authority_url,omitempty"`
+	// HasVMC indicates whether the record points at a Verified Mark
+	// Certificate, which providers such as Gmail require to show the logo.
+	HasVMC bool `json:"has_vmc"`
+	// Error describes what is wrong with the record, if anything.
+	Error string `json:"error,omitempty"`
+}
+
+// GetDomainInfo gathers a health report of a domain's mail setup: its mail
+// servers, who runs them and whether they offer TLS, whether its MX records are protected
+// by DNSSEC, its SPF, DMARC, MTA-STS and BIMI records, the
+// DKIM selectors found by CheckDKIM, and whether the domain or its mail servers are on
+// common DNS blocklists, along with whether the domain is catch-all and its
+// reputation from earlier validations if the client's cache knows. The checks run in parallel. Problems with a single
+// check are reported in that part of the report rather than as an error.
+//
+// Mail servers are only connected to in ModeFull.
+//
+// Parameters:
+//   - domain: The domain to report on.
+//   - dkimSelectors: DKIM selectors to look up besides the common ones. They
+//     are reported even if missing; common selectors only if found.
+//
+// Returns:
+//   - *DomainInfo: The report.
+//   - error: An error if the domain is not a valid domain name.
+func (c *Client) GetDomainInfo(domain string, dkimSelectors ...string) (*DomainInfo, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if err := checkDomain(domain); err != nil {
+		return nil, fmt.Errorf("invalid domain %q: %w", domain, err)
+	}
+	info := &DomainInfo{Domain: domain}
+
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+	run(func() { info.MX, info.MXError = c.mxInfo(domain) })
+	run(func() { info.SPF = c.spfInfo(domain) })
+	run(func() { info.DMARC = c.dmarcInfo(domain) })
+	run(func() { info.MTASTS = c.mtaSTSInfo(domain) })
+	run(func() { info.BIMI = c.bimiInfo(domain) })
+	run(func() { info.DNSSEC, _ = c.CheckDNSSEC(domain) })
+	run(func() {
+		found, _ := c.CheckDKIM(domain, dkimSelectors...)
+		for _, dkim := range found {
+			if dkim.Found || containsString(dkimSelectors, dkim.Selector) {
+				info.DKIM = append(info.DKIM, dkim)
+			}
+		}
+	})
+	run(func() { info.Blocklists = c.blocklisted(domain, defaultDomainBlocklists) })
+	wg.Wait()
+
+	if len(info.MX) > 0 {
+		info.Provider = mailProvider(info.MX[0].Host)
+	}
+	if record, ok := c.CatchAllRecord(domain); ok {
+		info.CatchAll = &record
+	}
+	var mailServers []string
+	for _, mx := range info.MX {
+		mailServers = append(mailServers, mx.Host)
+	}
+	if rule, ok := c.AcceptAllRuleFor(domain, mailServers); ok {
+		info.AcceptAll = &rule
+	}
+	if rep, ok := c.DomainReputation(domain); ok {
+		info.Reputation = &rep
+	}
+
+	return info, nil
+}
+
+// mxInfo looks up and checks each of the domain's mail servers.
+func (c *Client) mxInfo(domain string) ([]MXInfo, string) {
+	records, err := c.resolver.LookupMX(context.Background(), domain)
+	if err != nil {
+		return nil, err.Error()
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+
+	localName, _ := c.GetHostname()
+	infos := make([]MXInfo, len(records))
+	var wg sync.WaitGroup
+	for i, record := range records {
+		wg.Add(1)
+		go func(info *MXInfo, record *net.MX) {
+			defer wg.Done()
+			info.Host = strings.TrimSuffix(record.Host, ".")
+			info.Preference = record.Pref
+
+			ips, err := c.lookupIP(info.Host)
+			if err != nil {
+				info.Error = fmt.Sprintf("failed to lookup IP: %v", err)
+				return
+			}
+			if !c.allowBogonMX && len(publicIPs(ips)) == 0 {
+				info.Error = ErrBogonMX.Error()
+			}
+			for _, ip := range ips {
+				info.IPAddresses = append(info.IPAddresses, ip.String())
+				for _, list := range c.blocklisted(reverseIP(ip), defaultIPBlocklists) {
+					if !containsString(info.Blocklists, list) {
+						info.Blocklists = append(info.Blocklists, list)
+					}
+				}
+			}
+
+			if c.mode != ModeFull || info.Error != "" {
+				return
+			}
+			probe := c.probePort(info.Host, ips, c.smtpPorts(info.Host)[0], localName)
+			info.Reachable = probe.Reachable
+			info.TLS = probe.TLS
+			info.Error = probe.Error
+		}(&infos[i], record)
+	}
+	wg.Wait()
+	return infos, ""
+}
+
+// spfInfo looks up the domain's SPF record.
+func (c *Client) spfInfo(domain string) SPFInfo {
+	records, err := c.lookupTXTPrefix(domain, "v=spf1")
+	switch {
+	case err != nil:
+		return SPFInfo{Error: err.Error()}
+	case len(records) == 0:
+		return SPFInfo{Error: "no SPF record"}
+	case len(records) > 1:
+		return SPFInfo{Record: records[0], Error: "multiple SPF records"}
+	}
+	return SPFInfo{Record: records[0], Valid: true}
+}
+
+// dmarcInfo looks up the domain's DMARC record.
+func (c *Client) dmarcInfo(domain string) DMARCInfo {
+	records, err := c.lookupTXTPrefix("_dmarc."+domain, "v=DMARC1")
+	switch {
+	case err != nil:
+		return DMARCInfo{Error: err.Error()}
+	case len(records) == 0:
+		return DMARCInfo{Error: "no DMARC record"}
+	case len(records) > 1:
+		return DMARCInfo{Record: records[0], Error: "multiple DMARC records"}
+	}
+
+	info := DMARCInfo{Record: records[0]}
+	info.Policy = tagValue(records[0], "p")
+	if info.Policy == "" {
+		info.Error = "record has no policy"
+	}
+	return info
+}
+
+// mtaSTSInfo looks up the domain's MTA-STS record and fetches its policy.
+func (c *Client) mtaSTSInfo(domain string) MTASTSInfo {
+	records, err := c.lookupTXTPrefix("_mta-sts."+domain, "v=STSv1")
+	switch {
+	case err != nil:
+		return MTASTSInfo{Error: err.Error()}
+	case len(records) == 0:
+		return MTASTSInfo{Error: "no MTA-STS record"}
+	}
+	info := MTASTSInfo{ID: tagValue(records[0], "id")}
+
+	httpClient := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: c.dialer.DialContext},
+		// The policy must be served directly, without redirects
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := httpClient.Get("https://mta-sts." + domain + "/.well-known/mta-sts.txt")
+	if err != nil {
+		info.Error = fmt.Sprintf("failed to fetch policy: %v", err)
+		return info
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		info.Error = fmt.Sprintf("failed to fetch policy: %s", resp.Status)
+		return info
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "mode":
+			info.Mode = value
+		case "mx":
+			info.MX = append(info.MX, value)
+		case "max_age":
+			info.MaxAge, _ = strconv.Atoi(value)
+		}
+	}
+	if info.Mode == "" {
+		info.Error = "policy has no mode"
+	}
+	return info
+}
+
+// bimiInfo looks up the domain's default BIMI record.
+func (c *Client) bimiInfo(domain string) BIMIInfo {
+	records, err := c.lookupTXTPrefix("default._bimi."+domain, "v=BIMI1")
+	switch {
+	case err != nil:
+		return BIMIInfo{Error: err.Error()}
+	case len(records) == 0:
+		return BIMIInfo{Error: "no BIMI record"}
+	case len(records) > 1:
+		return BIMIInfo{Record: records[0], Error: "multiple BIMI records"}
+	}
+
+	info := BIMIInfo{
+		Record:       records[0],
+		LogoURL:      tagValue(records[0], "l"),
+		AuthorityURL: tagValue(records[0], "a"),
+	}
+	info.HasVMC = info.AuthorityURL != ""
+	switch {
+	case info.LogoURL == "":
+		// An empty l= declines to take part in BIMI
+		info.Error = "record declines BIMI"
+	case !strings.HasPrefix(info.LogoURL, "https://"):
+		info.Error = "logo must be served over HTTPS"
+	case info.HasVMC && !strings.HasPrefix(info.AuthorityURL, "https://"):
+		info.Error = "certificate must be served over HTTPS"
+	}
+	return info
+}
+
+// blocklisted returns the DNS blocklists that list name, which is a domain or
+// a reversed IP address. Answers outside 127.0.0.0/8, and the 127.255.255.x
+// answers lists use to refuse queries (e.g. from public resolvers), don't count.
+func (c *Client) blocklisted(name string, lists []string) []string {
+	var listed []string
+	for _, list := range lists {
+		addrs, err := c.resolver.LookupIPAddr(context.Background(), name+"."+list)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ip := addr.IP.To4()
+			if ip != nil && ip[0] == 127 && !(ip[1] == 255 && ip[2] == 255) {
+				listed = append(listed, list)
+				break
+			}
+		}
+	}
+	return listed
+}
+
+// lookupTXT returns the TXT records of name, or none if it doesn't exist.
+func (c *Client) lookupTXT(name string) ([]string, error) {
+	records, err := c.resolver.LookupTXT(context.Background(), name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	return records, err
+}
+
+// lookupTXTPrefix returns the TXT records of name that start with prefix, such as "v=spf1".
+func (c *Client) lookupTXTPrefix(name, prefix string) ([]string, error) {
+	records, err := c.lookupTXT(name)
+	if err != nil {
+		return nil, err
+	}
+	var matching []string
+	for _, record := range records {
+		if len(record) >= len(prefix) && strings.EqualFold(record[:len(prefix)], prefix) {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+// tagValue returns the value of a tag in a "tag=value; tag=value" record.
+func tagValue(record, tag string) string {
+	for _, field := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), tag) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// reverseIP returns ip in the reversed form DNS blocklists are queried with:
+// reversed octets for IPv4, reversed nibbles for IPv6.
+func reverseIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0])
+	}
+	const hexDigits = "0123456789abcdef"
+	ip = ip.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(ip) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hexDigits[ip[i]&0xf]), string(hexDigits[ip[i]>>4]))
+	}
+	return strings.Join(nibbles, ".")
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package mailify
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// domainReputationTTL is how long a domain's statistics are kept in the
+// cache given with WithCache after it was last validated.
+const domainReputationTTL = 180 * 24 * time.Hour
+
+// recentMXChange is how long after its mail servers changed a domain's
+// score is lowered, as mailboxes come and go with a migration.
+const recentMXChange = 30 * 24 * time.Hour
+
+// DomainReputation is what validating a domain's addresses over time has
+// shown about it. With WithCache, statistics are gathered from every SMTP
+// check, loaded from the cache and saved back on Close, so they build up
+// across runs.
+type DomainReputation struct {
+	// Domain is the domain the statistics are about.
+	Domain string `json:"domain"`
+	// Checks is how many of the domain's addresses got an answer or a
+	// deferral from its mail servers.
+	Checks int `json:"checks"`
+	// Bounces is how many of those the servers rejected as not existing or disabled.
+	Bounces int `json:"bounces"`
+	// Deferrals is how many of those the servers deferred, such as by greylisting.
+	Deferrals int `json:"deferrals"`
+	// BounceRate is Bounces as a share of Checks.
+	BounceRate float64 `json:"bounce_rate"`
+	// DeferralRate is Deferrals as a share of Checks.
+	DeferralRate float64 `json:"deferral_rate"`
+	// CatchAll indicates whether the domain accepted every recipient when last checked.
+	CatchAll bool `json:"catch_all"`
+	// CatchAllCheckedAt is when an address was last accepted, showing whether
+	// the domain accepts every recipient. Zero if none has been.
+	CatchAllCheckedAt time.Time `json:"catch_all_checked_at,omitempty"`
+	// CatchAllFlips is how many times the domain started or stopped accepting
+	// every recipient, a sign of a gateway or a misconfiguration.
+	CatchAllFlips int `json:"catch_all_flips"`
+	// MX holds the domain's mail servers when last validated, sorted.
+	MX []string `json:"mx,omitempty"`
+	// MXChanges is how many times the domain's mail servers changed.
+	MXChanges int `json:"mx_changes"`
+	// MXChangedAt is when the mail servers last changed, zero if they never have.
+	MXChangedAt time.Time `json:"mx_changed_at,omitempty"`
+	// Score rates how reliably mail reaches the domain's mailboxes, from 0
+	// to 100: bounces, deferrals, accepting every recipient, catch-all flips
+	// and a recent change of mail servers each lower it.
+	Score int `json:"score"`
+	// FirstSeen is when the domain was first validated.
+	FirstSeen time.Time `json:"first_seen"`
+	// UpdatedAt is when the domain was last validated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// score computes the reputation's rates and score from its counts.
+func (r *DomainReputation) score(now time.Time) {
+	r.BounceRate, r.DeferralRate = 0, 0
+	if r.Checks > 0 {
+		r.BounceRate = float64(r.Bounces) / float64(r.Checks)
+		r.DeferralRate = float64(r.Deferrals) / float64(r.Checks)
+	}
+	score := 100 - 50*r.BounceRate - 30*r.DeferralRate
+	if r.CatchAll {
+		score -= 10
+	}
+	score -= float64(min(r.CatchAllFlips*5, 15))
+	if !r.MXChangedAt.IsZero() && now.Sub(r.MXChangedAt) < recentMXChange {
+		score -= 10
+	}
+	r.Score = max(0, int(score+0.5))
+}
+
+// domainReputations tracks DomainReputation for the domains a client
+// validates, as mxStats does for mail servers.
+type domainReputations struct {
+	mu      sync.Mutex
+	domains map[string]*DomainReputation
+	// loaded holds the domains the cache has been asked about.
+	loaded map[string]bool
+	// changed holds the domains validated since the statistics were last saved.
+	changed map[string]bool
+}
+
+// newDomainReputations returns an empty tracker.
+func newDomainReputations() *domainReputations {
+	return &domainReputations{domains: make(map[string]*DomainReputation), loaded: make(map[string]bool), changed: make(map[string]bool)}
+}
+
+// domainReputationKey is the cache key a domain's statistics are kept under.
+func domainReputationKey(domain string) string {
+	return "domainrep:" + strings.ToLower(domain)
+}
+
+// get returns the statistics of domain, loading them from cache the first
+// time. It must be called with d.mu held.
+func (d *domainReputations) get(cache Cache, domain string) *DomainReputation {
+	domain = strings.ToLower(domain)
+	if rep, ok := d.domains[domain]; ok || d.loaded[domain] || cache == nil {
+		return rep
+	}
+	d.loaded[domain] = true
+	data, ok := cache.Get(domainReputationKey(domain))
+	if !ok {
+		return nil
+	}
+	var rep DomainReputation
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return nil
+	}
+	d.domains[domain] = &rep
+	return &rep
+}
+
+// DomainReputation returns what validating a domain's addresses has shown
+// about it, across runs with WithCache.
+//
+// Parameters:
+//   - domain: The domain to look up.
+//
+// Returns:
+//   - DomainReputation: The statistics, with the rates and score computed.
+//   - bool: False if the client has no cache or hasn't validated the domain.
+func (c *Client) DomainReputation(domain string) (DomainReputation, bool) {
+	if c.cache == nil {
+		return DomainReputation{}, false
+	}
+	d := c.domainReputations
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rep := d.get(c.cache, strings.TrimSuffix(domain, "."))
+	if rep == nil {
+		return DomainReputation{}, false
+	}
+	out := *rep
+	out.MX = slices.Clone(rep.MX)
+	out.score(time.Now())
+	return out, true
+}
+
+// recordDomain adds what a validation showed about its domain to the
+// domain's statistics: its mail servers, and for SMTP checks whether the
+// address bounced or was deferred and whether the domain accepts everyone.
+// It returns the change of the domain's mail servers, nil if they didn't
+// change.
+func (c *Client) recordDomain(v *validation, result *ValidationResult) *MXChange {
+	if c.cache == nil || v.email == "" || len(v.mailServers) == 0 {
+		return nil
+	}
+	domain := emailDomain(v.email)
+	// Dated as the result, so it doesn't count as cached before a change it found
+	now := result.ValidatedAt
+	d := c.domainReputations
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rep := d.get(c.cache, domain)
+	if rep == nil {
+		rep = &DomainReputation{Domain: domain, FirstSeen: now}
+		d.domains[domain] = rep
+	}
+
+	mx := slices.Clone(v.mailServers)
+	for i := range mx {
+		mx[i] = strings.ToLower(strings.TrimSuffix(mx[i], "."))
+	}
+	slices.Sort(mx)
+	var change *MXChange
+	if len(rep.MX) > 0 && !slices.Equal(rep.MX, mx) {
+		rep.MXChanges++
+		rep.MXChangedAt = now
+		change = &MXChange{Domain: domain, Previous: rep.MX, Current: slices.Clone(mx), DetectedAt: now}
+	}
+	rep.MX = mx
+
+	// A check cut short by the probe budget says nothing about the domain
+	smtp := result.Provenance != nil && result.Provenance.Source == ProvenanceSMTP && result.SubStatus != SubStatusBudgetExhausted
+	if smtp && (result.Verdict != VerdictUnknown || result.RetryAfter > 0) {
+		rep.Checks++
+		switch {
+		case result.SubStatus == SubStatusMailboxNotFound || result.SubStatus == SubStatusMailboxDisabled:
+			rep.Bounces++
+		case result.RetryAfter > 0:
+			rep.Deferrals++
+		}
+		if result.Verdict == VerdictDeliverable || result.SubStatus == SubStatusCatchAll {
+			if !rep.CatchAllCheckedAt.IsZero() && rep.CatchAll != result.IsCatchAll {
+				rep.CatchAllFlips++
+			}
+			rep.CatchAll = result.IsCatchAll
+			rep.CatchAllCheckedAt = now
+		}
+	}
+	rep.UpdatedAt = now
+	d.changed[domain] = true
+	return change
+}
+
+// saveDomainReputations writes the statistics of the domains validated since
+// the last save to the client's cache, if it has one.
+func (c *Client) saveDomainReputations() {
+	d := c.domainReputations
+	if c.cache == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for domain := range d.changed {
+		data, err := json.Marshal(d.domains[domain])
+		if err != nil {
+			continue
+		}
+		c.cache.Set(domainReputationKey(domain), data, domainReputationTTL)
+		delete(d.changed, domain)
+	}
+}
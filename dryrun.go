@@ -0,0 +1,149 @@
+package mailify
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RunPlan is what processing files would do, as PlanFiles finds it without
+// validating anything or writing to the files.
+type RunPlan struct {
+	// Files are the files the run would process, in order.
+	Files []FilePlan `json:"files"`
+	// Analysis describes the addresses the run would validate, across the files.
+	Analysis *ListAnalysis `json:"analysis"`
+	// Estimate is how long the run would take, see ListAnalysis.EstimateDuration.
+	Estimate time.Duration `json:"estimate"`
+}
+
+// FilePlan is what processing files would do with one of them.
+type FilePlan struct {
+	// Path is the file.
+	Path string `json:"path"`
+	// Output is where the results would be written: the file itself, or
+	// the archive next to it for a ZIP file.
+	Output string `json:"output"`
+	// Emails is the number of addresses the run would validate in the file.
+	Emails int `json:"emails"`
+	// Kept is the number of rows the run would leave as they are, see
+	// BulkOptions.SkipValidated.
+	Kept int `json:"kept,omitempty"`
+	// Error is why the run would fail on the file, such as a missing email
+	// column or an output directory it can't write to, if it would.
+	Error string `json:"error,omitempty"`
+}
+
+// PlanFiles reports what ProcessAndValidateEmailsViaFiles would do with the
+// same pattern and options, without any network activity: the files it
+// would process, the addresses in them it would validate, how many domains
+// and SMTP probes they come to and how long the run would take. Each file
+// is read as the run would read it, and the directory its results would be
+// written to is checked to be writable by creating and removing a
+// temporary file in it. Nothing else is written.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern such as "lists/*.xlsx", or a single file.
+//   - opts: Options the run would be given.
+//   - perAddress: How long validating one address takes, for the estimate.
+//
+// Returns:
+//   - *RunPlan: The plan, with the files that would fail among its files.
+//   - error: An error if no files match, or the errors of the files that would fail.
+func (c *Client) PlanFiles(pattern string, opts BulkOptions, perAddress time.Duration) (*RunPlan, error) {
+	files, err := listFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &RunPlan{}
+	var emails []string
+	var errs []error
+	for _, filename := range files {
+		file, fileEmails, err := planFile(filename, opts)
+		if err != nil {
+			file.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", filename, err))
+		}
+		plan.Files = append(plan.Files, file)
+		emails = append(emails, fileEmails...)
+	}
+	plan.Analysis = c.AnalyzeList(emails)
+	plan.Estimate = plan.Analysis.EstimateDuration(opts, perAddress)
+	return plan, errors.Join(errs...)
+}
+
+// planFile reads the addresses a run would validate in a file and checks
+// that its results could be written.
+func planFile(filename string, opts BulkOptions) (FilePlan, []string, error) {
+	file := FilePlan{Path: filename, Output: filename}
+	var emails []string
+	var err error
+	if strings.EqualFold(filepath.Ext(filename), ".zip") {
+		file.Output = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".validated.zip"
+		emails, file.Kept, err = zipFileEmails(filename, opts)
+	} else {
+		emails, file.Kept, err = listFileEmails(filename, opts)
+	}
+	if err != nil {
+		return file, nil, err
+	}
+	file.Emails = len(emails)
+	if err := checkWritable(filepath.Dir(file.Output)); err != nil {
+		return file, emails, err
+	}
+	return file, emails, nil
+}
+
+// zipFileEmails reads the addresses a run would validate in the Excel and
+// CSV files of a ZIP archive, like listFileEmails.
+func zipFileEmails(filename string, opts BulkOptions) ([]string, int, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	tmpDir, err := os.MkdirTemp("", "mailify-zip-*")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to extract archive: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var emails []string
+	kept := 0
+	for i, member := range r.File {
+		// The members processZipFile would skip
+		ext := strings.ToLower(path.Ext(member.Name))
+		if member.FileInfo().IsDir() || ext == ".zip" || !isListFile(member.Name) || strings.HasPrefix(member.Name, "__MACOSX/") {
+			continue
+		}
+		local := filepath.Join(tmpDir, fmt.Sprintf("%d%s", i, ext))
+		if err := extractZipMember(member, local); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", member.Name, err)
+		}
+		memberEmails, memberKept, err := listFileEmails(local, opts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", member.Name, err)
+		}
+		emails = append(emails, memberEmails...)
+		kept += memberKept
+	}
+	return emails, kept, nil
+}
+
+// checkWritable checks that files can be created in a directory, as saving
+// results creates a temporary file next to the file it replaces.
+func checkWritable(dir string) error {
+	tmp, err := os.CreateTemp(dir, ".mailify-*")
+	if err != nil {
+		return fmt.Errorf("results can't be written: %w", err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
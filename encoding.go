@@ -0,0 +1,177 @@
+package mailify
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// TextEncoding is the character encoding of a text or CSV file.
+type TextEncoding string
+
+const (
+	// EncodingAuto detects the encoding from a byte order mark, or else from
+	// the content, see DetectTextEncoding.
+	EncodingAuto TextEncoding = "auto"
+	// EncodingUTF8 is UTF-8, with or without a byte order mark.
+	EncodingUTF8 TextEncoding = "utf-8"
+	// EncodingUTF16LE is little-endian UTF-16, as Excel's "Unicode Text" exports.
+	EncodingUTF16LE TextEncoding = "utf-16le"
+	// EncodingUTF16BE is big-endian UTF-16.
+	EncodingUTF16BE TextEncoding = "utf-16be"
+	// EncodingLatin1 is ISO 8859-1.
+	EncodingLatin1 TextEncoding = "latin-1"
+	// EncodingWindows1252 is the Windows superset of Latin-1 that most
+	// Windows software means by "ANSI".
+	EncodingWindows1252 TextEncoding = "windows-1252"
+)
+
+// detectSampleSize is how much of a file DetectTextEncoding looks at.
+const detectSampleSize = 4096
+
+// ParseTextEncoding parses the name of an encoding, such as "utf-16le",
+// "latin1" or "cp1252". Case, hyphens and underscores don't matter.
+//
+// Parameters:
+//   - name: The name of the encoding.
+//
+// Returns:
+//   - TextEncoding: The encoding.
+//   - bool: False if the name is unknown.
+func ParseTextEncoding(name string) (TextEncoding, bool) {
+	switch strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name)) {
+	case "", "auto":
+		return EncodingAuto, true
+	case "utf8":
+		return EncodingUTF8, true
+	case "utf16", "utf16le":
+		return EncodingUTF16LE, true
+	case "utf16be":
+		return EncodingUTF16BE, true
+	case "latin1", "iso88591":
+		return EncodingLatin1, true
+	case "windows1252", "cp1252", "ansi":
+		return EncodingWindows1252, true
+	}
+	return EncodingAuto, false
+}
+
+// DetectTextEncoding guesses the encoding of the start of a file. A byte
+// order mark decides it. Otherwise text with many zero bytes in every other
+// position is taken as UTF-16, valid UTF-8 as UTF-8, and anything else as
+// Windows-1252, which also decodes Latin-1 text correctly.
+//
+// Parameters:
+//   - sample: The start of the file, a few KB is plenty.
+//
+// Returns:
+//   - TextEncoding: The likely encoding, never EncodingAuto.
+func DetectTextEncoding(sample []byte) TextEncoding {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	// ASCII text in UTF-16 has a zero byte in every character
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	if half := len(sample) / 2; half > 0 {
+		switch {
+		case oddZeros > half/4 && oddZeros > 4*evenZeros:
+			return EncodingUTF16LE
+		case evenZeros > half/4 && evenZeros > 4*oddZeros:
+			return EncodingUTF16BE
+		}
+	}
+
+	// The sample may end part way through a character
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if utf8.Valid(sample) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// NewTextReader returns a reader that decodes r from the given encoding to
+// UTF-8, dropping any byte order mark, so that text and CSV files exported
+// by CRMs and spreadsheets in other encodings don't turn into garbled
+// domains.
+//
+// Parameters:
+//   - r: The encoded text.
+//   - enc: The encoding of r, EncodingAuto to detect it.
+//
+// Returns:
+//   - io.Reader: The text as UTF-8.
+//   - error: An error if the encoding is unknown or r can't be read.
+func NewTextReader(r io.Reader, enc TextEncoding) (io.Reader, error) {
+	if enc == EncodingAuto {
+		br := bufio.NewReaderSize(r, detectSampleSize)
+		sample, err := br.Peek(detectSampleSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("failed to read text: %w", err)
+		}
+		enc, r = DetectTextEncoding(sample), br
+	}
+
+	var decoder encoding.Encoding
+	switch enc {
+	case EncodingUTF8:
+		decoder = unicode.UTF8BOM
+	case EncodingUTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case EncodingUTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case EncodingLatin1:
+		decoder = charmap.ISO8859_1
+	case EncodingWindows1252:
+		decoder = charmap.Windows1252
+	default:
+		return nil, fmt.Errorf("unknown text encoding %q", enc)
+	}
+	return transform.NewReader(r, decoder.NewDecoder()), nil
+}
+
+// DecodeText decodes text in the given encoding to a UTF-8 string, see NewTextReader.
+//
+// Parameters:
+//   - data: The encoded text.
+//   - enc: The encoding of data, EncodingAuto to detect it.
+//
+// Returns:
+//   - string: The text.
+//   - error: An error if the encoding is unknown or the text can't be decoded.
+func DecodeText(data []byte, enc TextEncoding) (string, error) {
+	r, err := NewTextReader(bytes.NewReader(data), enc)
+	if err != nil {
+		return "", err
+	}
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode text: %w", err)
+	}
+	return string(text), nil
+}
@@ -16,6 +16,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create mailify client: %v", err)
 	}
+	defer client.Close()
 
 	// Get mail servers for a domain
 	resp, err := client.GetMailServers("namanrai.tech")
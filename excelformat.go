@@ -0,0 +1,219 @@
+package mailify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// summarySheet is the sheet processed workbooks get with the run's counts.
+// It is skipped when a workbook is processed again.
+const summarySheet = "Mailify Summary"
+
+// verdictFills are the background colors verdict cells are given.
+var verdictFills = []struct {
+	verdict Verdict
+	color   string
+}{
+	{VerdictDeliverable, "C6EFCE"},
+	{VerdictUndeliverable, "FFC7CE"},
+	{VerdictRisky, "FFEB9C"},
+	{VerdictUnknown, "FFEB9C"},
+}
+
+// styleResults makes a processed sheet ready to hand over: the header row is
+// bold and frozen, has filters on every column, and verdict cells are
+// colored green, red or yellow by verdict.
+//
+// Parameters:
+//   - lastRow: The number of the sheet's last row.
+//   - lastCol: The index of the sheet's last column.
+//   - verdictCol: The index of the verdict column, -1 if it isn't written.
+func (s excelSheet) styleResults(lastRow, lastCol, verdictCol int) error {
+	if err := s.f.SetPanes(s.name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return fmt.Errorf("failed to freeze header: %w", err)
+	}
+	bold, err := s.f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("failed to style header: %w", err)
+	}
+	if err := s.f.SetRowStyle(s.name, 1, 1, bold); err != nil {
+		return fmt.Errorf("failed to style header: %w", err)
+	}
+	if err := s.f.AutoFilter(s.name, fmt.Sprintf("A1:%s%d", columnToLetter(lastCol), lastRow), nil); err != nil {
+		return fmt.Errorf("failed to add filters: %w", err)
+	}
+	if verdictCol < 0 {
+		return nil
+	}
+
+	// Replace the colors of an earlier run rather than stacking them
+	verdicts := fmt.Sprintf("%[1]s2:%[1]s%[2]d", columnToLetter(verdictCol), lastRow)
+	if err := s.f.UnsetConditionalFormat(s.name, verdicts); err != nil {
+		return fmt.Errorf("failed to color verdicts: %w", err)
+	}
+	var formats []excelize.ConditionalFormatOptions
+	for _, fill := range verdictFills {
+		style, err := s.f.NewConditionalStyle(&excelize.Style{Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{fill.color}}})
+		if err != nil {
+			return fmt.Errorf("failed to color verdicts: %w", err)
+		}
+		formats = append(formats, excelize.ConditionalFormatOptions{
+			Type: "cell", Criteria: "==", Format: &style, Value: fmt.Sprintf("%q", fill.verdict),
+		})
+	}
+	if err := s.f.SetConditionalFormat(s.name, verdicts, formats); err != nil {
+		return fmt.Errorf("failed to color verdicts: %w", err)
+	}
+	return nil
+}
+
+// summaryTopDomains is how many domains the summary sheet lists per table.
+const summaryTopDomains = 10
+
+// writeSummarySheet adds a sheet about the run to a workbook, in place of the
+// one an earlier run added: the counts by verdict with a chart of them, the
+// run's duration, the domains with the most undeliverable addresses, the
+// catch-all domains met and the configuration used.
+func writeSummarySheet(f *excelize.File, summary BulkSummary, duration time.Duration, config ManifestConfig) error {
+	if index, err := f.GetSheetIndex(summarySheet); err == nil && index >= 0 {
+		if err := f.DeleteSheet(summarySheet); err != nil {
+			return fmt.Errorf("failed to replace summary sheet: %w", err)
+		}
+	}
+	if _, err := f.NewSheet(summarySheet); err != nil {
+		return fmt.Errorf("failed to add summary sheet: %w", err)
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("failed to write summary sheet: %w", err)
+	}
+
+	// Each section is a bold heading row followed by its rows, then a gap
+	row := 1
+	section := func(heading []any, rows [][]any) error {
+		if err := f.SetSheetRow(summarySheet, fmt.Sprintf("A%d", row), &heading); err != nil {
+			return err
+		}
+		if err := f.SetRowStyle(summarySheet, row, row, bold); err != nil {
+			return err
+		}
+		row++
+		for _, r := range rows {
+			if err := f.SetSheetRow(summarySheet, fmt.Sprintf("A%d", row), &r); err != nil {
+				return err
+			}
+			row++
+		}
+		row++
+		return nil
+	}
+
+	sections := []struct {
+		heading []any
+		rows    [][]any
+	}{
+		{[]any{"Verdict", "Emails"}, [][]any{
+			{string(VerdictDeliverable), summary.Verdicts[VerdictDeliverable]},
+			{string(VerdictUndeliverable), summary.Verdicts[VerdictUndeliverable]},
+			{string(VerdictRisky), summary.Verdicts[VerdictRisky]},
+			{string(VerdictUnknown), summary.Verdicts[VerdictUnknown]},
+			{"Total", summary.Total},
+			{"Mailbox full", summary.MailboxFull},
+			{"Duplicates", summary.Duplicates},
+			{"Rows without an email", summary.Skipped},
+			{"Kept from earlier runs", summary.Kept},
+			{"Errors", summary.Errors},
+		}},
+		{[]any{"Run", ""}, [][]any{
+			{"Finished", time.Now().Format("2006-01-02 15:04:05 MST")},
+			{"Duration", duration.Round(time.Second).String()},
+		}},
+		{[]any{"Top undeliverable domains", "Emails"}, domainRows(summary.undeliverableDomains)},
+		{[]any{"Catch-all domains", "Emails"}, domainRows(summary.catchAllDomains)},
+		{[]any{"Configuration", ""}, configRows(config)},
+	}
+	for _, s := range sections {
+		if err := section(s.heading, s.rows); err != nil {
+			return fmt.Errorf("failed to write summary sheet: %w", err)
+		}
+	}
+	if err := f.SetColWidth(summarySheet, "A", "A", 28); err != nil {
+		return fmt.Errorf("failed to write summary sheet: %w", err)
+	}
+	if err := f.SetColWidth(summarySheet, "B", "B", 24); err != nil {
+		return fmt.Errorf("failed to write summary sheet: %w", err)
+	}
+
+	sheetRef := "'" + summarySheet + "'"
+	err = f.AddChart(summarySheet, "D2", &excelize.Chart{
+		Type: excelize.Pie,
+		Series: []excelize.ChartSeries{{
+			Name:       sheetRef + "!$B$1",
+			Categories: sheetRef + "!$A$2:$A$5",
+			Values:     sheetRef + "!$B$2:$B$5",
+		}},
+		Title:    []excelize.RichTextRun{{Text: "Verdicts"}},
+		PlotArea: excelize.ChartPlotArea{ShowPercent: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add chart: %w", err)
+	}
+	return nil
+}
+
+// domainRows returns the rows of a domain table, the domains with the most
+// addresses first, or a row saying there are none.
+func domainRows(counts map[string]int) [][]any {
+	if len(counts) == 0 {
+		return [][]any{{"None", ""}}
+	}
+	domains := make([]string, 0, len(counts))
+	for domain := range counts {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if counts[domains[i]] != counts[domains[j]] {
+			return counts[domains[i]] > counts[domains[j]]
+		}
+		return domains[i] < domains[j]
+	})
+	if len(domains) > summaryTopDomains {
+		domains = domains[:summaryTopDomains]
+	}
+	rows := make([][]any, len(domains))
+	for i, domain := range domains {
+		rows[i] = []any{domain, counts[domain]}
+	}
+	return rows
+}
+
+// configRows returns the rows of the configuration table, a setting per
+// row named as in manifests.
+func configRows(config ManifestConfig) [][]any {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	// Decode numbers as written, so the probe seed isn't rounded
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var settings map[string]any
+	if err := dec.Decode(&settings); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rows := make([][]any, len(names))
+	for i, name := range names {
+		rows[i] = []any{name, fmt.Sprint(settings[name])}
+	}
+	return rows
+}
@@ -0,0 +1,74 @@
+package mailify
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ExtractEmails finds the email addresses embedded in arbitrary text, such as
+// signatures, scraped web pages or mailto: links, and returns those that are
+// syntactically valid, normalized as by NormalizeEmail. Each address is
+// returned once, in the order it first appears, so the result can be passed
+// straight to ValidateBulk.
+//
+// Only local parts made of letters, digits and the characters .+-_' are
+// recognized, as the other characters RFC 5322 allows, such as = and ?, are
+// far more often the surrounding text, e.g. a URL's query string. Punctuation
+// around an address, like a sentence's final period or enclosing brackets, is
+// left out, and %40 in URL-encoded mailto: links is read as @.
+//
+// Parameters:
+//   - text: The text to search.
+//
+// Returns:
+//   - []string: The addresses found, nil if there are none.
+func ExtractEmails(text string) []string {
+	text = strings.ReplaceAll(text, "%40", "@")
+	runes := []rune(text)
+
+	var emails []string
+	seen := make(map[string]bool)
+	for at, r := range runes {
+		if r != '@' {
+			continue
+		}
+
+		start := at
+		for start > 0 && isLocalRune(runes[start-1]) {
+			start--
+		}
+		end := at + 1
+		for end < len(runes) && isDomainRune(runes[end]) {
+			end++
+		}
+
+		local := strings.TrimLeft(string(runes[start:at]), ".'")
+		domain := strings.TrimRight(string(runes[at+1:end]), ".-")
+		email, err := NormalizeEmail(local + "@" + domain)
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(email)
+		if !seen[key] {
+			seen[key] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// isLocalRune reports whether r can be part of an unquoted local part found in text.
+func isLocalRune(r rune) bool {
+	if r >= 0x80 {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return isAlnum(r) || strings.ContainsRune(".+-_'", r)
+}
+
+// isDomainRune reports whether r can be part of a domain found in text.
+func isDomainRune(r rune) bool {
+	if r >= 0x80 {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return isAlnum(r) || r == '.' || r == '-'
+}
@@ -0,0 +1,362 @@
+package mailify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FallbackVerifier checks a mailbox through a third-party verification API,
+// for when mail servers can't be asked from this host, e.g. because the
+// cloud provider blocks port 25 or the host's IP address is blocklisted.
+// ZeroBounce, NeverBounce and Kickbox are built in; see NewFallbackVerifier.
+type FallbackVerifier interface {
+	// Name identifies the API in results, such as "zerobounce".
+	Name() string
+	// Verify checks the address. The result carries the API's verdict in
+	// the standard schema, with what the API said in Extra. Returning an
+	// error, or a result whose verdict is VerdictUnknown, lets the next
+	// verifier try.
+	Verify(ctx context.Context, email string) (*ValidationResult, error)
+}
+
+// WithFallbackVerifiers sets the verification APIs to ask, in order, about
+// addresses whose mail servers couldn't be checked from this host: the SMTP
+// conversation failed, the server refused on policy grounds or wanted
+// reverse DNS, it was tarpitting, or its provider blocks verification. The
+// first definite verdict replaces the SMTP check's, with a warning saying
+// so, and what the API said is kept in Extra under "fallback". If none gives
+// one, the SMTP check's result stands.
+func WithFallbackVerifiers(verifiers ...FallbackVerifier) Option {
+	return func(c *Client) {
+		c.fallbacks = append(c.fallbacks, verifiers...)
+	}
+}
+
+// fallbackProviders builds the built-in verifiers by name.
+var fallbackProviders = map[string]func(apiKey string) FallbackVerifier{
+	"zerobounce":  func(apiKey string) FallbackVerifier { return &ZeroBounceVerifier{APIKey: apiKey} },
+	"neverbounce": func(apiKey string) FallbackVerifier { return &NeverBounceVerifier{APIKey: apiKey} },
+	"kickbox":     func(apiKey string) FallbackVerifier { return &KickboxVerifier{APIKey: apiKey} },
+}
+
+// FallbackProviders returns the names of the built-in verification APIs,
+// as NewFallbackVerifier takes them.
+//
+// Returns:
+//   - []string: The names, sorted.
+func FallbackProviders() []string {
+	names := make([]string, 0, len(fallbackProviders))
+	for name := range fallbackProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewFallbackVerifier returns a built-in verifier by name.
+//
+// Parameters:
+//   - provider: The API's name: zerobounce, neverbounce or kickbox.
+//   - apiKey: The key of the account the checks are billed to.
+//
+// Returns:
+//   - FallbackVerifier: The verifier.
+//   - error: An error if the provider is unknown or the key is empty.
+func NewFallbackVerifier(provider, apiKey string) (FallbackVerifier, error) {
+	build, ok := fallbackProviders[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("unknown verification API %q, want one of %v", provider, FallbackProviders())
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("no API key for %s", provider)
+	}
+	return build(apiKey), nil
+}
+
+// smtpBlocked reports whether a result says the mailbox couldn't be checked
+// from this host, rather than anything about the mailbox.
+func smtpBlocked(result *ValidationResult) bool {
+	switch result.SubStatus {
+	case SubStatusSMTPError, SubStatusPolicyBlocked, SubStatusReverseDNSRequired, SubStatusTarpit, SubStatusVerificationBlocked:
+		return true
+	}
+	return false
+}
+
+// fallback returns the result of asking the fallback verifiers about the
+// validation's address, if the SMTP check's result says it was blocked from
+// this host and one of them gives a definite verdict, and result otherwise.
+func (c *Client) fallback(v *validation, result *ValidationResult) *ValidationResult {
+	if len(c.fallbacks) == 0 || v.email == "" || v.cached || result == nil || !smtpBlocked(result) {
+		return result
+	}
+	if c.IsProtectedDomain(emailDomain(v.email)) {
+		return result
+	}
+	ctx := context.Background()
+	if !v.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, v.deadline)
+		defer cancel()
+	}
+	for _, verifier := range c.fallbacks {
+		answer, err := verifier.Verify(ctx, v.email)
+		if err != nil || answer == nil || answer.Verdict == VerdictUnknown {
+			continue
+		}
+
+		fields := map[string]any{
+			"provider":        verifier.Name(),
+			"smtp_sub_status": string(result.SubStatus),
+		}
+		if result.ErrorMessage != "" {
+			fields["smtp_error"] = result.ErrorMessage
+		}
+		for key, value := range answer.Extra {
+			fields[key] = value
+		}
+		if v.extra == nil {
+			v.extra = make(map[string]any)
+		}
+		v.extra["fallback"] = fields
+
+		answer.Extra = nil
+		answer.HasMX = true
+		answer.SMTPReply = result.SMTPReply
+		answer.SMTPDetails = result.SMTPDetails
+		answer.Provenance = &Provenance{Source: ProvenanceExternalAPI, Detail: verifier.Name()}
+		answer.Warnings = append(result.Warnings, "SMTP check blocked from this host, verdict from a verification API: "+verifier.Name())
+		return answer
+	}
+	return result
+}
+
+// ZeroBounceVerifier checks mailboxes with the ZeroBounce API.
+type ZeroBounceVerifier struct {
+	// APIKey is the ZeroBounce account's API key.
+	APIKey string
+	// Endpoint is the API root, "https://api.zerobounce.net/v2" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements FallbackVerifier.
+func (z *ZeroBounceVerifier) Name() string {
+	return "zerobounce"
+}
+
+// Verify implements FallbackVerifier.
+func (z *ZeroBounceVerifier) Verify(ctx context.Context, email string) (*ValidationResult, error) {
+	endpoint := z.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.zerobounce.net/v2"
+	}
+	query := url.Values{"api_key": {z.APIKey}, "email": {email}, "ip_address": {""}}
+	var answer struct {
+		Status    string `json:"status"`
+		SubStatus string `json:"sub_status"`
+		Error     string `json:"error"`
+	}
+	if err := verificationAPIGet(ctx, z.HTTPClient, endpoint+"/validate?"+query.Encode(), &answer); err != nil {
+		return nil, fmt.Errorf("zerobounce: %w", err)
+	}
+	if answer.Error != "" {
+		return nil, fmt.Errorf("zerobounce: %s", answer.Error)
+	}
+
+	var result *ValidationResult
+	switch answer.Status {
+	case "valid":
+		result = apiResult(VerdictDeliverable)
+	case "invalid", "spamtrap":
+		result = apiResult(VerdictUndeliverable)
+	case "catch-all":
+		result = apiCatchAllResult()
+	case "abuse", "do_not_mail":
+		result = apiResult(VerdictRisky)
+		result.ErrorMessage = "Verification API flags the address: " + answer.Status
+	default:
+		result = apiResult(VerdictUnknown)
+	}
+	result.Extra = map[string]any{"status": answer.Status}
+	if answer.SubStatus != "" {
+		result.Extra["sub_status"] = answer.SubStatus
+	}
+	return result, nil
+}
+
+// NeverBounceVerifier checks mailboxes with the NeverBounce API.
+type NeverBounceVerifier struct {
+	// APIKey is the NeverBounce account's API key.
+	APIKey string
+	// Endpoint is the API root, "https://api.neverbounce.com/v4" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements FallbackVerifier.
+func (n *NeverBounceVerifier) Name() string {
+	return "neverbounce"
+}
+
+// Verify implements FallbackVerifier.
+func (n *NeverBounceVerifier) Verify(ctx context.Context, email string) (*ValidationResult, error) {
+	endpoint := n.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.neverbounce.com/v4"
+	}
+	query := url.Values{"key": {n.APIKey}, "email": {email}}
+	var answer struct {
+		Status  string   `json:"status"`
+		Result  string   `json:"result"`
+		Flags   []string `json:"flags"`
+		Message string   `json:"message"`
+	}
+	if err := verificationAPIGet(ctx, n.HTTPClient, endpoint+"/single/check?"+query.Encode(), &answer); err != nil {
+		return nil, fmt.Errorf("neverbounce: %w", err)
+	}
+	if answer.Status != "success" {
+		return nil, fmt.Errorf("neverbounce: %s: %s", answer.Status, answer.Message)
+	}
+
+	var result *ValidationResult
+	switch answer.Result {
+	case "valid":
+		result = apiResult(VerdictDeliverable)
+	case "invalid":
+		result = apiResult(VerdictUndeliverable)
+	case "catchall":
+		result = apiCatchAllResult()
+	case "disposable":
+		result = apiResult(VerdictRisky)
+		result.ErrorMessage = "Verification API flags the address: " + answer.Result
+	default:
+		result = apiResult(VerdictUnknown)
+	}
+	result.Extra = map[string]any{"status": answer.Result}
+	if len(answer.Flags) > 0 {
+		result.Extra["flags"] = answer.Flags
+	}
+	return result, nil
+}
+
+// KickboxVerifier checks mailboxes with the Kickbox API.
+type KickboxVerifier struct {
+	// APIKey is the Kickbox account's API key.
+	APIKey string
+	// Endpoint is the API root, "https://api.kickbox.com/v2" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements FallbackVerifier.
+func (k *KickboxVerifier) Name() string {
+	return "kickbox"
+}
+
+// Verify implements FallbackVerifier.
+func (k *KickboxVerifier) Verify(ctx context.Context, email string) (*ValidationResult, error) {
+	endpoint := k.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.kickbox.com/v2"
+	}
+	query := url.Values{"apikey": {k.APIKey}, "email": {email}}
+	var answer struct {
+		Success   bool   `json:"success"`
+		Message   string `json:"message"`
+		Result    string `json:"result"`
+		Reason    string `json:"reason"`
+		AcceptAll bool   `json:"accept_all"`
+	}
+	if err := verificationAPIGet(ctx, k.HTTPClient, endpoint+"/verify?"+query.Encode(), &answer); err != nil {
+		return nil, fmt.Errorf("kickbox: %w", err)
+	}
+	if !answer.Success {
+		return nil, fmt.Errorf("kickbox: %s", answer.Message)
+	}
+
+	var result *ValidationResult
+	switch answer.Result {
+	case "deliverable":
+		result = apiResult(VerdictDeliverable)
+	case "undeliverable":
+		result = apiResult(VerdictUndeliverable)
+	case "risky":
+		if answer.AcceptAll {
+			result = apiCatchAllResult()
+		} else {
+			result = apiResult(VerdictRisky)
+			result.ErrorMessage = "Verification API flags the address: " + answer.Reason
+		}
+	default:
+		result = apiResult(VerdictUnknown)
+	}
+	result.Extra = map[string]any{"status": answer.Result}
+	if answer.Reason != "" {
+		result.Extra["reason"] = answer.Reason
+	}
+	return result, nil
+}
+
+// apiResult builds the result of a verification API's verdict.
+func apiResult(verdict Verdict) *ValidationResult {
+	switch verdict {
+	case VerdictDeliverable:
+		return &ValidationResult{Verdict: verdict, IsValid: true}
+	case VerdictUndeliverable:
+		return &ValidationResult{Verdict: verdict, SubStatus: SubStatusMailboxNotFound, ErrorMessage: "Verification API says the mailbox doesn't exist"}
+	case VerdictRisky:
+		return &ValidationResult{Verdict: verdict, IsValid: true}
+	}
+	return &ValidationResult{Verdict: verdict}
+}
+
+// apiCatchAllResult builds the result of a verification API saying the
+// domain accepts every recipient.
+func apiCatchAllResult() *ValidationResult {
+	return &ValidationResult{Verdict: VerdictRisky, SubStatus: SubStatusCatchAll, IsValid: true, IsCatchAll: true, CatchAllConfidence: ConfidenceMedium}
+}
+
+// verificationAPIGet fetches a verification API URL and decodes a 200
+// response into v.
+func verificationAPIGet(ctx context.Context, client *http.Client, rawURL string, v any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL carries the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
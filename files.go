@@ -1,8 +1,12 @@
 package mailify
 
 import (
+	"errors"
 	"strings"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -21,20 +25,246 @@ import (
 //
 // The function performs the following steps:
 //   1. Opens the specified Excel file.
-//   2. Reads all rows from the first sheet ("Sheet1").
+//   2. Reads all rows from each sheet in turn, skipping sheets without data.
 //   3. Creates a map of headers from the first row.
-//   4. Adds a new column header for email validation results if it doesn't exist.
-//   5. Iterates over each row, validates the email address, and writes the validation result to the new column.
-//   6. Saves the modified Excel file with the validation results.
+//   4. Finds the column holding the addresses: the one headed "email", or else the
+//      one that looks most like it, see DetectEmailColumn.
+//   5. Adds new column headers for the validation results (display_name, verdict, sub_status, confidence, is_valid_email, is_mailbox_full, validated_at, provenance, validation_error,
+//      or those BulkOptions.Mapping asks for) if they don't exist.
+//   6. Iterates over each row, validates the email address, and writes the validation result to the new column.
+//   7. Saves the modified Excel file with the validation results.
 //
 // The function prints progress and summary information to the console.
 func(c *Client) ProcessAndValidateEmailsViaExcel(filename string, senderEmail string) error {
+	return c.ProcessAndValidateEmailsViaExcelWithOptions(filename, BulkOptions{})
+}
+
+// ProcessAndValidateEmailsViaExcelWithOptions works like ProcessAndValidateEmailsViaExcel,
+// but validates the addresses with ValidateBulk using the given options, so large sheets
+// can be processed concurrently.
+//
+// Parameters:
+//   - filename: The path to the Excel file containing the email addresses.
+//   - opts: Options controlling the bulk run, such as concurrency. OnResult is called
+//     after each address's result has been validated. MultiAddress says whether cells
+//     holding several addresses are split, see MultiAddressMode. With Stats set, each
+//     progress line starts with the run's progress, rate and ETA.
+//
+// Returns:
+//   - error: An error if any issue occurs during the process, otherwise nil.
+func (c *Client) ProcessAndValidateEmailsViaExcelWithOptions(filename string, opts BulkOptions) error {
+	fmt.Println("\n=== Starting Email Validation Process ===")
+
+	start := time.Now()
+	summary, err := c.processExcelFile(filename, opts)
+	notify(opts.Notifiers, newJobReport("Validation of "+filename, start, summary, filename, err))
+	if err != nil && !errors.Is(err, ErrInterrupted) {
+		return err
+	}
+
+	summary.print("Email Validation Summary")
+	fmt.Printf("Results have been written to: %s\n", filename)
+	if err != nil {
+		fmt.Println("Run interrupted: the rows not validated were left blank")
+	}
+	fmt.Println("===============================")
+	return err
+}
+
+// ProcessAndValidateEmailsViaFiles works like ProcessAndValidateEmailsViaExcelWithOptions
+// for several files at once: every Excel (.xlsx), CSV (.csv) and ZIP (.zip) file in a
+// directory, or every such file matching a glob pattern. The results are written back
+// to each Excel file, to every sheet in it, and to each CSV file, which is rewritten as
+// UTF-8. Excel sheets get a bold, frozen header row with filters and verdicts colored
+// green, red or yellow, and each workbook a "Mailify Summary" sheet with the counts
+// and a chart of the verdicts, the run's duration, the top undeliverable and the
+// catch-all domains, and the configuration used, replaced on later runs. The Excel and CSV files in a ZIP archive are processed likewise, and written
+// to a new archive next to it, named like lists.validated.zip. A summary is printed for
+// each file along with a combined one. The files share the client's caches, so domains
+// seen in one file aren't looked up again for the next.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern such as "lists/*.xlsx", or a single file.
+//   - opts: Options controlling the bulk run, as for ProcessAndValidateEmailsViaExcelWithOptions.
+//
+// Returns:
+//   - BulkSummary: The combined counts of every file processed.
+//   - error: An error if no files match, or the errors of the files that failed. The
+//     other files are still processed. If opts.Context is done first, the file being
+//     processed is saved with the results it has, the files after it are left as
+//     they are, and ErrInterrupted is among the errors.
+func (c *Client) ProcessAndValidateEmailsViaFiles(pattern string, opts BulkOptions) (BulkSummary, error) {
+	files, err := listFiles(pattern)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+
 	fmt.Println("\n=== Starting Email Validation Process ===")
+	fmt.Printf("Found %d file(s) to process\n", len(files))
+	start := time.Now()
+
+	var combined BulkSummary
+	var errs []error
+	for _, filename := range files {
+		if opts.interrupted() {
+			fmt.Println("Run interrupted: the remaining files were left as they are")
+			errs = append(errs, ErrInterrupted)
+			break
+		}
+		summary, err := c.processFile(filename, opts)
+		if err != nil {
+			// An archive may have failed only in part
+			fmt.Printf("ERROR: %s: %v\n", filename, err)
+			errs = append(errs, fmt.Errorf("%s: %w", filename, err))
+		}
+		if summary.Files == 0 {
+			continue
+		}
+		if len(files) > 1 {
+			summary.print("Summary for " + filename)
+		}
+		combined.add(summary)
+	}
+
+	combined.print("Email Validation Summary")
+	fmt.Println("===============================")
+	err = errors.Join(errs...)
+	notify(opts.Notifiers, newJobReport("Validation of "+pattern, start, combined, pattern, err))
+	return combined, err
+}
+
+// listFiles returns the Excel, CSV and ZIP files in a directory, or those
+// matching a glob pattern. Archives written by an earlier run are left out.
+func listFiles(pattern string) ([]string, error) {
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		pattern = filepath.Join(pattern, "*")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || info.IsDir() || !isListFile(match) || strings.HasSuffix(match, ".validated.zip") {
+			continue
+		}
+		files = append(files, match)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+	return files, nil
+}
+
+// BulkSummary counts the outcomes of processing files.
+type BulkSummary struct {
+	// Files is the number of files processed.
+	Files int `json:"files"`
+	// Sheets is the number of sheets that held addresses.
+	Sheets int `json:"sheets"`
+	// Total is the number of addresses validated.
+	Total int `json:"total"`
+	// Verdicts counts the validated addresses by verdict.
+	Verdicts map[Verdict]int `json:"verdicts"`
+	// MailboxFull is the number of addresses whose mailbox is over its quota.
+	MailboxFull int `json:"mailbox_full"`
+	// Errors is the number of addresses that failed to validate.
+	Errors int `json:"errors"`
+	// Duplicates is the number of addresses skipped as duplicates, see BulkOptions.Dedupe.
+	Duplicates int `json:"duplicates,omitempty"`
+	// Skipped is the number of rows without an address, marked as such in
+	// their validation_error column.
+	Skipped int `json:"skipped,omitempty"`
+	// Kept is the number of rows left as they were, validated by an earlier
+	// run, see BulkOptions.SkipValidated.
+	Kept int `json:"kept,omitempty"`
+
+	// undeliverableDomains and catchAllDomains count the undeliverable
+	// addresses of each domain, and the addresses at catch-all domains, for
+	// summary sheets.
+	undeliverableDomains map[string]int
+	catchAllDomains      map[string]int
+}
 
+// add adds the counts of other to s.
+func (s *BulkSummary) add(other BulkSummary) {
+	s.Files += other.Files
+	s.Sheets += other.Sheets
+	s.Total += other.Total
+	s.MailboxFull += other.MailboxFull
+	s.Errors += other.Errors
+	s.Duplicates += other.Duplicates
+	s.Kept += other.Kept
+	s.Skipped += other.Skipped
+	for verdict, n := range other.Verdicts {
+		s.count(verdict, n)
+	}
+	for domain, n := range other.undeliverableDomains {
+		s.undeliverableDomains = countDomain(s.undeliverableDomains, domain, n)
+	}
+	for domain, n := range other.catchAllDomains {
+		s.catchAllDomains = countDomain(s.catchAllDomains, domain, n)
+	}
+}
+
+// countDomain adds n addresses of domain to counts, creating it if needed.
+func countDomain(counts map[string]int, domain string, n int) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[domain] += n
+	return counts
+}
+
+// count adds n addresses with the given verdict.
+func (s *BulkSummary) count(verdict Verdict, n int) {
+	if s.Verdicts == nil {
+		s.Verdicts = make(map[Verdict]int)
+	}
+	s.Verdicts[verdict] += n
+}
+
+// print prints the summary under the given title.
+func (s BulkSummary) print(title string) {
+	fmt.Printf("\n=== %s ===\n", title)
+	if s.Files > 1 {
+		fmt.Printf("Files processed: %d\n", s.Files)
+	}
+	if s.Sheets > 1 {
+		fmt.Printf("Sheets processed: %d\n", s.Sheets)
+	}
+	fmt.Printf("Total emails processed: %d\n", s.Total)
+	fmt.Printf("Deliverable: %d\n", s.Verdicts[VerdictDeliverable])
+	fmt.Printf("Undeliverable: %d\n", s.Verdicts[VerdictUndeliverable])
+	fmt.Printf("Risky: %d\n", s.Verdicts[VerdictRisky])
+	fmt.Printf("Unknown: %d\n", s.Verdicts[VerdictUnknown])
+	fmt.Printf("Mailbox full (retry later): %d\n", s.MailboxFull)
+	if s.Duplicates > 0 {
+		fmt.Printf("Duplicates skipped: %d\n", s.Duplicates)
+	}
+	if s.Skipped > 0 {
+		fmt.Printf("Rows without an email, skipped: %d\n", s.Skipped)
+	}
+	if s.Kept > 0 {
+		fmt.Printf("Already validated, kept: %d\n", s.Kept)
+	}
+	if s.Errors > 0 {
+		fmt.Printf("Errors: %d\n", s.Errors)
+	}
+}
+
+// errNoData is returned for sheets with nothing but a header row.
+var errNoData = errors.New("excel file has no data except field names")
+
+// processExcelFile validates the addresses in every sheet of an Excel file and
+// saves the results to it. Sheets without data are skipped. If the run is
+// interrupted, the results so far are saved and ErrInterrupted returned.
+func (c *Client) processExcelFile(filename string, opts BulkOptions) (BulkSummary, error) {
 	// Open the Excel file
 	f, err := excelize.OpenFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return BulkSummary{}, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -44,99 +274,441 @@ func(c *Client) ProcessAndValidateEmailsViaExcel(filename string, senderEmail st
 
 	fmt.Printf("Successfully opened Excel file: %s\n", filename)
 
-	// Get all the rows in Sheet1
-	rows, err := f.GetRows("sheet1")
+	start := time.Now()
+	summary := BulkSummary{Files: 1}
+	var interrupted error
+	for _, sheet := range f.GetSheetList() {
+		if sheet == summarySheet {
+			continue
+		}
+		if opts.interrupted() {
+			interrupted = ErrInterrupted
+			break
+		}
+		sheetSummary, err := c.processTable(excelSheet{f: f, name: sheet}, opts)
+		if errors.Is(err, errNoData) {
+			fmt.Printf("Skipping sheet %q: no data except field names\n", sheet)
+			continue
+		}
+		if errors.Is(err, ErrInterrupted) {
+			summary.add(sheetSummary)
+			interrupted = err
+			break
+		}
+		if err != nil {
+			return BulkSummary{}, fmt.Errorf("sheet %q: %w", sheet, err)
+		}
+		summary.add(sheetSummary)
+	}
+	if summary.Sheets == 0 && interrupted == nil {
+		return BulkSummary{}, errNoData
+	}
+
+	if err := writeSummarySheet(f, summary, time.Since(start), c.manifestConfig(opts)); err != nil {
+		return BulkSummary{}, err
+	}
+
+	// Save the modified Excel file
+	fmt.Println("\nSaving results to Excel file...")
+	if err := saveExcel(f, filename); err != nil {
+		return BulkSummary{}, err
+	}
+	return summary, interrupted
+}
+
+// saveExcel saves a workbook to filename, writing it to a temporary file
+// first so that a failed or interrupted save leaves the original intact.
+func saveExcel(f *excelize.File, filename string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".mailify-*"+filepath.Ext(filename))
 	if err != nil {
-		return fmt.Errorf("failed to get rows: %w", err)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if info, err := os.Stat(filename); err == nil {
+		tmp.Chmod(info.Mode().Perm())
+	}
+
+	if _, err := f.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
+
+// processTable validates the addresses in one sheet and writes the results to
+// it. If the run is interrupted, it returns the counts so far along with
+// ErrInterrupted, the results so far having been written.
+func (c *Client) processTable(t table, opts BulkOptions) (BulkSummary, error) {
+	rows, err := t.rows()
+	if err != nil {
+		return BulkSummary{}, fmt.Errorf("failed to get rows: %w", err)
 	}
 
 	if len(rows) < 2 {
-		return fmt.Errorf("excel file has no data except field names")
+		return BulkSummary{}, errNoData
 	}
 
-	fmt.Printf("Found %d rows in the Excel file (including header)\n", len(rows))
+	fmt.Printf("Found %d rows in %s (including header)\n", len(rows), t)
 
 	// Create headers map and add new column
 	headers := make(map[string]int)
 	for i, cell := range rows[0] {
-		header := strings.ToLower(strings.ReplaceAll(cell, " ", "_"))
-		headers[header] = i
+		headers[headerKey(cell)] = i
 	}
 
-	// Add new column for email validation if it doesn't exist
-	isValidEmailCol := len(rows[0])
-	headers["is_valid_email"] = isValidEmailCol
-
-	// Add the new column header
-	err = f.SetCellValue("Sheet1", fmt.Sprintf("%s1", columnToLetter(isValidEmailCol)), "is_valid_email")
+	// Find the column holding the addresses
+	emailCol, err := emailColumn(rows, headers, opts.emailColumnName())
+	if err != nil {
+		return BulkSummary{}, err
+	}
+	columns, err := opts.Mapping.columns()
 	if err != nil {
-		return fmt.Errorf("failed to add header: %w", err)
+		return BulkSummary{}, err
+	}
+	rowName, err := opts.Mapping.rowNamer(headers)
+	if err != nil {
+		return BulkSummary{}, err
+	}
+
+	// Give each address of cells holding several its own row
+	if opts.MultiAddress == MultiAddressExplode {
+		rows, err = explodeRows(t, rows, emailCol)
+		if err != nil {
+			return BulkSummary{}, err
+		}
+		fmt.Printf("Split cells with several addresses into %d rows (including header)\n", len(rows))
+	}
+
+	// Add new columns for the validation results if they don't exist
+	resultCols := make([]int, len(columns))
+	nextCol := len(rows[0])
+	for i, column := range columns {
+		col, ok := headers[column.header]
+		if !ok {
+			col = nextCol
+			nextCol++
+			headers[column.header] = col
+
+			// Add the new column header
+			err = t.setCell(col, 1, column.header)
+			if err != nil {
+				return BulkSummary{}, fmt.Errorf("failed to add header: %w", err)
+			}
+		}
+		resultCols[i] = col
 	}
 
 	fmt.Println("\nStarting email validation process...")
 	fmt.Println("=====================================")
 
-	validCount := 0
-	invalidCount := 0
+	summary := BulkSummary{Sheets: 1}
 
-	// Process each row
+	// Collect the addresses to validate along with the rows they came from, and
+	// where in the row's cell they were
+	var emails []string
+	var rowNumbers, positions []int
+	rowResults := make(map[int][]*ValidationResult)
+	rowErrs := make(map[int][]error)
+	pending := make(map[int]int)
+	now := time.Now()
 	for i := 1; i < len(rows); i++ {
 		row := rows[i]
 		if len(row) == 0 {
 			continue
 		}
+		if opts.SkipValidated && alreadyValidated(row, headers, opts.MaxAge, now) {
+			summary.Kept++
+			continue
+		}
 
 		// Get email from the row
 		var email string
-		if idx, ok := headers["email"]; ok && idx < len(row) {
-			email = strings.TrimSpace(row[idx])
+		if emailCol < len(row) {
+			email = strings.TrimSpace(row[emailCol])
+		}
+		if email == "" {
+			summary.Skipped++
+			if err := writeRowError(t, i+1, columns, resultCols, errNoAddress); err != nil {
+				fmt.Printf("ERROR: Failed to write result: %v\n", err)
+			}
+			continue
+		}
+
+		addresses := []string{email}
+		if opts.MultiAddress == MultiAddressAggregate {
+			addresses = SplitAddresses(email)
+		}
+		rowResults[i] = make([]*ValidationResult, len(addresses))
+		rowErrs[i] = make([]error, len(addresses))
+		pending[i] = len(addresses)
+		for k, address := range addresses {
+			emails = append(emails, address)
+			rowNumbers = append(rowNumbers, i)
+			positions = append(positions, k)
 		}
+	}
 
-		if email != "" {
-			fmt.Printf("Validating email %d/%d: %s... ", i, len(rows)-1, email)
+	// Validate the addresses, writing each row's results to the new columns
+	// once all of its addresses are done
+	onResult := opts.OnResult
+	opts.OnResult = func(res BulkResult) {
+		i := rowNumbers[res.Index]
+		if opts.Stats != nil {
+			fmt.Printf("[%s] ", opts.Stats.Snapshot())
+		}
+		fmt.Printf("Validating email %d/%d: %s... ", i, len(rows)-1, res.Email)
 
-			// Validate email
-			result, err := c.ValidateEmail(email)
-			if err != nil {
-				fmt.Printf("ERROR: %v\n", err)
-				continue
+		pending[i]--
+		rowErrs[i][positions[res.Index]] = res.Err
+		if errors.Is(res.Err, ErrDuplicate) {
+			summary.Duplicates++
+			fmt.Println("DUPLICATE, skipped")
+		} else if res.Err != nil {
+			summary.Errors++
+			fmt.Printf("ERROR: %v\n", res.Err)
+		} else {
+			rowResults[i][positions[res.Index]] = res.Result
+			if res.Result.IsMailboxFull {
+				summary.MailboxFull++
 			}
 
-			// Write validation result to the new column
-			cellRef := fmt.Sprintf("%s%d", columnToLetter(isValidEmailCol), i+1)
-			err = f.SetCellValue("Sheet1", cellRef, result.IsValid)
-			if err != nil {
+			summary.Total++
+			summary.count(res.Result.Verdict, 1)
+			if res.Result.Verdict == VerdictUndeliverable {
+				summary.undeliverableDomains = countDomain(summary.undeliverableDomains, emailDomain(res.Email), 1)
+			}
+			if res.Result.IsCatchAll {
+				summary.catchAllDomains = countDomain(summary.catchAllDomains, emailDomain(res.Email), 1)
+			}
+			switch res.Result.Verdict {
+			case VerdictDeliverable:
+				fmt.Println("DELIVERABLE ✓")
+			case VerdictUndeliverable:
+				fmt.Println("UNDELIVERABLE ✗")
+			default:
+				fmt.Println(strings.ToUpper(string(res.Result.Verdict)), "?")
+			}
+		}
+
+		if pending[i] == 0 {
+			results := rowResults[i]
+			if rowName != nil {
+				results = withDisplayName(results, rowName(rows[i]))
+			}
+			if err := writeRowResults(t, i+1, columns, resultCols, results, rowErrs[i]); err != nil {
 				fmt.Printf("ERROR: Failed to write result: %v\n", err)
-				continue
 			}
+		}
+
+		if onResult != nil && res.Err == nil {
+			onResult(res)
+		}
+	}
+	c.ValidateBulk(emails, opts)
+	verdictCol, ok := headers["verdict"]
+	if !ok {
+		verdictCol = -1
+	}
+	if err := t.styleResults(len(rows), nextCol-1, verdictCol); err != nil {
+		return summary, err
+	}
+	if opts.interrupted() {
+		return summary, ErrInterrupted
+	}
+
+	return summary, nil
+}
 
-			if result.IsValid {
-				fmt.Println("VALID ✓")
-				validCount++
-			} else {
-				fmt.Println("INVALID ✗")
-				invalidCount++
+// alreadyValidated reports whether a row holds a verdict from an earlier run,
+// validated no longer than maxAge before now if maxAge is set.
+func alreadyValidated(row []string, headers map[string]int, maxAge time.Duration, now time.Time) bool {
+	cell := func(header string) string {
+		col, ok := headers[header]
+		if !ok || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+	if cell("verdict") == "" {
+		return false
+	}
+	if maxAge <= 0 {
+		return true
+	}
+	validatedAt, err := time.Parse(time.RFC3339, cell("validated_at"))
+	return err == nil && now.Sub(validatedAt) <= maxAge
+}
+
+// headerKey normalizes a column header for lookups, e.g. "Email Address"
+// becomes "email_address".
+func headerKey(header string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(header), " ", "_"))
+}
+
+// emailColumn returns the index of the column holding the addresses: the
+// column named by name if given, else the one headed "email", else the one
+// DetectEmailColumn picks, which is reported.
+func emailColumn(rows [][]string, headers map[string]int, name string) (int, error) {
+	if name != "" {
+		col, ok := headers[headerKey(name)]
+		if !ok {
+			return 0, fmt.Errorf("column %q not found", name)
+		}
+		return col, nil
+	}
+	if col, ok := headers["email"]; ok {
+		return col, nil
+	}
+
+	col, ok := DetectEmailColumn(rows)
+	if !ok {
+		return 0, fmt.Errorf("no column holding email addresses found, name one with EmailColumn")
+	}
+	header := ""
+	if col < len(rows[0]) {
+		header = rows[0][col]
+	}
+	fmt.Printf("Detected email addresses in column %s (%q)\n", columnToLetter(col), header)
+	return col, nil
+}
+
+// explodeRows gives each address of a cell in the email column holding
+// several addresses a row of its own, a copy of the original row, and
+// returns the sheet's rows afterwards.
+func explodeRows(t table, rows [][]string, emailCol int) ([][]string, error) {
+	// Work upwards, so rows still to be split keep their numbers
+	for i := len(rows) - 1; i >= 1; i-- {
+		if emailCol >= len(rows[i]) {
+			continue
+		}
+		addresses := SplitAddresses(rows[i][emailCol])
+		if len(addresses) < 2 {
+			continue
+		}
+		for range addresses[1:] {
+			if err := t.duplicateRow(i + 1); err != nil {
+				return nil, fmt.Errorf("failed to split row %d: %w", i+1, err)
+			}
+		}
+		for k, address := range addresses {
+			if err := t.setCell(emailCol, i+1+k, address); err != nil {
+				return nil, fmt.Errorf("failed to split row %d: %w", i+1, err)
 			}
 		}
 	}
 
-	// Save the modified Excel file
-	fmt.Println("\nSaving results to Excel file...")
-	err = f.Save()
+	rows, err := t.rows()
 	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
+		return nil, fmt.Errorf("failed to get rows: %w", err)
 	}
+	return rows, nil
+}
 
-	fmt.Println("\n=== Email Validation Summary ===")
-	fmt.Printf("Total emails processed: %d\n", validCount+invalidCount)
-	fmt.Printf("Valid emails: %d\n", validCount)
-	fmt.Printf("Invalid emails: %d\n", invalidCount)
-	fmt.Printf("Results have been written to: %s\n", filename)
-	fmt.Println("===============================")
+// withDisplayName returns copies of a row's results in which those without a
+// display name have the given one, the name a ColumnMapping finds in the row.
+func withDisplayName(results []*ValidationResult, name string) []*ValidationResult {
+	named := make([]*ValidationResult, len(results))
+	for k, result := range results {
+		if result != nil && result.DisplayName == "" {
+			copied := *result
+			copied.DisplayName = name
+			result = &copied
+		}
+		named[k] = result
+	}
+	return named
+}
+
+// writeRowResults writes the results of a row's addresses to its result
+// columns. A row with several addresses gets one value per address,
+// separated by "; ". Addresses that failed to validate, duplicates
+// included, get their error in the validation_error column and empty
+// values in the others, so a row whose address failed isn't left with the
+// results of an earlier run.
+func writeRowResults(t table, row int, columns []resultColumn, resultCols []int, results []*ValidationResult, errs []error) error {
+	for j, column := range columns {
+		var value any
+		if len(results) == 1 {
+			value = column.value(results[0], errs[0])
+		} else {
+			values := make([]string, len(results))
+			for k, result := range results {
+				values[k] = fmt.Sprint(column.value(result, errs[k]))
+			}
+			value = strings.Join(values, "; ")
+		}
 
+		if err := t.setCell(resultCols[j], row, value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// writeRowError writes an error to the validation_error column of a row
+// without an address to validate, emptying its other result columns.
+func writeRowError(t table, row int, columns []resultColumn, resultCols []int, err error) error {
+	return writeRowResults(t, row, columns, resultCols, []*ValidationResult{nil}, []error{err})
+}
+
+// errNoAddress is the error of rows whose email cell is empty.
+var errNoAddress = errors.New("no email address")
+
+// resultColumn describes a column that bulk processing writes for every row.
+type resultColumn struct {
+	// header is the column's name in the header row.
+	header string
+	// value extracts the cell value from a validation result, or from the
+	// error the address failed with, in which case the result is nil.
+	value func(*ValidationResult, error) any
+}
+
+// fromResult makes the value of a column from a validation result, empty for
+// addresses that failed.
+func fromResult(value func(*ValidationResult) any) func(*ValidationResult, error) any {
+	return func(r *ValidationResult, err error) any {
+		if r == nil {
+			return ""
+		}
+		return value(r)
+	}
+}
+
+// resultColumns are the columns added to processed files, in order. Columns that
+// already exist, e.g. from an earlier run, are overwritten rather than duplicated.
+var resultColumns = []resultColumn{
+	{header: "display_name", value: fromResult(func(r *ValidationResult) any { return r.DisplayName })},
+	{header: "verdict", value: fromResult(func(r *ValidationResult) any { return string(r.Verdict) })},
+	{header: "sub_status", value: fromResult(func(r *ValidationResult) any { return string(r.SubStatus) })},
+	{header: "confidence", value: fromResult(func(r *ValidationResult) any { return string(r.Confidence) })},
+	{header: "is_valid_email", value: fromResult(func(r *ValidationResult) any { return r.IsValid })},
+	{header: "is_mailbox_full", value: fromResult(func(r *ValidationResult) any { return r.IsMailboxFull })},
+	{header: "validated_at", value: fromResult(func(r *ValidationResult) any {
+		if r.ValidatedAt.IsZero() {
+			return time.Now().UTC().Format(time.RFC3339)
+		}
+		return r.ValidatedAt.UTC().Format(time.RFC3339)
+	})},
+	{header: "provenance", value: fromResult(func(r *ValidationResult) any {
+		if r.Provenance == nil {
+			return ""
+		}
+		return string(r.Provenance.Source)
+	})},
+	{header: "validation_error", value: func(r *ValidationResult, err error) any {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}},
+}
+
 // columnToLetter converts a given column number (0-indexed) to its corresponding
 // Excel-style column letter. For example, 0 -> "A", 1 -> "B", 25 -> "Z", 26 -> "AA", etc.
 // 
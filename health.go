@@ -0,0 +1,106 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// readinessDomain is the domain whose mail servers readiness checks look
+// up and connect to, one that is always there.
+const readinessDomain = "gmail.com"
+
+// readinessCacheKey is the key readiness checks write to and read back
+// from the cache.
+const readinessCacheKey = "mailify:readiness"
+
+// Readiness is whether the client can validate addresses, as CheckReadiness
+// finds out by checking what validation depends on.
+type Readiness struct {
+	// Ready indicates whether every check passed.
+	Ready bool `json:"ready"`
+	// Checks are the dependencies checked: "dns", "cache" if WithCache was
+	// given, and "smtp" in ModeFull.
+	Checks []DependencyCheck `json:"checks"`
+}
+
+// DependencyCheck is the outcome of checking one dependency of validation.
+type DependencyCheck struct {
+	// Name is the dependency.
+	Name string `json:"name"`
+	// OK indicates whether it is usable.
+	OK bool `json:"ok"`
+	// Error is why it isn't, if it isn't.
+	Error string `json:"error,omitempty"`
+	// Duration is how long checking it took, in milliseconds.
+	Duration int64 `json:"duration_ms"`
+}
+
+// CheckReadiness checks that the client can validate addresses, e.g. for a
+// readiness probe: that its resolver answers, by looking up the mail servers
+// of a well-known domain; that the cache given with WithCache keeps what is
+// written to it; and, in ModeFull, that outbound SMTP isn't blocked, by
+// connecting to one of those mail servers as validation does, through the
+// client's dialer and on the first of its SMTP ports that answers, without
+// sending anything. In ModeOffline only the cache is checked.
+//
+// Parameters:
+//   - ctx: Bounds the checks.
+//
+// Returns:
+//   - Readiness: The checks, and whether they all passed.
+func (c *Client) CheckReadiness(ctx context.Context) Readiness {
+	readiness := Readiness{Ready: true}
+	check := func(name string, fn func() error) {
+		start := time.Now()
+		err := fn()
+		result := DependencyCheck{Name: name, OK: err == nil, Duration: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Error = err.Error()
+			readiness.Ready = false
+		}
+		readiness.Checks = append(readiness.Checks, result)
+	}
+
+	var mailServers []*net.MX
+	if c.mode != ModeOffline {
+		check("dns", func() error {
+			var err error
+			mailServers, err = c.resolver.LookupMX(ctx, readinessDomain)
+			if err != nil {
+				return fmt.Errorf("resolver can't look up MX records: %w", err)
+			}
+			if len(mailServers) == 0 {
+				return fmt.Errorf("resolver found no MX records for %s", readinessDomain)
+			}
+			return nil
+		})
+	}
+	if c.cache != nil {
+		check("cache", func() error {
+			value := strconv.FormatInt(time.Now().UnixNano(), 10)
+			c.cache.Set(readinessCacheKey, []byte(value), time.Minute)
+			if got, ok := c.cache.Get(readinessCacheKey); !ok || string(got) != value {
+				return errors.New("cache doesn't keep what is written to it")
+			}
+			return nil
+		})
+	}
+	if c.mode == ModeFull {
+		check("smtp", func() error {
+			if len(mailServers) == 0 {
+				return errors.New("no mail server to connect to, see the dns check")
+			}
+			deadline, _ := ctx.Deadline()
+			host := mailServers[0].Host
+			if _, err := c.getSMTPServer(host, &Timings{}, deadline); err != nil {
+				return fmt.Errorf("can't connect to %s, outbound SMTP may be blocked: %w", host, err)
+			}
+			return nil
+		})
+	}
+	return readiness
+}
@@ -0,0 +1,74 @@
+package mailify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// heloMismatchWarning is the warning results carry when the HELO name has no
+// matching forward and reverse DNS.
+const heloMismatchWarning = "HELO name has no matching forward and reverse DNS, mail servers may reject or distrust the check"
+
+// WithHELOName sets the name the client introduces itself with in EHLO and
+// HELO, in place of the one GetHostname guesses, which is often a local name
+// receiving servers refuse. It should be the fully qualified name the
+// verifying host's IP address resolves back to. NewClient checks that it is
+// a syntactically valid FQDN with address records, outside ModeOffline.
+func WithHELOName(name string) Option {
+	return func(c *Client) {
+		c.heloName = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	}
+}
+
+// checkHELOName checks the name WithHELOName gave, if any.
+func (c *Client) checkHELOName() error {
+	if c.heloName == "" {
+		return nil
+	}
+	if err := checkDomain(c.heloName); err != nil || strings.HasPrefix(c.heloName, "[") {
+		return fmt.Errorf("invalid HELO name %q: not a fully qualified domain name", c.heloName)
+	}
+	if c.mode == ModeOffline {
+		return nil
+	}
+	if _, err := c.resolver.LookupIPAddr(context.Background(), c.heloName); err != nil {
+		return fmt.Errorf("invalid HELO name %q: %w", c.heloName, err)
+	}
+	return nil
+}
+
+// heloWarning returns the warning results of SMTP checks carry when the HELO
+// name has no forward-confirmed reverse DNS, that is no address of the name
+// resolves back to it, or "" if it has. Receiving servers commonly check
+// this and refuse or mislead verifiers that fail it, causing false
+// negatives. The check is made once per client.
+func (c *Client) heloWarning() string {
+	c.heloOnce.Do(func() {
+		name, err := c.GetHostname()
+		if err != nil || !c.forwardConfirmed(name) {
+			c.heloMismatch = heloMismatchWarning
+		}
+	})
+	return c.heloMismatch
+}
+
+// forwardConfirmed reports whether one of name's addresses resolves back to name.
+func (c *Client) forwardConfirmed(name string) bool {
+	addrs, err := c.resolver.LookupIPAddr(context.Background(), name)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		names, err := c.resolver.LookupAddr(context.Background(), addr.IP.String())
+		if err != nil {
+			continue
+		}
+		for _, ptr := range names {
+			if strings.EqualFold(strings.TrimSuffix(ptr, "."), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package mailify
+
+import "time"
+
+// StageName identifies a stage of the validation pipeline.
+type StageName string
+
+const (
+	// StageSyntax checks and normalizes the address.
+	StageSyntax StageName = "syntax"
+	// StageMX looks up the domain's mail servers.
+	StageMX StageName = "mx"
+	// StageSMTP asks the mail servers about the mailbox.
+	StageSMTP StageName = "smtp"
+)
+
+// StageEvent describes a completed validation stage.
+type StageEvent struct {
+	// Email is the address being validated, normalized once the syntax stage
+	// has passed, and redacted if WithPIIMode is given.
+	Email string
+	// Stage is the stage that completed.
+	Stage StageName
+	// Duration is how long the stage took.
+	Duration time.Duration
+	// MailServers holds the domain's mail servers, once the MX stage has found them.
+	MailServers []string
+	// Result is the result the stage ends validation with, or nil if validation continues.
+	Result *ValidationResult
+	// Err is the error the stage ran into, if any.
+	Err error
+}
+
+// Hooks let applications run their own logic around validation, such as
+// logging, custom blocklists or enriching results. Every field is optional.
+// Hooks are called from the goroutine running the validation, so in bulk runs
+// they must be safe for concurrent use.
+type Hooks struct {
+	// OnStart is called before an address is validated. Returning a result
+	// skips validation and uses that result instead, e.g. for blocklisted addresses.
+	OnStart func(email string) *ValidationResult
+	// OnStageComplete is called after each stage that runs. Returning a result
+	// ends validation with it, replacing the stage's own result if it had one.
+	OnStageComplete func(event StageEvent) *ValidationResult
+	// OnResult is called with the final result before it is returned, and may modify it.
+	OnResult func(email string, result *ValidationResult)
+	// OnMXChange is called when a domain's mail servers turn out to have
+	// changed since it was last validated. Domains are only tracked with
+	// WithCache, so changes are noticed across runs.
+	OnMXChange func(change MXChange)
+}
+
+// WithHooks adds hooks to the validation pipeline. It can be given more than
+// once; hooks run in the order they were added, and the first one to return a
+// result short-circuits the rest.
+func WithHooks(hooks Hooks) Option {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hooks)
+	}
+}
+
+// startHooks runs the OnStart hooks and returns the first result one of them gives.
+func (c *Client) startHooks(email string) *ValidationResult {
+	for _, h := range c.hooks {
+		if h.OnStart == nil {
+			continue
+		}
+		if result := h.OnStart(email); result != nil {
+			if result.Provenance == nil {
+				result.Provenance = &Provenance{Source: ProvenanceStage, Detail: "hook"}
+			}
+			return result
+		}
+	}
+	return nil
+}
+
+// finishStage runs the OnStageComplete hooks for a stage and returns the
+// result validation should end with, or nil if it should continue. The
+// address is redacted from the event as WithPIIMode says.
+func (c *Client) finishStage(event StageEvent) *ValidationResult {
+	event.Err = c.redactError(event.Err, event.Email)
+	event.Email = c.RedactEmail(event.Email)
+	for _, h := range c.hooks {
+		if h.OnStageComplete == nil {
+			continue
+		}
+		if result := h.OnStageComplete(event); result != nil {
+			return result
+		}
+	}
+	return event.Result
+}
+
+// resultHooks runs the OnResult hooks.
+func (c *Client) resultHooks(email string, result *ValidationResult) {
+	for _, h := range c.hooks {
+		if h.OnResult != nil {
+			h.OnResult(email, result)
+		}
+	}
+}
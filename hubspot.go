@@ -0,0 +1,138 @@
+package mailify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultHubSpotTagProperty is the contact property HubSpotConnector writes
+// tags to when none is set. It must exist in the HubSpot account.
+const DefaultHubSpotTagProperty = "mailify_status"
+
+// hubspotPageSize is how many list memberships are read per request.
+const hubspotPageSize = 250
+
+// hubspotBatchSize is the most contacts read in one batch request.
+const hubspotBatchSize = 100
+
+// HubSpotConnector is a ListConnector for a HubSpot contact list. HubSpot has
+// no tags, so tagging writes the tag to a contact property, and removing
+// takes the contact off the list without deleting it. Dynamic lists can't
+// have contacts removed.
+type HubSpotConnector struct {
+	// Token is a private app access token with the crm.lists and
+	// crm.objects.contacts scopes.
+	Token string
+	// ListID is the list's ID.
+	ListID string
+	// TagProperty is the contact property tags are written to,
+	// DefaultHubSpotTagProperty if empty.
+	TagProperty string
+	// Endpoint is the API root, "https://api.hubapi.com" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements ListConnector.
+func (h *HubSpotConnector) Name() string {
+	return "hubspot"
+}
+
+// Contacts implements ListConnector. Contacts without an email address are
+// left out.
+func (h *HubSpotConnector) Contacts(ctx context.Context) ([]Contact, error) {
+	// The list only holds record IDs, the addresses are read in batches
+	var ids []string
+	after := ""
+	for {
+		var page struct {
+			Results []struct {
+				RecordID string `json:"recordId"`
+			} `json:"results"`
+			Paging struct {
+				Next struct {
+					After string `json:"after"`
+				} `json:"next"`
+			} `json:"paging"`
+		}
+		query := url.Values{"limit": {fmt.Sprint(hubspotPageSize)}}
+		if after != "" {
+			query.Set("after", after)
+		}
+		path := "/crm/v3/lists/" + url.PathEscape(h.ListID) + "/memberships?" + query.Encode()
+		if err := h.request(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, member := range page.Results {
+			ids = append(ids, member.RecordID)
+		}
+		after = page.Paging.Next.After
+		if after == "" {
+			break
+		}
+	}
+
+	var contacts []Contact
+	for start := 0; start < len(ids); start += hubspotBatchSize {
+		end := min(start+hubspotBatchSize, len(ids))
+		inputs := make([]map[string]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			inputs = append(inputs, map[string]string{"id": id})
+		}
+		var batch struct {
+			Results []struct {
+				ID         string `json:"id"`
+				Properties struct {
+					Email string `json:"email"`
+				} `json:"properties"`
+			} `json:"results"`
+		}
+		body := map[string]any{"properties": []string{"email"}, "inputs": inputs}
+		if err := h.request(ctx, http.MethodPost, "/crm/v3/objects/contacts/batch/read", body, &batch); err != nil {
+			return nil, err
+		}
+		for _, contact := range batch.Results {
+			if contact.Properties.Email != "" {
+				contacts = append(contacts, Contact{ID: contact.ID, Email: contact.Properties.Email})
+			}
+		}
+	}
+	return contacts, nil
+}
+
+// Tag implements ListConnector. The tag is written to TagProperty,
+// replacing its value.
+func (h *HubSpotConnector) Tag(ctx context.Context, contact Contact, tag string) error {
+	property := h.TagProperty
+	if property == "" {
+		property = DefaultHubSpotTagProperty
+	}
+	body := map[string]any{"properties": map[string]string{property: tag}}
+	return h.request(ctx, http.MethodPatch, "/crm/v3/objects/contacts/"+url.PathEscape(contact.ID), body, nil)
+}
+
+// Remove implements ListConnector. The contact is taken off the list and
+// otherwise left alone.
+func (h *HubSpotConnector) Remove(ctx context.Context, contact Contact) error {
+	path := "/crm/v3/lists/" + url.PathEscape(h.ListID) + "/memberships/remove"
+	return h.request(ctx, http.MethodPut, path, []string{contact.ID}, nil)
+}
+
+// request sends a request to a path under the API root.
+func (h *HubSpotConnector) request(ctx context.Context, method, path string, body, out any) error {
+	endpoint := h.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.hubapi.com"
+	}
+	auth := func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	if err := apiRequest(ctx, h.HTTPClient, auth, method, strings.TrimSuffix(endpoint, "/")+path, body, out); err != nil {
+		return fmt.Errorf("hubspot: %w", err)
+	}
+	return nil
+}
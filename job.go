@@ -0,0 +1,155 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+)
+
+// BulkJob is a bulk run going on in the background, started with StartBulk
+// or StartFiles, that can be paused, resumed and cancelled while it runs, e.g. to throttle
+// a run when a provider starts deferring. None of them loses work: the
+// validations in progress when the job is paused or cancelled finish and
+// keep their results. A BulkJob is safe for concurrent use.
+type BulkJob struct {
+	pause   *PauseSwitch
+	stats   *RunStats
+	cancel  context.CancelFunc
+	done    chan struct{}
+	results []BulkResult
+	// summary and err are what file processing returned, for jobs started with StartFiles.
+	summary BulkSummary
+	err     error
+}
+
+// StartBulk starts validating a list of email addresses in the background,
+// like ValidateBulk, and returns the job to control and wait for it.
+//
+// Parameters:
+//   - emails: The addresses to validate.
+//   - opts: Options as for ValidateBulk. If Pause or Stats are unset, the
+//     job makes its own. Cancelling the job doesn't cancel opts.Context,
+//     but the job stops if opts.Context is done.
+//
+// Returns:
+//   - *BulkJob: The running job.
+func (c *Client) StartBulk(emails []string, opts BulkOptions) *BulkJob {
+	return startJob(opts, func(job *BulkJob, opts BulkOptions) {
+		job.results = c.ValidateBulk(emails, opts)
+	})
+}
+
+// StartFiles starts processing files in the background, like
+// ProcessAndValidateEmailsViaFiles, and returns the job to control and wait
+// for it. Cancelling the job saves the file being processed with the
+// results it has, as opts.Context being done does.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern or a single file, as for
+//     ProcessAndValidateEmailsViaFiles.
+//   - opts: Options as for StartBulk.
+//
+// Returns:
+//   - *BulkJob: The running job, see Summary for its outcome.
+func (c *Client) StartFiles(pattern string, opts BulkOptions) *BulkJob {
+	return startJob(opts, func(job *BulkJob, opts BulkOptions) {
+		job.summary, job.err = c.ProcessAndValidateEmailsViaFiles(pattern, opts)
+	})
+}
+
+// startJob runs a job in the background, giving run options with the job's
+// pause switch, stats and context.
+func startJob(opts BulkOptions, run func(job *BulkJob, opts BulkOptions)) *BulkJob {
+	if opts.Pause == nil {
+		opts.Pause = NewPauseSwitch()
+	}
+	if opts.Stats == nil {
+		opts.Stats = NewRunStats()
+	}
+	parent := opts.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	opts.Context = ctx
+
+	job := &BulkJob{pause: opts.Pause, stats: opts.Stats, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		run(job, opts)
+	}()
+	return job
+}
+
+// Pause stops the job from starting new validations until Resume is called.
+// Validations already running finish.
+func (j *BulkJob) Pause() {
+	j.pause.Pause()
+}
+
+// Resume lets a paused job start validations again.
+func (j *BulkJob) Resume() {
+	j.pause.Resume()
+}
+
+// Paused reports whether the job is paused.
+func (j *BulkJob) Paused() bool {
+	return j.pause.Paused()
+}
+
+// Cancel stops the job for good, paused or not: no new validations start,
+// those already running finish, and the addresses left come back with
+// ErrInterrupted, see Remaining to pick them up later. It returns at once;
+// Wait for the job to finish.
+func (j *BulkJob) Cancel() {
+	j.cancel()
+}
+
+// Done returns a channel that is closed once the job has finished.
+func (j *BulkJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Stats returns the job's progress so far, see RunStats.
+func (j *BulkJob) Stats() RunSnapshot {
+	return j.stats.Snapshot()
+}
+
+// Wait waits for the job to finish, by validating every address or by
+// being cancelled.
+//
+// Returns:
+//   - []BulkResult: The results, as ValidateBulk returns them, nil for a
+//     job started with StartFiles.
+func (j *BulkJob) Wait() []BulkResult {
+	<-j.done
+	return j.results
+}
+
+// Summary waits for a job started with StartFiles to finish and returns
+// what file processing returned. Jobs started with StartBulk have no summary.
+//
+// Returns:
+//   - BulkSummary: The combined counts of every file processed.
+//   - error: The error ProcessAndValidateEmailsViaFiles returned, among
+//     which ErrInterrupted if the job was cancelled.
+func (j *BulkJob) Summary() (BulkSummary, error) {
+	<-j.done
+	return j.summary, j.err
+}
+
+// Remaining waits for the job to finish and returns the addresses it didn't
+// get to because it was cancelled, in input order, to validate in a later
+// run. It is empty if the job validated every address.
+//
+// Returns:
+//   - []string: The addresses left.
+func (j *BulkJob) Remaining() []string {
+	var emails []string
+	for _, res := range j.Wait() {
+		if errors.Is(res.Err, ErrInterrupted) {
+			emails = append(emails, res.Email)
+		}
+	}
+	return emails
+}
@@ -0,0 +1,65 @@
+package mailify_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/mailifytest"
+)
+
+// bulkEmails are the addresses the bulk tests validate.
+var bulkEmails = []string{"a@example.com", "b@example.com", "c@example.org", "d@example.org"}
+
+func TestBulkJobPauseResume(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	c := newTestClient(t, srv, mailify.WithCatchAllProbes(0))
+
+	var pause mailify.PauseSwitch
+	pause.Pause()
+	job := c.StartBulk(bulkEmails, mailify.BulkOptions{Concurrency: 2, Pause: &pause})
+
+	time.Sleep(100 * time.Millisecond)
+	if got := len(rcpts(srv)); got != 0 {
+		t.Fatalf("paused job sent %d RCPT TO commands, want none", got)
+	}
+	if !job.Paused() {
+		t.Error("job isn't paused")
+	}
+
+	job.Resume()
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("job didn't finish after Resume")
+	}
+	for _, res := range job.Wait() {
+		if res.Err != nil || res.Result.Verdict != mailify.VerdictDeliverable {
+			t.Errorf("%s: %v, %v, want deliverable", res.Email, res.Result, res.Err)
+		}
+	}
+	if got := len(rcpts(srv)); got != len(bulkEmails) {
+		t.Errorf("server got %d RCPT TO commands, want %d", got, len(bulkEmails))
+	}
+}
+
+func TestBulkJobCancelWhilePaused(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	c := newTestClient(t, srv, mailify.WithCatchAllProbes(0))
+
+	job := c.StartBulk(bulkEmails, mailify.BulkOptions{Concurrency: 2})
+	job.Pause()
+	job.Cancel()
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("paused job didn't stop when cancelled")
+	}
+
+	validated := len(bulkEmails) - len(job.Remaining())
+	if got := len(rcpts(srv)); got != validated {
+		t.Errorf("server got %d RCPT TO commands, want one for each of the %d addresses validated", got, validated)
+	}
+}
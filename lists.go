@@ -0,0 +1,87 @@
+package mailify
+
+import "strings"
+
+// roleAccounts are local parts that usually belong to a team or function
+// rather than a person.
+var roleAccounts = map[string]bool{
+	"abuse": true, "admin": true, "administrator": true, "billing": true,
+	"contact": true, "help": true, "hostmaster": true, "hr": true,
+	"info": true, "jobs": true, "mailer-daemon": true, "marketing": true,
+	"no-reply": true, "noreply": true, "office": true, "postmaster": true,
+	"root": true, "sales": true, "security": true, "support": true,
+	"team": true, "webmaster": true,
+}
+
+// disposableDomains are domains of well-known throwaway mailbox providers.
+var disposableDomains = map[string]bool{
+	"10minutemail.com": true, "dispostable.com": true, "emailondeck.com": true,
+	"fakeinbox.com": true, "getnada.com": true, "guerrillamail.com": true,
+	"guerrillamail.net": true, "mailinator.com": true, "maildrop.cc": true,
+	"mintemail.com": true, "mohmal.com": true, "sharklasers.com": true,
+	"temp-mail.org": true, "tempmail.com": true, "throwawaymail.com": true,
+	"trashmail.com": true, "yopmail.com": true,
+}
+
+// freeProviders are domains of well-known free mailbox providers, whose
+// addresses belong to individuals rather than organizations.
+var freeProviders = map[string]bool{
+	"aol.com": true, "gmail.com": true, "gmx.com": true, "gmx.de": true,
+	"googlemail.com": true, "hotmail.com": true, "icloud.com": true,
+	"live.com": true, "mail.com": true, "mail.ru": true, "me.com": true,
+	"msn.com": true, "outlook.com": true, "proton.me": true,
+	"protonmail.com": true, "qq.com": true, "web.de": true, "yahoo.com": true,
+	"yandex.ru": true, "zoho.com": true,
+}
+
+// IsRoleAccount reports whether an email address belongs to a role, such as
+// info@ or support@, rather than a person. A +tag in the local part is ignored.
+//
+// Parameters:
+//   - email: The email address to check.
+//
+// Returns:
+//   - bool: True if the local part is a known role name.
+func IsRoleAccount(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	local, _, _ := strings.Cut(strings.ToLower(email[:at]), "+")
+	return roleAccounts[local]
+}
+
+// IsDisposableDomain reports whether a domain, or one of its parent domains,
+// belongs to a known disposable mailbox provider.
+//
+// Parameters:
+//   - domain: The domain to check.
+//
+// Returns:
+//   - bool: True if the domain is disposable.
+func IsDisposableDomain(domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
+
+// IsFreeProvider reports whether a domain belongs to a well-known free
+// mailbox provider, such as gmail.com, rather than an organization.
+//
+// Parameters:
+//   - domain: The domain to check.
+//
+// Returns:
+//   - bool: True if the domain is a free provider's.
+func IsFreeProvider(domain string) bool {
+	return freeProviders[strings.TrimSuffix(strings.ToLower(domain), ".")]
+}
@@ -0,0 +1,111 @@
+package mailify
+
+import (
+	"strings"
+)
+
+// Locale is a language that human-readable messages are written in, as an
+// ISO 639-1 code.
+type Locale string
+
+const (
+	// LocaleEnglish is the default.
+	LocaleEnglish Locale = "en"
+	// LocaleSpanish writes messages in Spanish.
+	LocaleSpanish Locale = "es"
+	// LocaleFrench writes messages in French.
+	LocaleFrench Locale = "fr"
+	// LocaleGerman writes messages in German.
+	LocaleGerman Locale = "de"
+	// LocaleHindi writes messages in Hindi.
+	LocaleHindi Locale = "hi"
+)
+
+// Locales returns the locales messages are available in.
+func Locales() []Locale {
+	return []Locale{LocaleEnglish, LocaleSpanish, LocaleFrench, LocaleGerman, LocaleHindi}
+}
+
+// ParseLocale parses a locale such as "es", "fr-CA" or "de_DE.UTF-8", using
+// only its language.
+//
+// Parameters:
+//   - name: The locale to parse.
+//
+// Returns:
+//   - Locale: The locale.
+//   - bool: Whether messages are available in the locale's language.
+func ParseLocale(name string) (Locale, bool) {
+	language := strings.ToLower(name)
+	if i := strings.IndexAny(language, "-_."); i >= 0 {
+		language = language[:i]
+	}
+	locale := Locale(language)
+	if locale == LocaleEnglish {
+		return locale, true
+	}
+	_, ok := catalogs[locale]
+	return locale, ok
+}
+
+// WithLocale writes the human-readable parts of results, ErrorMessage and the
+// report FormatValidationResult renders, in the given language. Messages
+// that carry details from the mail server or resolver keep those details as
+// they were. Unknown locales fall back to English. The default is English.
+func WithLocale(locale Locale) Option {
+	return func(c *Client) {
+		c.locale = locale
+	}
+}
+
+// DescribeVerdict explains a verdict in a sentence, in the client's locale.
+//
+// Parameters:
+//   - verdict: The verdict to explain.
+//
+// Returns:
+//   - string: The explanation, or the verdict itself if it is unknown.
+func (c *Client) DescribeVerdict(verdict Verdict) string {
+	description, ok := verdictDescriptions[verdict]
+	if !ok {
+		return string(verdict)
+	}
+	return c.translate(description)
+}
+
+// verdictDescriptions explains each verdict, in English.
+var verdictDescriptions = map[Verdict]string{
+	VerdictDeliverable:   "The mailbox exists and accepts mail",
+	VerdictUndeliverable: "Mail to this address will bounce",
+	VerdictRisky:         "The address may be deliverable, but delivery could fail or go unnoticed",
+	VerdictUnknown:       "The mail server didn't say whether the mailbox exists",
+}
+
+// translate returns msg in the client's locale. A message of the form
+// "summary: details" whose summary is in the catalog has only the summary
+// translated. Messages not in the catalog are returned as they are.
+func (c *Client) translate(msg string) string {
+	catalog, ok := catalogs[c.locale]
+	if !ok {
+		return msg
+	}
+	if translated, ok := catalog[msg]; ok {
+		return translated
+	}
+	if summary, details, ok := strings.Cut(msg, ": "); ok {
+		if translated, ok := catalog[summary]; ok {
+			return translated + ": " + details
+		}
+	}
+	return msg
+}
+
+// localize translates the human-readable parts of a result.
+func (c *Client) localize(result *ValidationResult) {
+	if result.ErrorMessage != "" {
+		result.ErrorMessage = c.translate(result.ErrorMessage)
+	}
+	for i, warning := range result.Warnings {
+		result.Warnings[i] = c.translate(warning)
+	}
+}
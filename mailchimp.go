@@ -0,0 +1,105 @@
+package mailify
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mailchimpPageSize is how many members are read per request, Mailchimp's maximum.
+const mailchimpPageSize = 1000
+
+// MailchimpConnector is a ListConnector for a Mailchimp audience. Tags are
+// Mailchimp member tags, and removed contacts are archived, which keeps
+// their history in Mailchimp but stops sends to them.
+type MailchimpConnector struct {
+	// APIKey is a Mailchimp API key. Its suffix, such as "us6", picks the data center.
+	APIKey string
+	// ListID is the audience's ID.
+	ListID string
+	// Endpoint is the API root, "https://<dc>.api.mailchimp.com/3.0" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements ListConnector.
+func (m *MailchimpConnector) Name() string {
+	return "mailchimp"
+}
+
+// Contacts implements ListConnector. Archived members are left out.
+func (m *MailchimpConnector) Contacts(ctx context.Context) ([]Contact, error) {
+	var contacts []Contact
+	for offset := 0; ; offset += mailchimpPageSize {
+		var page struct {
+			Members []struct {
+				ID           string `json:"id"`
+				EmailAddress string `json:"email_address"`
+				Status       string `json:"status"`
+			} `json:"members"`
+			TotalItems int `json:"total_items"`
+		}
+		query := url.Values{
+			"count":  {fmt.Sprint(mailchimpPageSize)},
+			"offset": {fmt.Sprint(offset)},
+			"fields": {"members.id,members.email_address,members.status,total_items"},
+		}
+		if err := m.request(ctx, http.MethodGet, "/members?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, member := range page.Members {
+			if member.Status != "archived" {
+				contacts = append(contacts, Contact{ID: member.ID, Email: member.EmailAddress})
+			}
+		}
+		if len(page.Members) < mailchimpPageSize || offset+len(page.Members) >= page.TotalItems {
+			return contacts, nil
+		}
+	}
+}
+
+// Tag implements ListConnector.
+func (m *MailchimpConnector) Tag(ctx context.Context, contact Contact, tag string) error {
+	body := map[string]any{"tags": []map[string]string{{"name": tag, "status": "active"}}}
+	return m.request(ctx, http.MethodPost, "/members/"+m.memberID(contact)+"/tags", body, nil)
+}
+
+// Remove implements ListConnector. The member is archived.
+func (m *MailchimpConnector) Remove(ctx context.Context, contact Contact) error {
+	return m.request(ctx, http.MethodDelete, "/members/"+m.memberID(contact), nil, nil)
+}
+
+// memberID returns a contact's member ID, which Mailchimp defines as the MD5
+// hash of the lowercased address.
+func (m *MailchimpConnector) memberID(contact Contact) string {
+	if contact.ID != "" {
+		return contact.ID
+	}
+	sum := md5.Sum([]byte(strings.ToLower(contact.Email)))
+	return hex.EncodeToString(sum[:])
+}
+
+// request sends a request to a path under the audience.
+func (m *MailchimpConnector) request(ctx context.Context, method, path string, body, out any) error {
+	endpoint := m.Endpoint
+	if endpoint == "" {
+		_, dc, ok := strings.Cut(m.APIKey, "-")
+		if !ok || dc == "" {
+			return fmt.Errorf("mailchimp API key has no data center suffix, such as -us6")
+		}
+		endpoint = "https://" + dc + ".api.mailchimp.com/3.0"
+	}
+	auth := func(req *http.Request) {
+		req.SetBasicAuth("mailify", m.APIKey)
+	}
+	rawURL := strings.TrimSuffix(endpoint, "/") + "/lists/" + url.PathEscape(m.ListID) + path
+	if err := apiRequest(ctx, m.HTTPClient, auth, method, rawURL, body, out); err != nil {
+		return fmt.Errorf("mailchimp: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package mailifytest
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// Resolver is a fake DNS resolver that answers from records added to it.
+// Lookups of names without records fail with a "no such host" error, and
+// names can be made to fail with a temporary error through Fail.
+type Resolver struct {
+	mu   sync.Mutex
+	mx   map[string][]*net.MX
+	ips  map[string][]net.IPAddr
+	txt  map[string][]string
+	ptr  map[string][]string
+	fail map[string]bool
+}
+
+// NewResolver creates a resolver without any records.
+func NewResolver() *Resolver {
+	return &Resolver{
+		mx:   make(map[string][]*net.MX),
+		ips:  make(map[string][]net.IPAddr),
+		txt:  make(map[string][]string),
+		ptr:  make(map[string][]string),
+		fail: make(map[string]bool),
+	}
+}
+
+// AddMX adds MX records for domain. Hosts are given preferences 10, 20, ...
+// in the order they are passed.
+func (r *Resolver) AddMX(domain string, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := normalize(domain)
+	for _, host := range hosts {
+		pref := uint16(10 * (len(r.mx[key]) + 1))
+		r.mx[key] = append(r.mx[key], &net.MX{Host: host + ".", Pref: pref})
+	}
+}
+
+// AddIP adds address records for host. Invalid addresses are ignored.
+func (r *Resolver) AddIP(host string, ips ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := normalize(host)
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			r.ips[key] = append(r.ips[key], net.IPAddr{IP: parsed})
+		}
+	}
+}
+
+// AddTXT adds TXT records for name.
+func (r *Resolver) AddTXT(name string, records ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := normalize(name)
+	r.txt[key] = append(r.txt[key], records...)
+}
+
+// AddPTR adds reverse DNS names for the IP address addr.
+func (r *Resolver) AddPTR(addr string, names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		r.ptr[addr] = append(r.ptr[addr], name+".")
+	}
+}
+
+// Fail makes every lookup of name fail with a temporary DNS error, as if the
+// name servers were unreachable.
+func (r *Resolver) Fail(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fail[normalize(name)] = true
+}
+
+// LookupMX implements mailify.Resolver.
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, name, len(r.mx[normalize(name)])); err != nil {
+		return nil, err
+	}
+	return append([]*net.MX(nil), r.mx[normalize(name)]...), nil
+}
+
+// LookupIPAddr implements mailify.Resolver. Literal IP addresses resolve to themselves.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, host, len(r.ips[normalize(host)])); err != nil {
+		return nil, err
+	}
+	return append([]net.IPAddr(nil), r.ips[normalize(host)]...), nil
+}
+
+// LookupTXT implements mailify.Resolver.
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, name, len(r.txt[normalize(name)])); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), r.txt[normalize(name)]...), nil
+}
+
+// LookupAddr implements mailify.Resolver.
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.check(ctx, addr, len(r.ptr[addr])); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), r.ptr[addr]...), nil
+}
+
+// check returns the error a lookup of name with n records should fail with, if any.
+// The caller must hold r.mu.
+func (r *Resolver) check(ctx context.Context, name string, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.fail[normalize(name)] {
+		return &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	if n == 0 {
+		return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return nil
+}
+
+// normalize lowercases a DNS name and strips its trailing dot.
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// Options returns the client options that send every DNS lookup to resolver
+// and every connection to server. Mail servers are allowed to resolve to
+// reserved addresses, such as 192.0.2.1 or 127.0.0.1.
+func Options(server *Server, resolver *Resolver) []mailify.Option {
+	return []mailify.Option{
+		mailify.WithDialer(server.Dialer()),
+		mailify.WithResolver(resolver),
+		// Test setups use documentation and loopback addresses
+		mailify.WithAllowBogonMX(),
+	}
+}
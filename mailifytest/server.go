@@ -0,0 +1,473 @@
+// Package mailifytest provides helpers for testing code that uses mailify
+// without touching the network: a scriptable in-memory SMTP server and a fake
+// DNS resolver, both of which plug into a mailify.Client through its options.
+//
+//	server := mailifytest.NewServer()
+//	defer server.Close()
+//	server.SetRcptReply("gone@example.com", 550, "5.1.1 No such user")
+//
+//	resolver := mailifytest.NewResolver()
+//	resolver.AddMX("example.com", "mx.example.com")
+//	resolver.AddIP("mx.example.com", "192.0.2.1")
+//
+//	client, _ := mailify.NewClient("sender@example.org", mailifytest.Options(server, resolver)...)
+//	result, _ := client.ValidateEmail("gone@example.com")
+package mailifytest
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+// Reply is an SMTP reply the server sends.
+type Reply struct {
+	// Code is the three digit reply code, e.g. 250 or 550.
+	Code int
+	// Message is the reply text, e.g. "5.1.1 No such user".
+	Message string
+}
+
+// Message is a mail message the server received through DATA.
+type Message struct {
+	// From is the MAIL FROM address.
+	From string
+	// To holds the accepted RCPT TO addresses.
+	To []string
+	// Data is the message content, without the terminating dot.
+	Data string
+
+	// rcpts counts the RCPT TO commands of the transaction.
+	rcpts int
+}
+
+// Server is a scriptable SMTP server that runs in memory. Connections are made
+// through the Dialer it returns, so no ports are opened. Whatever address is
+// dialed ends up at this server; connections to port 465 use implicit TLS.
+//
+// The zero value is not usable, create servers with NewServer. The exported
+// fields must be set before the server is used.
+type Server struct {
+	// Hostname is the name the server greets with.
+	Hostname string
+	// Extensions are the ESMTP extensions advertised in the EHLO response.
+	// STARTTLS is added automatically when TLS is enabled.
+	Extensions []string
+	// TLS enables STARTTLS and implicit TLS on port 465, using a self-signed certificate.
+	TLS bool
+	// Tarpit delays every reply, simulating servers that slow down suspicious clients.
+	Tarpit time.Duration
+	// MaxRecipients, if set, limits how many recipients are accepted per
+	// transaction; RCPT TO commands beyond it get 452 4.5.3.
+	MaxRecipients int
+	// Users, if set, enables AUTH PLAIN, LOGIN and XOAUTH2 with these
+	// usernames and passwords, the password being the access token for
+	// XOAUTH2. MAIL is then refused until the client has logged in.
+	Users map[string]string
+
+	mu          sync.Mutex
+	greeting    Reply
+	mailReply   Reply
+	rcptReplies map[string]Reply
+	rcptDefault Reply
+	greylist    int
+	attempts    map[string]int
+	transcript  []string
+	messages    []Message
+	conns       []net.Conn
+	tlsConfig   *tls.Config
+	closed      bool
+}
+
+// NewServer creates a server that accepts every sender and recipient.
+func NewServer() *Server {
+	return &Server{
+		Hostname:    "mx.mailifytest",
+		Extensions:  []string{"SIZE 10240000", "PIPELINING", "8BITMIME"},
+		greeting:    Reply{220, "mx.mailifytest ESMTP mailifytest"},
+		mailReply:   Reply{250, "2.1.0 Ok"},
+		rcptReplies: make(map[string]Reply),
+		rcptDefault: Reply{250, "2.1.5 Ok"},
+		attempts:    make(map[string]int),
+	}
+}
+
+// SetGreeting sets the reply sent when a connection is opened. A code other
+// than 220 makes the server hang up after greeting.
+func (s *Server) SetGreeting(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.greeting = Reply{code, message}
+}
+
+// SetMailReply sets the reply to every MAIL FROM command.
+func (s *Server) SetMailReply(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mailReply = Reply{code, message}
+}
+
+// SetRcptReply sets the reply to RCPT TO for a specific address. Addresses are
+// matched case-insensitively.
+func (s *Server) SetRcptReply(address string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcptReplies[strings.ToLower(address)] = Reply{code, message}
+}
+
+// SetDefaultRcptReply sets the reply to RCPT TO for addresses without a
+// specific reply. Accepting everything makes the server look like a catch-all.
+func (s *Server) SetDefaultRcptReply(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcptDefault = Reply{code, message}
+}
+
+// Greylist makes the server defer the first attempts RCPT TO commands for each
+// address with 450 before giving its normal reply.
+func (s *Server) Greylist(attempts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.greylist = attempts
+}
+
+// Transcript returns every command the server has received, in order.
+func (s *Server) Transcript() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.transcript...)
+}
+
+// Messages returns every message the server has received through DATA.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Dialer returns a mailify.Dialer whose connections are served by this server.
+func (s *Server) Dialer() mailify.Dialer {
+	return dialerFunc(s.dial)
+}
+
+// Close hangs up every open connection and makes further dials fail.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// dialerFunc adapts a function to the mailify.Dialer interface.
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// dial creates an in-memory connection and starts serving its server side.
+func (s *Server) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("dial %s %s: connection refused", network, address)
+	}
+	client, server := net.Pipe()
+	s.conns = append(s.conns, server)
+	s.mu.Unlock()
+
+	_, port, _ := net.SplitHostPort(address)
+	go s.serve(server, port == "465")
+	return client, nil
+}
+
+// serve runs the SMTP conversation on one connection.
+func (s *Server) serve(conn net.Conn, implicitTLS bool) {
+	defer conn.Close()
+
+	if implicitTLS {
+		if !s.TLS {
+			return
+		}
+		conn = tls.Server(conn, s.certificate())
+	}
+
+	text := textproto.NewConn(conn)
+	s.mu.Lock()
+	greeting := s.greeting
+	s.mu.Unlock()
+	if !s.reply(text, greeting) || greeting.Code != 220 {
+		return
+	}
+
+	var msg *Message
+	var user string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.transcript = append(s.transcript, line)
+		s.mu.Unlock()
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{s.Hostname + " Hello " + arg}
+			lines = append(lines, s.Extensions...)
+			if s.Users != nil {
+				lines = append(lines, "AUTH PLAIN LOGIN XOAUTH2")
+			}
+			if _, isTLS := conn.(*tls.Conn); s.TLS && !isTLS {
+				lines = append(lines, "STARTTLS")
+			}
+			if !s.reply(text, Reply{250, strings.Join(lines, "\n")}) {
+				return
+			}
+
+		case "HELO":
+			if !s.reply(text, Reply{250, s.Hostname}) {
+				return
+			}
+
+		case "STARTTLS":
+			if _, isTLS := conn.(*tls.Conn); !s.TLS || isTLS {
+				s.reply(text, Reply{502, "5.5.1 STARTTLS not available"})
+				continue
+			}
+			if !s.reply(text, Reply{220, "2.0.0 Ready to start TLS"}) {
+				return
+			}
+			conn = tls.Server(conn, s.certificate())
+			text = textproto.NewConn(conn)
+			msg = nil
+			user = ""
+
+		case "AUTH":
+			if s.Users == nil || user != "" {
+				s.reply(text, Reply{503, "5.5.1 AUTH not available"})
+				continue
+			}
+			name, ok := s.auth(text, arg)
+			if !ok {
+				if !s.reply(text, Reply{535, "5.7.8 Authentication credentials invalid"}) {
+					return
+				}
+				continue
+			}
+			user = name
+			if !s.reply(text, Reply{235, "2.7.0 Authentication successful"}) {
+				return
+			}
+
+		case "MAIL":
+			if s.Users != nil && user == "" {
+				s.reply(text, Reply{530, "5.7.0 Authentication required"})
+				continue
+			}
+			s.mu.Lock()
+			reply := s.mailReply
+			s.mu.Unlock()
+			if reply.Code/100 == 2 {
+				msg = &Message{From: addressArg(arg)}
+			}
+			if !s.reply(text, reply) {
+				return
+			}
+
+		case "RCPT":
+			if msg == nil {
+				s.reply(text, Reply{503, "5.5.1 Need MAIL command"})
+				continue
+			}
+			if s.MaxRecipients > 0 && msg.rcpts >= s.MaxRecipients {
+				if !s.reply(text, Reply{452, "4.5.3 Too many recipients"}) {
+					return
+				}
+				continue
+			}
+			msg.rcpts++
+			address := addressArg(arg)
+			reply := s.rcptReply(address)
+			if reply.Code/100 == 2 {
+				msg.To = append(msg.To, address)
+			}
+			if !s.reply(text, reply) {
+				return
+			}
+
+		case "DATA":
+			if msg == nil || len(msg.To) == 0 {
+				s.reply(text, Reply{503, "5.5.1 Need RCPT command"})
+				continue
+			}
+			if !s.reply(text, Reply{354, "End data with <CR><LF>.<CR><LF>"}) {
+				return
+			}
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, *msg)
+			s.mu.Unlock()
+			msg = nil
+			if !s.reply(text, Reply{250, "2.0.0 Ok: queued"}) {
+				return
+			}
+
+		case "RSET":
+			msg = nil
+			if !s.reply(text, Reply{250, "2.0.0 Ok"}) {
+				return
+			}
+
+		case "NOOP":
+			if !s.reply(text, Reply{250, "2.0.0 Ok"}) {
+				return
+			}
+
+		case "QUIT":
+			s.reply(text, Reply{221, "2.0.0 Bye"})
+			return
+
+		default:
+			if !s.reply(text, Reply{502, "5.5.2 Command not recognized"}) {
+				return
+			}
+		}
+	}
+}
+
+// auth runs the AUTH exchange for the given argument and returns the username
+// if the credentials match one of Users.
+func (s *Server) auth(text *textproto.Conn, arg string) (string, bool) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+	prompt := func(challenge string) (string, bool) {
+		if !s.reply(text, Reply{334, base64.StdEncoding.EncodeToString([]byte(challenge))}) {
+			return "", false
+		}
+		line, err := text.ReadLine()
+		return decode(line), err == nil
+	}
+
+	var username, password string
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		parts := strings.Split(decode(initial), "\x00")
+		if len(parts) != 3 {
+			return "", false
+		}
+		username, password = parts[1], parts[2]
+	case "LOGIN":
+		var ok bool
+		if username, ok = prompt("Username:"); !ok {
+			return "", false
+		}
+		if password, ok = prompt("Password:"); !ok {
+			return "", false
+		}
+	case "XOAUTH2":
+		for _, field := range strings.Split(decode(initial), "\x01") {
+			if value, ok := strings.CutPrefix(field, "user="); ok {
+				username = value
+			}
+			if value, ok := strings.CutPrefix(field, "auth=Bearer "); ok {
+				password = value
+			}
+		}
+	default:
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if want, ok := s.Users[username]; !ok || want != password {
+		return "", false
+	}
+	return username, true
+}
+
+// rcptReply works out the reply to RCPT TO for an address, applying greylisting.
+func (s *Server) rcptReply(address string) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(address)
+	s.attempts[key]++
+	if s.attempts[key] <= s.greylist {
+		return Reply{450, "4.2.0 Greylisted, please try again later"}
+	}
+	if reply, ok := s.rcptReplies[key]; ok {
+		return reply
+	}
+	return s.rcptDefault
+}
+
+// reply writes a possibly multi-line reply after the tarpit delay, reporting
+// whether the write succeeded.
+func (s *Server) reply(text *textproto.Conn, reply Reply) bool {
+	if s.Tarpit > 0 {
+		time.Sleep(s.Tarpit)
+	}
+
+	lines := strings.Split(reply.Message, "\n")
+	for i, line := range lines {
+		sep := "-"
+		if i == len(lines)-1 {
+			sep = " "
+		}
+		if err := text.PrintfLine("%03d%s%s", reply.Code, sep, line); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// addressArg extracts the address from a "FROM:<addr> PARAMS" or "TO:<addr>" argument.
+func addressArg(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start < 0 || end < start {
+		_, addr, _ := strings.Cut(arg, ":")
+		return strings.TrimSpace(addr)
+	}
+	return arg[start+1 : end]
+}
+
+// certificate returns the server's TLS configuration, generating a
+// self-signed certificate the first time it is needed.
+func (s *Server) certificate() *tls.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tlsConfig == nil {
+		cert, err := selfSignedCertificate(s.Hostname)
+		if err != nil {
+			panic(fmt.Sprintf("mailifytest: failed to generate certificate: %v", err))
+		}
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	return s.tlsConfig
+}
@@ -0,0 +1,622 @@
+package mailify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSignature is returned by VerifyManifest for a manifest that wasn't
+// signed with the given key, or was changed after it was signed.
+var ErrInvalidSignature = errors.New("manifest signature is invalid")
+
+// modulePath is this module's import path, used to find its version.
+const modulePath = "github.com/adarsh-jaiss/mailify"
+
+// Manifest records how and when a list was validated: the files that went
+// in and came out, with their hashes, the tool version and configuration,
+// and the counts. It is what SignedManifest signs.
+type Manifest struct {
+	// Tool is always "mailify".
+	Tool string `json:"tool"`
+	// Version is the mailify version that ran, "(devel)" for local builds.
+	Version string `json:"version"`
+	// Inputs are the files as they were before validation.
+	Inputs []ManifestFile `json:"inputs"`
+	// Outputs are the files holding the results.
+	Outputs []ManifestFile `json:"outputs"`
+	// Config is the validation configuration.
+	Config ManifestConfig `json:"config"`
+	// Summary counts the results.
+	Summary BulkSummary `json:"summary"`
+	// StartedAt and FinishedAt bound the run, in UTC.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// ManifestFile is a file named in a Manifest.
+type ManifestFile struct {
+	// Path is the file's path as given to the run.
+	Path string `json:"path"`
+	// SHA256 is the hex SHA-256 hash of the file's contents.
+	SHA256 string `json:"sha256"`
+	// Size is the file's size in bytes.
+	Size int64 `json:"size"`
+}
+
+// ManifestConfig is the configuration a Manifest records, the settings that
+// decide results. Options and BulkOptions turn it back into settings, to
+// repeat the run. ProbeSeed and ProbeGenerator are what the catch-all probes
+// were derived from, see WithProbeSeed and WithProbeGenerator. Durations are
+// written like "1m30s".
+type ManifestConfig struct {
+	SenderEmail       string          `json:"sender_email"`
+	Mode              string          `json:"mode"`
+	MaxAttempts       int             `json:"max_attempts"`
+	Timeout           string          `json:"timeout,omitempty"`
+	TarpitThreshold   string          `json:"tarpit_threshold,omitempty"`
+	HELOName          string          `json:"helo_name,omitempty"`
+	CatchAllProbes    int             `json:"catch_all_probes"`
+	ProbeSeed         int64           `json:"probe_seed"`
+	ProbeGenerator    *ProbeGenerator `json:"probe_generator,omitempty"`
+	AllowBogonMX      bool            `json:"allow_bogon_mx"`
+	CompareResolvers  int             `json:"compare_resolvers"`
+	Locale            Locale          `json:"locale,omitempty"`
+	Concurrency       int             `json:"concurrency"`
+	DomainConcurrency int             `json:"domain_concurrency"`
+	DomainInterval    string          `json:"domain_interval"`
+	BatchSize         int             `json:"batch_size"`
+	EmailColumn       string          `json:"email_column,omitempty"`
+	Mapping           *ColumnMapping  `json:"mapping,omitempty"`
+	MultiAddress      string          `json:"multi_address"`
+	SkipValidated     bool            `json:"skip_validated,omitempty"`
+	MaxAge            string          `json:"max_age,omitempty"`
+}
+
+// Options returns the client options that repeat the configuration: the
+// mode, attempts, timeouts, HELO name, catch-all probes, their seed and generator,
+// bogon handling and locale. The resolvers compared against aren't
+// recorded, only how many there were, so give WithMXConsistencyCheck again
+// if the run used it.
+//
+// Returns:
+//   - []Option: The options, to pass to NewClient with SenderEmail.
+//   - error: An error if the configuration holds a value that doesn't parse.
+func (cfg ManifestConfig) Options() ([]Option, error) {
+	mode, ok := ParseValidationMode(cfg.Mode)
+	if !ok {
+		return nil, fmt.Errorf("invalid manifest: unknown mode %q", cfg.Mode)
+	}
+	retry := DefaultRetryPolicy()
+	if cfg.MaxAttempts > 0 {
+		retry.MaxAttempts = cfg.MaxAttempts
+	}
+	opts := []Option{
+		WithMode(mode),
+		WithRetryPolicy(retry),
+		WithCatchAllProbes(cfg.CatchAllProbes),
+		WithProbeSeed(cfg.ProbeSeed),
+	}
+	if cfg.ProbeGenerator != nil {
+		opts = append(opts, WithProbeGenerator(*cfg.ProbeGenerator))
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: timeout: %w", err)
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if cfg.TarpitThreshold != "" {
+		threshold, err := time.ParseDuration(cfg.TarpitThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest: tarpit threshold: %w", err)
+		}
+		opts = append(opts, WithTarpitThreshold(threshold))
+	}
+	if cfg.HELOName != "" {
+		opts = append(opts, WithHELOName(cfg.HELOName))
+	}
+	if cfg.AllowBogonMX {
+		opts = append(opts, WithAllowBogonMX())
+	}
+	if cfg.Locale != "" {
+		opts = append(opts, WithLocale(cfg.Locale))
+	}
+	return opts, nil
+}
+
+// BulkOptions returns the bulk options that repeat the configuration: the
+// concurrency, per-domain limits, batch size, email column, column mapping,
+// handling of cells holding several addresses and skipping of rows already
+// validated.
+//
+// Returns:
+//   - BulkOptions: The options, to which a Context, Pause and so on may be added.
+//   - error: An error if the configuration holds a value that doesn't parse.
+func (cfg ManifestConfig) BulkOptions() (BulkOptions, error) {
+	opts := BulkOptions{
+		Concurrency:       cfg.Concurrency,
+		DomainConcurrency: cfg.DomainConcurrency,
+		BatchSize:         cfg.BatchSize,
+		EmailColumn:       cfg.EmailColumn,
+		Mapping:           cfg.Mapping,
+	}
+	if cfg.DomainInterval != "" {
+		interval, err := time.ParseDuration(cfg.DomainInterval)
+		if err != nil {
+			return BulkOptions{}, fmt.Errorf("invalid manifest: domain interval: %w", err)
+		}
+		opts.DomainInterval = interval
+	}
+	multiAddress, ok := ParseMultiAddressMode(cfg.MultiAddress)
+	if !ok {
+		return BulkOptions{}, fmt.Errorf("invalid manifest: unknown multi-address mode %q", cfg.MultiAddress)
+	}
+	opts.MultiAddress = multiAddress
+	opts.SkipValidated = cfg.SkipValidated
+	if cfg.MaxAge != "" {
+		maxAge, err := time.ParseDuration(cfg.MaxAge)
+		if err != nil {
+			return BulkOptions{}, fmt.Errorf("invalid manifest: max age: %w", err)
+		}
+		opts.MaxAge = maxAge
+	}
+	return opts, nil
+}
+
+// SignedManifest is a Manifest with an ed25519 signature over its JSON
+// encoding, as written by ProcessAndSignFiles.
+type SignedManifest struct {
+	// Manifest is the signed manifest, kept as the exact JSON that was signed.
+	Manifest json.RawMessage `json:"manifest"`
+	// PublicKey is the base64 public key matching the signing key, for
+	// reference. Verify against a key obtained separately, not this one.
+	PublicKey string `json:"public_key"`
+	// Signature is the base64 ed25519 signature of Manifest.
+	Signature string `json:"signature"`
+}
+
+// ProcessAndSignFiles validates files as ProcessAndRecordFiles does, and
+// signs the manifest of the run. It lets compliance teams prove when and how
+// a list was verified, and that the results haven't been edited since.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern or a single file, as for ProcessAndValidateEmailsViaFiles.
+//   - opts: Options controlling the bulk run.
+//   - key: The ed25519 key to sign the manifest with, see LoadSigningKey.
+//
+// Returns:
+//   - BulkSummary: The combined counts of every file processed.
+//   - SignedManifest: The signed manifest, which WriteFile saves.
+//   - error: An error if no files match or the files can't be hashed, or the
+//     errors of the files that failed. A manifest is still returned for a run
+//     that failed in part.
+func (c *Client) ProcessAndSignFiles(pattern string, opts BulkOptions, key ed25519.PrivateKey) (BulkSummary, SignedManifest, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return BulkSummary{}, SignedManifest{}, errors.New("invalid ed25519 signing key")
+	}
+	summary, manifest, runErr := c.ProcessAndRecordFiles(pattern, opts)
+	if manifest.Tool == "" {
+		return summary, SignedManifest{}, runErr
+	}
+	signed, err := SignManifest(manifest, key)
+	if err != nil {
+		return summary, SignedManifest{}, err
+	}
+	return summary, signed, runErr
+}
+
+// ProcessAndRecordFiles validates files as ProcessAndValidateEmailsViaFiles
+// does, and records the run in a manifest: the hashes of the files before
+// and after, the tool version, the configuration, including the seed of the
+// catch-all probes, and the counts. Repeat the run with the manifest's
+// configuration, see ManifestConfig.Options, on the files its inputs name.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern or a single file, as for ProcessAndValidateEmailsViaFiles.
+//   - opts: Options controlling the bulk run.
+//
+// Returns:
+//   - BulkSummary: The combined counts of every file processed.
+//   - Manifest: The manifest, which WriteFile saves.
+//   - error: An error if no files match or the files can't be hashed, or the
+//     errors of the files that failed. A manifest is still returned for a run
+//     that failed in part.
+func (c *Client) ProcessAndRecordFiles(pattern string, opts BulkOptions) (BulkSummary, Manifest, error) {
+	files, err := listFiles(pattern)
+	if err != nil {
+		return BulkSummary{}, Manifest{}, err
+	}
+
+	manifest := Manifest{
+		Tool:      "mailify",
+		Version:   Version(),
+		Config:    c.manifestConfig(opts),
+		StartedAt: time.Now().UTC(),
+	}
+	for _, filename := range files {
+		file, err := hashFile(filename)
+		if err != nil {
+			return BulkSummary{}, Manifest{}, err
+		}
+		manifest.Inputs = append(manifest.Inputs, file)
+	}
+
+	summary, runErr := c.ProcessAndValidateEmailsViaFiles(pattern, opts)
+	manifest.Summary = summary
+	manifest.FinishedAt = time.Now().UTC()
+
+	for _, filename := range files {
+		if strings.EqualFold(filepath.Ext(filename), ".zip") {
+			filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".validated.zip"
+		}
+		file, err := hashFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			// The file failed before any results were written
+			continue
+		}
+		if err != nil {
+			return summary, Manifest{}, err
+		}
+		manifest.Outputs = append(manifest.Outputs, file)
+	}
+	return summary, manifest, runErr
+}
+
+// manifestConfig returns the configuration of a run for its manifest.
+func (c *Client) manifestConfig(opts BulkOptions) ManifestConfig {
+	multiAddress := map[MultiAddressMode]string{
+		MultiAddressOff:       "off",
+		MultiAddressAggregate: "aggregate",
+		MultiAddressExplode:   "explode",
+	}[opts.MultiAddress]
+	maxAge := ""
+	if opts.MaxAge > 0 {
+		maxAge = opts.MaxAge.String()
+	}
+	return ManifestConfig{
+		SenderEmail:       c.SenderEmail,
+		Mode:              c.mode.String(),
+		MaxAttempts:       c.retry.MaxAttempts,
+		Timeout:           c.timeout.String(),
+		TarpitThreshold:   c.tarpitThreshold.String(),
+		HELOName:          c.heloName,
+		CatchAllProbes:    c.catchAllProbes,
+		ProbeSeed:         c.probeSeed,
+		ProbeGenerator:    c.probeGenerator,
+		AllowBogonMX:      c.allowBogonMX,
+		CompareResolvers:  len(c.mxResolvers),
+		Locale:            c.locale,
+		Concurrency:       opts.Concurrency,
+		DomainConcurrency: opts.DomainConcurrency,
+		DomainInterval:    opts.DomainInterval.String(),
+		BatchSize:         opts.BatchSize,
+		EmailColumn:       opts.EmailColumn,
+		Mapping:           opts.Mapping,
+		MultiAddress:      multiAddress,
+		SkipValidated:     opts.SkipValidated,
+		MaxAge:            maxAge,
+	}
+}
+
+// SignManifest signs a manifest with an ed25519 key.
+//
+// Parameters:
+//   - manifest: The manifest to sign.
+//   - key: The signing key.
+//
+// Returns:
+//   - SignedManifest: The manifest with its signature.
+//   - error: An error if the key is invalid or the manifest can't be encoded.
+func SignManifest(manifest Manifest, key ed25519.PrivateKey) (SignedManifest, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return SignedManifest{}, errors.New("invalid ed25519 signing key")
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return SignedManifest{}, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return SignedManifest{
+		Manifest:  data,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)),
+	}, nil
+}
+
+// VerifyManifest checks a signed manifest's signature and decodes it.
+//
+// Parameters:
+//   - signed: The signed manifest, as read by ReadSignedManifest.
+//   - key: The public key of whoever should have signed it.
+//
+// Returns:
+//   - Manifest: The manifest, if the signature is valid.
+//   - error: ErrInvalidSignature, or an error if the manifest is malformed.
+func VerifyManifest(signed SignedManifest, key ed25519.PublicKey) (Manifest, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return Manifest{}, errors.New("invalid ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil {
+		return Manifest{}, ErrInvalidSignature
+	}
+	// An indented file re-indents the manifest, which was signed compact
+	var data bytes.Buffer
+	if err := json.Compact(&data, signed.Manifest); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if !ed25519.Verify(key, data.Bytes(), sig) {
+		return Manifest{}, ErrInvalidSignature
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data.Bytes(), &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// WriteFile saves a manifest as indented JSON.
+//
+// Parameters:
+//   - path: The file to write.
+//
+// Returns:
+//   - error: An error if the file can't be written.
+func (m Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest reads a manifest written by Manifest.WriteFile, or the
+// manifest inside one written by SignedManifest.WriteFile without checking
+// its signature, see VerifyManifest for that.
+//
+// Parameters:
+//   - path: The manifest file.
+//
+// Returns:
+//   - Manifest: The manifest.
+//   - error: An error if the file can't be read or parsed.
+func ReadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var signed SignedManifest
+	if err := json.Unmarshal(data, &signed); err == nil && signed.Manifest != nil {
+		data = signed.Manifest
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Tool != "mailify" {
+		return Manifest{}, fmt.Errorf("%s is not a mailify manifest", path)
+	}
+	return manifest, nil
+}
+
+// CheckInputs reports whether the files a run over pattern would process
+// are the manifest's inputs as they were before its run, wherever they now
+// are, e.g. copies kept of files the run wrote its results to.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern or a single file, as for ProcessAndValidateEmailsViaFiles.
+//
+// Returns:
+//   - error: An error naming a file that matches no input, or why the files can't be read.
+func (m Manifest) CheckInputs(pattern string) error {
+	files, err := listFiles(pattern)
+	if err != nil {
+		return err
+	}
+	for _, filename := range files {
+		file, err := hashFile(filename)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, input := range m.Inputs {
+			found = found || input.SHA256 == file.SHA256
+		}
+		if !found {
+			return fmt.Errorf("%s matches none of the manifest's inputs", filename)
+		}
+	}
+	return nil
+}
+
+// WriteFile saves a signed manifest as indented JSON.
+//
+// Parameters:
+//   - path: The file to write.
+//
+// Returns:
+//   - error: An error if the file can't be written.
+func (s SignedManifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadSignedManifest reads a signed manifest written by WriteFile. It doesn't
+// check the signature, see VerifyManifest.
+//
+// Parameters:
+//   - path: The manifest file.
+//
+// Returns:
+//   - SignedManifest: The signed manifest.
+//   - error: An error if the file can't be read or parsed.
+func ReadSignedManifest(path string) (SignedManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SignedManifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var signed SignedManifest
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return SignedManifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return signed, nil
+}
+
+// CheckFile reports whether a file still matches its entry in a manifest.
+//
+// Returns:
+//   - error: An error describing the mismatch, or why the file can't be read.
+func (f ManifestFile) CheckFile() error {
+	current, err := hashFile(f.Path)
+	if err != nil {
+		return err
+	}
+	if current.SHA256 != f.SHA256 {
+		return fmt.Errorf("%s has changed since it was signed", f.Path)
+	}
+	return nil
+}
+
+// GenerateSigningKey creates an ed25519 key pair and writes it in PEM form,
+// the private key as PKCS #8 to privPath, readable only by its owner, and
+// the public key as PKIX to pubPath. OpenSSL's
+// "openssl genpkey -algorithm ed25519" makes compatible keys.
+//
+// Parameters:
+//   - privPath: Where to write the private key.
+//   - pubPath: Where to write the public key.
+//
+// Returns:
+//   - error: An error if the keys can't be written. Existing files aren't overwritten.
+func GenerateSigningKey(privPath, pubPath string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+	if err := writeNewFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600); err != nil {
+		return err
+	}
+	return writeNewFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644)
+}
+
+// LoadSigningKey reads a PEM PKCS #8 ed25519 private key.
+//
+// Parameters:
+//   - path: The key file.
+//
+// Returns:
+//   - ed25519.PrivateKey: The key.
+//   - error: An error if the file can't be read or isn't an ed25519 private key.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey reads a PEM PKIX ed25519 public key.
+//
+// Parameters:
+//   - path: The key file.
+//
+// Returns:
+//   - ed25519.PublicKey: The key.
+//   - error: An error if the file can't be read or isn't an ed25519 public key.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 key", path)
+	}
+	return pub, nil
+}
+
+// readPEM reads the first PEM block of a file, which must be of the given type.
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s holds no PEM %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// writeNewFile writes a file that mustn't exist yet.
+func writeNewFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	return f.Close()
+}
+
+// hashFile returns a file's size and SHA-256 hash.
+func hashFile(path string) (ManifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("failed to hash file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("failed to hash file: %w", err)
+	}
+	return ManifestFile{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Size: n}, nil
+}
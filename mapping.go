@@ -0,0 +1,137 @@
+package mailify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ColumnMapping describes a recurring file format for file processing: the
+// columns holding the address and the contact's name and company, and the
+// result columns to write. Keep one per client whose exports look the same,
+// and load it with LoadColumnMapping.
+//
+//	email: Contact E-mail
+//	first_name: Given Name
+//	last_name: Surname
+//	company: Account
+//	output: [verdict, sub_status]
+//
+// Columns are named by their headers, compared like EmailColumn. When an
+// address has no display name of its own, the display_name column gets the
+// row's first and last name, or else its company.
+type ColumnMapping struct {
+	// Email is the header of the column holding the addresses. If empty, it
+	// is found as without a mapping. BulkOptions.EmailColumn wins over it.
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	// FirstName is the header of the column holding the contact's first name.
+	FirstName string `json:"first_name,omitempty" yaml:"first_name,omitempty"`
+	// LastName is the header of the column holding the contact's last name.
+	LastName string `json:"last_name,omitempty" yaml:"last_name,omitempty"`
+	// Company is the header of the column holding the contact's company.
+	Company string `json:"company,omitempty" yaml:"company,omitempty"`
+	// Output are the result columns to write, in order, from display_name,
+	// verdict, sub_status, confidence, is_valid_email, is_mailbox_full,
+	// validated_at, provenance and validation_error. If empty, all of them are written.
+	Output []string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// LoadColumnMapping reads a column mapping and checks its output columns.
+//
+// Parameters:
+//   - path: The file, YAML if it ends in .yaml or .yml and JSON otherwise.
+//
+// Returns:
+//   - *ColumnMapping: The mapping, to set on BulkOptions.Mapping.
+//   - error: An error if the file can't be read or parsed, or names an unknown output column.
+func LoadColumnMapping(path string) (*ColumnMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column mapping: %w", err)
+	}
+	var mapping ColumnMapping
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &mapping)
+	default:
+		err = json.Unmarshal(data, &mapping)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid column mapping: %w", err)
+	}
+	if _, err := mapping.columns(); err != nil {
+		return nil, fmt.Errorf("invalid column mapping: %w", err)
+	}
+	return &mapping, nil
+}
+
+// columns returns the result columns the mapping asks for, all of them if
+// the mapping is nil or names none.
+func (m *ColumnMapping) columns() ([]resultColumn, error) {
+	if m == nil || len(m.Output) == 0 {
+		return resultColumns, nil
+	}
+	columns := make([]resultColumn, 0, len(m.Output))
+	for _, name := range m.Output {
+		column, ok := findResultColumn(headerKey(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown output column %q", name)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// findResultColumn returns the result column with the given header.
+func findResultColumn(header string) (resultColumn, bool) {
+	for _, column := range resultColumns {
+		if column.header == header {
+			return column, true
+		}
+	}
+	return resultColumn{}, false
+}
+
+// rowNamer returns the display name of each row from the columns of the
+// mapping's name fields, checking that they exist. It returns nil if the
+// mapping has none.
+func (m *ColumnMapping) rowNamer(headers map[string]int) (func(row []string) string, error) {
+	if m == nil || m.FirstName == "" && m.LastName == "" && m.Company == "" {
+		return nil, nil
+	}
+	lookup := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		col, ok := headers[headerKey(name)]
+		if !ok {
+			return 0, fmt.Errorf("column %q not found", name)
+		}
+		return col, nil
+	}
+	var cols [3]int
+	for i, name := range []string{m.FirstName, m.LastName, m.Company} {
+		col, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		cols[i] = col
+	}
+
+	cell := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+	return func(row []string) string {
+		if name := strings.TrimSpace(cell(row, cols[0]) + " " + cell(row, cols[1])); name != "" {
+			return name
+		}
+		return cell(row, cols[2])
+	}, nil
+}
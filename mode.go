@@ -0,0 +1,54 @@
+package mailify
+
+// ValidationMode controls how much of the network validation may use.
+type ValidationMode int
+
+const (
+	// ModeFull runs every check, including the SMTP conversation. This is the default.
+	ModeFull ValidationMode = iota
+	// ModeDNSOnly looks up MX records but never opens SMTP connections.
+	ModeDNSOnly
+	// ModeOffline never touches the network. MX records are only checked if
+	// they are in the cache enabled by WithMXCache.
+	ModeOffline
+)
+
+// String returns the name of the mode as accepted by ParseValidationMode.
+func (m ValidationMode) String() string {
+	switch m {
+	case ModeDNSOnly:
+		return "dns"
+	case ModeOffline:
+		return "offline"
+	default:
+		return "full"
+	}
+}
+
+// ParseValidationMode parses "full", "dns" or "offline".
+//
+// Parameters:
+//   - name: The name of the mode.
+//
+// Returns:
+//   - ValidationMode: The mode.
+//   - bool: False if the name is unknown.
+func ParseValidationMode(name string) (ValidationMode, bool) {
+	for _, m := range []ValidationMode{ModeFull, ModeDNSOnly, ModeOffline} {
+		if m.String() == name {
+			return m, true
+		}
+	}
+	return ModeFull, false
+}
+
+// WithMode sets how much of the network validation may use. In ModeDNSOnly and
+// ModeOffline, syntax, role and disposable checks still run, and results that
+// would need an SMTP conversation get VerdictUnknown with SubStatusSkipped.
+// This is meant for air-gapped environments and tests. WebAssembly builds,
+// which can't open SMTP connections, run in ModeDNSOnly when ModeFull is asked for.
+func WithMode(mode ValidationMode) Option {
+	return func(c *Client) {
+		c.mode = mode
+	}
+}
@@ -0,0 +1,54 @@
+package mailify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MXChange reports that a domain's mail servers changed since it was last
+// validated, such as when it moved from its own servers to Microsoft 365.
+// Mailboxes often come and go with such a migration, so results cached
+// before it are validated again rather than answered from the cache.
+type MXChange struct {
+	// Domain is the domain whose mail servers changed.
+	Domain string `json:"domain"`
+	// Previous holds the mail servers the domain had, sorted.
+	Previous []string `json:"previous"`
+	// Current holds the mail servers the domain has now, sorted.
+	Current []string `json:"current"`
+	// DetectedAt is when the change was noticed.
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// WithMXChangeWebhook posts every MXChange as JSON to url, for services of
+// one's own to act on. Changes are only noticed with WithCache, see
+// Hooks.OnMXChange. A webhook failing prints a warning and doesn't fail the
+// validation.
+func WithMXChangeWebhook(url string) Option {
+	return WithHooks(Hooks{OnMXChange: func(change MXChange) {
+		if err := postJSON(context.Background(), nil, url, change); err != nil {
+			fmt.Printf("Warning: MX change notification failed: %v\n", err)
+		}
+	}})
+}
+
+// mxChangeHooks runs the OnMXChange hooks.
+func (c *Client) mxChangeHooks(change MXChange) {
+	for _, h := range c.hooks {
+		if h.OnMXChange != nil {
+			h.OnMXChange(change)
+		}
+	}
+}
+
+// changedMX reports whether a domain's mail servers changed after a result
+// of one of its addresses was validated, making the result due for
+// validating again.
+func (c *Client) changedMX(domain string, validatedAt time.Time) bool {
+	d := c.domainReputations
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rep := d.get(c.cache, domain)
+	return rep != nil && rep.MXChangedAt.After(validatedAt)
+}
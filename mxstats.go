@@ -0,0 +1,169 @@
+package mailify
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mxStatsTTL is how long what was learned about a mail server is kept in the
+// cache given with WithCache.
+const mxStatsTTL = 7 * 24 * time.Hour
+
+// MXStats is what has been learned about how a mail server responds. With
+// WithCache, stats are loaded from the cache and saved back on Close.
+type MXStats struct {
+	// Host is the mail server's host name.
+	Host string `json:"host"`
+	// Attempts is how many times the server was asked about a recipient.
+	Attempts int `json:"attempts"`
+	// Answers is how many of those times it answered, accepting, rejecting
+	// or deferring the recipient, rather than failing to connect or talk.
+	Answers int `json:"answers"`
+	// Latency is the average time an answer took.
+	Latency time.Duration `json:"latency"`
+	// UpdatedAt is when the server was last asked.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// score is the share of attempts the server answered, smoothed so a server
+// that hasn't been asked scores one half and a single failure or answer
+// doesn't count for everything.
+func (s MXStats) score() float64 {
+	return float64(s.Answers+1) / float64(s.Attempts+2)
+}
+
+// mxStats tracks MXStats for the mail servers a client talks to. A nil
+// tracker tracks nothing.
+type mxStats struct {
+	mu    sync.Mutex
+	hosts map[string]*MXStats
+	// loaded holds the hosts the cache has been asked about.
+	loaded map[string]bool
+	// changed holds the hosts asked about since the stats were last saved.
+	changed map[string]bool
+}
+
+// WithMXLearning makes the client learn how each mail server responds, how
+// often it answers and how fast, and try a domain's mail servers in order of
+// how responsive they have been instead of always from the top of the MX
+// list. Servers that haven't been asked yet keep their MX order among
+// themselves, and rank below servers that have answered and above ones
+// that have failed. With WithCache, what is learned is kept across runs.
+func WithMXLearning() Option {
+	return func(c *Client) {
+		c.mxStats = &mxStats{hosts: make(map[string]*MXStats), loaded: make(map[string]bool), changed: make(map[string]bool)}
+	}
+}
+
+// MXStats returns what the client has learned about a mail server, with ok
+// false if nothing has or WithMXLearning wasn't given.
+func (c *Client) MXStats(host string) (MXStats, bool) {
+	if c.mxStats == nil {
+		return MXStats{}, false
+	}
+	c.mxStats.mu.Lock()
+	defer c.mxStats.mu.Unlock()
+	stats := c.mxStats.get(c.cache, host)
+	if stats == nil {
+		return MXStats{}, false
+	}
+	return *stats, true
+}
+
+// mxStatsKey is the cache key a mail server's stats are kept under.
+func mxStatsKey(host string) string {
+	return "mxstats:" + strings.ToLower(host)
+}
+
+// get returns the stats of host, loading them from cache the first time. It
+// must be called with m.mu held.
+func (m *mxStats) get(cache Cache, host string) *MXStats {
+	host = strings.ToLower(host)
+	if stats, ok := m.hosts[host]; ok || m.loaded[host] || cache == nil {
+		return stats
+	}
+	m.loaded[host] = true
+	data, ok := cache.Get(mxStatsKey(host))
+	if !ok {
+		return nil
+	}
+	var stats MXStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil
+	}
+	m.hosts[host] = &stats
+	return &stats
+}
+
+// orderMailServers returns the mail servers in the order to try them, most
+// responsive first, or as given without WithMXLearning.
+func (c *Client) orderMailServers(mailServers []string) []string {
+	m := c.mxStats
+	if m == nil || len(mailServers) < 2 {
+		return mailServers
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ordered := append([]string(nil), mailServers...)
+	stats := make(map[string]MXStats, len(ordered))
+	for _, host := range ordered {
+		if s := m.get(c.cache, host); s != nil {
+			stats[host] = *s
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := stats[ordered[i]], stats[ordered[j]]
+		if a.score() != b.score() {
+			return a.score() > b.score()
+		}
+		// Equally responsive servers that have both answered go fastest first
+		return a.Answers > 0 && b.Answers > 0 && a.Latency < b.Latency
+	})
+	return ordered
+}
+
+// recordMX records the outcome of asking a mail server about a recipient:
+// whether it answered, and how long that took.
+func (c *Client) recordMX(host string, answered bool, took time.Duration) {
+	m := c.mxStats
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.get(c.cache, host)
+	if stats == nil {
+		stats = &MXStats{Host: strings.ToLower(host)}
+		m.hosts[stats.Host] = stats
+	}
+	stats.Attempts++
+	if answered {
+		stats.Answers++
+		stats.Latency += (took - stats.Latency) / time.Duration(stats.Answers)
+	}
+	stats.UpdatedAt = time.Now().UTC()
+	m.changed[stats.Host] = true
+}
+
+// saveMXStats writes what was learned about mail servers since the last save
+// to the client's cache, if it has one.
+func (c *Client) saveMXStats() {
+	m := c.mxStats
+	if m == nil || c.cache == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for host := range m.changed {
+		data, err := json.Marshal(m.hosts[host])
+		if err != nil {
+			continue
+		}
+		c.cache.Set(mxStatsKey(host), data, mxStatsTTL)
+		delete(m.changed, host)
+	}
+}
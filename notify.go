@@ -0,0 +1,200 @@
+package mailify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// JobReport summarizes a finished bulk job, such as a file run, a file
+// processed by WatchDirectory or a SyncList run, for notifications.
+type JobReport struct {
+	// Job describes the job, such as the files or list it processed.
+	Job string `json:"job"`
+	// Summary counts the results.
+	Summary BulkSummary `json:"summary"`
+	// StartedAt is when the job started.
+	StartedAt time.Time `json:"started_at"`
+	// Duration is how long the job took.
+	Duration time.Duration `json:"duration"`
+	// Results is where the results are, a path or a URL, if anywhere.
+	Results string `json:"results,omitempty"`
+	// Error describes why the job failed, in whole or in part, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Text renders the report as a short plain text message.
+//
+// Returns:
+//   - string: The message, one fact per line.
+func (r JobReport) Text() string {
+	var b strings.Builder
+	status := "finished"
+	if r.Error != "" {
+		status = "finished with errors"
+	}
+	fmt.Fprintf(&b, "mailify: %s %s in %s\n", r.Job, status, r.Duration.Round(time.Second))
+	fmt.Fprintf(&b, "Total: %d", r.Summary.Total)
+	for _, verdict := range []Verdict{VerdictDeliverable, VerdictUndeliverable, VerdictRisky, VerdictUnknown} {
+		fmt.Fprintf(&b, ", %s: %d", verdict, r.Summary.Verdicts[verdict])
+	}
+	fmt.Fprintf(&b, ", errors: %d\n", r.Summary.Errors)
+	if r.Results != "" {
+		fmt.Fprintf(&b, "Results: %s\n", r.Results)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", r.Error)
+	}
+	return b.String()
+}
+
+// Notifier sends a JobReport somewhere people will see it, so long-running
+// jobs don't need watching. Set them in BulkOptions.Notifiers.
+type Notifier interface {
+	Notify(ctx context.Context, report JobReport) error
+}
+
+// SlackNotifier posts job reports to a Slack incoming webhook.
+type SlackNotifier struct {
+	// WebhookURL is the incoming webhook's URL.
+	WebhookURL string
+	// HTTPClient sends the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Notify implements Notifier.
+func (s *SlackNotifier) Notify(ctx context.Context, report JobReport) error {
+	if err := postJSON(ctx, s.HTTPClient, s.WebhookURL, map[string]string{"text": report.Text()}); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// DiscordNotifier posts job reports to a Discord webhook.
+type DiscordNotifier struct {
+	// WebhookURL is the webhook's URL.
+	WebhookURL string
+	// HTTPClient sends the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Notify implements Notifier.
+func (d *DiscordNotifier) Notify(ctx context.Context, report JobReport) error {
+	if err := postJSON(ctx, d.HTTPClient, d.WebhookURL, map[string]string{"content": report.Text()}); err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
+
+// WebhookNotifier posts job reports as JSON to any URL, for services of
+// one's own to act on.
+type WebhookNotifier struct {
+	// URL is where reports are posted.
+	URL string
+	// HTTPClient sends the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Notify implements Notifier.
+func (h *WebhookNotifier) Notify(ctx context.Context, report JobReport) error {
+	if err := postJSON(ctx, h.HTTPClient, h.URL, report); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// EmailNotifier emails job reports through an SMTP relay.
+type EmailNotifier struct {
+	// Relay is the SMTP server the reports are sent through.
+	Relay Relay
+	// From is the sender address.
+	From string
+	// To are the addresses the reports are sent to.
+	To []string
+	// Dialer opens the connection to the relay, a net.Dialer if nil.
+	Dialer Dialer
+}
+
+// Notify implements Notifier. Each recipient is sent a copy; a failure for
+// one doesn't stop the others.
+func (e *EmailNotifier) Notify(ctx context.Context, report JobReport) error {
+	if len(e.To) == 0 {
+		return errors.New("email: no recipients")
+	}
+	dialer := e.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	sender := &Client{SenderEmail: e.From, dialer: dialer}
+
+	subject := "mailify: " + report.Job + " finished"
+	body := strings.ReplaceAll(report.Text(), "\n", "\r\n")
+	var errs []error
+	for _, to := range e.To {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := sender.sendMail(e.Relay, to, textMessage(e.From, to, subject, body)); err != nil {
+			errs = append(errs, fmt.Errorf("email to %s: %w", to, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notify sends a report through every notifier, printing a warning for those
+// that fail, as a notification failing doesn't fail the job.
+func notify(notifiers []Notifier, report JobReport) {
+	for _, n := range notifiers {
+		if err := n.Notify(context.Background(), report); err != nil {
+			fmt.Printf("Warning: notification failed: %v\n", err)
+		}
+	}
+}
+
+// newJobReport builds the report of a job that started at start.
+func newJobReport(job string, start time.Time, summary BulkSummary, results string, err error) JobReport {
+	report := JobReport{
+		Job:       job,
+		Summary:   summary,
+		StartedAt: start,
+		Duration:  time.Since(start),
+		Results:   results,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// postJSON POSTs a value to a URL as JSON.
+func postJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook replied %s", resp.Status)
+	}
+	return nil
+}
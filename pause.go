@@ -0,0 +1,91 @@
+package mailify
+
+import (
+	"context"
+	"sync"
+)
+
+// PauseSwitch pauses and resumes a bulk run, e.g. to free up the network or
+// let a provider's rate limit reset during a long job. While it is paused,
+// validations already running finish, but no new ones start. Set it on
+// BulkOptions.Pause. The zero value is a switch that isn't paused. A
+// PauseSwitch is safe for concurrent use.
+type PauseSwitch struct {
+	mu sync.Mutex
+	// cond wakes the waiting workers. It is created under mu by the first
+	// wait, so the zero value is usable.
+	cond   *sync.Cond
+	paused bool
+}
+
+// NewPauseSwitch creates a switch that isn't paused.
+func NewPauseSwitch() *PauseSwitch {
+	return &PauseSwitch{}
+}
+
+// Pause stops new validations from starting until Resume is called.
+func (p *PauseSwitch) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+}
+
+// Resume lets validations start again.
+func (p *PauseSwitch) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = false
+	p.wake()
+}
+
+// Toggle pauses the run if it is running and resumes it if it is paused.
+//
+// Returns:
+//   - bool: Whether the run is paused afterwards.
+func (p *PauseSwitch) Toggle() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = !p.paused
+	if !p.paused {
+		p.wake()
+	}
+	return p.paused
+}
+
+// Paused reports whether the run is paused.
+func (p *PauseSwitch) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// wait blocks while the switch is paused, or until ctx is done. A nil
+// switch never blocks.
+func (p *PauseSwitch) wait(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	stop := context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.wake()
+	})
+	defer stop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cond == nil {
+		p.cond = sync.NewCond(&p.mu)
+	}
+	for p.paused && ctx.Err() == nil {
+		p.cond.Wait()
+	}
+}
+
+// wake wakes the workers waiting on the switch, if any. The caller must hold
+// mu.
+func (p *PauseSwitch) wake() {
+	if p.cond != nil {
+		p.cond.Broadcast()
+	}
+}
@@ -0,0 +1,90 @@
+package mailify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitReturns reports whether p.wait(ctx) returns within d.
+func waitReturns(p *PauseSwitch, ctx context.Context, d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		p.wait(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func TestPauseSwitch(t *testing.T) {
+	switches := map[string]func() *PauseSwitch{
+		"zero value":     func() *PauseSwitch { return &PauseSwitch{} },
+		"NewPauseSwitch": NewPauseSwitch,
+	}
+	for name, newSwitch := range switches {
+		t.Run(name, func(t *testing.T) {
+			p := newSwitch()
+			if p.Paused() {
+				t.Fatal("new switch is paused")
+			}
+			if !waitReturns(p, context.Background(), time.Second) {
+				t.Fatal("wait blocked on a switch that isn't paused")
+			}
+
+			p.Pause()
+			if !p.Paused() {
+				t.Fatal("Paused() = false after Pause")
+			}
+			done := make(chan struct{})
+			go func() {
+				p.wait(context.Background())
+				close(done)
+			}()
+			select {
+			case <-done:
+				t.Fatal("wait returned while paused")
+			case <-time.After(50 * time.Millisecond):
+			}
+			p.Resume()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("wait still blocked after Resume")
+			}
+
+			if !p.Toggle() {
+				t.Fatal("Toggle() = false, want paused")
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if !waitReturns(p, ctx, time.Second) {
+				t.Fatal("wait blocked after its context was cancelled")
+			}
+			if p.Toggle() {
+				t.Fatal("Toggle() = true, want resumed")
+			}
+		})
+	}
+}
+
+func TestPauseSwitchResumeBeforeWait(t *testing.T) {
+	var p PauseSwitch
+	p.Resume()
+	p.Toggle()
+	p.Toggle()
+	if !waitReturns(&p, context.Background(), time.Second) {
+		t.Fatal("wait blocked on a resumed zero-value switch")
+	}
+}
+
+func TestNilPauseSwitchNeverBlocks(t *testing.T) {
+	var p *PauseSwitch
+	if !waitReturns(p, context.Background(), time.Second) {
+		t.Fatal("wait blocked on a nil switch")
+	}
+}
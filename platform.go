@@ -0,0 +1,25 @@
+//go:build !(js && wasm)
+
+package mailify
+
+import (
+	"context"
+	"net"
+)
+
+// defaultResolver returns a resolver that queries Google's public DNS server.
+func defaultResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, "8.8.8.8:53") // Use Google DNS
+		},
+	}
+}
+
+// platformMode returns the mode validation runs in on this platform when
+// asked for mode: any mode, as every check can run.
+func platformMode(mode ValidationMode) ValidationMode {
+	return mode
+}
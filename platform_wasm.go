@@ -0,0 +1,24 @@
+//go:build js && wasm
+
+package mailify
+
+import "net"
+
+// wasmDoHURL is the DoH endpoint WebAssembly builds query by default,
+// Google's, which answers browsers' cross-origin requests.
+const wasmDoHURL = "https://dns.google/dns-query"
+
+// defaultResolver returns a resolver that queries Google's public DNS server
+// over DNS-over-HTTPS, as WebAssembly has no sockets but can fetch.
+func defaultResolver() *net.Resolver {
+	return NewDoHResolver(wasmDoHURL, nil)
+}
+
+// platformMode returns the mode validation runs in on this platform when
+// asked for mode: at most ModeDNSOnly, as SMTP needs raw sockets.
+func platformMode(mode ValidationMode) ValidationMode {
+	if mode == ModeFull {
+		return ModeDNSOnly
+	}
+	return mode
+}
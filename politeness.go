@@ -0,0 +1,219 @@
+package mailify
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderPoliteness is how gently the mail servers of a provider are
+// checked, so its rate limits and reputation systems don't turn on the
+// verifying host. The limits are the client's, across every domain whose
+// mail the provider runs. See DefaultProviderPoliteness.
+type ProviderPoliteness struct {
+	// Match is a mail server name, such as "google.com", that matches itself
+	// and its subdomains.
+	Match string `json:"match"`
+	// Provider names who runs the mail servers, such as Google.
+	Provider string `json:"provider,omitempty"`
+	// MaxConnections is the most SMTP conversations at once with the
+	// provider's mail servers. 0 means no cap.
+	MaxConnections int `json:"max_connections,omitempty"`
+	// ProbesPerMinute is the most SMTP conversations started with the
+	// provider's mail servers in a minute, spread evenly. 0 means no cap.
+	ProbesPerMinute int `json:"probes_per_minute,omitempty"`
+	// PreferredPort is the port tried first, such as "25". Empty keeps the
+	// usual order.
+	PreferredPort string `json:"preferred_port,omitempty"`
+	// NullSender sends MAIL FROM:<>, the sender of bounces, which mail
+	// servers must accept, in place of the validation's sender.
+	NullSender bool `json:"null_sender,omitempty"`
+}
+
+// defaultProviderPoliteness are tuned for the providers that run the most
+// mailboxes, and the gateway vendors in front of many more. Gateways check
+// the sender's domain and reputation on MAIL FROM, so they are given the
+// null sender.
+var defaultProviderPoliteness = []ProviderPoliteness{
+	{Match: "google.com", Provider: "Google", MaxConnections: 5, ProbesPerMinute: 60, PreferredPort: "25"},
+	{Match: "googlemail.com", Provider: "Google", MaxConnections: 5, ProbesPerMinute: 60, PreferredPort: "25"},
+	{Match: "outlook.com", Provider: "Microsoft", MaxConnections: 5, ProbesPerMinute: 60, PreferredPort: "25"},
+	{Match: "hotmail.com", Provider: "Microsoft", MaxConnections: 5, ProbesPerMinute: 60, PreferredPort: "25"},
+	{Match: "yahoodns.net", Provider: "Yahoo", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25"},
+	{Match: "icloud.com", Provider: "Apple", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25"},
+	{Match: "zoho.com", Provider: "Zoho", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25"},
+	{Match: "yandex.net", Provider: "Yandex", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25"},
+	{Match: "gmx.net", Provider: "GMX", MaxConnections: 2, ProbesPerMinute: 20, PreferredPort: "25"},
+	{Match: "mail.ru", Provider: "Mail.ru", MaxConnections: 2, ProbesPerMinute: 20, PreferredPort: "25"},
+	{Match: "pphosted.com", Provider: "Proofpoint", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25", NullSender: true},
+	{Match: "mimecast.com", Provider: "Mimecast", MaxConnections: 2, ProbesPerMinute: 20, PreferredPort: "25", NullSender: true},
+	{Match: "messagelabs.com", Provider: "Broadcom", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25", NullSender: true},
+	{Match: "iphmx.com", Provider: "Cisco", MaxConnections: 3, ProbesPerMinute: 30, PreferredPort: "25", NullSender: true},
+	{Match: "barracudanetworks.com", Provider: "Barracuda", MaxConnections: 2, ProbesPerMinute: 20, PreferredPort: "25", NullSender: true},
+}
+
+// DefaultProviderPoliteness returns the politeness clients apply unless
+// WithoutProviderPoliteness is given, for Google, Microsoft, Yahoo, Apple and
+// other large providers, and for the Proofpoint, Mimecast, Broadcom, Cisco
+// and Barracuda gateways.
+//
+// Returns:
+//   - []ProviderPoliteness: A copy of the settings.
+func DefaultProviderPoliteness() []ProviderPoliteness {
+	return append([]ProviderPoliteness(nil), defaultProviderPoliteness...)
+}
+
+// WithProviderPoliteness adds politeness settings to the client's, applied
+// to the mail servers they match as validations reach them. Settings whose
+// Match is the same as shipped ones' replace them. Where several match a
+// mail server, the longest Match wins.
+func WithProviderPoliteness(politeness ...ProviderPoliteness) Option {
+	return func(c *Client) {
+		for _, p := range politeness {
+			c.politeness[strings.ToLower(strings.TrimSuffix(p.Match, "."))] = p
+		}
+	}
+}
+
+// WithoutProviderPoliteness drops the client's politeness settings,
+// including the shipped ones, so every mail server is checked as the
+// client's other options say. Settings added after it with
+// WithProviderPoliteness still apply.
+func WithoutProviderPoliteness() Option {
+	return func(c *Client) {
+		c.politeness = make(map[string]ProviderPoliteness)
+	}
+}
+
+// checkProviderPoliteness checks that politeness settings have a Match,
+// limits that aren't negative and a numeric port.
+func checkProviderPoliteness(politeness []ProviderPoliteness) error {
+	for _, p := range politeness {
+		if strings.TrimSuffix(p.Match, ".") == "" {
+			return errors.New("politeness settings without a match")
+		}
+		if p.MaxConnections < 0 || p.ProbesPerMinute < 0 {
+			return fmt.Errorf("politeness settings for %s: negative limit", p.Match)
+		}
+		if p.PreferredPort != "" {
+			if port, err := strconv.Atoi(p.PreferredPort); err != nil || port < 1 || port > 65535 {
+				return fmt.Errorf("politeness settings for %s: invalid port %q", p.Match, p.PreferredPort)
+			}
+		}
+	}
+	return nil
+}
+
+// newPolitenessRegistry returns the politeness settings clients start with,
+// the default ones by Match.
+func newPolitenessRegistry() map[string]ProviderPoliteness {
+	registry := make(map[string]ProviderPoliteness)
+	for _, p := range defaultProviderPoliteness {
+		registry[p.Match] = p
+	}
+	return registry
+}
+
+// PolitenessFor returns the politeness settings the client applies to a
+// mail server.
+//
+// Parameters:
+//   - mailServer: The mail server's name, such as aspmx.l.google.com.
+//
+// Returns:
+//   - ProviderPoliteness: The settings.
+//   - bool: False if none match the mail server.
+func (c *Client) PolitenessFor(mailServer string) (ProviderPoliteness, bool) {
+	return matchSuffix(c.politeness, mailServer)
+}
+
+// mailFrom returns the MAIL FROM address of a validation's conversation
+// with a mail server: the null sender if the server's provider is given
+// it, the validation's sender otherwise.
+func (c *Client) mailFrom(v *validation, mailServer string) string {
+	if p, ok := c.PolitenessFor(mailServer); ok && p.NullSender {
+		return ""
+	}
+	return c.senderOf(v)
+}
+
+// providerGate holds back conversations with the mail servers of one
+// provider beyond its politeness settings.
+type providerGate struct {
+	// slots holds a token for each conversation in progress, nil if there is no cap.
+	slots chan struct{}
+	// interval is the least time between the starts of two conversations, 0 for none.
+	interval time.Duration
+
+	mu sync.Mutex
+	// next is when the next conversation may start.
+	next time.Time
+}
+
+// gate returns the gate of the provider whose politeness settings apply to
+// a mail server, nil if none do or they set no limits.
+func (c *Client) gate(mailServer string) *providerGate {
+	p, ok := c.PolitenessFor(mailServer)
+	if !ok || p.MaxConnections <= 0 && p.ProbesPerMinute <= 0 {
+		return nil
+	}
+	c.gatesMu.Lock()
+	defer c.gatesMu.Unlock()
+	g, ok := c.gates[p.Match]
+	if !ok {
+		g = &providerGate{}
+		if p.MaxConnections > 0 {
+			g.slots = make(chan struct{}, p.MaxConnections)
+		}
+		if p.ProbesPerMinute > 0 {
+			g.interval = time.Minute / time.Duration(p.ProbesPerMinute)
+		}
+		c.gates[p.Match] = g
+	}
+	return g
+}
+
+// politely waits until a conversation with a mail server may start, as its
+// provider's politeness settings allow, and returns the function that ends
+// it. It gives up with ErrValidationTimeout if the wait would pass deadline,
+// unless that is zero.
+func (c *Client) politely(mailServer string, deadline time.Time) (func(), error) {
+	g := c.gate(mailServer)
+	if g == nil {
+		return func() {}, nil
+	}
+	release := func() {}
+	if g.slots != nil {
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			timer := time.NewTimer(time.Until(deadline))
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case g.slots <- struct{}{}:
+			release = func() { <-g.slots }
+		case <-timeout:
+			return nil, ErrValidationTimeout
+		}
+	}
+	if g.interval > 0 {
+		g.mu.Lock()
+		start := time.Now()
+		if g.next.After(start) {
+			start = g.next
+		}
+		if !deadline.IsZero() && start.After(deadline) {
+			g.mu.Unlock()
+			release()
+			return nil, ErrValidationTimeout
+		}
+		g.next = start.Add(g.interval)
+		g.mu.Unlock()
+		time.Sleep(time.Until(start))
+	}
+	return release, nil
+}
@@ -0,0 +1,137 @@
+package mailify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sessionPool keeps idle SMTP sessions so that consecutive validations against
+// the same server during a bulk run can skip connecting, EHLO and STARTTLS.
+// Sessions are closed if they sit idle for longer than idleTimeout.
+type sessionPool struct {
+	mu          sync.Mutex
+	idle        map[string][]*pooledSession
+	servers     map[string]SMTPDetails
+	idleTimeout time.Duration
+	closed      bool
+}
+
+// pooledSession is an idle session together with the timer that expires it.
+type pooledSession struct {
+	session *smtpSession
+	timer   *time.Timer
+}
+
+// newSessionPool creates an empty pool whose sessions expire after idleTimeout.
+func newSessionPool(idleTimeout time.Duration) *sessionPool {
+	return &sessionPool{
+		idle:        make(map[string][]*pooledSession),
+		servers:     make(map[string]SMTPDetails),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// sessionKey identifies sessions that can be shared: same server, address,
+// port and TLS mode.
+func sessionKey(smtpDetails *SMTPDetails, useTLS bool) string {
+	return fmt.Sprintf("%s|%s|%s|%v", smtpDetails.Server, smtpDetails.IPAddress, smtpDetails.Port, useTLS)
+}
+
+// get takes an idle session for key out of the pool, or returns nil if there is none.
+func (p *sessionPool) get(key string) *smtpSession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sessions := p.idle[key]
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ps := sessions[len(sessions)-1]
+	p.idle[key] = sessions[:len(sessions)-1]
+	ps.timer.Stop()
+	return ps.session
+}
+
+// server returns the SMTP server details last used with a pooled session for
+// the given mail server, so GetSMTPServer's port discovery can be skipped.
+// It returns nil if there is no idle session for the mail server.
+func (p *sessionPool) server(mailServer string, useTLS bool) *SMTPDetails {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	details, ok := p.servers[mailServer]
+	if !ok || len(p.idle[sessionKey(&details, useTLS)]) == 0 {
+		return nil
+	}
+	return &details
+}
+
+// put returns a session to the pool for key. The session is closed if it is
+// not taken out again within the idle timeout, or right away if the pool is closed.
+func (p *sessionPool) put(key string, smtpDetails *SMTPDetails, session *smtpSession) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		session.quit()
+		session.close()
+		return
+	}
+	defer p.mu.Unlock()
+
+	p.servers[smtpDetails.Server] = *smtpDetails
+
+	ps := &pooledSession{session: session}
+	ps.timer = time.AfterFunc(p.idleTimeout, func() {
+		p.remove(key, ps)
+	})
+	p.idle[key] = append(p.idle[key], ps)
+}
+
+// remove drops an expired session from the pool and closes it.
+func (p *sessionPool) remove(key string, ps *pooledSession) {
+	p.mu.Lock()
+	found := false
+	sessions := p.idle[key]
+	for i, s := range sessions {
+		if s == ps {
+			p.idle[key] = append(sessions[:i], sessions[i+1:]...)
+			found = true
+			break
+		}
+	}
+	p.mu.Unlock()
+
+	// The session may have been taken out just as the timer fired
+	if !found {
+		return
+	}
+	ps.session.quit()
+	ps.session.close()
+}
+
+// closeAll closes every idle session in the pool.
+func (p *sessionPool) closeAll() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = make(map[string][]*pooledSession)
+	p.servers = make(map[string]SMTPDetails)
+	p.mu.Unlock()
+
+	for _, sessions := range idle {
+		for _, ps := range sessions {
+			ps.timer.Stop()
+			ps.session.quit()
+			ps.session.close()
+		}
+	}
+}
+
+// close closes every idle session and stops the pool from keeping new ones.
+func (p *sessionPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.closeAll()
+}
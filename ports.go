@@ -0,0 +1,116 @@
+package mailify
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DefaultSMTPPorts are the ports a mail server's SMTP service is looked for
+// on, in order, unless WithPorts says otherwise. Mail servers receive mail
+// for their domains on 25; submission (587) and SMTPS (465) are for mail
+// clients, and only tried when 25 is blocked.
+var DefaultSMTPPorts = []string{"25", "587", "465"}
+
+// TLSMode says how connections to mail servers are secured.
+type TLSMode string
+
+const (
+	// TLSAuto starts in plain text, upgrading with STARTTLS when a retry is
+	// made, and uses implicit TLS on port 465. It is the default.
+	TLSAuto TLSMode = "auto"
+	// TLSStartTLS upgrades every connection with STARTTLS from the first
+	// attempt, and fails with servers that don't offer it. Port 465 keeps
+	// implicit TLS.
+	TLSStartTLS TLSMode = "starttls"
+	// TLSImplicit starts every connection with a TLS handshake, whatever
+	// its port.
+	TLSImplicit TLSMode = "implicit"
+	// TLSNone never upgrades with STARTTLS. Port 465 keeps implicit TLS, as
+	// nothing else is spoken on it.
+	TLSNone TLSMode = "none"
+)
+
+// ParseTLSMode parses a TLS mode as --tls takes it: auto, starttls,
+// implicit or none.
+//
+// Parameters:
+//   - name: The mode's name.
+//
+// Returns:
+//   - TLSMode: The mode.
+//   - error: An error if name isn't a mode.
+func ParseTLSMode(name string) (TLSMode, error) {
+	switch mode := TLSMode(name); mode {
+	case TLSAuto, TLSStartTLS, TLSImplicit, TLSNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown TLS mode %q, want auto, starttls, implicit or none", name)
+}
+
+// WithPorts sets the ports a mail server's SMTP service is looked for on,
+// in order, in place of DefaultSMTPPorts, e.g. only 25 to never fall back
+// to the submission ports. A preferred port of a provider's politeness
+// settings is only tried first if it is among them. Invalid ports are
+// ignored, and if none is left the default ports are kept.
+func WithPorts(ports ...string) Option {
+	return func(c *Client) {
+		var valid []string
+		for _, port := range ports {
+			if n, err := strconv.Atoi(port); err == nil && n > 0 && n <= 65535 {
+				valid = append(valid, port)
+			}
+		}
+		if len(valid) > 0 {
+			c.ports = valid
+		}
+	}
+}
+
+// WithTLSMode sets how connections to mail servers are secured, TLSAuto by
+// default.
+func WithTLSMode(mode TLSMode) Option {
+	return func(c *Client) {
+		c.tlsMode = mode
+	}
+}
+
+// smtpPorts returns the ports to look for a mail server's SMTP service on,
+// in order, with its provider's preferred port first if it is one of them.
+func (c *Client) smtpPorts(mailServer string) []string {
+	ports := c.ports
+	if len(ports) == 0 {
+		ports = DefaultSMTPPorts
+	}
+	p, ok := c.PolitenessFor(mailServer)
+	if !ok || p.PreferredPort == "" || !containsString(ports, p.PreferredPort) {
+		return ports
+	}
+	ordered := []string{p.PreferredPort}
+	for _, port := range ports {
+		if port != p.PreferredPort {
+			ordered = append(ordered, port)
+		}
+	}
+	return ordered
+}
+
+// implicitTLS reports whether connections to a port start with a TLS handshake.
+func (c *Client) implicitTLS(port string) bool {
+	return port == "465" || c.tlsMode == TLSImplicit
+}
+
+// startTLS reports whether a connection to a port that doesn't start with a
+// TLS handshake is upgraded with STARTTLS, useTLS being whether the attempt
+// asks for it.
+func (c *Client) startTLS(port string, useTLS bool) bool {
+	if c.implicitTLS(port) {
+		return false
+	}
+	switch c.tlsMode {
+	case TLSStartTLS:
+		return true
+	case TLSNone:
+		return false
+	}
+	return useTLS
+}
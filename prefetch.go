@@ -0,0 +1,62 @@
+package mailify
+
+import (
+	"context"
+	"sync"
+)
+
+// prefetchDNS looks up the MX records of every distinct domain among emails,
+// and in ModeFull the addresses of their mail servers, with up to workers
+// lookups at once, so the validations that follow find them in the client's
+// MX cache instead of each waiting on DNS. Addresses marked in skip are left
+// out. It does nothing without WithMXCache, which holds what it looks up, or
+// in ModeOffline, and stops early once ctx is done.
+func (c *Client) prefetchDNS(ctx context.Context, emails []string, skip []bool, workers int) {
+	if c.mxCache == nil || c.mode == ModeOffline || workers < 1 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var domains []string
+	for i, email := range emails {
+		if skip != nil && skip[i] {
+			continue
+		}
+		domain := emailDomain(email)
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+
+	jobs := make(chan string)
+	var hosts sync.Map
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(domains); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range jobs {
+				mailServers, err := c.GetMailServers(domain)
+				if err != nil || c.mode != ModeFull {
+					continue
+				}
+				for _, mailServer := range mailServers {
+					// Domains hosted by the same provider share mail servers
+					if _, done := hosts.LoadOrStore(mailServer, true); !done {
+						c.lookupIP(mailServer)
+					}
+				}
+			}
+		}()
+	}
+
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- domain
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,175 @@
+package mailify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// PIIMode controls how email addresses appear in what mailify records
+// besides the result itself: the stage events given to hooks, the errors
+// validations return, error messages and cache keys.
+type PIIMode int
+
+const (
+	// PIIPlain records addresses as they are. This is the default.
+	PIIPlain PIIMode = iota
+	// PIIHash replaces addresses with a keyed hash, e.g. hmac:3f2a…, so the
+	// same address can still be correlated across records.
+	PIIHash
+	// PIIRedact replaces the local part with the keyed hash but keeps the
+	// domain, e.g. hmac:3f2a…@example.com, which helps tell provider
+	// problems apart.
+	PIIRedact
+)
+
+// String returns the name of the mode as accepted by ParsePIIMode.
+func (m PIIMode) String() string {
+	switch m {
+	case PIIHash:
+		return "hash"
+	case PIIRedact:
+		return "redact"
+	default:
+		return "plain"
+	}
+}
+
+// ParsePIIMode parses "plain", "hash" or "redact".
+//
+// Parameters:
+//   - name: The name of the mode.
+//
+// Returns:
+//   - PIIMode: The mode.
+//   - bool: False if the name is unknown.
+func ParsePIIMode(name string) (PIIMode, bool) {
+	for _, m := range []PIIMode{PIIPlain, PIIHash, PIIRedact} {
+		if m.String() == name {
+			return m, true
+		}
+	}
+	return PIIPlain, false
+}
+
+// WithPIIMode keeps email addresses out of what mailify records besides the
+// result: StageEvent.Email and StageEvent.Err as hooks see them, the errors
+// validations return, ValidationResult.ErrorMessage, where servers often
+// echo the address, the cache keys of confirmations and results, and the
+// results WithResultCache keeps. Addresses are
+// replaced with an HMAC-SHA256 keyed with key, so records about the same
+// address can still be correlated without revealing it. Keep key secret and
+// the same across runs; anyone who has it can test guesses against the hashes.
+//
+// Addresses given to OnStart and OnResult hooks, custom stages and results
+// are left alone, as they decide what happens to the address. Use
+// RedactEmail to keep them out of application logs and metrics labels too.
+func WithPIIMode(mode PIIMode, key []byte) Option {
+	return func(c *Client) {
+		c.piiMode = mode
+		c.piiKey = append([]byte(nil), key...)
+	}
+}
+
+// RedactEmail returns an address as the client's PIIMode records it: as it
+// is in PIIPlain, or with the whole address or its local part replaced by its
+// keyed hash. The address is normalized and lowercased first, so every
+// spelling of it hashes the same.
+//
+// Parameters:
+//   - email: The address to redact.
+//
+// Returns:
+//   - string: The address to record.
+func (c *Client) RedactEmail(email string) string {
+	if c.piiMode == PIIPlain || email == "" {
+		return email
+	}
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		normalized = strings.TrimSpace(email)
+	}
+	normalized = strings.ToLower(normalized)
+
+	mac := hmac.New(sha256.New, c.piiKey)
+	mac.Write([]byte(normalized))
+	hash := "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+
+	if c.piiMode == PIIRedact {
+		if at := strings.LastIndex(normalized, "@"); at >= 0 {
+			return hash + normalized[at:]
+		}
+	}
+	return hash
+}
+
+// redactText replaces every occurrence of the given addresses in text,
+// ignoring case, with their redacted form.
+func (c *Client) redactText(text string, emails ...string) string {
+	if c.piiMode == PIIPlain {
+		return text
+	}
+	for _, email := range emails {
+		if email == "" {
+			continue
+		}
+		replacement := c.RedactEmail(email)
+		lower, target := strings.ToLower(text), strings.ToLower(email)
+		if len(lower) != len(text) {
+			// Lowercasing changed the length, so indexes wouldn't line up
+			text = strings.ReplaceAll(text, email, replacement)
+			continue
+		}
+		var b strings.Builder
+		for {
+			i := strings.Index(lower, target)
+			if i < 0 {
+				break
+			}
+			b.WriteString(text[:i])
+			b.WriteString(replacement)
+			text, lower = text[i+len(target):], lower[i+len(target):]
+		}
+		b.WriteString(text)
+		text = b.String()
+	}
+	return text
+}
+
+// redactResult returns a copy of a result to record, with its address
+// redacted and, unless in PIIPlain, its display name left out. The error
+// message was redacted when the result was finished.
+func (c *Client) redactResult(result *ValidationResult) *ValidationResult {
+	redacted := *result
+	redacted.NormalizedEmail = c.RedactEmail(result.NormalizedEmail)
+	if c.piiMode != PIIPlain {
+		redacted.DisplayName = ""
+	}
+	return &redacted
+}
+
+// redactedError is an error whose message has had addresses redacted. It
+// still unwraps to the original, so errors.Is and errors.As keep working.
+type redactedError struct {
+	msg string
+	err error
+}
+
+// Error implements error.
+func (e *redactedError) Error() string { return e.msg }
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redactError returns err with the given addresses redacted from its message.
+func (c *Client) redactError(err error, emails ...string) error {
+	if err == nil || c.piiMode == PIIPlain {
+		return err
+	}
+	msg := c.redactText(err.Error(), emails...)
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
@@ -0,0 +1,128 @@
+package mailify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultProbePorts are the SMTP ports that ProbePorts checks unless WithPorts
+// is given.
+var defaultProbePorts = []string{"25", "465", "587"}
+
+// defaultProbeTimeout is how long a port probe may take after connecting,
+// unless WithTimeout allows a different time.
+const defaultProbeTimeout = 10 * time.Second
+
+// ProbePorts checks every common SMTP port (25, 465, 587), or the ports WithPorts
+// gave, on the given mail server, plus any additional ports supplied, and reports
+// on each one. Unlike GetSMTPServer, which stops at the first port that accepts a
+// connection, all ports are probed in parallel so the result gives a complete
+// picture of the server.
+//
+// For every port that accepts a connection the greeting banner is read, EHLO is sent
+// to discover whether TLS is available, and the session is closed with QUIT. Each
+// connection attempt may take as long as WithConnectTimeout allows, and the rest of
+// the probe as long as WithTimeout does, 10 seconds without it.
+//
+// Parameters:
+//   - mailServer: The hostname of the mail server to probe.
+//   - ports: Additional ports to probe besides the client's.
+//
+// Returns:
+//   - []PortProbe: One entry per port, in the order the ports were checked.
+//   - error: An error if the mail server's IP addresses could not be looked up.
+func (c *Client) ProbePorts(mailServer string, ports ...string) ([]PortProbe, error) {
+	ips, err := c.lookupIP(mailServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup IP for %s: %v", mailServer, err)
+	}
+
+	// The HELO name only matters for the EHLO response, so fall back to
+	// whatever GetHostname came up with when it can't find an FQDN.
+	localName, _ := c.GetHostname()
+
+	// Always probe the client's ports first, followed by any custom ones
+	clientPorts := c.ports
+	if len(clientPorts) == 0 {
+		clientPorts = defaultProbePorts
+	}
+	var allPorts []string
+	seen := make(map[string]bool)
+	for _, port := range append(append([]string{}, clientPorts...), ports...) {
+		if !seen[port] {
+			seen[port] = true
+			allPorts = append(allPorts, port)
+		}
+	}
+
+	probes := make([]PortProbe, len(allPorts))
+	var wg sync.WaitGroup
+	for i, port := range allPorts {
+		wg.Add(1)
+		go func(i int, port string) {
+			defer wg.Done()
+			probes[i] = c.probePort(mailServer, ips, port, localName)
+		}(i, port)
+	}
+	wg.Wait()
+
+	return probes, nil
+}
+
+// probePort connects to a single port, racing the server's addresses, and
+// records the server's banner, extensions and TLS support. A port that
+// accepts the connection is reachable, whether or not the server greets us.
+func (c *Client) probePort(mailServer string, ips []net.IP, port, localName string) PortProbe {
+	probe := PortProbe{Port: port}
+
+	start := time.Now()
+	conn, ip, err := c.dialHappyEyeballs(ips, port, c.connectTimeout)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	probe.Reachable = true
+	probe.IPAddress = ip.String()
+	probe.Latency = time.Since(start)
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if c.implicitTLS(port) {
+		conn = tls.Client(conn, &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         mailServer,
+		})
+	}
+
+	session, err := newSMTPSession(conn, mailServer)
+	if err != nil {
+		conn.Close()
+		probe.Error = fmt.Sprintf("failed to read greeting: %v", err)
+		return probe
+	}
+	defer session.close()
+
+	probe.Greeted = true
+	probe.Latency = time.Since(start)
+	probe.Banner = session.banner
+	probe.TLS = session.tls
+
+	if err := session.hello(localName); err != nil {
+		probe.Error = fmt.Sprintf("HELO failed: %v", err)
+		return probe
+	}
+	probe.Extensions = session.ext
+	if ok, _ := session.extension("STARTTLS"); ok {
+		probe.TLS = true
+	}
+
+	session.quit()
+	return probe
+}
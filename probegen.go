@@ -0,0 +1,193 @@
+package mailify
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultProbeLength is the length of the random part of a probe mailbox,
+// unless the ProbeGenerator says otherwise.
+const defaultProbeLength = 12
+
+// defaultProbeCharset is what the random part of a probe mailbox is drawn
+// from, unless the ProbeGenerator says otherwise.
+const defaultProbeCharset = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// maxProbeAttempts is how many mailboxes are drawn for a probe before one
+// with an avoided pattern is taken anyway, as the charset may leave no other.
+const maxProbeAttempts = 100
+
+// DefaultProbeAvoid lists what some spam and anti-harvesting filters look
+// for in the mailboxes of verification probes. A ProbeGenerator redraws any
+// mailbox containing one of them.
+var DefaultProbeAvoid = []string{
+	"test", "fake", "invalid", "nonexist", "noexist", "random", "probe", "verify",
+	"check", "mailify", "bounce", "dummy", "bogus", "null", "noreply", "xxx",
+}
+
+// ProbeGenerator makes up the random mailboxes catch-all probes ask for, see
+// WithProbeGenerator; the last probe's follows a fixed known-bad pattern. Every field is optional. Mailboxes depend only on the
+// generator, the probe seed (see WithProbeSeed) and the domain, so a test
+// run given the same ones probes the same mailboxes.
+type ProbeGenerator struct {
+	// Length is the length of the random part of each mailbox, 12 if 0.
+	Length int `json:"length,omitempty"`
+	// Charset holds the characters the random part is drawn from, lowercase
+	// letters and digits if empty. The random part always starts with one
+	// of its letters, if it has any.
+	Charset string `json:"charset,omitempty"`
+	// Prefixes are put before the random part, the first before the first
+	// probe's, the second before the second's and so on, starting over once
+	// they run out, such as "info." or "j.", so probes look like the
+	// domain's own mailboxes. None if empty.
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Avoid lists what no mailbox may contain, case-insensitively.
+	// DefaultProbeAvoid if nil; an empty list avoids nothing.
+	Avoid []string `json:"avoid,omitempty"`
+}
+
+// WithProbeGenerator sets how the made-up mailboxes of catch-all probes are
+// generated, such as their length, the characters they use and a prefix.
+// Without it, they are made up by the zero ProbeGenerator. NewClient fails
+// if the generator doesn't pass Check.
+func WithProbeGenerator(g ProbeGenerator) Option {
+	return func(c *Client) {
+		c.probeGenerator = &g
+	}
+}
+
+// Check reports whether the generator can make up valid mailboxes.
+//
+// Returns:
+//   - error: An error if the length is negative or the charset, a prefix or
+//     the mailboxes made of them aren't valid in a local part.
+func (g ProbeGenerator) Check() error {
+	if g.Length < 0 {
+		return errors.New("probe generator: negative length")
+	}
+	for _, r := range g.Charset {
+		if r == '.' || r >= 0x80 || !validLocalPart(string(r)) {
+			return fmt.Errorf("probe generator: %q can't be in the charset", r)
+		}
+	}
+	for _, prefix := range g.Prefixes {
+		if !validLocalPart(prefix+"x") || len(prefix)+g.length() > 64 {
+			return fmt.Errorf("probe generator: invalid prefix %q", prefix)
+		}
+	}
+	if len(g.Prefixes) == 0 && g.length() > 64 {
+		return errors.New("probe generator: length over 64")
+	}
+	return nil
+}
+
+// length returns the length of the random part of each mailbox.
+func (g ProbeGenerator) length() int {
+	if g.Length > 0 {
+		return g.Length
+	}
+	return defaultProbeLength
+}
+
+// addresses returns n addresses at domain made up by the generator, derived
+// from seed.
+func (g ProbeGenerator) addresses(domain string, n int, seed int64) []string {
+	charset := g.Charset
+	if charset == "" {
+		charset = defaultProbeCharset
+	}
+	var letters []byte
+	for i := 0; i < len(charset); i++ {
+		if b := charset[i]; b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' {
+			letters = append(letters, b)
+		}
+	}
+	avoid := g.Avoid
+	if avoid == nil {
+		avoid = DefaultProbeAvoid
+	}
+
+	probes := make([]string, n)
+	for i := range probes {
+		prefix := ""
+		if len(g.Prefixes) > 0 {
+			prefix = g.Prefixes[i%len(g.Prefixes)]
+		}
+		var local string
+		for attempt := 0; attempt < maxProbeAttempts; attempt++ {
+			local = prefix + randomPart(fmt.Sprintf("%d/%s/%d/%d", seed, domain, i, attempt), charset, letters, g.length())
+			if !containsAny(strings.ToLower(local), avoid) {
+				break
+			}
+		}
+		probes[i] = local + "@" + domain
+	}
+	return probes
+}
+
+// knownBad returns an address at domain that follows a pattern no real
+// mailbox uses: 24 hex digits between "zq" and "qz", derived from seed. It
+// is drawn again like the others if it contains what the generator avoids.
+func (g ProbeGenerator) knownBad(domain string, seed int64) string {
+	avoid := g.Avoid
+	if avoid == nil {
+		avoid = DefaultProbeAvoid
+	}
+	var local string
+	for attempt := 0; attempt < maxProbeAttempts; attempt++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%s/known-bad/%d", seed, domain, attempt)))
+		local = "zq" + hex.EncodeToString(sum[:12]) + "qz"
+		if !containsAny(local, avoid) {
+			break
+		}
+	}
+	return local + "@" + domain
+}
+
+// randomPart draws length characters of charset, the first of letters if
+// there are any, from a stream of hashes of key.
+func randomPart(key, charset string, letters []byte, length int) string {
+	var b strings.Builder
+	var block [sha256.Size]byte
+	for i := 0; i < length; i++ {
+		if i%(sha256.Size/4) == 0 {
+			block = sha256.Sum256([]byte(fmt.Sprintf("%s/%d", key, i)))
+		}
+		n := binary.BigEndian.Uint32(block[i%(sha256.Size/4)*4:])
+		if i == 0 && len(letters) > 0 {
+			b.WriteByte(letters[n%uint32(len(letters))])
+		} else {
+			b.WriteByte(charset[n%uint32(len(charset))])
+		}
+	}
+	return b.String()
+}
+
+// containsAny reports whether s contains any of the non-empty patterns,
+// case-insensitively.
+func containsAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern != "" && strings.Contains(s, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeAddresses returns the made-up addresses the catch-all probes of
+// domain ask for: random ones, then a known-bad one.
+func (c *Client) probeAddresses(domain string) []string {
+	if c.catchAllProbes <= 0 {
+		return nil
+	}
+	var g ProbeGenerator
+	if c.probeGenerator != nil {
+		g = *c.probeGenerator
+	}
+	probes := g.addresses(domain, c.catchAllProbes-1, c.probeSeed)
+	return append(probes, g.knownBad(domain, c.probeSeed))
+}
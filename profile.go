@@ -0,0 +1,385 @@
+package mailify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profile bundles the settings that decide how hard validation pushes on mail
+// servers: timeouts, retries, catch-all probing, concurrency and rate limits.
+// The built-in profiles are ProfileAggressive, ProfilePolite and
+// ProfileOffline, and more can be defined in a config file, see LoadConfig.
+// Apply one with WithProfile. Zero fields leave the client's settings as
+// they are.
+type Profile struct {
+	// Name is the profile's name.
+	Name string
+	// Mode is how much of the network validation may use, see WithMode.
+	// ModeFull, the zero value, leaves the client's mode as it is.
+	Mode ValidationMode
+	// Timeout is the most time a validation may take, see WithTimeout.
+	Timeout time.Duration
+	// ConnectTimeout is the most time a connection attempt may take, see WithConnectTimeout.
+	ConnectTimeout time.Duration
+	// Attempts is how many attempts the retry policy makes, see RetryPolicy.
+	Attempts int
+	// CatchAllProbes is how many made-up mailboxes are probed, see
+	// WithCatchAllProbes. -1 disables catch-all detection.
+	CatchAllProbes int
+	// Concurrency, DomainConcurrency, DomainInterval and BatchSize are the
+	// defaults of the BulkOptions fields of the same names in bulk runs.
+	Concurrency       int
+	DomainConcurrency int
+	DomainInterval    time.Duration
+	BatchSize         int
+}
+
+var (
+	// ProfileAggressive validates as fast as mail servers allow, for
+	// dedicated infrastructure with a good sending reputation: many
+	// addresses at once, batched, with short timeouts and no retries.
+	ProfileAggressive = Profile{
+		Name:              "aggressive",
+		Timeout:           10 * time.Second,
+		ConnectTimeout:    3 * time.Second,
+		Attempts:          1,
+		CatchAllProbes:    1,
+		Concurrency:       32,
+		DomainConcurrency: 8,
+		BatchSize:         10,
+	}
+	// ProfilePolite goes easy on mail servers, to keep a shared IP off
+	// blocklists: few addresses at once, one connection per domain every two
+	// seconds, patient timeouts and retries.
+	ProfilePolite = Profile{
+		Name:              "polite",
+		Timeout:           60 * time.Second,
+		ConnectTimeout:    10 * time.Second,
+		Attempts:          3,
+		Concurrency:       4,
+		DomainConcurrency: 1,
+		DomainInterval:    2 * time.Second,
+	}
+	// ProfileOffline never touches the network, see ModeOffline.
+	ProfileOffline = Profile{
+		Name:        "offline",
+		Mode:        ModeOffline,
+		Concurrency: 16,
+	}
+)
+
+// builtinProfiles are the profiles every config has, by name.
+var builtinProfiles = map[string]Profile{
+	ProfileAggressive.Name: ProfileAggressive,
+	ProfilePolite.Name:     ProfilePolite,
+	ProfileOffline.Name:    ProfileOffline,
+}
+
+// WithProfile applies a profile's client settings: its mode, timeouts,
+// retries and catch-all probing. Its concurrency and rate limits become the
+// defaults of bulk runs, used where BulkOptions leaves them zero. Options
+// given after WithProfile override it.
+func WithProfile(profile Profile) Option {
+	return func(c *Client) {
+		if profile.Mode != ModeFull {
+			c.mode = profile.Mode
+		}
+		if profile.Timeout > 0 {
+			c.timeout = profile.Timeout
+		}
+		if profile.ConnectTimeout > 0 {
+			c.connectTimeout = profile.ConnectTimeout
+		}
+		if profile.Attempts > 0 {
+			c.retry.MaxAttempts = profile.Attempts
+		}
+		switch {
+		case profile.CatchAllProbes > 0:
+			c.catchAllProbes = profile.CatchAllProbes
+		case profile.CatchAllProbes < 0:
+			c.catchAllProbes = 0
+		}
+		c.profile = &profile
+	}
+}
+
+// bulkDefaults fills in the fields opts leaves zero from the client's
+// profile, if it has one.
+func (c *Client) bulkDefaults(opts BulkOptions) BulkOptions {
+	p := c.profile
+	if p == nil {
+		return opts
+	}
+	if opts.Concurrency == 0 && opts.Adaptive == nil {
+		opts.Concurrency = p.Concurrency
+	}
+	if opts.DomainConcurrency == 0 {
+		opts.DomainConcurrency = p.DomainConcurrency
+	}
+	if opts.DomainInterval == 0 {
+		opts.DomainInterval = p.DomainInterval
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = p.BatchSize
+	}
+	return opts
+}
+
+// ProfileConfig is a profile as a config file defines it. Durations are
+// written like "1m30s" and the mode like --mode; settings left out come
+// from the profile it extends, if any.
+type ProfileConfig struct {
+	// Extends names a profile whose settings this one starts from.
+	Extends           string `json:"extends,omitempty"`
+	Mode              string `json:"mode,omitempty"`
+	Timeout           string `json:"timeout,omitempty"`
+	ConnectTimeout    string `json:"connect_timeout,omitempty"`
+	Attempts          int    `json:"attempts,omitempty"`
+	CatchAllProbes    int    `json:"catch_all_probes,omitempty"`
+	Concurrency       int    `json:"concurrency,omitempty"`
+	DomainConcurrency int    `json:"domain_concurrency,omitempty"`
+	DomainInterval    string `json:"domain_interval,omitempty"`
+	BatchSize         int    `json:"batch_size,omitempty"`
+}
+
+// Config is the mailify config file: the profile to use unless another is
+// asked for, and profiles of one's own.
+//
+//	{
+//	  "profile": "nightly",
+//	  "profiles": {
+//	    "nightly": {"extends": "polite", "concurrency": 8, "timeout": "30s"}
+//	  }
+//	}
+//
+// A profile may extend a built-in profile or another one in the file, and
+// override any of its settings. Rules for the accept-all registry, see
+// WithAcceptAllRules, add to or override the shipped ones:
+//
+//	{
+//	  "accept_all": [
+//	    {"match": "example.com", "behavior": "verify"},
+//	    {"match": "mx.gateway.example", "provider": "Gateway", "behavior": "accept_all"}
+//	  ]
+//	}
+//
+// So do politeness settings for providers' mail servers, see
+// WithProviderPoliteness:
+//
+//	{
+//	  "politeness": [
+//	    {"match": "google.com", "max_connections": 2, "probes_per_minute": 20}
+//	  ]
+//	}
+//
+// And how catch-all probes make up mailboxes, see WithProbeGenerator:
+//
+//	{
+//	  "probe_generator": {"length": 10, "prefixes": ["info.", "j."]}
+//	}
+//
+// And domains never probed over SMTP, see WithProtectedDomains:
+//
+//	{
+//	  "protected_domains": ["example.com", "partner.example"]
+//	}
+//
+// And caps on the SMTP probes sent, see WithProbeBudget:
+//
+//	{
+//	  "probe_budget": {"per_domain_per_day": 500, "per_run": 20000}
+//	}
+type Config struct {
+	// Profile is the name of the profile to use by default, if any.
+	Profile string `json:"profile,omitempty"`
+	// Profiles are the profiles defined in the file, by name.
+	Profiles map[string]ProfileConfig `json:"profiles,omitempty"`
+	// AcceptAll are rules for the accept-all registry, see WithAcceptAllRules.
+	AcceptAll []AcceptAllRule `json:"accept_all,omitempty"`
+	// Politeness are politeness settings for providers' mail servers, see
+	// WithProviderPoliteness.
+	Politeness []ProviderPoliteness `json:"politeness,omitempty"`
+	// ProbeGenerator says how catch-all probes make up mailboxes, see
+	// WithProbeGenerator. The default ones if nil.
+	ProbeGenerator *ProbeGenerator `json:"probe_generator,omitempty"`
+	// ProtectedDomains are domains never probed over SMTP, see
+	// WithProtectedDomains.
+	ProtectedDomains []string `json:"protected_domains,omitempty"`
+	// ProbeBudget caps the SMTP probes sent, see WithProbeBudget. No caps
+	// if nil.
+	ProbeBudget *ProbeBudget `json:"probe_budget,omitempty"`
+}
+
+// DefaultConfigPath returns where the config file is looked for unless
+// another is given: mailify/config.json in the user's config directory,
+// such as ~/.config on Linux.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mailify", "config.json"), nil
+}
+
+// LoadConfig reads a config file and checks that its profiles are valid.
+//
+// Parameters:
+//   - path: The JSON file.
+//
+// Returns:
+//   - Config: The configuration.
+//   - error: An error if the file can't be read or parsed, or a profile,
+//     accept-all rule, politeness setting, probe generator or probe budget
+//     is invalid.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	for name := range config.Profiles {
+		if _, err := config.LookupProfile(name); err != nil {
+			return Config{}, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	if err := checkAcceptAllRules(config.AcceptAll); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := checkProviderPoliteness(config.Politeness); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	if b := config.ProbeBudget; b != nil && (b.PerDomainPerDay < 0 || b.PerRun < 0) {
+		return Config{}, fmt.Errorf("invalid config: negative probe budget")
+	}
+	if config.ProbeGenerator != nil {
+		if err := config.ProbeGenerator.Check(); err != nil {
+			return Config{}, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// ProfileNames returns the names of the built-in profiles and those the
+// config defines, sorted.
+func (cfg Config) ProfileNames() []string {
+	var names []string
+	for name := range builtinProfiles {
+		if _, ok := cfg.Profiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupProfile returns the profile with the given name: one the config
+// defines, which takes precedence, or else a built-in one. The zero Config
+// knows the built-in profiles only.
+//
+// Parameters:
+//   - name: The profile's name.
+//
+// Returns:
+//   - Profile: The profile, with the settings of the profiles it extends.
+//   - error: An error if there is no such profile or it is invalid.
+func (cfg Config) LookupProfile(name string) (Profile, error) {
+	return cfg.lookupProfile(name, nil)
+}
+
+// lookupProfile resolves a profile, following what it extends. seen holds
+// the profiles already on the way, to catch profiles that extend themselves.
+func (cfg Config) lookupProfile(name string, seen []string) (Profile, error) {
+	for _, s := range seen {
+		if s == name {
+			return Profile{}, fmt.Errorf("profile %q extends itself", name)
+		}
+	}
+	custom, ok := cfg.Profiles[name]
+	if !ok {
+		if builtin, ok := builtinProfiles[name]; ok {
+			return builtin, nil
+		}
+		return Profile{}, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(cfg.ProfileNames(), ", "))
+	}
+
+	var profile Profile
+	// A profile may share a built-in's name, extending it to override it
+	if custom.Extends != "" {
+		base, on := cfg, append(seen, name)
+		if custom.Extends == name {
+			base, on = Config{}, nil
+		}
+		var err error
+		profile, err = base.lookupProfile(custom.Extends, on)
+		if err != nil {
+			return Profile{}, err
+		}
+	}
+	profile.Name = name
+	if err := custom.apply(&profile); err != nil {
+		return Profile{}, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return profile, nil
+}
+
+// apply overrides the settings of profile that p gives.
+func (p ProfileConfig) apply(profile *Profile) error {
+	if p.Mode != "" {
+		mode, ok := ParseValidationMode(p.Mode)
+		if !ok {
+			return fmt.Errorf("unknown mode %q, expected full, dns or offline", p.Mode)
+		}
+		profile.Mode = mode
+	}
+	durations := []struct {
+		name  string
+		value string
+		field *time.Duration
+	}{
+		{"timeout", p.Timeout, &profile.Timeout},
+		{"connect_timeout", p.ConnectTimeout, &profile.ConnectTimeout},
+		{"domain_interval", p.DomainInterval, &profile.DomainInterval},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		value, err := time.ParseDuration(d.value)
+		if err != nil || value < 0 {
+			return fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.field = value
+	}
+	counts := []struct {
+		name  string
+		value int
+		field *int
+	}{
+		{"attempts", p.Attempts, &profile.Attempts},
+		{"catch_all_probes", p.CatchAllProbes, &profile.CatchAllProbes},
+		{"concurrency", p.Concurrency, &profile.Concurrency},
+		{"domain_concurrency", p.DomainConcurrency, &profile.DomainConcurrency},
+		{"batch_size", p.BatchSize, &profile.BatchSize},
+	}
+	for _, n := range counts {
+		if n.value == 0 {
+			continue
+		}
+		if n.value < 0 && n.name != "catch_all_probes" {
+			return fmt.Errorf("invalid %s %d", n.name, n.value)
+		}
+		*n.field = n.value
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package mailify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithProtectedDomains lists domains whose mail servers are never asked
+// about mailboxes, whatever the input, such as one's own domains and
+// partners'. Their subdomains are protected too. Addresses at them are
+// answered from the result cache of WithResultCache, however old the
+// result, or else by mailify's rules: syntax, MX records, and otherwise
+// VerdictUnknown with SubStatusProtected. They are never sent to the
+// verification APIs of WithFallbackVerifiers either. It can be given more
+// than once.
+func WithProtectedDomains(domains ...string) Option {
+	return func(c *Client) {
+		if c.protected == nil {
+			c.protected = make(map[string]string)
+		}
+		for _, domain := range domains {
+			domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+			if domain != "" {
+				c.protected[domain] = domain
+			}
+		}
+	}
+}
+
+// IsProtectedDomain reports whether a domain is, or is a subdomain of, one
+// given with WithProtectedDomains.
+//
+// Parameters:
+//   - domain: The domain to check.
+//
+// Returns:
+//   - bool: True if the domain's mail servers are never asked about mailboxes.
+func (c *Client) IsProtectedDomain(domain string) bool {
+	_, ok := matchSuffix(c.protected, domain)
+	return ok
+}
+
+// protectedResult returns the result of validating an address at a protected
+// domain in place of the SMTP check, nil if the domain isn't protected: the
+// cached result of the address if there is one, else VerdictUnknown.
+func (c *Client) protectedResult(email, domain string, v *validation) *ValidationResult {
+	match, ok := matchSuffix(c.protected, domain)
+	if !ok {
+		return nil
+	}
+	// Any cached result beats none, as the mail servers can't be asked for a fresher one
+	if result := c.cachedResult(email, &validation{}); result != nil {
+		v.cached = true
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is a protected domain, result from the cache", match))
+		return result
+	}
+	return &ValidationResult{
+		Verdict:      VerdictUnknown,
+		SubStatus:    SubStatusProtected,
+		IsValid:      false,
+		HasMX:        true,
+		ErrorMessage: fmt.Sprintf("SMTP check skipped, %s is a protected domain", match),
+		Provenance:   &Provenance{Source: ProvenanceRules, Detail: "protected domain " + match},
+	}
+}
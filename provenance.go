@@ -0,0 +1,64 @@
+package mailify
+
+import "time"
+
+// ProvenanceSource is the kind of evidence a verdict was derived from.
+type ProvenanceSource string
+
+const (
+	// ProvenanceSMTP means a live SMTP conversation with the domain's mail
+	// servers, even one that failed before the mailbox was checked.
+	ProvenanceSMTP ProvenanceSource = "smtp"
+	// ProvenanceCache means a result kept by WithResultCache, itself derived
+	// from the source in Detail.
+	ProvenanceCache ProvenanceSource = "cache"
+	// ProvenanceRules means mailify's own checks and registries, such as the
+	// syntax check, the MX lookup, the accept-all registry or the mode.
+	ProvenanceRules ProvenanceSource = "rules"
+	// ProvenanceStage means a custom stage, such as a directory lookup, or an
+	// OnStart hook.
+	ProvenanceStage ProvenanceSource = "stage"
+	// ProvenanceSuppressionList means a SuppressionList entry.
+	ProvenanceSuppressionList ProvenanceSource = "suppression_list"
+	// ProvenanceExternalAPI means a third-party verification API.
+	ProvenanceExternalAPI ProvenanceSource = "external_api"
+	// ProvenanceConfirmation means the address was confirmed by opening a
+	// confirmation link.
+	ProvenanceConfirmation ProvenanceSource = "confirmation"
+)
+
+// Provenance says how a result's verdict was derived and when, so consumers
+// can weigh it: a live SMTP answer from a minute ago is worth more than a
+// month-old cached one or a third party's word.
+type Provenance struct {
+	// Source is the kind of evidence the verdict was derived from.
+	Source ProvenanceSource `json:"source"`
+	// Detail names the evidence: the mail server for ProvenanceSMTP, the
+	// original source for ProvenanceCache, the registry entry for
+	// ProvenanceRules, the stage, or the API.
+	Detail string `json:"detail,omitempty"`
+	// At is when the verdict was derived.
+	At time.Time `json:"at"`
+}
+
+// smtpProvenance returns the provenance of a result of the SMTP check.
+func smtpProvenance(result *ValidationResult) *Provenance {
+	provenance := &Provenance{Source: ProvenanceSMTP}
+	if result.SMTPDetails != nil {
+		provenance.Detail = result.SMTPDetails.Server
+	}
+	return provenance
+}
+
+// stampProvenance completes the provenance of a result just validated:
+// confirmed addresses are put down to the confirmation, results without any
+// to mailify's rules, and every one is dated when it was validated.
+func stampProvenance(result *ValidationResult) {
+	switch {
+	case result.SubStatus == SubStatusConfirmed:
+		result.Provenance = &Provenance{Source: ProvenanceConfirmation}
+	case result.Provenance == nil:
+		result.Provenance = &Provenance{Source: ProvenanceRules}
+	}
+	result.Provenance.At = result.ValidatedAt
+}
@@ -0,0 +1,170 @@
+package mailify
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// AuthMechanism is an SMTP AUTH mechanism (RFC 4954) used to log in to a relay.
+type AuthMechanism string
+
+const (
+	// AuthPlain sends the username and password in one step (RFC 4616).
+	AuthPlain AuthMechanism = "PLAIN"
+	// AuthLogin sends the username and password in answer to the server's prompts.
+	AuthLogin AuthMechanism = "LOGIN"
+	// AuthXOAUTH2 logs in with an OAuth 2.0 access token, as Gmail and
+	// Microsoft 365 expect.
+	AuthXOAUTH2 AuthMechanism = "XOAUTH2"
+)
+
+// Relay is the SMTP server confirmation emails are handed to for delivery,
+// such as a company smarthost or a mail provider's submission server. It is
+// what lets confirmations go out where policy forbids talking to other
+// domains' mail servers directly.
+type Relay struct {
+	// Host is the relay's host name.
+	Host string
+	// Port is the relay's port, 587 if empty. Port 465 uses implicit TLS,
+	// other ports upgrade with STARTTLS when the relay offers it.
+	Port string
+	// TLSConfig is used for the TLS connection. By default the relay's
+	// certificate is verified against Host.
+	TLSConfig *tls.Config
+	// Username, if set, logs in to the relay with AUTH before sending.
+	Username string
+	// Password is the password to log in with, or the OAuth 2.0 access token for AuthXOAUTH2.
+	Password string
+	// Mechanism is the AUTH mechanism to use. If empty, PLAIN is used when the
+	// relay offers it and LOGIN otherwise.
+	Mechanism AuthMechanism
+}
+
+// sendMail delivers msg to a single recipient through a relay, logging in
+// first if the relay has credentials.
+func (c *Client) sendMail(relay Relay, to string, msg []byte) error {
+	port := relay.Port
+	if port == "" {
+		port = "587"
+	}
+	config := relay.TLSConfig
+	if config == nil {
+		config = &tls.Config{ServerName: relay.Host}
+	}
+
+	timeout := 30 * time.Second
+	conn, err := c.dial(net.JoinHostPort(relay.Host, port), timeout)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if port == "465" {
+		conn = tls.Client(conn, config)
+	}
+
+	session, err := newSMTPSession(conn, relay.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP client creation failed: %w", err)
+	}
+	defer session.close()
+
+	localName, err := c.GetHostname()
+	if err != nil {
+		return err
+	}
+	if err := session.hello(localName); err != nil {
+		return fmt.Errorf("HELO failed: %w", err)
+	}
+	if ok, _ := session.extension("STARTTLS"); ok && !session.tls {
+		if err := session.startTLS(config); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if relay.Username != "" {
+		if err := session.auth(relay.Mechanism, relay.Username, relay.Password); err != nil {
+			return fmt.Errorf("AUTH failed: %w", err)
+		}
+	}
+
+	if err := session.mail(c.SenderEmail); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	if _, _, err := session.rcpt(to); err != nil {
+		return fmt.Errorf("RCPT TO failed: %w", err)
+	}
+	if err := session.data(msg); err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	// The relay has taken the message, a failed QUIT doesn't change that
+	session.quit()
+	return nil
+}
+
+// auth logs in with the given mechanism, or the best one the server offers if
+// it is empty. Credentials are only sent over TLS, or to localhost.
+func (s *smtpSession) auth(mechanism AuthMechanism, username, password string) error {
+	ok, offered := s.extension("AUTH")
+	if !ok {
+		return fmt.Errorf("server does not support AUTH")
+	}
+	if !s.tls && !isLocalhost(s.serverName) {
+		return fmt.Errorf("refusing to send credentials over an unencrypted connection")
+	}
+
+	if mechanism == "" {
+		mechanism = AuthLogin
+		for _, m := range strings.Fields(offered) {
+			if strings.EqualFold(m, string(AuthPlain)) {
+				mechanism = AuthPlain
+			}
+		}
+	}
+
+	enc := base64.StdEncoding
+	switch mechanism {
+	case AuthPlain:
+		_, _, err := s.cmd(235, "AUTH PLAIN %s", enc.EncodeToString([]byte("\x00"+username+"\x00"+password)))
+		return err
+
+	case AuthLogin:
+		if _, _, err := s.cmd(334, "AUTH LOGIN"); err != nil {
+			return err
+		}
+		if _, _, err := s.cmd(334, "%s", enc.EncodeToString([]byte(username))); err != nil {
+			return err
+		}
+		_, _, err := s.cmd(235, "%s", enc.EncodeToString([]byte(password)))
+		return err
+
+	case AuthXOAUTH2:
+		initial := "user=" + username + "\x01auth=Bearer " + password + "\x01\x01"
+		code, msg, err := s.cmd(235, "AUTH XOAUTH2 %s", enc.EncodeToString([]byte(initial)))
+		if code == 334 {
+			// The server sent its error details as a challenge, an empty reply ends the exchange
+			_, _, err = s.cmd(235, "")
+			if err == nil {
+				err = fmt.Errorf("unexpected success after XOAUTH2 error: %s", msg)
+			}
+		}
+		return err
+
+	default:
+		return fmt.Errorf("unsupported AUTH mechanism %q", mechanism)
+	}
+}
+
+// isLocalhost reports whether host is the local machine, where credentials
+// can be sent without TLS.
+func isLocalhost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
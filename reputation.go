@@ -0,0 +1,161 @@
+package mailify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultIPEndpoint is the URL CheckHostReputation asks for the host's public
+// address when the host only has a private one, e.g. behind NAT.
+const DefaultIPEndpoint = "https://api.ipify.org"
+
+// HostCheckOptions configures CheckHostReputation.
+type HostCheckOptions struct {
+	// IP is the public address the host connects to mail servers from. If
+	// empty, it is the address the host connects to the internet with, or if
+	// that is private, the one IPEndpoint sees.
+	IP string
+	// IPEndpoint is a URL that answers with the caller's address in plain
+	// text. DefaultIPEndpoint if empty.
+	IPEndpoint string
+	// HTTPClient asks IPEndpoint, a client with a 10 second timeout if nil.
+	HTTPClient *http.Client
+	// Blocklists are the DNS blocklists the address is checked against,
+	// Spamhaus ZEN, SpamCop and Barracuda if empty.
+	Blocklists []string
+}
+
+// HostReputation is how the verifying host looks to the mail servers it
+// talks to, as gathered by CheckHostReputation.
+type HostReputation struct {
+	// IP is the public address that was checked.
+	IP string `json:"ip"`
+	// PTR holds the address's reverse DNS names.
+	PTR []string `json:"ptr,omitempty"`
+	// ForwardConfirmed indicates whether a reverse DNS name resolves back to the address.
+	ForwardConfirmed bool `json:"forward_confirmed"`
+	// HELOName is the name the client introduces itself with, see WithHELOName.
+	HELOName string `json:"helo_name"`
+	// HELOMatches indicates whether HELOName is one of the reverse DNS names.
+	HELOMatches bool `json:"helo_matches"`
+	// Blocklists holds the DNS blocklists that list the address.
+	Blocklists []string `json:"blocklists,omitempty"`
+	// Warnings explain what about the host may lead mail servers to refuse
+	// or mislead it, empty if nothing does.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Reliable reports whether nothing was found that would make SMTP verdicts
+// from this host unreliable.
+func (r *HostReputation) Reliable() bool {
+	return len(r.Warnings) == 0
+}
+
+// CheckHostReputation checks how the verifying host looks to mail servers
+// before validating: whether its public address has reverse DNS, whether that
+// resolves back to the address and matches the HELO name, and whether the
+// address is on major DNS blocklists. Mail servers commonly refuse, tarpit or
+// answer falsely to hosts that fail these checks, so verdicts from such a
+// host are unreliable; the report's Warnings say why.
+//
+// Parameters:
+//   - ctx: Bounds the lookups.
+//   - opts: The address to check, if known, and where to look it up otherwise.
+//
+// Returns:
+//   - *HostReputation: The report.
+//   - error: An error if the host's public address can't be found out.
+func (c *Client) CheckHostReputation(ctx context.Context, opts HostCheckOptions) (*HostReputation, error) {
+	ip := net.ParseIP(opts.IP)
+	if opts.IP == "" {
+		var err error
+		if ip, err = c.publicAddress(ctx, opts); err != nil {
+			return nil, err
+		}
+	} else if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", opts.IP)
+	}
+	lists := opts.Blocklists
+	if len(lists) == 0 {
+		lists = defaultIPBlocklists
+	}
+
+	report := &HostReputation{IP: ip.String()}
+	report.HELOName, _ = c.GetHostname()
+
+	names, _ := c.resolver.LookupAddr(ctx, ip.String())
+	for _, name := range names {
+		name = strings.TrimSuffix(name, ".")
+		report.PTR = append(report.PTR, name)
+		if strings.EqualFold(name, report.HELOName) {
+			report.HELOMatches = true
+		}
+		addrs, err := c.resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			report.ForwardConfirmed = report.ForwardConfirmed || addr.IP.Equal(ip)
+		}
+	}
+	report.Blocklists = c.blocklisted(reverseIP(ip), lists)
+
+	switch {
+	case len(report.PTR) == 0:
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s has no reverse DNS record, which many mail servers require", report.IP))
+	case !report.ForwardConfirmed:
+		report.Warnings = append(report.Warnings, fmt.Sprintf("the reverse DNS name of %s doesn't resolve back to it", report.IP))
+	case !report.HELOMatches:
+		report.Warnings = append(report.Warnings, fmt.Sprintf("HELO name %s isn't the reverse DNS name of %s, set it with WithHELOName", report.HELOName, report.IP))
+	}
+	if len(report.Blocklists) > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s is listed on %s, mail servers may refuse or mislead checks from it", report.IP, strings.Join(report.Blocklists, ", ")))
+	}
+	return report, nil
+}
+
+// publicAddress finds out the address the host reaches the internet with:
+// the local address of a route to the resolver's usual server, or if that is
+// private, the one opts.IPEndpoint sees. No packets are sent to find the route.
+func (c *Client) publicAddress(ctx context.Context, opts HostCheckOptions) (net.IP, error) {
+	if conn, err := net.Dial("udp", "8.8.8.8:53"); err == nil {
+		local := conn.LocalAddr().(*net.UDPAddr).IP
+		conn.Close()
+		if !isBogon(local) {
+			return local, nil
+		}
+	}
+
+	endpoint := opts.IPEndpoint
+	if endpoint == "" {
+		endpoint = DefaultIPEndpoint
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find out public IP address: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent())
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find out public IP address: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find out public IP address: %w", err)
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if resp.StatusCode != http.StatusOK || ip == nil {
+		return nil, fmt.Errorf("failed to find out public IP address: %s answered %s", endpoint, resp.Status)
+	}
+	return ip, nil
+}
@@ -0,0 +1,196 @@
+package mailify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NewDoTResolver returns a resolver that sends every query over DNS-over-TLS
+// (RFC 7858), for networks that block plain DNS to public resolvers and for
+// deployments that don't want lookups visible on the wire. Pass it to
+// WithResolver.
+//
+// Parameters:
+//   - address: The DoT server as host:port, e.g. "1.1.1.1:853". The port
+//     defaults to 853 if missing.
+//   - serverName: The name to verify the server's certificate against, e.g.
+//     "cloudflare-dns.com". If empty, the host of address is used.
+//
+// Returns:
+//   - *net.Resolver: The resolver.
+func NewDoTResolver(address, serverName string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "853")
+	}
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(address)
+	}
+	config := &tls.Config{ServerName: serverName}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			// A stream connection makes the resolver frame messages as it does
+			// over TCP, which is what DoT expects
+			d := tls.Dialer{Config: config}
+			return d.DialContext(ctx, "tcp", address)
+		},
+	}
+}
+
+// NewDoHResolver returns a resolver that sends every query over
+// DNS-over-HTTPS (RFC 8484), for networks that block plain DNS to public
+// resolvers and for deployments that don't want lookups visible on the wire.
+// Pass it to WithResolver.
+//
+// Parameters:
+//   - url: The DoH endpoint, e.g. "https://cloudflare-dns.com/dns-query" or
+//     "https://dns.google/dns-query".
+//   - httpClient: The client to send queries with, a client with a 10 second
+//     timeout if nil.
+//
+// Returns:
+//   - *net.Resolver: The resolver.
+func NewDoHResolver(url string, httpClient *http.Client) *net.Resolver {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: url, client: httpClient}, nil
+		},
+	}
+}
+
+// dohConn is a net.Conn that the Go resolver treats as a stream connection.
+// Each length-prefixed query written to it is sent as an HTTPS POST, and the
+// answer is made available to Read with a length prefix.
+type dohConn struct {
+	ctx    context.Context
+	url    string
+	client *http.Client
+
+	mu       sync.Mutex
+	written  bytes.Buffer
+	response bytes.Buffer
+	deadline time.Time
+	closed   bool
+}
+
+// Write collects a query, sending it once all of it has been written.
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.written.Write(b)
+
+	for c.written.Len() >= 2 {
+		length := int(binary.BigEndian.Uint16(c.written.Bytes()))
+		if c.written.Len() < 2+length {
+			break
+		}
+		c.written.Next(2)
+		query := c.written.Next(length)
+		answer, err := c.exchange(query)
+		if err != nil {
+			return 0, err
+		}
+		c.response.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+		c.response.Write(answer)
+	}
+	return len(b), nil
+}
+
+// exchange sends a query to the DoH endpoint and returns the answer.
+func (c *dohConn) exchange(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server replied %s", resp.Status)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 65535+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(answer) > 65535 {
+		return nil, errors.New("DoH answer is too large")
+	}
+	return answer, nil
+}
+
+// Read returns the answers to the queries written so far.
+func (c *dohConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if c.response.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.response.Read(b)
+}
+
+// Close discards any pending answers.
+func (c *dohConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// SetDeadline limits how long the next queries may take.
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+// SetReadDeadline does nothing, answers are read as part of writing the query.
+func (c *dohConn) SetReadDeadline(t time.Time) error { return nil }
+
+// SetWriteDeadline limits how long the next queries may take.
+func (c *dohConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+// LocalAddr returns a placeholder, a DoH connection has no address of its own.
+func (c *dohConn) LocalAddr() net.Addr { return dohAddr("local") }
+
+// RemoteAddr returns the DoH endpoint.
+func (c *dohConn) RemoteAddr() net.Addr { return dohAddr(c.url) }
+
+// dohAddr is the net.Addr of a DoH connection.
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
@@ -0,0 +1,122 @@
+package mailify
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// defaultResultTTL is how long a verdict is expected to hold, as results
+// report it in TTL, unless WithResultCache says otherwise. Mailboxes come and
+// go, so a verdict older than this is worth checking again.
+const defaultResultTTL = 30 * 24 * time.Hour
+
+// WithResultCache keeps the results of validations in the cache given with
+// WithCache for ttl, so an address validated again within it is answered
+// from the cache, with Cached set, instead of with another SMTP
+// conversation. A result is kept for the TTL it reports, which is ttl for
+// definite verdicts but shorter for those worth retrying sooner. Callers
+// that need fresher results than that give a maximum age, see
+// ValidateEmailMaxAge and BulkOptions.MaxAge. It takes WithCache; without
+// it, nothing is cached.
+func WithResultCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.resultTTL = ttl
+	}
+}
+
+// ValidateEmailMaxAge validates an address like ValidateEmail, but only takes
+// a result from the cache of WithResultCache if it was validated at most
+// maxAge ago, e.g. before letting a signup through or sending a campaign.
+//
+// Parameters:
+//   - recipientEmail: The email address to validate.
+//   - maxAge: The oldest cached result to accept. 0 accepts any result
+//     still in the cache, and a negative age always validates again.
+//
+// Returns:
+//   - *ValidationResult: The validation result, with Cached set if it came
+//     from the cache.
+//   - error: Any error ValidateEmail returns.
+func (c *Client) ValidateEmailMaxAge(recipientEmail string, maxAge time.Duration) (*ValidationResult, error) {
+	return c.validate(recipientEmail, &validation{maxAge: maxAge})
+}
+
+// resultKey is the cache key of the result of validating an address, keyed
+// by its hash unless in PIIPlain, see WithPIIMode.
+func (c *Client) resultKey(email string) string {
+	return "result:" + strings.ToLower(c.RedactEmail(email))
+}
+
+// resultTTLOf returns how long a result's verdict is expected to hold: none
+// for skipped SMTP checks and unknown verdicts, until it is worth retrying
+// for deferrals and full mailboxes, and the client's result TTL otherwise.
+func (c *Client) resultTTLOf(result *ValidationResult) time.Duration {
+	ttl := c.resultTTL
+	if ttl <= 0 {
+		ttl = defaultResultTTL
+	}
+	switch {
+	case result.SubStatus == SubStatusSkipped, result.SubStatus == SubStatusBudgetExhausted:
+		return 0
+	case result.RetryAfter > 0:
+		return min(result.RetryAfter, ttl)
+	case result.Verdict == VerdictUnknown:
+		return 0
+	}
+	return ttl
+}
+
+// cachedResult returns the cached result of validating an address, if the
+// client keeps results and has one no older than the validation's maxAge
+// and from before the domain last changed mail servers.
+// The validation takes the address from it, as the syntax stage would.
+func (c *Client) cachedResult(recipientEmail string, v *validation) *ValidationResult {
+	if c.cache == nil || c.resultTTL <= 0 || v.maxAge < 0 {
+		return nil
+	}
+	address, err := ParseAddress(recipientEmail)
+	if err != nil {
+		return nil
+	}
+	data, ok := c.cache.Get(c.resultKey(address.Email))
+	if !ok {
+		return nil
+	}
+	var result ValidationResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	if v.maxAge > 0 && time.Since(result.ValidatedAt) > v.maxAge {
+		return nil
+	}
+	if c.changedMX(emailDomain(address.Email), result.ValidatedAt) {
+		// The domain has moved mail servers since, its mailboxes may have changed with it
+		return nil
+	}
+	v.email = address.Email
+	v.displayName = address.Name
+	v.cached = true
+	result.Cached = true
+	provenance := &Provenance{Source: ProvenanceCache, At: result.ValidatedAt}
+	if result.Provenance != nil {
+		provenance.Detail = string(result.Provenance.Source)
+	}
+	result.Provenance = provenance
+	return &result
+}
+
+// rememberResult keeps the result of validating an address in the cache for
+// as long as its verdict is expected to hold, if the client keeps results.
+// The address is redacted from it as WithPIIMode says; the address the
+// result is asked for with fills it back in.
+func (c *Client) rememberResult(email string, result *ValidationResult) {
+	if c.cache == nil || c.resultTTL <= 0 || email == "" || result.TTL <= 0 {
+		return
+	}
+	data, err := json.Marshal(c.redactResult(result))
+	if err != nil {
+		return
+	}
+	c.cache.Set(c.resultKey(email), data, result.TTL)
+}
@@ -0,0 +1,124 @@
+package mailify_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/mailifytest"
+)
+
+// recordingCache is a memory cache that remembers everything written to it.
+type recordingCache struct {
+	*mailify.MemoryCache
+
+	mu      sync.Mutex
+	written []string
+}
+
+func newRecordingCache() *recordingCache {
+	return &recordingCache{MemoryCache: mailify.NewMemoryCache()}
+}
+
+func (r *recordingCache) Set(key string, value []byte, ttl time.Duration) {
+	r.mu.Lock()
+	r.written = append(r.written, key+" "+string(value))
+	r.mu.Unlock()
+	r.MemoryCache.Set(key, value, ttl)
+}
+
+// contains reports whether anything written to the cache contains s.
+func (r *recordingCache) contains(s string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.written {
+		if strings.Contains(strings.ToLower(entry), strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResultCache(t *testing.T) {
+	tests := []struct {
+		name string
+		mode mailify.PIIMode
+		// stored is whether the address appears in the cache.
+		stored bool
+	}{
+		{name: "plain", mode: mailify.PIIPlain, stored: true},
+		{name: "hash", mode: mailify.PIIHash},
+		{name: "redact", mode: mailify.PIIRedact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mailifytest.NewServer()
+			defer srv.Close()
+			cache := newRecordingCache()
+			c := newTestClient(t, srv,
+				mailify.WithCatchAllProbes(0),
+				mailify.WithCache(cache),
+				mailify.WithResultCache(time.Hour),
+				mailify.WithPIIMode(tt.mode, []byte("test key")),
+			)
+
+			first, err := c.ValidateEmail("user@example.com")
+			if err != nil {
+				t.Fatalf("ValidateEmail: %v", err)
+			}
+			if first.Cached {
+				t.Error("first validation came from the cache")
+			}
+			if got := cache.contains("user@example.com"); got != tt.stored {
+				t.Errorf("address in the cache = %v, want %v", got, tt.stored)
+			}
+
+			second, err := c.ValidateEmail("User <USER@example.com>")
+			if err != nil {
+				t.Fatalf("ValidateEmail: %v", err)
+			}
+			if !second.Cached || second.Verdict != first.Verdict {
+				t.Errorf("second validation = cached %v, %s, want cached %s", second.Cached, second.Verdict, first.Verdict)
+			}
+			if second.NormalizedEmail != "USER@example.com" || second.DisplayName != "User" {
+				t.Errorf("second validation is of %q <%s>, want the address it was asked for", second.DisplayName, second.NormalizedEmail)
+			}
+			if got := len(rcpts(srv)); got != 1 {
+				t.Errorf("server got %d RCPT TO commands, want 1", got)
+			}
+
+			if _, err := c.ValidateEmailMaxAge("user@example.com", -1); err != nil {
+				t.Fatalf("ValidateEmailMaxAge: %v", err)
+			}
+			if got := len(rcpts(srv)); got != 2 {
+				t.Errorf("server got %d RCPT TO commands after a negative max age, want 2", got)
+			}
+		})
+	}
+}
+
+func TestResultCacheKeepsDeferralsUntilRetry(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	srv.SetDefaultRcptReply(451, "4.3.0 Local error")
+	c := newTestClient(t, srv,
+		mailify.WithCatchAllProbes(0),
+		mailify.WithCache(mailify.NewMemoryCache()),
+		mailify.WithResultCache(24*time.Hour),
+		mailify.WithRetryPolicy(mailify.RetryPolicy{MaxAttempts: 1}),
+	)
+
+	first, _ := c.ValidateEmail("user@example.com")
+	if first.SubStatus != mailify.SubStatusTemporaryFailure || first.RetryAfter <= 0 {
+		t.Fatalf("first validation = %s, retry after %v, want a temporary failure to retry", first.SubStatus, first.RetryAfter)
+	}
+	if first.TTL != first.RetryAfter {
+		t.Errorf("TTL = %v, want the retry delay %v", first.TTL, first.RetryAfter)
+	}
+	second, _ := c.ValidateEmail("user@example.com")
+	if !second.Cached {
+		t.Error("deferral wasn't answered from the cache before its retry delay")
+	}
+}
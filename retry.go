@@ -0,0 +1,148 @@
+package mailify
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/textproto"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorClass identifies a kind of failure for retry decisions. Classes can be
+// combined with | to form the set of classes a RetryPolicy retries.
+type ErrorClass int
+
+const (
+	// ErrorClassDNS covers temporary DNS failures and timeouts.
+	ErrorClassDNS ErrorClass = 1 << iota
+	// ErrorClassConnect covers failed, refused, reset and timed out connections.
+	ErrorClassConnect
+	// ErrorClassTransient covers SMTP 4xx replies such as greylisting and rate limiting.
+	ErrorClassTransient
+	// ErrorClassTLSRequired covers servers that refuse to continue without STARTTLS.
+	ErrorClassTLSRequired
+
+	// ErrorClassAll covers every retryable class.
+	ErrorClassAll = ErrorClassDNS | ErrorClassConnect | ErrorClassTransient | ErrorClassTLSRequired
+)
+
+// RetryPolicy controls how failed DNS lookups, connections and SMTP
+// conversations are retried. Attempts after the first one wait an exponentially
+// growing backoff, randomized by Jitter so many workers don't retry in lockstep.
+// SMTP retries are made with STARTTLS, as servers commonly refuse plain text.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+	// Multiplier is what the backoff is multiplied by after each attempt.
+	Multiplier float64
+	// Jitter is the fraction (0 to 1) by which each backoff is randomly shortened or lengthened.
+	Jitter float64
+	// RetryOn is the set of error classes that are retried.
+	RetryOn ErrorClass
+}
+
+// DefaultRetryPolicy returns the policy used unless WithRetryPolicy is given:
+// two attempts with a 500ms backoff, retrying every error class. This matches
+// the old behavior of retrying a failed conversation once with STARTTLS.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		RetryOn:        ErrorClassAll,
+	}
+}
+
+// WithRetryPolicy sets how failed DNS lookups, connections and SMTP
+// conversations are retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// retryable reports whether err should be retried after the given attempt.
+func (p RetryPolicy) retryable(err error, attempt int) bool {
+	return attempt < p.MaxAttempts && classifyError(err)&p.RetryOn != 0
+}
+
+// backoff returns how long to wait after the given attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(backoff)
+}
+
+// withRetry calls fn until it succeeds or the client's retry policy gives up,
+// sleeping between attempts, and returns the last error. It also gives up
+// when the backoff would run past deadline, unless that is zero. A deferral
+// that says when to try again is waited out if that is within MaxBackoff,
+// and not retried otherwise, as retrying sooner would only be deferred again.
+func (c *Client) withRetry(deadline time.Time, fn func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || !c.retry.retryable(err, attempt) {
+			return err
+		}
+		backoff := c.retry.backoff(attempt)
+		if hint := errorRetryHint(err); hint > backoff {
+			if c.retry.MaxBackoff > 0 && hint > c.retry.MaxBackoff {
+				return err
+			}
+			backoff = hint
+		}
+		if expired(deadline) || !deadline.IsZero() && time.Until(deadline) < backoff {
+			return err
+		}
+		time.Sleep(backoff)
+	}
+}
+
+// classifyError works out which ErrorClass an error belongs to, or 0 if it is
+// permanent and retrying won't help.
+func classifyError(err error) ErrorClass {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		switch {
+		case protoErr.Code >= 400 && protoErr.Code < 500:
+			return ErrorClassTransient
+		case protoErr.Code == 530, strings.Contains(strings.ToUpper(protoErr.Msg), "STARTTLS"):
+			return ErrorClassTLSRequired
+		}
+		return 0
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout || dnsErr.IsTemporary {
+			return ErrorClassDNS
+		}
+		return 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorClassConnect
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package mailify
+
+import (
+	"errors"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRetryAfter caps the retry hints taken from replies, so a garbled
+// message can't put an address off for days.
+const maxRetryAfter = 24 * time.Hour
+
+// Default retry hints for deferrals whose reply doesn't say when to retry.
+const (
+	// greylistRetryAfter is how long greylisting servers commonly hold off
+	// new senders, 5 minutes for postgrey and most others.
+	greylistRetryAfter = 5 * time.Minute
+	// temporaryRetryAfter is the wait after a server's local error.
+	temporaryRetryAfter = time.Minute
+	// storageRetryAfter is the wait for a server that ran out of storage.
+	storageRetryAfter = 15 * time.Minute
+	// quotaRetryAfter is the wait for a mailbox over its quota to be emptied.
+	quotaRetryAfter = time.Hour
+)
+
+// retryHintPatterns match the ways servers say when to try again, such as
+// "try again in 300 seconds", "please retry after 5 minutes" or
+// "Retry-After: 60". Numbers must follow a retry word, so enhanced status
+// codes such as 4.7.1 aren't taken for one.
+var retryHintPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)retry-after:\s*(\d+)()`),
+	regexp.MustCompile(`(?i)\b(?:try|retry|again|wait|later)\b[^0-9]{0,24}?(\d+)\s*(seconds?|secs?|s|minutes?|mins?|m|hours?|hrs?|h)\b`),
+}
+
+// parseRetryHint returns when a reply says to try again, or 0 if it doesn't.
+func parseRetryHint(msg string) time.Duration {
+	for _, pattern := range retryHintPatterns {
+		match := pattern.FindStringSubmatch(msg)
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n <= 0 {
+			continue
+		}
+		unit := time.Second
+		switch strings.ToLower(match[2])[:min(len(match[2]), 1)] {
+		case "m":
+			unit = time.Minute
+		case "h":
+			unit = time.Hour
+		}
+		if time.Duration(n) > maxRetryAfter/unit {
+			return maxRetryAfter
+		}
+		return time.Duration(n) * unit
+	}
+	return 0
+}
+
+// retryAfter returns when a deferral says to try again, or fallback if it
+// doesn't say.
+func retryAfter(msg string, fallback time.Duration) time.Duration {
+	if hint := parseRetryHint(msg); hint > 0 {
+		return hint
+	}
+	return fallback
+}
+
+// errorRetryHint returns when the SMTP reply err carries says to try again,
+// or 0 if it isn't one or doesn't say.
+func errorRetryHint(err error) time.Duration {
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) || protoErr.Code < 400 || protoErr.Code >= 500 {
+		return 0
+	}
+	return parseRetryHint(protoErr.Msg)
+}
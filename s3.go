@@ -0,0 +1,206 @@
+package mailify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultS3BatchSize is how many records S3AuditSink collects into one object by default.
+const defaultS3BatchSize = 1000
+
+// S3AuditSink uploads AuditRecords to an S3 bucket, or an S3-compatible
+// store, as objects of JSON lines. Records are collected and uploaded in
+// batches, so Close must be called to upload the last one.
+type S3AuditSink struct {
+	// Bucket is the bucket to upload to.
+	Bucket string
+	// Region is the bucket's region, e.g. eu-west-1.
+	Region string
+	// Endpoint is the store's URL for S3-compatible stores, such as
+	// https://minio.example.com. Objects are then addressed by path. If
+	// empty, AWS's virtual-hosted endpoint for Bucket and Region is used.
+	Endpoint string
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials
+	// requests are signed with. SessionToken is only needed for temporary ones.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// Prefix is prepended to object keys, e.g. "audit/". Objects are named
+	// by the time of upload, e.g. audit/2024/05/01/150405.000000000.jsonl.
+	Prefix string
+	// BatchSize is how many records go in one object, 1000 if 0.
+	BatchSize int
+	// HTTPClient sends the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	pending bytes.Buffer
+	count   int
+}
+
+// Record implements AuditSink. Once BatchSize records have been collected
+// they are uploaded, and the upload's error returned.
+func (s *S3AuditSink) Record(ctx context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending.Write(data)
+	s.pending.WriteByte('\n')
+	s.count++
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultS3BatchSize
+	}
+	if s.count < batchSize {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// Flush uploads the records collected so far, if any.
+//
+// Parameters:
+//   - ctx: Cancels the upload.
+//
+// Returns:
+//   - error: An error if the upload failed. The records are kept for the next try.
+func (s *S3AuditSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush(ctx)
+}
+
+// Close uploads the records collected so far.
+func (s *S3AuditSink) Close() error {
+	return s.Flush(context.Background())
+}
+
+// flush does the work for Flush. The caller must hold s.mu.
+func (s *S3AuditSink) flush(ctx context.Context) error {
+	if s.count == 0 {
+		return nil
+	}
+	key := s.Prefix + time.Now().UTC().Format("2006/01/02/150405.000000000") + ".jsonl"
+	if err := s.put(ctx, key, s.pending.Bytes()); err != nil {
+		return fmt.Errorf("s3: %w", err)
+	}
+	s.pending.Reset()
+	s.count = 0
+	return nil
+}
+
+// put uploads an object.
+func (s *S3AuditSink) put(ctx context.Context, key string, body []byte) error {
+	objectURL := "https://" + s.Bucket + ".s3." + s.Region + ".amazonaws.com/" + escapeS3Key(key)
+	if s.Endpoint != "" {
+		objectURL = strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + escapeS3Key(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", userAgent())
+	s.sign(req, body, time.Now().UTC())
+
+	client := s.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req, see
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html.
+func (s *S3AuditSink) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if s.SessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, name := range signed {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// escapeS3Key escapes an object key for a URL path, keeping its slashes.
+func escapeS3Key(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// sha256Hex returns the hex SHA-256 hash of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data keyed with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,224 @@
+package mailify
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sampleZ is the z-score of the 95% confidence intervals of sample estimates.
+const sampleZ = 1.96
+
+// SampleSize is how much of a list ValidateSample validates: a number of
+// addresses, or a percentage of the list.
+type SampleSize struct {
+	// Count is the number of addresses, used if Percent is 0.
+	Count int
+	// Percent is the share of the list, from 0 to 100.
+	Percent float64
+}
+
+// ParseSampleSize parses a sample size such as "500" or "2%".
+//
+// Parameters:
+//   - s: The size, a number of addresses or a percentage.
+//
+// Returns:
+//   - SampleSize: The size.
+//   - bool: Whether s is a positive count or a percentage up to 100.
+func ParseSampleSize(s string) (SampleSize, bool) {
+	s = strings.TrimSpace(s)
+	if percent, ok := strings.CutSuffix(s, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return SampleSize{}, false
+		}
+		return SampleSize{Percent: p}, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return SampleSize{}, false
+	}
+	return SampleSize{Count: n}, true
+}
+
+// of returns the number of addresses to sample from a list of n, at least
+// one and at most n.
+func (s SampleSize) of(n int) int {
+	size := s.Count
+	if s.Percent > 0 {
+		size = int(math.Ceil(float64(n) * s.Percent / 100))
+	}
+	return max(1, min(size, n))
+}
+
+// SampleEstimate is what validating a random sample of a list says about
+// the whole list, as ValidateSample makes it.
+type SampleEstimate struct {
+	// Population is the number of addresses in the list.
+	Population int `json:"population"`
+	// Sampled is the number of addresses validated.
+	Sampled int `json:"sampled"`
+	// Errors is the number of sampled addresses whose validation failed,
+	// left out of the estimates.
+	Errors int `json:"errors"`
+	// Verdicts estimates the share of the list with each verdict.
+	Verdicts map[Verdict]Proportion `json:"verdicts"`
+	// MailboxFull estimates the share of the list whose mailbox is over its quota.
+	MailboxFull Proportion `json:"mailbox_full"`
+}
+
+// Proportion is the estimated share of a list with some property, from the
+// share of a sample with it, with a 95% confidence interval.
+type Proportion struct {
+	// Count is the number of sampled addresses with the property.
+	Count int `json:"count"`
+	// Share is the share of the sample with it, from 0 to 1.
+	Share float64 `json:"share"`
+	// Low and High bound the share of the list with it, at 95% confidence.
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+	// Estimate, EstimateLow and EstimateHigh are those shares as numbers of
+	// addresses in the list.
+	Estimate     int `json:"estimate"`
+	EstimateLow  int `json:"estimate_low"`
+	EstimateHigh int `json:"estimate_high"`
+}
+
+// String formats the proportion, e.g. "61.2% (56.4%-65.9%), about 73440
+// emails (67680-79080)".
+func (p Proportion) String() string {
+	return fmt.Sprintf("%.1f%% (%.1f%%-%.1f%%), about %d emails (%d-%d)",
+		p.Share*100, p.Low*100, p.High*100, p.Estimate, p.EstimateLow, p.EstimateHigh)
+}
+
+// ValidateSample validates a random sample of a list and estimates from its
+// results the share of the whole list with each verdict, e.g. to judge a
+// purchased list before paying for a full run. The intervals are Wilson
+// score intervals, narrowed for samples that are a large part of the list.
+//
+// Parameters:
+//   - emails: The list.
+//   - size: How much of it to validate.
+//   - opts: Options for the bulk run of the sample, as for ValidateBulk.
+//
+// Returns:
+//   - *SampleEstimate: The estimates.
+//   - error: An error if the list is empty, or ErrInterrupted if opts.Context
+//     was done before the sample was validated, the estimates then being
+//     made from the addresses validated so far.
+func (c *Client) ValidateSample(emails []string, size SampleSize, opts BulkOptions) (*SampleEstimate, error) {
+	if len(emails) == 0 {
+		return nil, errors.New("no emails to sample")
+	}
+	n := size.of(len(emails))
+
+	// A partial Fisher-Yates shuffle picks the sample
+	shuffled := append([]string(nil), emails...)
+	for i := 0; i < n; i++ {
+		j := i + rand.Intn(len(shuffled)-i)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	sample := shuffled[:n]
+
+	estimate := &SampleEstimate{Population: len(emails), Sampled: n}
+	counts := make(map[Verdict]int)
+	mailboxFull, validated := 0, 0
+	interrupted := false
+	for _, res := range c.ValidateBulk(sample, opts) {
+		switch {
+		case errors.Is(res.Err, ErrInterrupted):
+			interrupted = true
+			estimate.Sampled--
+		case res.Err != nil:
+			estimate.Errors++
+		default:
+			validated++
+			counts[res.Result.Verdict]++
+			if res.Result.IsMailboxFull {
+				mailboxFull++
+			}
+		}
+	}
+
+	estimate.Verdicts = make(map[Verdict]Proportion)
+	for _, verdict := range []Verdict{VerdictDeliverable, VerdictUndeliverable, VerdictRisky, VerdictUnknown} {
+		estimate.Verdicts[verdict] = proportion(counts[verdict], validated, len(emails))
+	}
+	estimate.MailboxFull = proportion(mailboxFull, validated, len(emails))
+	if interrupted {
+		return estimate, ErrInterrupted
+	}
+	return estimate, nil
+}
+
+// SampleFiles reads the addresses of the files ProcessAndValidateEmailsViaFiles
+// would process, from the same columns, and validates a random sample of
+// them like ValidateSample. Nothing is written to the files.
+//
+// Parameters:
+//   - pattern: A directory, a glob pattern such as "lists/*.xlsx", or a single file.
+//   - size: How much of the addresses to validate.
+//   - opts: Options for the bulk run of the sample, and for reading the
+//     files as for ProcessAndValidateEmailsViaFiles.
+//
+// Returns:
+//   - *SampleEstimate: The estimates.
+//   - error: An error if no files match or one can't be read, or as for ValidateSample.
+func (c *Client) SampleFiles(pattern string, size SampleSize, opts BulkOptions) (*SampleEstimate, error) {
+	files, err := listFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, filename := range files {
+		var fileEmails []string
+		if strings.EqualFold(filepath.Ext(filename), ".zip") {
+			fileEmails, _, err = zipFileEmails(filename, opts)
+		} else {
+			fileEmails, _, err = listFileEmails(filename, opts)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		emails = append(emails, fileEmails...)
+	}
+	return c.ValidateSample(emails, size, opts)
+}
+
+// proportion estimates the share of a population of size population with a
+// property that count of a sample of size n have.
+func proportion(count, n, population int) Proportion {
+	p := Proportion{Count: count}
+	if n == 0 {
+		p.High = 1
+		p.EstimateHigh = population
+		return p
+	}
+	share := float64(count) / float64(n)
+	z2 := sampleZ * sampleZ
+	denominator := 1 + z2/float64(n)
+	center := (share + z2/(2*float64(n))) / denominator
+	margin := sampleZ * math.Sqrt(share*(1-share)/float64(n)+z2/(4*float64(n)*float64(n))) / denominator
+
+	low, high := share-(center-margin), center+margin-share
+
+	// Finite population correction: a sample of most of the list leaves
+	// little to guess
+	if population > 1 {
+		fpc := math.Sqrt(float64(population-n) / float64(population-1))
+		low, high = low*fpc, high*fpc
+	}
+
+	p.Share = share
+	p.Low = math.Max(0, share-low)
+	p.High = math.Min(1, share+high)
+	p.Estimate = int(math.Round(share * float64(population)))
+	p.EstimateLow = int(math.Round(p.Low * float64(population)))
+	p.EstimateHigh = int(math.Round(p.High * float64(population)))
+	return p
+}
@@ -0,0 +1,210 @@
+package mailify
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// domainScheduler hands out bulk work partitioned by recipient domain. Each
+// domain has its own queue, a cap on how many of its addresses are validated
+// at once, and a minimum interval between starting validations, so a single
+// large domain can't be hammered while other domains sit idle. Workers tend to
+// stay on one domain, so its addresses go through the same pooled SMTP session.
+type domainScheduler struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queues   map[string]*domainQueue
+	order    []string
+	next     int
+	pending  int
+	urgent   int
+	perLimit int
+	interval time.Duration
+	wakeAt   time.Time
+	stopped  bool
+}
+
+// domainQueue holds the outstanding work for a single domain.
+type domainQueue struct {
+	// items holds the priority addresses first, urgent of them
+	items     []int
+	urgent    int
+	inFlight  int
+	lastStart time.Time
+}
+
+// newDomainScheduler partitions the addresses by domain.
+//
+// Parameters:
+//   - emails: The addresses in the bulk run.
+//   - skip: Marks the addresses not to hand out, nil to hand out all of them.
+//   - priority: Marks the addresses to hand out before the rest, nil for none.
+//   - perLimit: The most addresses of one domain validated at once, 0 for no limit.
+//   - interval: The minimum time between starting validations for one domain.
+func newDomainScheduler(emails []string, skip, priority []bool, perLimit int, interval time.Duration) *domainScheduler {
+	s := &domainScheduler{
+		queues:   make(map[string]*domainQueue),
+		perLimit: perLimit,
+		interval: interval,
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	urgent := make(map[string][]int)
+	for i, email := range emails {
+		if skip != nil && skip[i] {
+			continue
+		}
+		s.pending++
+		domain := emailDomain(email)
+		q, ok := s.queues[domain]
+		if !ok {
+			q = &domainQueue{}
+			s.queues[domain] = q
+			s.order = append(s.order, domain)
+		}
+		if priority != nil && priority[i] {
+			urgent[domain] = append(urgent[domain], i)
+		} else {
+			q.items = append(q.items, i)
+		}
+	}
+	for domain, items := range urgent {
+		q := s.queues[domain]
+		q.items = append(items, q.items...)
+		q.urgent = len(items)
+		s.urgent += len(items)
+	}
+	return s
+}
+
+// emailDomain returns the lowercased domain of an address, which may carry a
+// display name, or "" if it has none.
+func emailDomain(email string) string {
+	if address, err := ParseAddress(email); err == nil {
+		email = address.Email
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// take blocks until addresses can be validated without breaking the
+// per-domain limits, and returns the indexes of up to max addresses of one
+// domain, along with the domain. The addresses count as one validation
+// towards the limits, as they are checked over one SMTP session. ok is false
+// once every address has been handed out.
+//
+// A worker passes the domain of its previous address as prev and keeps getting
+// work from that domain while there is some, so consecutive addresses of a
+// domain go through the same pooled session. Otherwise domains nobody is
+// working on are preferred, spreading workers across domains. Priority
+// addresses come before all others: a worker only takes from the background
+// lane when no priority address may start, e.g. because its domain is at
+// its limits.
+func (s *domainScheduler) take(prev string, max int) (indexes []int, domain string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.pending == 0 || s.stopped {
+			return nil, "", false
+		}
+
+		now := time.Now()
+		var wait time.Duration
+
+		// runnable reports whether the domain's next address may start now
+		runnable := func(d string) bool {
+			q := s.queues[d]
+			if len(q.items) == 0 {
+				return false
+			}
+			if s.perLimit > 0 && q.inFlight >= s.perLimit {
+				return false
+			}
+			if s.interval > 0 && !q.lastStart.IsZero() {
+				if ready := q.lastStart.Add(s.interval); ready.After(now) {
+					if wait == 0 || ready.Sub(now) < wait {
+						wait = ready.Sub(now)
+					}
+					return false
+				}
+			}
+			return true
+		}
+
+		chosen, found := "", false
+		for _, urgentOnly := range []bool{s.urgent > 0, false} {
+			if q, known := s.queues[prev]; !found && known && (!urgentOnly || q.urgent > 0) && runnable(prev) {
+				chosen, found = prev, true
+			}
+			// Prefer a domain nobody is working on, then any domain with room
+			for _, idleOnly := range []bool{true, false} {
+				for n := 0; !found && n < len(s.order); n++ {
+					d := s.order[(s.next+n)%len(s.order)]
+					if idleOnly && s.queues[d].inFlight > 0 || urgentOnly && s.queues[d].urgent == 0 {
+						continue
+					}
+					if runnable(d) {
+						chosen, found = d, true
+						s.next = (s.next + n + 1) % len(s.order)
+					}
+				}
+			}
+		}
+
+		if found {
+			q := s.queues[chosen]
+			// Below 2, every address goes in its own transaction
+			n := len(q.items)
+			if max < 1 {
+				max = 1
+			}
+			if n > max {
+				n = max
+			}
+			indexes = q.items[:n:n]
+			q.items = q.items[n:]
+			urgent := min(n, q.urgent)
+			q.urgent -= urgent
+			s.urgent -= urgent
+			q.inFlight++
+			q.lastStart = now
+			s.pending -= n
+			return indexes, chosen, true
+		}
+
+		// Everything runnable is rate limited, wake up when the first domain is ready
+		if wake := now.Add(wait); wait > 0 && (s.wakeAt.IsZero() || wake.Before(s.wakeAt)) {
+			s.wakeAt = wake
+			time.AfterFunc(wait, func() {
+				s.mu.Lock()
+				if !s.wakeAt.After(wake) {
+					s.wakeAt = time.Time{}
+				}
+				s.mu.Unlock()
+				s.cond.Broadcast()
+			})
+		}
+		s.cond.Wait()
+	}
+}
+
+// stop makes take hand out no more work, waking the workers waiting on it.
+func (s *domainScheduler) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// done marks a validation for the domain as finished.
+func (s *domainScheduler) done(domain string) {
+	s.mu.Lock()
+	s.queues[domain].inFlight--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
@@ -0,0 +1,124 @@
+package mailify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SenderError is the error NewClient returns when the sender address is
+// unusable, before it would have surfaced as a MAIL FROM rejection during
+// validation.
+type SenderError struct {
+	// Sender is the address that was given.
+	Sender string
+	// Err is what is wrong with it.
+	Err error
+}
+
+// Error implements error.
+func (e *SenderError) Error() string {
+	return fmt.Sprintf("invalid sender %q: %v", e.Sender, e.Err)
+}
+
+// Unwrap returns what is wrong with the sender.
+func (e *SenderError) Unwrap() error { return e.Err }
+
+// WithoutSenderCheck lets NewClient accept any sender address as given, for
+// clients that never send MAIL FROM, such as ones that only look up mail
+// servers, or for senders mail servers accept despite RFC 5321.
+func WithoutSenderCheck() Option {
+	return func(c *Client) {
+		c.skipSenderCheck = true
+	}
+}
+
+// WithSenderDomainCheck has NewClient also check that the sender's domain has
+// MX records and an SPF record. Receiving servers commonly reject or distrust
+// MAIL FROM addresses whose domain can't receive bounces or doesn't say who
+// may send for it, which makes mailboxes look invalid when they aren't. The
+// check needs DNS, so it is skipped in ModeOffline.
+func WithSenderDomainCheck() Option {
+	return func(c *Client) {
+		c.checkSenderDomain = true
+	}
+}
+
+// ValidateEmailFrom validates an address like ValidateEmail, but with
+// senderEmail in MAIL FROM in place of the client's sender, so one client,
+// with its pooled sessions and caches, can serve several sender identities,
+// e.g. one per tenant. The sender is checked as NewClient checks its own.
+//
+// Parameters:
+//   - recipientEmail: The email address to validate.
+//   - senderEmail: The address to send MAIL FROM with.
+//
+// Returns:
+//   - *ValidationResult: The validation result.
+//   - error: A *SenderError if the sender is unusable, or any error
+//     ValidateEmail returns.
+func (c *Client) ValidateEmailFrom(recipientEmail, senderEmail string) (*ValidationResult, error) {
+	sender, err := c.normalizeSender(senderEmail)
+	if err != nil {
+		return nil, err
+	}
+	return c.validate(recipientEmail, &validation{sender: sender})
+}
+
+// checkSender checks the sender address NewClient was given and normalizes it.
+func (c *Client) checkSender() error {
+	sender, err := c.normalizeSender(c.SenderEmail)
+	if err != nil {
+		return err
+	}
+	c.SenderEmail = sender
+	return nil
+}
+
+// normalizeSender checks a sender address and returns it normalized, or as
+// given if WithoutSenderCheck was given.
+func (c *Client) normalizeSender(sender string) (string, error) {
+	if c.skipSenderCheck {
+		return sender, nil
+	}
+	normalized, err := NormalizeEmail(sender)
+	if err != nil {
+		return "", &SenderError{Sender: sender, Err: err}
+	}
+	if c.checkSenderDomain && c.mode != ModeOffline {
+		if err := c.checkSenderDNS(normalized[strings.LastIndex(normalized, "@")+1:]); err != nil {
+			return "", &SenderError{Sender: sender, Err: err}
+		}
+	}
+	return normalized, nil
+}
+
+// senderOf returns the MAIL FROM address of a validation.
+func (c *Client) senderOf(v *validation) string {
+	if v.sender != "" {
+		return v.sender
+	}
+	return c.SenderEmail
+}
+
+// checkSenderDNS checks that the sender's domain has MX and SPF records.
+// Address literals have no DNS to check.
+func (c *Client) checkSenderDNS(domain string) error {
+	if strings.HasPrefix(domain, "[") {
+		return nil
+	}
+	servers, err := c.GetMailServers(domain)
+	if err != nil {
+		return err
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("%s has no MX records", domain)
+	}
+	records, err := c.lookupTXTPrefix(domain, "v=spf1")
+	if err != nil {
+		return fmt.Errorf("error looking up SPF record: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("%s has no SPF record", domain)
+	}
+	return nil
+}
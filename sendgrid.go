@@ -0,0 +1,99 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SendGridConnector is a ListConnector for a SendGrid Marketing Campaigns
+// list. SendGrid has no tags, so tagging adds the contact to another list,
+// TagListID, which can then be excluded from sends. Removing takes the
+// contact off the list without deleting it.
+type SendGridConnector struct {
+	// APIKey is a SendGrid API key with Marketing access.
+	APIKey string
+	// ListID is the list's ID.
+	ListID string
+	// TagListID is the list tagged contacts are added to. Tagging fails
+	// without it.
+	TagListID string
+	// Endpoint is the API root, "https://api.sendgrid.com" if empty.
+	Endpoint string
+	// HTTPClient makes the requests, a client with a 30 second timeout if nil.
+	HTTPClient *http.Client
+}
+
+// Name implements ListConnector.
+func (s *SendGridConnector) Name() string {
+	return "sendgrid"
+}
+
+// Contacts implements ListConnector.
+func (s *SendGridConnector) Contacts(ctx context.Context) ([]Contact, error) {
+	var contacts []Contact
+	query := fmt.Sprintf("CONTAINS(list_ids, '%s')", strings.ReplaceAll(s.ListID, "'", ""))
+	path := "/v3/marketing/contacts/search"
+	for path != "" {
+		var page struct {
+			Result []struct {
+				ID    string `json:"id"`
+				Email string `json:"email"`
+			} `json:"result"`
+			Metadata struct {
+				Next string `json:"next"`
+			} `json:"_metadata"`
+		}
+		if err := s.request(ctx, http.MethodPost, path, map[string]string{"query": query}, &page); err != nil {
+			return nil, err
+		}
+		for _, contact := range page.Result {
+			contacts = append(contacts, Contact{ID: contact.ID, Email: contact.Email})
+		}
+
+		// The next page is given as a full URL
+		path = ""
+		if next, err := url.Parse(page.Metadata.Next); err == nil && next.Path != "" {
+			path = next.RequestURI()
+		}
+	}
+	return contacts, nil
+}
+
+// Tag implements ListConnector. The contact is added to TagListID; the tag
+// name itself is only meaningful as that list's name.
+func (s *SendGridConnector) Tag(ctx context.Context, contact Contact, tag string) error {
+	if s.TagListID == "" {
+		return errors.New("sendgrid: tagging needs a tag list ID")
+	}
+	body := map[string]any{
+		"list_ids": []string{s.TagListID},
+		"contacts": []map[string]string{{"email": contact.Email}},
+	}
+	return s.request(ctx, http.MethodPut, "/v3/marketing/contacts", body, nil)
+}
+
+// Remove implements ListConnector. The contact is taken off the list and
+// otherwise left alone.
+func (s *SendGridConnector) Remove(ctx context.Context, contact Contact) error {
+	path := "/v3/marketing/lists/" + url.PathEscape(s.ListID) + "/contacts?" + url.Values{"contact_ids": {contact.ID}}.Encode()
+	return s.request(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// request sends a request to a path under the API root.
+func (s *SendGridConnector) request(ctx context.Context, method, path string, body, out any) error {
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.sendgrid.com"
+	}
+	auth := func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	if err := apiRequest(ctx, s.HTTPClient, auth, method, strings.TrimSuffix(endpoint, "/")+path, body, out); err != nil {
+		return fmt.Errorf("sendgrid: %w", err)
+	}
+	return nil
+}
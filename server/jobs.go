@@ -0,0 +1,366 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/api"
+)
+
+// defaultMaxUpload is the largest file accepted for a bulk job unless
+// WithMaxUpload says otherwise.
+const defaultMaxUpload = 64 << 20
+
+// jobTTL is how long a finished job, its file and its results are kept for
+// download before they are removed.
+const jobTTL = 24 * time.Hour
+
+// reapInterval is how often jobs that finished more than jobTTL ago are
+// looked for and removed.
+const reapInterval = time.Hour
+
+// job is a bulk job: an uploaded file being processed in the background.
+type job struct {
+	// dir is the job's directory, holding the file.
+	dir string
+	// path is the file, processed in place.
+	path string
+	// tenant is the tenant that started the job, nil if the server has none.
+	tenant *Tenant
+	// bulk is the running job, to pause, resume and cancel it with.
+	bulk *mailify.BulkJob
+
+	mu sync.Mutex
+	// status is the job's state.
+	status api.JobStatus
+	// results are the results so far, encoded as JSON lines.
+	results []byte
+}
+
+// WithMaxUpload sets the largest file, in bytes, accepted for a bulk job.
+// The default is 64 MiB.
+func WithMaxUpload(bytes int64) Option {
+	return func(s *Server) {
+		s.maxUpload = bytes
+	}
+}
+
+// handleCreateJob starts a bulk job on a CSV or Excel file uploaded as the
+// "file" field of a multipart/form-data request. The optional field
+// "email_column" names the column holding the addresses. It answers 202
+// Accepted with the job's status and its URL in the Location header. The
+// job counts against the server's limits until it has finished.
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	release, ok := s.admit(w, r)
+	if !ok {
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			release()
+		}
+	}()
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUpload)
+	file, header, err := r.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", s.maxUpload))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("a CSV or Excel file is required as the \"file\" field of a multipart/form-data request: %w", err))
+		return
+	}
+	defer file.Close()
+
+	name := filepath.Base(header.Filename)
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".csv" && ext != ".xlsx" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported file %q: only .csv and .xlsx files are accepted", name))
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	dir, err := os.MkdirTemp("", "mailify-job-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to store file: %w", err))
+		return
+	}
+	j := &job{
+		dir:    dir,
+		tenant: tenantOf(r),
+		path:   filepath.Join(dir, "upload"+ext),
+		status: api.JobStatus{ID: id, Status: api.JobRunning, File: name, Created: time.Now().UTC()},
+	}
+	if err := saveUpload(file, j.path); err != nil {
+		os.RemoveAll(dir)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to store file: %w", err))
+		return
+	}
+
+	opts := mailify.BulkOptions{EmailColumn: r.FormValue("email_column")}
+	if j.tenant != nil {
+		if err := s.checkJobDomains(j, opts); err != nil {
+			os.RemoveAll(dir)
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		opts.Sender = j.tenant.Sender
+	}
+
+	opts.OnResult = j.record
+	opts.Context = s.jobsCtx
+	s.runningJobs.Add(1)
+	j.bulk = s.client.StartFiles(j.path, opts)
+	s.addJob(j)
+	started = true
+	go s.finishJob(j, release)
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+// handleJob serves the status of a bulk job.
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.findJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+// handleJobFile serves the processed file of a finished bulk job, with the
+// result columns file processing adds.
+func (s *Server) handleJobFile(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.findFinishedJob(w, r)
+	if !ok {
+		return
+	}
+	status := j.snapshot()
+	name := strings.TrimSuffix(status.File, filepath.Ext(status.File)) + ".validated" + filepath.Ext(status.File)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, j.path)
+}
+
+// handleJobResults serves the results of a finished bulk job as JSON lines,
+// one api.JobResult per address.
+func (s *Server) handleJobResults(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.findFinishedJob(w, r)
+	if !ok {
+		return
+	}
+	j.mu.Lock()
+	results := j.results
+	j.mu.Unlock()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Write(results)
+}
+
+// handleControlJob returns the handler of a route controlling a bulk job
+// with control, such as pausing it, which answers with the job's status, or
+// 409 Conflict if the job has finished.
+func (s *Server) handleControlJob(control func(*mailify.BulkJob)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		j, ok := s.findJob(w, r)
+		if !ok {
+			return
+		}
+		if j.snapshot().Finished != nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("job %q has finished", r.PathValue("id")))
+			return
+		}
+		control(j.bulk)
+		writeJSON(w, http.StatusOK, j.snapshot())
+	}
+}
+
+// record records a result of the job as it comes.
+func (j *job) record(res mailify.BulkResult) {
+	line := api.JobResult{Email: res.Email, Result: res.Result}
+	if res.Err != nil {
+		line.Error = res.Err.Error()
+	}
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	j.results = append(append(j.results, encoded...), '\n')
+	j.status.Validated++
+	j.mu.Unlock()
+}
+
+// finishJob waits for a job to finish, records its outcome and calls
+// release.
+func (s *Server) finishJob(j *job, release func()) {
+	defer s.runningJobs.Done()
+	defer release()
+	summary, err := j.bulk.Summary()
+
+	j.mu.Lock()
+	finished := time.Now().UTC()
+	j.status.Finished = &finished
+	j.status.Summary = &summary
+	j.status.Status = api.JobDone
+	switch {
+	case errors.Is(err, mailify.ErrInterrupted):
+		j.status.Status = api.JobCancelled
+	case err != nil:
+		j.status.Status = api.JobFailed
+		j.status.Error = strings.ReplaceAll(err.Error(), j.path, j.status.File)
+	}
+	status := j.status
+	j.mu.Unlock()
+
+	notifyTenant(j.tenant, mailify.JobReport{
+		Job:       fmt.Sprintf("Validation of %s (job %s)", status.File, status.ID),
+		Summary:   summary,
+		StartedAt: status.Created,
+		Duration:  finished.Sub(status.Created),
+		Results:   "/jobs/" + status.ID + "/file",
+		Error:     status.Error,
+	})
+}
+
+// checkJobDomains checks that the tenant of a job may validate addresses at
+// every domain in its file.
+func (s *Server) checkJobDomains(j *job, opts mailify.BulkOptions) error {
+	if len(j.tenant.Allow) == 0 && len(j.tenant.Deny) == 0 {
+		return nil
+	}
+	analysis, err := s.client.AnalyzeFile(j.path, opts)
+	if err != nil {
+		// Processing the file fails the job likewise
+		return nil
+	}
+	var denied []string
+	for _, domain := range analysis.Domains {
+		if !j.tenant.allows(domain.Domain) {
+			denied = append(denied, domain.Domain)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	if len(denied) > 5 {
+		denied = append(denied[:5], fmt.Sprintf("%d more", len(denied)-5))
+	}
+	return fmt.Errorf("tenant %q may not validate addresses at %s", j.tenant.Name, strings.Join(denied, ", "))
+}
+
+// snapshot returns the job's status.
+func (j *job) snapshot() api.JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	if status.Finished == nil && j.bulk.Paused() {
+		status.Status = api.JobPaused
+	}
+	return status
+}
+
+// addJob records a new job, starting to remove expired jobs in the
+// background with the first.
+func (s *Server) addJob(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[j.status.ID] = j
+	if !s.reaping {
+		s.reaping = true
+		go s.reapJobs()
+	}
+}
+
+// reapJobs removes the jobs that finished more than jobTTL ago, along with
+// their files, every reapInterval until the server is closed.
+func (s *Server) reapJobs() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.jobsCtx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		for id, old := range s.jobs {
+			if status := old.snapshot(); status.Finished != nil && time.Since(*status.Finished) > jobTTL {
+				os.RemoveAll(old.dir)
+				delete(s.jobs, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// findJob returns the job the request names, answering 404 Not Found if
+// there is none or it has expired.
+func (s *Server) findJob(w http.ResponseWriter, r *http.Request) (*job, bool) {
+	s.mu.Lock()
+	j, ok := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if ok {
+		// Jobs may outlive jobTTL by up to reapInterval before they are removed
+		status := j.snapshot()
+		ok = status.Finished == nil || time.Since(*status.Finished) <= jobTTL
+	}
+	// Jobs of other tenants are as good as missing
+	ok = ok && j.tenant == tenantOf(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %q not found", r.PathValue("id")))
+	}
+	return j, ok
+}
+
+// findFinishedJob returns the job the request names like findJob, answering
+// 409 Conflict if it is still running or paused.
+func (s *Server) findFinishedJob(w http.ResponseWriter, r *http.Request) (*job, bool) {
+	j, ok := s.findJob(w, r)
+	if !ok {
+		return nil, false
+	}
+	if j.snapshot().Finished == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %q is still running", r.PathValue("id")))
+		return nil, false
+	}
+	return j, true
+}
+
+// saveUpload writes an uploaded file to path.
+func saveUpload(file io.Reader, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, file); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// newJobID returns a random job ID.
+func newJobID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to create job ID: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
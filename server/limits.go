@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// retryAfter is how long clients turned away with 429 Too Many Requests are
+// told to wait before trying again.
+const retryAfter = time.Second
+
+// limiter admits validations while fewer than max are in flight overall,
+// and fewer than perClient for the client asking.
+type limiter struct {
+	// max is the most validations in flight at once, 0 for no limit.
+	max int
+	// perClient is the most validations in flight at once for one client, 0 for no limit.
+	perClient int
+
+	mu sync.Mutex
+	// inFlight is the number of validations in flight.
+	inFlight int
+	// clients holds the number of validations in flight of each client with any.
+	clients map[string]int
+}
+
+// WithMaxInFlight caps how many validations the server runs at once, to
+// protect the reputation of the address it validates from: single
+// validations, domain lookups and bulk jobs, each of which counts as one
+// until it has finished. Requests beyond it are answered with 429 Too Many
+// Requests and a Retry-After header. 0, the default, means no cap.
+func WithMaxInFlight(n int) Option {
+	return func(s *Server) {
+		s.limits.max = n
+	}
+}
+
+// WithClientLimit caps how many validations one client may have in flight
+// at once, as WithMaxInFlight counts them, so a single client can't take
+// all of them. Clients are told apart by WithClientKey, or by tenant if the
+// server has tenants, see WithTenants. 0, the default, means no cap.
+func WithClientLimit(n int) Option {
+	return func(s *Server) {
+		s.limits.perClient = n
+	}
+}
+
+// WithClientKey sets how clients are told apart for WithClientLimit, e.g.
+// by the X-Forwarded-For header set by a trusted proxy, or by API key. The
+// default is the request's remote IP address.
+func WithClientKey(key func(r *http.Request) string) Option {
+	return func(s *Server) {
+		s.clientKey = key
+	}
+}
+
+// remoteIP returns the IP address a request came from.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// acquire admits a validation of a client if the limits allow it, with
+// perClient as the client's limit.
+func (l *limiter) acquire(client string, perClient int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.inFlight >= l.max || perClient > 0 && l.clients[client] >= perClient {
+		return false
+	}
+	l.inFlight++
+	l.clients[client]++
+	return true
+}
+
+// release ends a validation of a client that acquire admitted.
+func (l *limiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	if l.clients[client]--; l.clients[client] <= 0 {
+		delete(l.clients, client)
+	}
+}
+
+// admit admits a validation for a request, returning the function that
+// ends it. Requests of a tenant count as the tenant's, against its
+// MaxInFlight if it has one. If the limits don't allow it, the request is
+// answered with 429 Too Many Requests and admit returns false.
+func (s *Server) admit(w http.ResponseWriter, r *http.Request) (func(), bool) {
+	client, limit := s.clientKey(r), s.limits.perClient
+	if t := tenantOf(r); t != nil {
+		client = "tenant:" + t.Name
+		if t.MaxInFlight > 0 {
+			limit = t.MaxInFlight
+		}
+	}
+	if !s.limits.acquire(client, limit) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		writeError(w, http.StatusTooManyRequests, errors.New("too many validations in flight, retry later"))
+		return nil, false
+	}
+	var once sync.Once
+	return func() { once.Do(func() { s.limits.release(client) }) }, true
+}
+
+// limited wraps a handler so each request is a validation admitted by admit.
+func (s *Server) limited(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, ok := s.admit(w, r)
+		if !ok {
+			return
+		}
+		defer release()
+		h(w, r)
+	}
+}
@@ -0,0 +1,377 @@
+// Package server serves mailify over HTTP, as mailify serve does, for
+// services written in other languages:
+//
+//	GET  /validate?email=...   the result of validating the address, see api.ValidationResult
+//	GET  /domains/{domain}     the domain's health report, see api.DomainReport
+//	POST /jobs                 start a bulk job on an uploaded CSV or Excel file
+//	GET  /jobs/{id}            the job's status, see api.JobStatus
+//	GET  /jobs/{id}/file       the processed file, once the job has finished
+//	GET  /jobs/{id}/results    the results as JSON lines, see api.JobResult
+//	POST /jobs/{id}/pause      pause the job, see api.JobStatus
+//	POST /jobs/{id}/resume     resume the paused job
+//	POST /jobs/{id}/cancel     cancel the job, keeping the results it has
+//	GET  /healthz              that the server is up, for liveness probes
+//	GET  /readyz               whether the server can validate, for readiness probes
+//	GET  /openapi.json         the OpenAPI document of the API, see api.OpenAPI
+//
+// Responses are JSON. Errors come with an error message:
+//
+//	{"error": "invalid domain \"example\": ..."}
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/api"
+)
+
+// defaultDomainTTL is how long domain reports are cached unless
+// WithDomainTTL says otherwise.
+const defaultDomainTTL = time.Hour
+
+// readinessTTL is how long a readiness check is answered with before the
+// dependencies are checked again, so frequent probes don't hammer them.
+const readinessTTL = 10 * time.Second
+
+// readinessTimeout is the most time the checks of a readiness check may take.
+const readinessTimeout = 10 * time.Second
+
+// maxCachedDomains is how many domain reports are cached at most. Expired
+// reports are dropped to make room, and then the oldest.
+const maxCachedDomains = 10000
+
+// serverHeader is the Server header of every response.
+var serverHeader = "mailify/" + mailify.Version()
+
+// Server is an http.Handler serving mailify's REST API.
+type Server struct {
+	// client is the client requests are served with.
+	client *mailify.Client
+	// mux routes requests to their handlers.
+	mux *http.ServeMux
+	// domainTTL is how long domain reports are cached and may be cached by clients.
+	domainTTL time.Duration
+	// maxUpload is the largest file accepted for a bulk job, in bytes.
+	maxUpload int64
+
+	mu sync.Mutex
+	// domains holds the cached domain reports, by domain.
+	domains map[string]domainReport
+	// tenants holds the tenants, by API key, empty if the server has none.
+	tenants map[string]*Tenant
+	ratesMu sync.Mutex
+	// rates counts the requests of each tenant in the current minute, by name.
+	rates map[string]rateWindow
+	// limits are the server's limits on validations in flight.
+	limits limiter
+	// clientKey tells clients apart for the limits.
+	clientKey func(r *http.Request) string
+	// jobs holds the bulk jobs, by ID.
+	jobs map[string]*job
+	// reaping indicates whether expired jobs are being removed in the background.
+	reaping bool
+	// jobsCtx is the context bulk jobs run in, done once the server is closed.
+	jobsCtx     context.Context
+	stopJobs    context.CancelFunc
+	runningJobs sync.WaitGroup
+
+	readinessMu sync.Mutex
+	// readiness is the last readiness check, made at readinessChecked.
+	readiness        mailify.Readiness
+	readinessChecked time.Time
+}
+
+// domainReport is a cached domain report, encoded.
+type domainReport struct {
+	body    []byte
+	etag    string
+	fetched time.Time
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithDomainTTL sets how long domain reports are cached, and how long
+// clients are told they may cache them with Cache-Control. 0 turns caching
+// off. The default is an hour.
+func WithDomainTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.domainTTL = ttl
+	}
+}
+
+// New creates a server answering with the given client.
+//
+// Parameters:
+//   - client: The client to validate and look up domains with.
+//   - opts: Options for the server.
+//
+// Returns:
+//   - *Server: The server, to pass to http.ListenAndServe or mount in a mux.
+func New(client *mailify.Client, opts ...Option) *Server {
+	s := &Server{
+		client:    client,
+		mux:       http.NewServeMux(),
+		domainTTL: defaultDomainTTL,
+		maxUpload: defaultMaxUpload,
+		domains:   make(map[string]domainReport),
+		jobs:      make(map[string]*job),
+		limits:    limiter{clients: make(map[string]int)},
+		rates:     make(map[string]rateWindow),
+		clientKey: remoteIP,
+	}
+	s.jobsCtx, s.stopJobs = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+	handlers := map[string]http.HandlerFunc{
+		"validateEmail": s.limited(s.handleValidate),
+		"getDomain":     s.handleDomain,
+		"createJob":     s.handleCreateJob,
+		"getJob":        s.handleJob,
+		"getJobFile":    s.handleJobFile,
+		"getJobResults": s.handleJobResults,
+		"pauseJob":      s.handleControlJob((*mailify.BulkJob).Pause),
+		"resumeJob":     s.handleControlJob((*mailify.BulkJob).Resume),
+		"cancelJob":     s.handleControlJob((*mailify.BulkJob).Cancel),
+		"getHealth":     s.handleHealth,
+		"getReadiness":  s.handleReadiness,
+		"getOpenAPI":    s.handleOpenAPI,
+	}
+	for _, route := range api.Routes {
+		handler, ok := handlers[route.OperationID]
+		if !ok {
+			panic("server: no handler for " + route.OperationID)
+		}
+		if !route.Public {
+			handler = s.authenticated(handler)
+		}
+		s.mux.HandleFunc(route.Method+" "+route.Path, handler)
+	}
+	return s
+}
+
+// Close cancels the bulk jobs still running and waits for them to save the
+// results they have, as cancelling them through POST /jobs/{id}/cancel
+// does. Call it once the HTTP server has shut down, so no new jobs start.
+func (s *Server) Close() {
+	s.stopJobs()
+	s.runningJobs.Wait()
+}
+
+// ServeHTTP implements http.Handler. Responses carry a Server header with
+// mailify's version, such as mailify/v1.4.0.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Server", serverHeader)
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleValidate serves the result of validating the address in the email
+// query parameter, from the sender of the request's tenant if it has one,
+// taking a cached result no older than the max_age query parameter and
+// giving up after the timeout query parameter, if given.
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.URL.Query().Get("email"))
+	if email == "" {
+		writeError(w, http.StatusBadRequest, errors.New("the email query parameter is required"))
+		return
+	}
+	if domain := emailDomain(email); domain != "" && !checkDomain(w, r, domain) {
+		return
+	}
+	maxAge, err := parseDuration("max_age", r.URL.Query().Get("max_age"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	timeout, err := parseDuration("timeout", r.URL.Query().Get("timeout"))
+	if err == nil && timeout < 0 {
+		err = fmt.Errorf("invalid timeout %q, want a positive duration", r.URL.Query().Get("timeout"))
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := mailify.ValidateOptions{MaxAge: maxAge, Timeout: timeout}
+	if t := tenantOf(r); t != nil {
+		opts.Sender = t.Sender
+	}
+	result, err := s.client.ValidateEmailWithOptions(email, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to validate email: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// emailDomain returns the domain of an address as validation sees it,
+// lowercased and without a trailing dot, or empty if it has none.
+func emailDomain(email string) string {
+	if address, err := mailify.ParseAddress(email); err == nil {
+		email = address.Email
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSuffix(email[at+1:], ">")), ".")
+}
+
+// parseDuration parses a duration query parameter of a validation, such as
+// max_age, given as a duration such as 24h or a number of seconds. Empty
+// means 0.
+func parseDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q, want a duration such as 24h or a number of seconds", name, value)
+	}
+	return d, nil
+}
+
+// handleDomain serves the health report of a domain, from the cache if it
+// is fresh, counting against the server's limits if it isn't. Clients are
+// told how long they may cache it, and get 304 Not Modified if the report
+// they have is current.
+func (s *Server) handleDomain(w http.ResponseWriter, r *http.Request) {
+	domain := strings.TrimSuffix(strings.ToLower(r.PathValue("domain")), ".")
+	if !checkDomain(w, r, domain) {
+		return
+	}
+	report, ok := s.cachedDomain(domain)
+	if !ok {
+		release, admitted := s.admit(w, r)
+		if !admitted {
+			return
+		}
+		info, err := s.client.GetDomainInfo(domain)
+		release()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		body, err := json.Marshal(info)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		sum := sha256.Sum256(body)
+		report = domainReport{body: body, etag: `"` + hex.EncodeToString(sum[:8]) + `"`, fetched: time.Now()}
+		s.cacheDomain(domain, report)
+	}
+
+	maxAge := int((s.domainTTL - time.Since(report.fetched)).Seconds())
+	if maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", report.etag)
+	w.Header().Set("Last-Modified", report.fetched.UTC().Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == report.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(report.body)
+}
+
+// handleHealth answers that the server is up.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.Health{Status: "ok"})
+}
+
+// handleReadiness serves whether the client can validate, see
+// mailify.Client.CheckReadiness, with 503 Service Unavailable if it can't.
+// Checks are reused for readinessTTL.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	s.readinessMu.Lock()
+	if time.Since(s.readinessChecked) >= readinessTTL {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		s.readiness = s.client.CheckReadiness(ctx)
+		s.readinessChecked = time.Now()
+		cancel()
+	}
+	readiness := s.readiness
+	s.readinessMu.Unlock()
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, readiness)
+}
+
+// handleOpenAPI serves the OpenAPI document of the API.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := api.OpenAPI(mailify.Version())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(doc)
+}
+
+// cachedDomain returns the cached report of a domain if it is fresh.
+func (s *Server) cachedDomain(domain string) (domainReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report, ok := s.domains[domain]
+	if !ok || time.Since(report.fetched) >= s.domainTTL {
+		return domainReport{}, false
+	}
+	return report, true
+}
+
+// cacheDomain caches the report of a domain, making room if the cache is full.
+func (s *Server) cacheDomain(domain string, report domainReport) {
+	if s.domainTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.domains) >= maxCachedDomains {
+		oldest := ""
+		for cached, r := range s.domains {
+			if time.Since(r.fetched) >= s.domainTTL {
+				delete(s.domains, cached)
+			} else if oldest == "" || r.fetched.Before(s.domains[oldest].fetched) {
+				oldest = cached
+			}
+		}
+		if len(s.domains) >= maxCachedDomains {
+			delete(s.domains, oldest)
+		}
+	}
+	s.domains[domain] = report
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, api.Error{Error: err.Error()})
+}
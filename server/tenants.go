@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+	"gopkg.in/yaml.v3"
+)
+
+// Tenant is a team or product served by a shared server, with its own API
+// keys and policies, see WithTenants. A tenant's validations, lookups and
+// jobs are its own: it can't see the jobs of others.
+type Tenant struct {
+	// Name names the tenant, in errors and job reports.
+	Name string `json:"name" yaml:"name"`
+	// APIKeys are the keys the tenant's requests carry, in the X-API-Key
+	// header or as a Bearer token.
+	APIKeys []string `json:"api_keys" yaml:"api_keys"`
+	// Sender is the MAIL FROM address of the tenant's validations, the
+	// client's if empty.
+	Sender string `json:"sender,omitempty" yaml:"sender,omitempty"`
+	// MaxInFlight caps the tenant's validations in flight at once, in
+	// place of WithClientLimit. 0 leaves WithClientLimit to cap them.
+	MaxInFlight int `json:"max_in_flight,omitempty" yaml:"max_in_flight,omitempty"`
+	// RequestsPerMinute caps the tenant's requests in each minute. 0 means no cap.
+	RequestsPerMinute int `json:"requests_per_minute,omitempty" yaml:"requests_per_minute,omitempty"`
+	// Allow are the domains the tenant may validate addresses at and look
+	// up, as patterns such as "example.com" or "*.example.com". Any domain
+	// if empty.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// Deny are the domains the tenant may not validate addresses at or
+	// look up, even if Allow matches them.
+	Deny []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+	// Webhooks are URLs the mailify.JobReport of each of the tenant's bulk
+	// jobs is posted to as JSON once it has finished.
+	Webhooks []string `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+}
+
+// tenantConfig is the file LoadTenants reads.
+type tenantConfig struct {
+	Tenants []Tenant `json:"tenants" yaml:"tenants"`
+}
+
+// tenantKey is the context key of the tenant a request was made by.
+type tenantKey struct{}
+
+// rateWindow counts a tenant's requests in the current minute.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// LoadTenants reads the tenants of a server and checks them:
+//
+//	tenants:
+//	  - name: growth
+//	    api_keys: [growth-7f3a9c]
+//	    sender: verify@growth.example.com
+//	    max_in_flight: 5
+//	    requests_per_minute: 600
+//	    deny: ["*.gov"]
+//	    webhooks: [https://hooks.example.com/mailify]
+//
+// Parameters:
+//   - file: The file, YAML if it ends in .yaml or .yml and JSON otherwise.
+//
+// Returns:
+//   - []Tenant: The tenants, to pass to WithTenants.
+//   - error: An error if the file can't be read or parsed, or a tenant has
+//     no name or API key, shares one with another tenant, or has an invalid
+//     sender or domain pattern.
+func LoadTenants(file string) ([]Tenant, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants: %w", err)
+	}
+	var config tenantConfig
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &config)
+	default:
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenants: %w", err)
+	}
+	if err := checkTenants(config.Tenants); err != nil {
+		return nil, fmt.Errorf("invalid tenants: %w", err)
+	}
+	return config.Tenants, nil
+}
+
+// checkTenants checks that tenants have distinct names and API keys, and
+// valid senders and domain patterns.
+func checkTenants(tenants []Tenant) error {
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, t := range tenants {
+		if t.Name == "" {
+			return errors.New("tenant without a name")
+		}
+		if names[t.Name] {
+			return fmt.Errorf("tenant %q is defined twice", t.Name)
+		}
+		names[t.Name] = true
+		if len(t.APIKeys) == 0 {
+			return fmt.Errorf("tenant %q has no API key", t.Name)
+		}
+		for _, key := range t.APIKeys {
+			if key == "" || keys[key] {
+				return fmt.Errorf("tenant %q has an empty API key or one another tenant has", t.Name)
+			}
+			keys[key] = true
+		}
+		if t.Sender != "" {
+			if _, err := mailify.NormalizeEmail(t.Sender); err != nil {
+				return fmt.Errorf("tenant %q: invalid sender %q: %w", t.Name, t.Sender, err)
+			}
+		}
+		for _, pattern := range append(append([]string(nil), t.Allow...), t.Deny...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("tenant %q: invalid domain pattern %q", t.Name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// WithTenants serves several tenants with their own API keys and policies,
+// see Tenant. Every request but those of health checks and the OpenAPI
+// document must then carry an API key, or is answered with 401
+// Unauthorized. The tenants are expected to be checked, as LoadTenants
+// checks them.
+func WithTenants(tenants []Tenant) Option {
+	return func(s *Server) {
+		s.tenants = make(map[string]*Tenant)
+		for i := range tenants {
+			t := &tenants[i]
+			for _, key := range t.APIKeys {
+				s.tenants[key] = t
+			}
+		}
+	}
+}
+
+// authenticated wraps a handler so requests must carry the API key of a
+// tenant, if the server has tenants, and stay within its rate limit. The
+// tenant is put in the request's context.
+func (s *Server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(s.tenants) == 0 {
+			h(w, r)
+			return
+		}
+		t, ok := s.tenants[apiKey(r)]
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mailify"`)
+			writeError(w, http.StatusUnauthorized, errors.New("a valid API key is required, in the X-API-Key header or as a Bearer token"))
+			return
+		}
+		if wait, ok := s.allowRequest(t); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			writeError(w, http.StatusTooManyRequests, fmt.Errorf("rate limit of %d requests a minute exceeded", t.RequestsPerMinute))
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, t)))
+	}
+}
+
+// apiKey returns the API key a request carries, in the X-API-Key header or
+// as a Bearer token.
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// allowRequest counts a request of a tenant against its rate limit,
+// returning how long until the next minute starts if it is exceeded.
+func (s *Server) allowRequest(t *Tenant) (time.Duration, bool) {
+	if t.RequestsPerMinute <= 0 {
+		return 0, true
+	}
+	s.ratesMu.Lock()
+	defer s.ratesMu.Unlock()
+	window := s.rates[t.Name]
+	if time.Since(window.start) >= time.Minute {
+		window = rateWindow{start: time.Now()}
+	}
+	if window.count >= t.RequestsPerMinute {
+		return time.Minute - time.Since(window.start), false
+	}
+	window.count++
+	s.rates[t.Name] = window
+	return 0, true
+}
+
+// tenantOf returns the tenant a request was made by, nil if the server has
+// no tenants.
+func tenantOf(r *http.Request) *Tenant {
+	t, _ := r.Context().Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// allows reports whether the tenant may validate addresses at a domain and
+// look it up.
+func (t *Tenant) allows(domain string) bool {
+	if t == nil {
+		return true
+	}
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToLower(pattern), domain); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(t.Deny) {
+		return false
+	}
+	return len(t.Allow) == 0 || matches(t.Allow)
+}
+
+// checkDomain answers 403 Forbidden if the tenant of a request may not
+// validate addresses at a domain, and reports whether it may.
+func checkDomain(w http.ResponseWriter, r *http.Request, domain string) bool {
+	t := tenantOf(r)
+	if t.allows(domain) {
+		return true
+	}
+	writeError(w, http.StatusForbidden, fmt.Errorf("tenant %q may not validate addresses at %s", t.Name, domain))
+	return false
+}
+
+// notifyTenant posts the report of a finished job to the webhooks of its
+// tenant. A webhook failing doesn't fail the job.
+func notifyTenant(t *Tenant, report mailify.JobReport) {
+	if t == nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, url := range t.Webhooks {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			notifier := &mailify.WebhookNotifier{URL: url}
+			if err := notifier.Notify(ctx, report); err != nil {
+				fmt.Printf("Warning: notification of tenant %q failed: %v\n", t.Name, err)
+			}
+		}(url)
+	}
+	wg.Wait()
+}
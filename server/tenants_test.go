@@ -0,0 +1,126 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/mailifytest"
+	"github.com/adarsh-jaiss/mailify/server"
+)
+
+// testTenants are the tenants of the tenant tests.
+var testTenants = []server.Tenant{
+	{Name: "growth", APIKeys: []string{"growth-key"}, Sender: "verify@growth.example", Deny: []string{"*.gov"}},
+	{Name: "partners", APIKeys: []string{"partners-key"}, Allow: []string{"example.com", "*.example.com"}},
+	{Name: "limited", APIKeys: []string{"limited-key"}, RequestsPerMinute: 1},
+}
+
+// newTenantServer creates a server for testTenants whose mail is handled by
+// srv.
+func newTenantServer(t *testing.T, srv *mailifytest.Server) *server.Server {
+	t.Helper()
+	r := mailifytest.NewResolver()
+	for _, domain := range []string{"example.com", "mail.example.com", "example.org", "agency.gov"} {
+		r.AddMX(domain, "mx."+domain)
+		r.AddIP("mx."+domain, "192.0.2.1")
+	}
+	opts := append(mailifytest.Options(srv, r), mailify.WithoutSenderCheck(), mailify.WithCatchAllProbes(0))
+	c, err := mailify.NewClient("sender@sender.example", opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	s := server.New(c, server.WithTenants(testTenants))
+	t.Cleanup(func() {
+		s.Close()
+		c.Close()
+	})
+	return s
+}
+
+// validate asks s to validate email with the given headers and returns the
+// response.
+func validate(s *server.Server, email string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/validate?email="+url.QueryEscape(email), nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestTenantRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		email  string
+		header []string
+		want   int
+	}{
+		{name: "no API key", email: "user@example.com", want: http.StatusUnauthorized},
+		{name: "unknown API key", email: "user@example.com", header: []string{"X-API-Key", "other-key"}, want: http.StatusUnauthorized},
+		{name: "API key header", email: "user@example.com", header: []string{"X-API-Key", "growth-key"}, want: http.StatusOK},
+		{name: "bearer token", email: "user@example.com", header: []string{"Authorization", "Bearer growth-key"}, want: http.StatusOK},
+		{name: "denied domain", email: "user@agency.gov", header: []string{"X-API-Key", "growth-key"}, want: http.StatusForbidden},
+		{name: "denied domain in other case", email: "user@Agency.GOV", header: []string{"X-API-Key", "growth-key"}, want: http.StatusForbidden},
+		{name: "denied domain with trailing dot", email: "user@agency.gov.", header: []string{"X-API-Key", "growth-key"}, want: http.StatusForbidden},
+		{name: "denied domain with display name", email: "User <user@agency.gov>", header: []string{"X-API-Key", "growth-key"}, want: http.StatusForbidden},
+		{name: "allowed domain", email: "user@example.com", header: []string{"X-API-Key", "partners-key"}, want: http.StatusOK},
+		{name: "allowed subdomain", email: "user@mail.example.com", header: []string{"X-API-Key", "partners-key"}, want: http.StatusOK},
+		{name: "domain not allowed", email: "user@example.org", header: []string{"X-API-Key", "partners-key"}, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mailifytest.NewServer()
+			defer srv.Close()
+			s := newTenantServer(t, srv)
+
+			w := validate(s, tt.email, tt.header...)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want != http.StatusOK && len(srv.Transcript()) > 0 {
+				t.Errorf("refused request reached the mail server: %v", srv.Transcript())
+			}
+		})
+	}
+}
+
+func TestTenantSender(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	s := newTenantServer(t, srv)
+
+	if w := validate(s, "user@example.com", "X-API-Key", "growth-key"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var from string
+	for _, line := range srv.Transcript() {
+		if strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:") {
+			from = line
+		}
+	}
+	if !strings.Contains(from, "<verify@growth.example>") {
+		t.Errorf("MAIL FROM = %q, want the tenant's sender", from)
+	}
+}
+
+func TestTenantRateLimit(t *testing.T) {
+	srv := mailifytest.NewServer()
+	defer srv.Close()
+	s := newTenantServer(t, srv)
+
+	if w := validate(s, "user@example.com", "X-API-Key", "limited-key"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want 200: %s", w.Code, w.Body)
+	}
+	w := validate(s, "user@example.com", "X-API-Key", "limited-key")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second request: status = %d, Retry-After %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := validate(s, "user@example.com", "X-API-Key", "growth-key"); w.Code != http.StatusOK {
+		t.Errorf("other tenant: status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,203 @@
+// Package serverless adapts mailify's REST API to serverless platforms, for
+// signup validation whose traffic comes in bursts. Handler serves the
+// routes that answer within the request as a plain http.Handler, as Google
+// Cloud Functions and most other platforms take one:
+//
+//	functions.HTTP("mailify", serverless.Handler(client).ServeHTTP)
+//
+// and LambdaHandler turns it into an AWS Lambda function behind API Gateway:
+//
+//	lambda.Start(serverless.LambdaHandler(serverless.Handler(client)))
+//
+// Create the client once, outside the function, so warm instances share
+// its caches. Bulk jobs aren't served, as instances are frozen between
+// requests; run mailify serve for those.
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/adarsh-jaiss/mailify"
+	"github.com/adarsh-jaiss/mailify/api"
+	"github.com/adarsh-jaiss/mailify/server"
+)
+
+// statelessRoutes are the operations of the routes Handler serves, by
+// their api.Route OperationID.
+var statelessRoutes = []string{"validateEmail", "getDomain", "getOpenAPI"}
+
+// Handler returns an http.Handler serving the routes of the REST API that
+// answer within the request: GET /validate, GET /domains/{domain} and
+// GET /openapi.json. Other paths are answered with 404 Not Found. Responses
+// carry a Server header with mailify's version, as the server's do.
+//
+// Parameters:
+//   - client: The client to validate and look up domains with.
+//   - opts: Options for the server, see server.New.
+//
+// Returns:
+//   - http.Handler: The handler.
+func Handler(client *mailify.Client, opts ...server.Option) http.Handler {
+	srv := server.New(client, opts...)
+	mux := http.NewServeMux()
+	for _, route := range api.Routes {
+		for _, id := range statelessRoutes {
+			if route.OperationID == id {
+				mux.Handle(route.Method+" "+route.Path, srv)
+			}
+		}
+	}
+	header := "mailify/" + mailify.Version()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", header)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// APIGatewayProxyRequest is the event API Gateway sends a Lambda function
+// for a request, in the REST API proxy integration format (payload 1.0).
+// It decodes the events github.com/aws/aws-lambda-go passes to handlers.
+type APIGatewayProxyRequest struct {
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+}
+
+// APIGatewayProxyResponse is what a Lambda function answers API Gateway
+// with, in the REST API proxy integration format.
+type APIGatewayProxyResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// LambdaHandler returns a Lambda function serving API Gateway proxy events
+// with an http.Handler, such as Handler's, to pass to lambda.Start of
+// github.com/aws/aws-lambda-go. Bodies that aren't text are base64-encoded
+// both ways, as API Gateway expects.
+//
+// Parameters:
+//   - h: The handler to serve requests with.
+//
+// Returns:
+//   - func(context.Context, APIGatewayProxyRequest) (APIGatewayProxyResponse, error):
+//     The function. It only fails on events that don't make a valid request.
+func LambdaHandler(h http.Handler) func(context.Context, APIGatewayProxyRequest) (APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, event APIGatewayProxyRequest) (APIGatewayProxyResponse, error) {
+		req, err := newRequest(ctx, event)
+		if err != nil {
+			return APIGatewayProxyResponse{}, err
+		}
+		w := &responseWriter{header: make(http.Header)}
+		h.ServeHTTP(w, req)
+		return w.response(), nil
+	}
+}
+
+// newRequest turns an API Gateway event into the request it stands for.
+func newRequest(ctx context.Context, event APIGatewayProxyRequest) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		body = decoded
+	}
+
+	query := make(url.Values)
+	for name, values := range event.MultiValueQueryStringParameters {
+		query[name] = values
+	}
+	for name, value := range event.QueryStringParameters {
+		if _, ok := query[name]; !ok {
+			query.Set(name, value)
+		}
+	}
+	target := (&url.URL{Path: event.Path, RawQuery: query.Encode()}).RequestURI()
+
+	req, err := http.NewRequestWithContext(ctx, event.HTTPMethod, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	for name, values := range event.MultiValueHeaders {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	for name, value := range event.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Host = req.Header.Get("Host")
+	req.RequestURI = target
+	return req, nil
+}
+
+// responseWriter records a response to return to API Gateway.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header implements http.ResponseWriter.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// Write implements http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(b)
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// response returns the recorded response.
+func (w *responseWriter) response() APIGatewayProxyResponse {
+	resp := APIGatewayProxyResponse{StatusCode: w.status, MultiValueHeaders: map[string][]string(w.header)}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	if isText(w.header.Get("Content-Type")) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+// isText reports whether a response of a media type can be returned as
+// is, rather than base64-encoded.
+func isText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType == ""
+	}
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-ndjson"
+}
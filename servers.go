@@ -3,13 +3,13 @@ package mailify
 import (
 	"context"
 	"fmt"
-	"net"
 	"strings"
 	"time"
 )
 
 // GetMailServers retrieves the mail servers (MX records) for a given domain.
-// It uses a custom DNS resolver that queries Google's public DNS server (8.8.8.8).
+// It uses the client's resolver, which by default queries Google's public DNS server (8.8.8.8).
+// Lookups are served from the cache when WithMXCache is given.
 
 // Parameters:
 //   - domain: The domain name for which to look up MX records.
@@ -26,21 +26,17 @@ import (
 //   fmt.Println("Mail servers:", mailServers)
 
 func(c *Client) GetMailServers(domain string) ([]string, error) {
-	// Use custom DNS resolver to query Google's public DNS server
-
-	resolver := net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{}
-			return d.DialContext(ctx, network, "8.8.8.8:53") // Use Google DNS
-		},
+	if mailServers, err, ok := c.mxCache.get(domain); ok {
+		return mailServers, err
 	}
 
 	// Lookup MX records for the domain
-	mx, err := resolver.LookupMX(context.Background(), domain)
+	mx, err := c.resolver.LookupMX(context.Background(), domain)
 	// mx, err := net.LookupMX(domain)
 	if err != nil {
-		return nil, fmt.Errorf("error looking up MX records: %v", err)
+		err = fmt.Errorf("error looking up MX records: %w", err)
+		c.mxCache.set(domain, nil, err)
+		return nil, err
 	}
 
 	// Extract mail server hostnames
@@ -49,6 +45,8 @@ func(c *Client) GetMailServers(domain string) ([]string, error) {
 		mailServers = append(mailServers, strings.TrimSuffix(record.Host, "."))
 	}
 
+	c.mxCache.set(domain, mailServers, nil)
+
 	// Print mail servers
 	// fmt.Printf("Found mail servers for %s: %v\n", domain, mailServers)
 	return mailServers, nil
@@ -56,7 +54,12 @@ func(c *Client) GetMailServers(domain string) ([]string, error) {
 
 // GetSMTPServer attempts to find an available SMTP server for the given mail server.
 // It performs a DNS lookup to get all IP addresses (both IPv4 and IPv6) associated with the mail server,
-// and then tries to connect to common SMTP ports (587, 25, 465) on each IP address.
+// and then tries to connect to the SMTP ports in turn: DefaultSMTPPorts (25, 587, 465), or those
+// WithPorts gave, with the preferred port of the provider's politeness settings first.
+//
+// For each port the addresses are dialed Happy-Eyeballs style: attempts are started in the order
+// given by the client's IP preference, alternating between IPv6 and IPv4, and raced against each
+// other so a slow or broken address family doesn't hold up the lookup.
 //
 // If a connection is successfully established, it returns the SMTP server details including
 // the server name, port, protocol, and IP address. If no available SMTP servers are found,
@@ -68,47 +71,54 @@ func(c *Client) GetMailServers(domain string) ([]string, error) {
 // Returns:
 //   - *SMTPDetails: A struct containing the details of the SMTP server if found.
 //   - error: An error if no available SMTP servers are found or if there is a lookup failure.
-func(c *Client) GetSMTPServer(mailServer string) (*SMTPDetails, error) {
+func (c *Client) GetSMTPServer(mailServer string) (*SMTPDetails, error) {
+	return c.getSMTPServer(mailServer, &Timings{}, time.Time{})
+}
+
+// getSMTPServer does the work for GetSMTPServer, adding the time spent on DNS
+// lookups and connection attempts to timings. Connection attempts are cut
+// short at deadline, unless it is zero.
+func (c *Client) getSMTPServer(mailServer string, timings *Timings, deadline time.Time) (*SMTPDetails, error) {
 	// Get all IPs (both IPv4 and IPv6)
-	ips, err := net.LookupIP(mailServer)
+	dnsStart := time.Now()
+	ips, err := c.lookupIP(mailServer)
+	timings.DNS += time.Since(dnsStart)
 	if err != nil {
-		return nil, fmt.Errorf("failed to lookup IP for %s: %v", mailServer, err)
+		return nil, fmt.Errorf("failed to lookup IP for %s: %w", mailServer, err)
 	}
+	if !c.allowBogonMX {
+		public := publicIPs(ips)
+		if len(public) == 0 {
+			return nil, fmt.Errorf("%w: %s resolves to %v", ErrBogonMX, mailServer, ips)
+		}
+		ips = public
+	}
+
+	// Try common SMTP ports, the provider's preferred one first
+	var lastErr error
+	for _, port := range c.smtpPorts(mailServer) {
+		if expired(deadline) {
+			return nil, fmt.Errorf("no available SMTP servers found for %s: %w", mailServer, ErrValidationTimeout)
+		}
 
-	// Try each IP address
-	for _, ip := range ips {
-		// Try common SMTP ports
-		ports := []string{"587", "25", "465"}
-		for _, port := range ports {
-			// Format address based on IP version
-			var address string
-			if ip.To4() != nil {
-				// IPv4
-				address = fmt.Sprintf("%s:%s", ip.String(), port)
-			} else {
-				// IPv6 - wrap in square brackets
-				address = fmt.Sprintf("[%s]:%s", ip.String(), port)
-			}
-
-			// Set timeout for connection
-			smtpTimeout := time.Duration(time.Second * 5)
-
-			// Try to connect
-			conn, err := net.DialTimeout("tcp", address, smtpTimeout)
-			if err != nil {
-				continue
-			}
-			defer conn.Close()
-
-			return &SMTPDetails{
-				Server:    mailServer,
-				Port:      port,
-				Protocol:  "SMTP",
-				IPAddress: ip.String(),
-			}, nil
+		// Race the addresses against each other
+		connectStart := time.Now()
+		conn, ip, err := c.dialHappyEyeballs(ips, port, within(c.connectTimeout, deadline))
+		timings.Connect += time.Since(connectStart)
+		if err != nil {
+			lastErr = err
+			continue
 		}
+		conn.Close()
+
+		return &SMTPDetails{
+			Server:    mailServer,
+			Port:      port,
+			Protocol:  "SMTP",
+			IPAddress: ip.String(),
+		}, nil
 	}
-	return nil, fmt.Errorf("no available SMTP servers found for %s", mailServer)
+	return nil, fmt.Errorf("no available SMTP servers found for %s: %w", mailServer, lastErr)
 }
 
 // GetMailServersFromReceipientEmail extracts the domain from the given email address
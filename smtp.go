@@ -0,0 +1,313 @@
+package mailify
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpSession is a minimal SMTP client built on net/textproto. Unlike net/smtp
+// it keeps hold of the server greeting and the complete EHLO extension list,
+// which are recorded on SMTPDetails to help debug provider-specific behavior.
+type smtpSession struct {
+	conn       net.Conn
+	text       *textproto.Conn
+	serverName string
+	localName  string
+	banner     string
+	ext        map[string]string
+	tls        bool
+}
+
+// newSMTPSession wraps an established connection and reads the server's 220
+// greeting. The connection is closed if the greeting cannot be read.
+func newSMTPSession(conn net.Conn, serverName string) (*smtpSession, error) {
+	text := textproto.NewConn(conn)
+	_, banner, err := text.ReadResponse(220)
+	if err != nil {
+		text.Close()
+		return nil, err
+	}
+
+	_, isTLS := conn.(*tls.Conn)
+	return &smtpSession{
+		conn:       conn,
+		text:       text,
+		serverName: serverName,
+		banner:     banner,
+		tls:        isTLS,
+	}, nil
+}
+
+// setDeadline sets when the conversation is cut short, the zero time for never.
+func (s *smtpSession) setDeadline(t time.Time) {
+	s.conn.SetDeadline(t)
+}
+
+// cmd sends a single command and reads its response, expecting expectCode.
+func (s *smtpSession) cmd(expectCode int, format string, args ...any) (int, string, error) {
+	id, err := s.text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	s.text.StartResponse(id)
+	defer s.text.EndResponse(id)
+	return s.text.ReadResponse(expectCode)
+}
+
+// hello sends EHLO, falling back to HELO for servers that do not support
+// ESMTP. The extensions advertised in the EHLO response are recorded.
+func (s *smtpSession) hello(localName string) error {
+	if err := validateLine(localName); err != nil {
+		return err
+	}
+	s.localName = localName
+
+	_, msg, err := s.cmd(250, "EHLO %s", localName)
+	if err != nil {
+		s.ext = nil
+		_, _, err = s.cmd(250, "HELO %s", localName)
+		return err
+	}
+
+	ext := make(map[string]string)
+	lines := strings.Split(msg, "\n")
+	// The first line is the server's greeting, the rest are extensions.
+	for _, line := range lines[1:] {
+		keyword, param, _ := strings.Cut(strings.TrimSpace(line), " ")
+		ext[strings.ToUpper(keyword)] = param
+	}
+	s.ext = ext
+	return nil
+}
+
+// extension reports whether the server advertised the given EHLO keyword,
+// along with its parameters.
+func (s *smtpSession) extension(name string) (bool, string) {
+	param, ok := s.ext[strings.ToUpper(name)]
+	return ok, param
+}
+
+// startTLS upgrades the session with STARTTLS and repeats EHLO, as the
+// extension list is allowed to change once the channel is encrypted.
+func (s *smtpSession) startTLS(config *tls.Config) error {
+	if _, _, err := s.cmd(220, "STARTTLS"); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(s.conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	s.conn = tlsConn
+	s.text = textproto.NewConn(s.conn)
+	s.tls = true
+	return s.hello(s.localName)
+}
+
+// mail sends MAIL FROM for the given sender address.
+func (s *smtpSession) mail(from string) error {
+	line, err := s.mailCommand(from)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.cmd(250, "%s", line)
+	return err
+}
+
+// mailCommand builds the MAIL FROM command for the given sender address,
+// asking for 8BITMIME and SMTPUTF8 when the server supports them.
+func (s *smtpSession) mailCommand(from string) (string, error) {
+	if err := validateLine(from); err != nil {
+		return "", err
+	}
+	line := fmt.Sprintf("MAIL FROM:<%s>", from)
+	if ok, _ := s.extension("8BITMIME"); ok {
+		line += " BODY=8BITMIME"
+	}
+	if ok, _ := s.extension("SMTPUTF8"); ok {
+		line += " SMTPUTF8"
+	}
+	return line, nil
+}
+
+// rcpt sends RCPT TO for the given recipient address and returns the reply.
+// Any 25x reply is treated as success, other replies are returned as a
+// *textproto.Error.
+func (s *smtpSession) rcpt(to string) (int, string, error) {
+	if err := validateLine(to); err != nil {
+		return 0, "", err
+	}
+	return s.cmd(25, "RCPT TO:<%s>", to)
+}
+
+// reply is the server's reply to one command of a transaction.
+type reply struct {
+	code int
+	msg  string
+	// err is a *textproto.Error if the reply was not the expected one.
+	err error
+	// took is the time between the previous reply, or the write, and this one.
+	took time.Duration
+}
+
+// transaction sends MAIL FROM followed by RCPT TO for each recipient and
+// returns the replies. If the server advertises PIPELINING (RFC 2920) the
+// commands go out in a single write, saving a round trip per command.
+// Otherwise they are sent one at a time, stopping if MAIL FROM is refused or
+// a recipient hits the server's recipient limit. err is set if the
+// connection failed; the replies read until then are still returned.
+func (s *smtpSession) transaction(from string, to []string) (mail reply, rcpts []reply, err error) {
+	mailLine, err := s.mailCommand(from)
+	if err != nil {
+		return reply{}, nil, err
+	}
+	return s.exchange(mailLine, to)
+}
+
+// addRecipients sends RCPT TO for more recipients within the current
+// transaction, the same way transaction does.
+func (s *smtpSession) addRecipients(to []string) ([]reply, error) {
+	_, rcpts, err := s.exchange("", to)
+	return rcpts, err
+}
+
+// exchange does the work for transaction and addRecipients, sending mailLine
+// first unless it is empty.
+func (s *smtpSession) exchange(mailLine string, to []string) (mail reply, rcpts []reply, err error) {
+	for _, addr := range to {
+		if err := validateLine(addr); err != nil {
+			return reply{}, nil, err
+		}
+	}
+
+	if ok, _ := s.extension("PIPELINING"); !ok {
+		if mailLine != "" {
+			start := time.Now()
+			mail.code, mail.msg, mail.err = s.cmd(250, "%s", mailLine)
+			mail.took = time.Since(start)
+			if mail.err != nil {
+				return mail, nil, connError(mail.err)
+			}
+		}
+		for _, addr := range to {
+			start := time.Now()
+			code, msg, err := s.rcpt(addr)
+			if err := connError(err); err != nil {
+				return mail, rcpts, err
+			}
+			rcpts = append(rcpts, reply{code: code, msg: msg, err: err, took: time.Since(start)})
+			if isTooManyRecipients(code, msg) {
+				break
+			}
+		}
+		return mail, rcpts, nil
+	}
+
+	// Pipelined: write everything, then read the replies in order
+	id := s.text.Next()
+	s.text.StartRequest(id)
+	w := s.text.Writer.W
+	if mailLine != "" {
+		w.WriteString(mailLine + "\r\n")
+	}
+	for _, addr := range to {
+		fmt.Fprintf(w, "RCPT TO:<%s>\r\n", addr)
+	}
+	err = w.Flush()
+	s.text.EndRequest(id)
+	if err != nil {
+		return reply{}, nil, err
+	}
+
+	s.text.StartResponse(id)
+	defer s.text.EndResponse(id)
+
+	start := time.Now()
+	read := func(expectCode int) (reply, error) {
+		var r reply
+		r.code, r.msg, r.err = s.text.ReadResponse(expectCode)
+		r.took = time.Since(start)
+		start = time.Now()
+		return r, connError(r.err)
+	}
+
+	if mailLine != "" {
+		if mail, err = read(250); err != nil {
+			return mail, nil, err
+		}
+	}
+	for range to {
+		r, err := read(25)
+		if err != nil {
+			return mail, rcpts, err
+		}
+		rcpts = append(rcpts, r)
+	}
+	if mail.err != nil {
+		// The recipients were refused for want of a sender, their replies mean nothing
+		rcpts = nil
+	}
+	return mail, rcpts, nil
+}
+
+// connError returns err if it means the connection failed rather than the
+// server replying with an unexpected code.
+func connError(err error) error {
+	var protoErr *textproto.Error
+	if err == nil || errors.As(err, &protoErr) {
+		return nil
+	}
+	return err
+}
+
+// data sends DATA followed by the message, which must use CRLF line endings.
+// Lines starting with a dot are escaped as the protocol requires.
+func (s *smtpSession) data(msg []byte) error {
+	if _, _, err := s.cmd(354, "DATA"); err != nil {
+		return err
+	}
+	w := s.text.DotWriter()
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	_, _, err := s.text.ReadResponse(250)
+	return err
+}
+
+// reset sends RSET, aborting the current mail transaction so the session can
+// be used for another one.
+func (s *smtpSession) reset() error {
+	_, _, err := s.cmd(250, "RSET")
+	return err
+}
+
+// quit sends QUIT and closes the connection.
+func (s *smtpSession) quit() error {
+	_, _, err := s.cmd(221, "QUIT")
+	if err != nil {
+		return err
+	}
+	return s.text.Close()
+}
+
+// close closes the underlying connection without sending QUIT.
+func (s *smtpSession) close() error {
+	return s.text.Close()
+}
+
+// validateLine checks that a value does not contain CR or LF, which would
+// allow injecting additional SMTP commands.
+func validateLine(line string) error {
+	if strings.ContainsAny(line, "\n\r") {
+		return fmt.Errorf("smtp: a line must not contain CR or LF")
+	}
+	return nil
+}
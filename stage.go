@@ -0,0 +1,85 @@
+package mailify
+
+import "time"
+
+// Stage is a custom validation step, such as a lookup in an internal
+// directory, run by ValidateEmail alongside the built-in stages.
+type Stage interface {
+	// Name identifies the stage in StageEvents, and is the key its fields are
+	// stored under in ValidationResult.Extra.
+	Name() StageName
+	// Run checks the address. It may record findings in input.Fields.
+	// Returning a result ends validation with it; returning an error reports
+	// it to the OnStageComplete hooks, but validation carries on.
+	Run(input StageInput) (*ValidationResult, error)
+}
+
+// StageInput is what a custom Stage gets to work with.
+type StageInput struct {
+	// Email is the normalized address being validated.
+	Email string
+	// Domain is the domain of the address.
+	Domain string
+	// MailServers holds the domain's mail servers for stages that run after the MX stage.
+	MailServers []string
+	// Extra holds the fields recorded by the stages that ran before. It must not be modified.
+	Extra map[string]any
+	// Fields is where the stage records its findings. They end up in
+	// ValidationResult.Extra under the stage's name.
+	Fields map[string]any
+}
+
+// customStage is a Stage together with the built-in stage it runs before.
+type customStage struct {
+	stage  Stage
+	before StageName
+}
+
+// WithStage registers a custom stage that runs after the syntax check, right
+// before the built-in stage named by before: StageMX, or StageSMTP for any
+// other value. Stages registered for the same place run in the order they
+// were added. Stages run concurrently in bulk runs, so they must be safe for
+// concurrent use.
+func WithStage(before StageName, stage Stage) Option {
+	if before != StageMX {
+		before = StageSMTP
+	}
+	return func(c *Client) {
+		c.stages = append(c.stages, customStage{stage: stage, before: before})
+	}
+}
+
+// runStages runs the custom stages registered before the given built-in stage
+// and returns the result validation should end with, or nil if it should continue.
+func (c *Client) runStages(before StageName, email string, mailServers []string, v *validation) *ValidationResult {
+	for _, cs := range c.stages {
+		if cs.before != before {
+			continue
+		}
+
+		start := time.Now()
+		fields := make(map[string]any)
+		result, err := cs.stage.Run(StageInput{
+			Email:       email,
+			Domain:      emailDomain(email),
+			MailServers: mailServers,
+			Extra:       v.extra,
+			Fields:      fields,
+		})
+		if len(fields) > 0 {
+			if v.extra == nil {
+				v.extra = make(map[string]any)
+			}
+			v.extra[string(cs.stage.Name())] = fields
+		}
+
+		event := StageEvent{Email: email, Stage: cs.stage.Name(), Duration: time.Since(start), MailServers: mailServers, Result: result, Err: err}
+		if result = c.finishStage(event); result != nil {
+			if result.Provenance == nil {
+				result.Provenance = &Provenance{Source: ProvenanceStage, Detail: string(cs.stage.Name())}
+			}
+			return result
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package mailify
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateWindow is how far back RunStats looks to measure the current rate.
+const rateWindow = 30 * time.Second
+
+// RunStats tracks the progress of bulk runs as they go: how many addresses
+// are done and with which verdicts, how fast they are being validated, how
+// many each domain has left, and when the runs should finish. Set it on
+// BulkOptions.Stats and read it with Snapshot from any goroutine, e.g. to
+// drive a progress bar. Runs given the same RunStats, such as the sheets of
+// a file, are tracked together. A RunStats is safe for concurrent use.
+type RunStats struct {
+	mu         sync.Mutex
+	start      time.Time
+	total      int
+	done       int
+	errors     int
+	duplicates int
+	verdicts   map[Verdict]int
+	backlog    map[string]int
+	recent     []time.Time
+}
+
+// NewRunStats creates stats with nothing to track yet.
+func NewRunStats() *RunStats {
+	return &RunStats{verdicts: make(map[Verdict]int), backlog: make(map[string]int)}
+}
+
+// RunSnapshot is the progress of the runs a RunStats tracks at one moment.
+type RunSnapshot struct {
+	// Total is the number of addresses the runs were given.
+	Total int `json:"total"`
+	// Done is the number of addresses finished, errors and duplicates included.
+	Done int `json:"done"`
+	// Verdicts counts the validated addresses by verdict.
+	Verdicts map[Verdict]int `json:"verdicts"`
+	// Errors is the number of addresses whose validation failed.
+	Errors int `json:"errors"`
+	// Duplicates is the number of addresses skipped by BulkOptions.Dedupe.
+	Duplicates int `json:"duplicates"`
+	// Backlog is the number of addresses each domain has left, for the
+	// domains with any left.
+	Backlog map[string]int `json:"backlog"`
+	// Elapsed is the time since the first run started.
+	Elapsed time.Duration `json:"elapsed"`
+	// Rate is the number of addresses validated per second over the last 30
+	// seconds, duplicates left out.
+	Rate float64 `json:"rate"`
+	// ETA is how much longer the runs should take at Rate, 0 if they are done
+	// or nothing has finished recently.
+	ETA time.Duration `json:"eta"`
+}
+
+// Remaining is the number of addresses not finished yet.
+func (s RunSnapshot) Remaining() int {
+	return s.Total - s.Done
+}
+
+// String formats the progress for a progress line, e.g.
+// "120/1000, 4.2/s, ETA 3m30s".
+func (s RunSnapshot) String() string {
+	if s.ETA == 0 {
+		return fmt.Sprintf("%d/%d, %.1f/s", s.Done, s.Total, s.Rate)
+	}
+	return fmt.Sprintf("%d/%d, %.1f/s, ETA %s", s.Done, s.Total, s.Rate, s.ETA.Round(time.Second))
+}
+
+// Snapshot returns the progress so far.
+//
+// Returns:
+//   - RunSnapshot: A copy of the progress, safe to keep.
+func (s *RunStats) Snapshot() RunSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.trim(now)
+
+	snap := RunSnapshot{
+		Total:      s.total,
+		Done:       s.done,
+		Verdicts:   make(map[Verdict]int, len(s.verdicts)),
+		Errors:     s.errors,
+		Duplicates: s.duplicates,
+		Backlog:    make(map[string]int, len(s.backlog)),
+	}
+	for verdict, n := range s.verdicts {
+		snap.Verdicts[verdict] = n
+	}
+	for domain, n := range s.backlog {
+		snap.Backlog[domain] = n
+	}
+	if s.start.IsZero() {
+		return snap
+	}
+	snap.Elapsed = now.Sub(s.start)
+
+	// Early on the window reaches back before the start
+	window := min(rateWindow, snap.Elapsed)
+	if window > 0 {
+		snap.Rate = float64(len(s.recent)) / window.Seconds()
+	}
+	if snap.Rate > 0 && snap.Remaining() > 0 {
+		snap.ETA = time.Duration(float64(snap.Remaining()) / snap.Rate * float64(time.Second))
+	}
+	return snap
+}
+
+// add counts the addresses of a run starting.
+func (s *RunStats) add(emails []string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	s.total += len(emails)
+	for _, email := range emails {
+		s.backlog[emailDomain(email)]++
+	}
+}
+
+// record counts an address finishing.
+func (s *RunStats) record(res BulkResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.done++
+	switch {
+	case errors.Is(res.Err, ErrDuplicate):
+		// Skipped at once, so left out of the rate
+		s.duplicates++
+	case res.Err != nil:
+		s.errors++
+		s.recent = append(s.recent, now)
+	default:
+		s.verdicts[res.Result.Verdict]++
+		s.recent = append(s.recent, now)
+	}
+	domain := emailDomain(res.Email)
+	if s.backlog[domain]--; s.backlog[domain] <= 0 {
+		delete(s.backlog, domain)
+	}
+	s.trim(now)
+}
+
+// trim forgets the finishes older than the rate window.
+func (s *RunStats) trim(now time.Time) {
+	cut := 0
+	for cut < len(s.recent) && now.Sub(s.recent[cut]) > rateWindow {
+		cut++
+	}
+	s.recent = s.recent[cut:]
+}
@@ -0,0 +1,102 @@
+package mailify
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// StatusCode is an enhanced mail system status code (RFC 3463), such as 5.1.1
+// for an unknown mailbox, which many servers put at the start of their
+// replies to say more precisely than the reply code why they answered so.
+type StatusCode struct {
+	// Class is 2 for success, 4 for a temporary and 5 for a permanent failure.
+	Class int
+	// Subject is what the status is about, e.g. 1 for addressing and 2 for the mailbox.
+	Subject int
+	// Detail narrows down the subject, e.g. 1.1 for a mailbox that doesn't exist.
+	Detail int
+}
+
+// statusCodePattern matches an enhanced status code. The code must not be
+// part of a longer dotted number, such as an IP address.
+var statusCodePattern = regexp.MustCompile(`(?:^|[^\d.])([245])\.(\d{1,3})\.(\d{1,3})(\.?\d*)`)
+
+// ParseStatusCode finds the enhanced status code in the text of an SMTP reply.
+//
+// Parameters:
+//   - msg: The reply text, e.g. "5.1.1 <jane@example.com>: Recipient address rejected".
+//
+// Returns:
+//   - StatusCode: The first enhanced status code in msg.
+//   - bool: false if msg has none.
+func ParseStatusCode(msg string) (StatusCode, bool) {
+	for _, match := range statusCodePattern.FindAllStringSubmatch(msg, -1) {
+		if strings.Trim(match[4], ".") != "" {
+			continue
+		}
+		class, _ := strconv.Atoi(match[1])
+		subject, _ := strconv.Atoi(match[2])
+		detail, _ := strconv.Atoi(match[3])
+		return StatusCode{Class: class, Subject: subject, Detail: detail}, true
+	}
+	return StatusCode{}, false
+}
+
+// IsZero reports whether s is the zero StatusCode, which replies without an
+// enhanced status code get.
+func (s StatusCode) IsZero() bool {
+	return s == StatusCode{}
+}
+
+// String returns s in dotted form, e.g. "5.1.1", or "" if s is zero.
+func (s StatusCode) String() string {
+	if s.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d", s.Class, s.Subject, s.Detail)
+}
+
+// MarshalText encodes s in dotted form.
+func (s StatusCode) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes s from dotted form. Empty text decodes to the zero StatusCode.
+func (s *StatusCode) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = StatusCode{}
+		return nil
+	}
+	code, ok := ParseStatusCode(string(text))
+	if !ok || code.String() != string(text) {
+		return fmt.Errorf("invalid status code %q", text)
+	}
+	*s = code
+	return nil
+}
+
+// statusReasons maps the subject and detail of enhanced status codes to the
+// SubStatus they stand for, whatever their class.
+var statusReasons = map[[2]int]SubStatus{
+	{1, 1}:  SubStatusMailboxNotFound,     // Bad destination mailbox address
+	{2, 1}:  SubStatusMailboxDisabled,     // Mailbox disabled, not accepting messages
+	{2, 2}:  SubStatusMailboxFull,         // Mailbox full
+	{3, 1}:  SubStatusInsufficientStorage, // Mail system full
+	{7, 1}:  SubStatusReverseDNSRequired,  // Delivery not authorized, commonly for lack of reverse DNS
+	{7, 25}: SubStatusReverseDNSRequired,  // Reverse DNS validation failed (RFC 7372)
+}
+
+// reason returns the SubStatus s stands for, or "" if it doesn't stand for one.
+func (s StatusCode) reason() SubStatus {
+	return statusReasons[[2]int{s.Subject, s.Detail}]
+}
+
+// SMTPReply is the mail server's reply to RCPT TO.
+type SMTPReply struct {
+	// Code is the reply code, e.g. 550.
+	Code int `json:"code"`
+	// Status is the enhanced status code of the reply, zero if it had none.
+	Status StatusCode `json:"status"`
+}
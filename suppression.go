@@ -0,0 +1,124 @@
+package mailify
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressionList holds the addresses and domains never to send to, such as
+// those that hard bounced, complained or unsubscribed. It is an
+// EvidenceSource: its entries are undeliverable, with SubStatusSuppressed.
+// It is safe for concurrent use.
+type SuppressionList struct {
+	mu sync.RWMutex
+	// entries holds when each address or domain was suppressed and why, by its lowercase form.
+	entries map[string]suppression
+}
+
+// suppression is an entry of a SuppressionList.
+type suppression struct {
+	at     time.Time
+	reason string
+}
+
+// NewSuppressionList returns an empty suppression list.
+func NewSuppressionList() *SuppressionList {
+	return &SuppressionList{entries: make(map[string]suppression)}
+}
+
+// LoadSuppressionList reads a suppression list from a file with an address
+// or domain per line, optionally followed by a comma and the reason, such as
+// "jane@example.com,hard bounce". Blank lines and lines starting with # are
+// skipped. Entries are taken to be suppressed when the file was last
+// modified.
+//
+// Parameters:
+//   - path: The file to read.
+//
+// Returns:
+//   - *SuppressionList: The list.
+//   - error: An error if the file can't be read.
+func LoadSuppressionList(path string) (*SuppressionList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open suppression list: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open suppression list: %w", err)
+	}
+
+	list := NewSuppressionList()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, reason, _ := strings.Cut(line, ",")
+		list.Suppress(strings.TrimSpace(entry), strings.TrimSpace(reason), info.ModTime())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read suppression list: %w", err)
+	}
+	return list, nil
+}
+
+// Suppress adds an address, or a domain to suppress all its addresses.
+//
+// Parameters:
+//   - entry: The address or domain.
+//   - reason: Why it is suppressed, such as "hard bounce". May be empty.
+//   - at: When it was suppressed.
+func (s *SuppressionList) Suppress(entry, reason string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[strings.ToLower(strings.TrimSuffix(entry, "."))] = suppression{at: at, reason: reason}
+}
+
+// lookup returns the entry suppressing an address, its own or its domain's.
+func (s *SuppressionList) lookup(email string) (suppression, bool) {
+	email = strings.ToLower(email)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if entry, ok := s.entries[email]; ok {
+		return entry, true
+	}
+	entry, ok := s.entries[emailDomain(email)]
+	return entry, ok
+}
+
+// Name implements EvidenceSource.
+func (s *SuppressionList) Name() string {
+	return "suppression_list"
+}
+
+// Evidence implements EvidenceSource.
+func (s *SuppressionList) Evidence(email string) (*Evidence, error) {
+	entry, ok := s.lookup(email)
+	if !ok {
+		return nil, nil
+	}
+	message := "Address is on the suppression list"
+	if entry.reason != "" {
+		message += ": " + entry.reason
+	}
+	return &Evidence{
+		Source: s.Name(),
+		At:     entry.at,
+		Result: &ValidationResult{
+			Verdict:         VerdictUndeliverable,
+			SubStatus:       SubStatusSuppressed,
+			Confidence:      ConfidenceHigh,
+			NormalizedEmail: strings.ToLower(email),
+			ErrorMessage:    message,
+			ValidatedAt:     entry.at,
+			Provenance:      &Provenance{Source: ProvenanceSuppressionList, Detail: entry.reason, At: entry.at},
+		},
+	}, nil
+}
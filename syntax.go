@@ -0,0 +1,222 @@
+package mailify
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// atomSpecials are the characters besides letters and digits allowed in an unquoted local part (RFC 5322 atext).
+const atomSpecials = "!#$%&'*+-/=?^_`{|}~"
+
+// NormalizeEmail checks that an email address is syntactically valid and
+// returns it in a canonical form: surrounding whitespace and angle brackets
+// are removed, and the domain is lowercased without a trailing dot. The local
+// part is left as is, since mail servers may treat it case-sensitively.
+//
+// Addresses follow RFC 5321: a local part of at most 64 characters, either a
+// dot-atom or a quoted string, and a domain name of at most 253 characters
+// with at least two labels, or an address literal such as [192.0.2.1].
+// Non-ASCII characters are allowed for internationalized addresses.
+//
+// Parameters:
+//   - email: The email address to normalize.
+//
+// Returns:
+//   - string: The normalized address.
+//   - error: An error describing why the address is invalid.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if strings.HasPrefix(email, "<") && strings.HasSuffix(email, ">") {
+		email = strings.TrimSpace(email[1 : len(email)-1])
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "", fmt.Errorf("missing @ sign")
+	}
+	local, domain := email[:at], email[at+1:]
+
+	if err := checkLocalPart(local); err != nil {
+		return "", err
+	}
+
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if err := checkDomain(domain); err != nil {
+		return "", err
+	}
+
+	return local + "@" + domain, nil
+}
+
+// checkLocalPart reports whether the part of an address before the @ is valid.
+func checkLocalPart(local string) error {
+	switch {
+	case local == "":
+		return fmt.Errorf("empty local part")
+	case len(local) > 64:
+		return fmt.Errorf("local part is longer than 64 characters")
+	}
+
+	if len(local) >= 2 && local[0] == '"' && local[len(local)-1] == '"' {
+		quoted := local[1 : len(local)-1]
+		for i := 0; i < len(quoted); i++ {
+			switch quoted[i] {
+			case '\r', '\n':
+				return fmt.Errorf("line break in quoted local part")
+			case '\\':
+				i++ // the next character is escaped
+			case '"':
+				return fmt.Errorf("unescaped quote in local part")
+			}
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return fmt.Errorf("misplaced dot in local part")
+	}
+	for _, r := range local {
+		if !isAlnum(r) && r < 0x80 && r != '.' && !strings.ContainsRune(atomSpecials, r) {
+			return fmt.Errorf("invalid character %q in local part", r)
+		}
+	}
+	return nil
+}
+
+// checkDomain reports whether the lowercased part of an address after the @ is valid.
+func checkDomain(domain string) error {
+	switch {
+	case domain == "":
+		return fmt.Errorf("empty domain")
+	case len(domain) > 253:
+		return fmt.Errorf("domain is longer than 253 characters")
+	}
+
+	// Address literals such as [192.0.2.1] or [ipv6:2001:db8::1]
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		literal := strings.TrimPrefix(domain[1:len(domain)-1], "ipv6:")
+		if net.ParseIP(literal) == nil {
+			return fmt.Errorf("invalid address literal %s", domain)
+		}
+		return nil
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("domain %s is not fully qualified", domain)
+	}
+	for _, label := range labels {
+		switch {
+		case label == "":
+			return fmt.Errorf("empty label in domain %s", domain)
+		case len(label) > 63:
+			return fmt.Errorf("label in domain %s is longer than 63 characters", domain)
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return fmt.Errorf("label in domain %s starts or ends with a hyphen", domain)
+		}
+		for _, r := range label {
+			if !isAlnum(r) && r < 0x80 && r != '-' {
+				return fmt.Errorf("invalid character %q in domain", r)
+			}
+		}
+	}
+	return nil
+}
+
+// isAlnum reports whether r is an ASCII letter or digit.
+func isAlnum(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// IsValidSyntax reports whether an email address is syntactically valid, by
+// the same rules as NormalizeEmail. Unlike NormalizeEmail it neither
+// allocates nor explains what is wrong, which suits filtering streams of
+// millions of addresses. ASCII addresses, the common case, are checked in a
+// single pass; the rare address literals and internationalized domains are
+// left to NormalizeEmail.
+//
+// Parameters:
+//   - email: The email address to check.
+//
+// Returns:
+//   - bool: true if the address is valid.
+func IsValidSyntax(email string) bool {
+	email = strings.TrimSpace(email)
+	if len(email) >= 2 && email[0] == '<' && email[len(email)-1] == '>' {
+		email = strings.TrimSpace(email[1 : len(email)-1])
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	local, domain := email[:at], email[at+1:]
+	if len(domain) > 0 && domain[len(domain)-1] == '.' {
+		domain = domain[:len(domain)-1]
+	}
+	return validLocalPart(local) && validDomain(email, domain)
+}
+
+// validLocalPart is checkLocalPart without the error.
+func validLocalPart(local string) bool {
+	if local == "" || len(local) > 64 {
+		return false
+	}
+
+	if len(local) >= 2 && local[0] == '"' && local[len(local)-1] == '"' {
+		for i := 1; i < len(local)-1; i++ {
+			switch local[i] {
+			case '\r', '\n', '"':
+				return false
+			case '\\':
+				i++ // the next character is escaped
+			}
+		}
+		return true
+	}
+
+	for i := 0; i < len(local); i++ {
+		b := local[i]
+		switch {
+		case b == '.':
+			if i == 0 || i == len(local)-1 || local[i-1] == '.' {
+				return false
+			}
+		case b < 0x80 && !isAlnum(rune(b)) && strings.IndexByte(atomSpecials, b) < 0:
+			return false
+		}
+	}
+	return true
+}
+
+// validDomain is checkDomain without the error or allocations for ASCII
+// domains. Other domains, and address literals, go through NormalizeEmail,
+// which email is the whole address for.
+func validDomain(email, domain string) bool {
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+
+	labels, start := 0, 0
+	for i := 0; i <= len(domain); i++ {
+		if i < len(domain) && domain[i] != '.' {
+			b := domain[i]
+			if b >= 0x80 || b == '[' {
+				_, err := NormalizeEmail(email)
+				return err == nil
+			}
+			if !isAlnum(rune(b)) && b != '-' {
+				return false
+			}
+			continue
+		}
+		label := domain[start:i]
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		labels++
+		start = i + 1
+	}
+	return labels >= 2
+}
@@ -0,0 +1,74 @@
+package mailify
+
+import "testing"
+
+// syntaxSamples are addresses, valid and not, that IsValidSyntax must judge
+// as NormalizeEmail does.
+var syntaxSamples = []string{
+	"user@example.com",
+	"User.Name+tag@Example.COM",
+	"  <user@example.com>  ",
+	"<user@example.com",
+	"user@example.com.",
+	"user@example.com..",
+	"a@b.co",
+	"user@localhost",
+	"@example.com",
+	"user@",
+	"user",
+	"",
+	"user@@example.com",
+	"us@er@example.com",
+	".user@example.com",
+	"user.@example.com",
+	"us..er@example.com",
+	"us er@example.com",
+	"us(er@example.com",
+	"!#$%&'*+/=?^_`{|}~-@example.com",
+	`"john doe"@example.com`,
+	`"john\"doe"@example.com`,
+	`"john"doe"@example.com`,
+	"\"john\ndoe\"@example.com",
+	`"@example.com`,
+	`""@example.com`,
+	"user@-example.com",
+	"user@example-.com",
+	"user@ex-ample.com",
+	"user@ex_ample.com",
+	"user@.example.com",
+	"user@example..com",
+	"user@[192.0.2.1]",
+	"user@[IPv6:2001:db8::1]",
+	"user@[300.0.2.1]",
+	"user@[example.com]",
+	"用户@例子.广告",
+	"user@bücher.de",
+	"user@exa mple.com",
+	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa@example.com",
+	"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa@example.com",
+	"user@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.com",
+	"user@aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa.com",
+}
+
+func TestIsValidSyntaxMatchesNormalizeEmail(t *testing.T) {
+	for _, email := range syntaxSamples {
+		_, err := NormalizeEmail(email)
+		if got, want := IsValidSyntax(email), err == nil; got != want {
+			t.Errorf("IsValidSyntax(%q) = %v, NormalizeEmail says %v (%v)", email, got, want, err)
+		}
+	}
+}
+
+func BenchmarkIsValidSyntax(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		IsValidSyntax(syntaxSamples[i%len(syntaxSamples)])
+	}
+}
+
+func BenchmarkNormalizeEmail(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NormalizeEmail(syntaxSamples[i%len(syntaxSamples)])
+	}
+}
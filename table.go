@@ -0,0 +1,73 @@
+package mailify
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// table is a sheet of rows that bulk processing reads addresses from and
+// writes results to, such as an Excel sheet or a CSV file. Rows are numbered
+// from 1, the header row, and columns from 0.
+type table interface {
+	// rows returns the rows, the header row first.
+	rows() ([][]string, error)
+	// duplicateRow inserts a copy of a row below it.
+	duplicateRow(row int) error
+	// setCell sets the value of a cell.
+	setCell(col, row int, value any) error
+	// styleResults formats the table once results are written, if it can be formatted.
+	styleResults(lastRow, lastCol, verdictCol int) error
+	// String names the table in progress messages.
+	String() string
+}
+
+// excelSheet is a sheet of an Excel workbook.
+type excelSheet struct {
+	f    *excelize.File
+	name string
+}
+
+func (s excelSheet) rows() ([][]string, error) { return s.f.GetRows(s.name) }
+
+func (s excelSheet) duplicateRow(row int) error { return s.f.DuplicateRow(s.name, row) }
+
+func (s excelSheet) setCell(col, row int, value any) error {
+	return s.f.SetCellValue(s.name, fmt.Sprintf("%s%d", columnToLetter(col), row), value)
+}
+
+func (s excelSheet) String() string { return fmt.Sprintf("sheet %q", s.name) }
+
+// csvTable is the records of a CSV file, held in memory.
+type csvTable struct {
+	name    string
+	records [][]string
+}
+
+func (t *csvTable) rows() ([][]string, error) { return t.records, nil }
+
+func (t *csvTable) duplicateRow(row int) error {
+	if row < 1 || row > len(t.records) {
+		return fmt.Errorf("row %d out of range", row)
+	}
+	t.records = slices.Insert(t.records, row, slices.Clone(t.records[row-1]))
+	return nil
+}
+
+func (t *csvTable) setCell(col, row int, value any) error {
+	for len(t.records) < row {
+		t.records = append(t.records, nil)
+	}
+	record := t.records[row-1]
+	for len(record) <= col {
+		record = append(record, "")
+	}
+	record[col] = fmt.Sprint(value)
+	t.records[row-1] = record
+	return nil
+}
+
+func (t *csvTable) styleResults(lastRow, lastCol, verdictCol int) error { return nil }
+
+func (t *csvTable) String() string { return t.name }
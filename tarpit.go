@@ -0,0 +1,124 @@
+package mailify
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// ErrTarpit is the error of the SMTP stage when a mail server took longer
+// than the tarpit threshold to answer a command, see WithTarpitThreshold.
+var ErrTarpit = errors.New("mail server is tarpitting")
+
+// defaultTarpitThreshold is how long a mail server may take to answer a
+// command unless WithTarpitThreshold is given. Servers that answer normally
+// take well under a second.
+const defaultTarpitThreshold = 15 * time.Second
+
+// tarpitMemory is how long a mail server that was caught tarpitting is skipped.
+const tarpitMemory = time.Hour
+
+// WithTarpitThreshold sets how long a mail server may take to answer its
+// greeting or a command, 15 seconds by default. Servers that drag out every
+// reply, known as tarpitting, can otherwise hold each validation up until its
+// WithTimeout runs out, and a bulk run for hours. A server that goes over is
+// dropped at once without retrying, and skipped by later validations for an
+// hour; if no other mail server answers, the result is unknown with the
+// tarpit sub-status. 0 disables the check.
+func WithTarpitThreshold(threshold time.Duration) Option {
+	return func(c *Client) {
+		c.tarpitThreshold = threshold
+	}
+}
+
+// tarpitConn is a connection that fails reads with ErrTarpit once the server
+// has been silent for longer than limit after the last command was written
+// or the last data was read.
+type tarpitConn struct {
+	net.Conn
+	limit time.Duration
+	// since is when the server's silence started.
+	since time.Time
+	// deadline is the read deadline set by the connection's user, if any.
+	deadline time.Time
+}
+
+// Read reads from the connection, cutting the read short at whichever comes
+// first of the tarpit limit and the connection's own deadline.
+func (c *tarpitConn) Read(b []byte) (int, error) {
+	limit := c.since.Add(c.limit)
+	tarpit := c.deadline.IsZero() || limit.Before(c.deadline)
+	if !tarpit {
+		limit = c.deadline
+	}
+	c.Conn.SetReadDeadline(limit)
+
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.since = time.Now()
+	}
+	var netErr net.Error
+	if tarpit && errors.As(err, &netErr) && netErr.Timeout() {
+		return n, ErrTarpit
+	}
+	return n, err
+}
+
+// Write writes to the connection, starting the wait for the server's reply.
+func (c *tarpitConn) Write(b []byte) (int, error) {
+	c.since = time.Now()
+	return c.Conn.Write(b)
+}
+
+// SetDeadline sets the read and write deadlines of the connection.
+func (c *tarpitConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the connection.
+func (c *tarpitConn) SetReadDeadline(t time.Time) error {
+	c.deadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// watchTarpit wraps conn so it fails with ErrTarpit when the server takes
+// longer than the client's tarpit threshold to say anything, or returns conn
+// as is if the check is disabled.
+func (c *Client) watchTarpit(conn net.Conn) net.Conn {
+	if c.tarpitThreshold <= 0 {
+		return conn
+	}
+	return &tarpitConn{Conn: conn, limit: c.tarpitThreshold, since: time.Now()}
+}
+
+// markTarpit remembers that a mail server was caught tarpitting.
+func (c *Client) markTarpit(mailServer string) {
+	c.tarpits.Store(mailServer, time.Now().Add(tarpitMemory))
+}
+
+// tarpitting reports whether a mail server was caught tarpitting recently
+// enough that it should be skipped.
+func (c *Client) tarpitting(mailServer string) bool {
+	until, ok := c.tarpits.Load(mailServer)
+	if !ok {
+		return false
+	}
+	if time.Now().After(until.(time.Time)) {
+		c.tarpits.Delete(mailServer)
+		return false
+	}
+	return true
+}
+
+// tarpitResult is the result of a validation whose mail servers were all
+// caught tarpitting.
+func tarpitResult() *ValidationResult {
+	return &ValidationResult{
+		Verdict:      VerdictUnknown,
+		SubStatus:    SubStatusTarpit,
+		IsValid:      false,
+		HasMX:        true,
+		ErrorMessage: "Mail server is tarpitting, check aborted",
+	}
+}
@@ -0,0 +1,49 @@
+package mailify
+
+import (
+	"strings"
+	"text/template"
+)
+
+// ResultTemplateData is what a template given to WithResultTemplate is
+// executed with. The result's fields can be used directly, e.g.
+// {{.Email}}: {{.Verdict}} ({{.Confidence}}).
+type ResultTemplateData struct {
+	// Email is the address that was validated, as given.
+	Email string
+	*ValidationResult
+}
+
+// WithResultTemplate makes FormatValidationResult render results with tmpl
+// instead of the built-in English report, e.g. for localized or custom
+// report formats. The template is executed with a ResultTemplateData.
+//
+//	tmpl := template.Must(template.New("result").Parse("{{.Email}}: {{.Verdict}}\n"))
+//	client, err := mailify.NewClient("me@example.com", mailify.WithResultTemplate(tmpl))
+func WithResultTemplate(tmpl *template.Template) Option {
+	return func(c *Client) {
+		c.resultTemplate = tmpl
+	}
+}
+
+// RenderValidationResult renders a validation result with the template given
+// to WithResultTemplate, or the built-in report if there is none. Unlike
+// FormatValidationResult it reports a template that fails to execute.
+//
+// Parameters:
+//   - recipientEmail: The email address that was validated.
+//   - result: The result of validating it.
+//
+// Returns:
+//   - string: The rendered result.
+//   - error: An error if the template failed to execute, otherwise nil.
+func (c *Client) RenderValidationResult(recipientEmail string, result *ValidationResult) (string, error) {
+	if c.resultTemplate == nil {
+		return c.defaultValidationResult(recipientEmail, result), nil
+	}
+	var out strings.Builder
+	if err := c.resultTemplate.Execute(&out, ResultTemplateData{Email: recipientEmail, ValidationResult: result}); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
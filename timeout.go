@@ -0,0 +1,101 @@
+package mailify
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrValidationTimeout is the error of the SMTP stage, as hooks see it, when
+// a validation runs out of the time WithTimeout gives it before a mail server
+// gave a definite answer.
+var ErrValidationTimeout = errors.New("validation timed out")
+
+// defaultConnectTimeout is how long each connection attempt may take unless
+// WithConnectTimeout is given.
+const defaultConnectTimeout = 5 * time.Second
+
+// WithTimeout caps how long a single validation may take, all mail servers,
+// ports and retries included, which otherwise can add up to minutes for a
+// domain with several unresponsive mail servers. Connection attempts and SMTP
+// conversations are cut short once the time is up, and no further server is
+// tried: the result is unknown with the timeout sub-status, unless a server
+// already deferred the recipient. DNS lookups count towards the time but are
+// bounded by the resolver's own timeouts. The default, 0, sets no limit.
+//
+// In bulk runs, each address gets the whole timeout, counted from when its
+// validation starts.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithConnectTimeout sets how long each connection attempt to a mail server
+// may take, 5 seconds by default. A validation's WithTimeout cuts it shorter
+// when less time is left.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.connectTimeout = timeout
+		}
+	}
+}
+
+// ValidateEmailWithTimeout validates an address like ValidateEmail, but with
+// its own time limit in place of the one WithTimeout gave the client, e.g.
+// for a caller that must answer within a request's deadline.
+//
+// Parameters:
+//   - recipientEmail: The email address to validate.
+//   - timeout: The most time the validation may take. 0 keeps the client's.
+//
+// Returns:
+//   - *ValidationResult: The validation result, unknown with the timeout
+//     sub-status if time ran out.
+//   - error: Any error ValidateEmail returns.
+func (c *Client) ValidateEmailWithTimeout(recipientEmail string, timeout time.Duration) (*ValidationResult, error) {
+	return c.validate(recipientEmail, &validation{timeout: timeout})
+}
+
+// startClock sets the validation's deadline, timeout after start, taking the
+// client's timeout unless the validation has one of its own.
+func (c *Client) startClock(v *validation, start time.Time) {
+	if v.timeout <= 0 {
+		v.timeout = c.timeout
+	}
+	if v.timeout > 0 {
+		v.deadline = start.Add(v.timeout)
+	}
+}
+
+// expired reports whether the validation has run out of time.
+func (v *validation) expired() bool {
+	return expired(v.deadline)
+}
+
+// expired reports whether deadline has passed. The zero deadline never does.
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// within cuts timeout short so it ends by deadline, if there is one.
+func within(timeout time.Duration, deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return timeout
+	}
+	if left := time.Until(deadline); left < timeout {
+		return left
+	}
+	return timeout
+}
+
+// timedOutResult is the result of a validation that ran out of time.
+func timedOutResult() *ValidationResult {
+	return &ValidationResult{
+		Verdict:      VerdictUnknown,
+		SubStatus:    SubStatusTimeout,
+		IsValid:      false,
+		HasMX:        true,
+		ErrorMessage: "No mail server answered in time",
+	}
+}
@@ -0,0 +1,68 @@
+package mailify
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Dialer opens the network connections used to talk to mail servers.
+// *net.Dialer satisfies this interface.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Resolver performs the DNS lookups needed for validation.
+// *net.Resolver satisfies this interface.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// WithDialer replaces the dialer used to connect to mail servers, e.g. with a
+// fake in tests or one that goes through a proxy. Timeouts are applied through
+// the context passed to DialContext.
+func WithDialer(d Dialer) Option {
+	return func(c *Client) {
+		c.dialer = d
+	}
+}
+
+// WithResolver replaces the resolver used to look up MX, address and TXT
+// records. By default Google's public DNS server (8.8.8.8) is queried, over
+// DNS-over-HTTPS in WebAssembly builds.
+func WithResolver(r Resolver) Option {
+	return func(c *Client) {
+		c.resolver = r
+	}
+}
+
+// lookupIP returns the IP addresses of host using the client's resolver.
+// Lookups are served from the cache when WithMXCache is given.
+func (c *Client) lookupIP(host string) ([]net.IP, error) {
+	if ips, err, ok := c.mxCache.getAddrs(host); ok {
+		return ips, err
+	}
+
+	addrs, err := c.resolver.LookupIPAddr(context.Background(), host)
+	if err != nil {
+		c.mxCache.setAddrs(host, nil, err)
+		return nil, err
+	}
+
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	c.mxCache.setAddrs(host, ips, nil)
+	return ips, nil
+}
+
+// dial connects to address using the client's dialer, giving up after timeout.
+func (c *Client) dial(address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.dialer.DialContext(ctx, "tcp", address)
+}
@@ -1,31 +1,287 @@
 package mailify
 
+import (
+	"encoding/json"
+	"time"
+)
 
 // SMTPDetails holds the details required to connect to an SMTP server.
 type SMTPDetails struct {
 	// Server is the address of the SMTP server.
-	Server string
+	Server string `json:"server"`
 	// Port is the port number on which the SMTP server is listening.
-	Port string
+	Port string `json:"port"`
 	// Protocol is the protocol used by the SMTP server (e.g., "SMTP", "SMTPS").
-	Protocol string
+	Protocol string `json:"protocol"`
 	// UsedTLS indicates whether TLS is used for the connection.
-	UsedTLS bool
+	UsedTLS bool `json:"used_tls"`
 	// IPAddress is the IP address of the SMTP server.
-	IPAddress string
+	IPAddress string `json:"ip_address"`
+	// Banner is the greeting the server sent when the connection was opened.
+	Banner string `json:"banner,omitempty"`
+	// Extensions holds the ESMTP extensions advertised in the EHLO response
+	// (e.g. SIZE, PIPELINING, STARTTLS, SMTPUTF8, 8BITMIME), keyed by keyword
+	// with any parameters as the value.
+	Extensions map[string]string `json:"extensions,omitempty"`
 }
 
+// Verdict is the overall outcome of validating an address.
+type Verdict string
+
+const (
+	// VerdictDeliverable means the server accepted the recipient.
+	VerdictDeliverable Verdict = "deliverable"
+	// VerdictUndeliverable means the address is malformed, the domain can't
+	// receive mail, or the server rejected the recipient.
+	VerdictUndeliverable Verdict = "undeliverable"
+	// VerdictRisky means the recipient exists or is accepted, but delivery may
+	// still fail or go unnoticed, e.g. on a catch-all domain or a full mailbox.
+	VerdictRisky Verdict = "risky"
+	// VerdictUnknown means the server's answer didn't tell us whether the
+	// mailbox exists, e.g. it deferred us or refused to verify.
+	VerdictUnknown Verdict = "unknown"
+)
+
+// SubStatus explains how a Verdict was reached.
+type SubStatus string
+
+const (
+	// SubStatusInvalidFormat means the address is not syntactically valid.
+	SubStatusInvalidFormat SubStatus = "invalid_format"
+	// SubStatusNoMX means the domain has no mail servers.
+	SubStatusNoMX SubStatus = "no_mx"
+	// SubStatusDNSError means the domain's DNS lookups kept failing.
+	SubStatusDNSError SubStatus = "dns_error"
+	// SubStatusMailboxNotFound means the server said the mailbox doesn't exist (5.1.1).
+	SubStatusMailboxNotFound SubStatus = "mailbox_not_found"
+	// SubStatusCatchAll means the server appears to accept every recipient.
+	SubStatusCatchAll SubStatus = "catch_all"
+	// SubStatusCannotVerify means the server won't verify the mailbox but will attempt delivery (252).
+	SubStatusCannotVerify SubStatus = "cannot_verify"
+	// SubStatusGreylisted means the server temporarily deferred the recipient (450).
+	SubStatusGreylisted SubStatus = "greylisted"
+	// SubStatusReverseDNSRequired means the server wants a reverse DNS record for our IP (450 4.7.1 or 4.7.25).
+	SubStatusReverseDNSRequired SubStatus = "reverse_dns_required"
+	// SubStatusTemporaryFailure means the server hit a local error and asked us to retry (451).
+	SubStatusTemporaryFailure SubStatus = "temporary_failure"
+	// SubStatusInsufficientStorage means the server is out of storage (452).
+	SubStatusInsufficientStorage SubStatus = "insufficient_storage"
+	// SubStatusMailboxFull means the mailbox exists but is over its quota (452 4.2.2, 552 or 5.2.2).
+	SubStatusMailboxFull SubStatus = "mailbox_full"
+	// SubStatusSMTPError means the SMTP conversation failed before the mailbox could be checked.
+	SubStatusSMTPError SubStatus = "smtp_error"
+	// SubStatusSkipped means the checks that need the network were not run (ModeDNSOnly or ModeOffline).
+	SubStatusSkipped SubStatus = "skipped"
+	// SubStatusConfirmed means the address was confirmed by opening a confirmation link (WithConfirmation).
+	SubStatusConfirmed SubStatus = "confirmed"
+	// SubStatusBogonMX means every mail server resolves to loopback, private or reserved addresses.
+	SubStatusBogonMX SubStatus = "bogon_mx"
+	// SubStatusDirectoryVerified means a directory API, such as Microsoft Graph, says the mailbox exists.
+	SubStatusDirectoryVerified SubStatus = "directory_verified"
+	// SubStatusMailboxDisabled means the server (5.2.1) or a directory API says the mailbox exists but is disabled.
+	SubStatusMailboxDisabled SubStatus = "mailbox_disabled"
+	// SubStatusTimeout means no mail server gave a definite answer within the time WithTimeout allows.
+	SubStatusTimeout SubStatus = "timeout"
+	// SubStatusPolicyBlocked means the server refused the check for policy reasons (5.7.x), such as
+	// our IP address being blocklisted, so the mailbox was never tested.
+	SubStatusPolicyBlocked SubStatus = "policy_blocked"
+	// SubStatusTarpit means every mail server dragged out its replies beyond the tarpit threshold
+	// (WithTarpitThreshold), so the check was given up.
+	SubStatusTarpit SubStatus = "tarpit"
+	// SubStatusAcceptAllProvider means the domain's mail is run by a provider known to accept every
+	// recipient (see WithAcceptAllRules), so the mailbox wasn't checked.
+	SubStatusAcceptAllProvider SubStatus = "accept_all_provider"
+	// SubStatusVerificationBlocked means the domain's mail is run by a provider known to block
+	// mailbox checks (see WithAcceptAllRules), so the mailbox wasn't checked.
+	SubStatusVerificationBlocked SubStatus = "verification_blocked"
+	// SubStatusSuppressed means the address or its domain is on a SuppressionList, such as after a
+	// hard bounce or a complaint.
+	SubStatusSuppressed SubStatus = "suppressed"
+	// SubStatusConflictingEvidence means the sources of a CompositeVerifier disagreed, and no
+	// verdict had the quorum MergeQuorum needs.
+	SubStatusConflictingEvidence SubStatus = "conflicting_evidence"
+	// SubStatusProtected means the domain is protected (see WithProtectedDomains), so the
+	// mailbox wasn't checked.
+	SubStatusProtected SubStatus = "protected"
+	// SubStatusBudgetExhausted means the probe budget of WithProbeBudget was spent, so the mailbox
+	// wasn't checked.
+	SubStatusBudgetExhausted SubStatus = "budget_exhausted"
+)
+
 // ValidationResult represents the result of an email validation check.
 type ValidationResult struct {
-	// IsValid indicates whether the email address is valid.
-	IsValid bool
+	// Verdict is the overall outcome of the validation, and the field to
+	// decide on. Unlike IsValid it distinguishes addresses that could not be
+	// verified from invalid ones.
+	Verdict Verdict `json:"verdict"`
+	// SubStatus explains how the verdict was reached.
+	SubStatus SubStatus `json:"sub_status,omitempty"`
+	// Confidence says how sure the verdict is: high when the server answered
+	// outright, e.g. a 550 for an unknown mailbox, medium when the verdict was
+	// inferred, e.g. on a catch-all domain, and low when the server gave no
+	// answer, e.g. a timeout or greylisting. Apply stricter policies to
+	// low-confidence results.
+	Confidence Confidence `json:"confidence"`
+	// IsValid indicates whether the email address is valid. It is false both
+	// for rejected addresses and for many that simply couldn't be checked.
+	//
+	// Deprecated: Use Verdict, which tells those cases apart. IsValid is kept
+	// for backward compatibility.
+	IsValid bool `json:"is_valid"`
 	// IsCatchAll indicates whether the domain has a catch-all address.
-	IsCatchAll bool
+	IsCatchAll bool `json:"is_catch_all"`
+	// CatchAllConfidence says how sure the catch-all determination is, based on
+	// how many made-up mailboxes the server accepted. Empty if IsCatchAll is false.
+	CatchAllConfidence Confidence `json:"catch_all_confidence,omitempty"`
+	// IsMailboxFull indicates whether the server said the mailbox is over its
+	// quota (452/552). Such addresses exist and are worth retrying later.
+	IsMailboxFull bool `json:"is_mailbox_full"`
 	// HasMX indicates whether the domain has MX records.
-	HasMX bool
+	HasMX bool `json:"has_mx"`
+	// MXConsistency compares the domain's MX records across resolvers, if
+	// WithMXConsistencyCheck is given.
+	MXConsistency *MXConsistency `json:"mx_consistency,omitempty"`
+	// IsDisposable indicates whether the domain belongs to a throwaway mailbox provider.
+	IsDisposable bool `json:"is_disposable"`
+	// IsRoleAccount indicates whether the address belongs to a role, such as info@, rather than a person.
+	IsRoleAccount bool `json:"is_role_account"`
+	// ConfirmationSent indicates whether a confirmation email was sent because
+	// the result was inconclusive (ConfirmationConfig.AutoSend).
+	ConfirmationSent bool `json:"confirmation_sent,omitempty"`
+	// DisplayName is the display name the address was given with, e.g. "Jane Doe"
+	// for "Jane Doe" <jane@example.com>. Empty for bare addresses.
+	DisplayName string `json:"display_name,omitempty"`
+	// NormalizedEmail is the address in canonical form, as checked against the mail server.
+	NormalizedEmail string `json:"normalized_email,omitempty"`
 	// ErrorMessage contains any error message encountered during validation.
-	ErrorMessage string
+	ErrorMessage string `json:"error_message,omitempty"`
+	// RetryAfter is when it is worth validating the address again, for
+	// risky and unknown verdicts reached on a deferral or a full mailbox. It
+	// is what the server said, e.g. "try again in 300 seconds", or else a
+	// usual wait for the kind of deferral, such as 5 minutes for greylisting.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+	// Warnings flag problems on the verifying side that may have skewed the
+	// result, such as a HELO name without matching forward and reverse DNS.
+	Warnings []string `json:"warnings,omitempty"`
+	// SMTPReply is the mail server's reply to RCPT TO, with its enhanced
+	// status code, if a server answered.
+	SMTPReply *SMTPReply `json:"smtp_reply,omitempty"`
 	// SMTPDetails contains the SMTP server details used for validation.
-	SMTPDetails *SMTPDetails
+	SMTPDetails *SMTPDetails `json:"smtp_details,omitempty"`
+	// Timings breaks down how long each stage of the validation took.
+	Timings Timings `json:"timings"`
+	// ValidatedAt is when the address was validated, earlier than now for
+	// results taken from the cache of WithResultCache.
+	ValidatedAt time.Time `json:"validated_at"`
+	// TTL is how long after ValidatedAt the verdict is expected to hold, and
+	// the result is kept by WithResultCache: 30 days for definite verdicts
+	// unless WithResultCache says otherwise, until RetryAfter for those
+	// reached on a deferral or a full mailbox, and 0, not to be reused, for
+	// other unknown verdicts and skipped SMTP checks.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Cached indicates whether the result was taken from the cache of
+	// WithResultCache rather than validated now.
+	Cached bool `json:"cached,omitempty"`
+	// Provenance says how the verdict was derived, such as from a live SMTP
+	// check, the cache or a verification API, and when.
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// Extra holds fields recorded by custom stages, keyed by stage name.
+	// Hooks may add their own entries.
+	Extra map[string]any `json:"extra,omitempty"`
+}
+
+// PortProbe holds the outcome of probing a single port on a mail server.
+type PortProbe struct {
+	// Port is the port that was probed.
+	Port string
+	// Reachable indicates whether a connection was established.
+	Reachable bool
+	// Greeted indicates whether the server sent its greeting once connected.
+	Greeted bool
+	// IPAddress is the IP address that answered on this port.
+	IPAddress string
+	// Banner is the greeting the server sent on this port.
+	Banner string
+	// TLS indicates whether the port supports TLS, either implicitly (465) or via STARTTLS.
+	TLS bool
+	// Extensions holds the ESMTP extensions advertised in the EHLO response.
+	Extensions map[string]string
+	// Latency is the time taken to connect and receive the greeting, or only to
+	// connect if the server didn't greet us.
+	Latency time.Duration
+	// Error contains the reason the port could not be probed, if any.
+	Error string
 }
 
+// Timings records how long each stage of a validation took. Stages that were
+// attempted more than once, such as connecting to several mail servers, report
+// the combined time. In JSON each stage is reported in milliseconds.
+type Timings struct {
+	// DNS is the time spent looking up MX and address records.
+	DNS time.Duration
+	// Connect is the time spent establishing TCP connections.
+	Connect time.Duration
+	// TLS is the time spent on TLS handshakes, implicit or via STARTTLS.
+	TLS time.Duration
+	// HELO is the time spent on the EHLO/HELO exchange.
+	HELO time.Duration
+	// Mail is the time spent on the MAIL FROM command. When the commands are
+	// pipelined, it includes the round trip shared with RCPT TO.
+	Mail time.Duration
+	// Rcpt is the time spent on the RCPT TO command.
+	Rcpt time.Duration
+	// Total is the wall-clock time of the whole validation.
+	Total time.Duration
+}
+
+// timingsJSON is the wire format of Timings.
+type timingsJSON struct {
+	DNS     int64 `json:"dns_ms"`
+	Connect int64 `json:"connect_ms"`
+	TLS     int64 `json:"tls_ms"`
+	HELO    int64 `json:"helo_ms"`
+	Mail    int64 `json:"mail_ms"`
+	Rcpt    int64 `json:"rcpt_ms"`
+	Total   int64 `json:"total_ms"`
+}
+
+// MarshalJSON encodes the timings as whole milliseconds.
+func (t Timings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(timingsJSON{
+		DNS:     t.DNS.Milliseconds(),
+		Connect: t.Connect.Milliseconds(),
+		TLS:     t.TLS.Milliseconds(),
+		HELO:    t.HELO.Milliseconds(),
+		Mail:    t.Mail.Milliseconds(),
+		Rcpt:    t.Rcpt.Milliseconds(),
+		Total:   t.Total.Milliseconds(),
+	})
+}
+
+// UnmarshalJSON decodes timings encoded by MarshalJSON.
+func (t *Timings) UnmarshalJSON(data []byte) error {
+	var v timingsJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Timings{
+		DNS:     time.Duration(v.DNS) * time.Millisecond,
+		Connect: time.Duration(v.Connect) * time.Millisecond,
+		TLS:     time.Duration(v.TLS) * time.Millisecond,
+		HELO:    time.Duration(v.HELO) * time.Millisecond,
+		Mail:    time.Duration(v.Mail) * time.Millisecond,
+		Rcpt:    time.Duration(v.Rcpt) * time.Millisecond,
+		Total:   time.Duration(v.Total) * time.Millisecond,
+	}
+	return nil
+}
+
+// add accumulates the stage timings of other into t. Total is left alone.
+func (t *Timings) add(other Timings) {
+	t.DNS += other.DNS
+	t.Connect += other.Connect
+	t.TLS += other.TLS
+	t.HELO += other.HELO
+	t.Mail += other.Mail
+	t.Rcpt += other.Rcpt
+}
@@ -2,9 +2,10 @@ package mailify
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
-	"net/smtp"
+	"net/textproto"
 	"os"
 	"strings"
 	"time"
@@ -18,7 +19,12 @@ import (
 // If successful, it performs a reverse DNS lookup on the first IPv4 address found using
 // net.LookupAddr(). If that succeeds and returns at least one name, it returns the first name
 // with the trailing dot removed. If all attempts fail, it returns the hostname with ".local" appended.
+// The name given with WithHELOName, if any, is returned as is.
 func (c *Client) GetHostname() (string, error) {
+	if c.heloName != "" {
+		return c.heloName, nil
+	}
+
 	// Try to get the hostname
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -54,8 +60,9 @@ func (c *Client) GetHostname() (string, error) {
 // 6. Performs HELO/EHLO command.
 // 7. Initiates STARTTLS if available and not already using TLS.
 // 8. Sends MAIL FROM command.
-// 9. Sends RCPT TO command.
-// 10. Interprets the response to determine if the email address is valid.
+// 9. Sends RCPT TO command, in the same write as MAIL FROM if the server advertises PIPELINING.
+// 10. If the recipient is accepted, probes made-up mailboxes to tell whether the domain is catch-all.
+// 11. Interprets the response to determine if the email address is valid.
 //
 // Parameters:
 // - smtpDetails: Details of the SMTP server (IP address, port, server name).
@@ -68,6 +75,15 @@ func (c *Client) GetHostname() (string, error) {
 // - A pointer to a ValidationResult struct containing the validation outcome.
 // - An error if any step in the process fails.
 func (c *Client) TryConnectingSMTP(smtpDetails *SMTPDetails, recipientEmail, localName string, useTLS bool) (*ValidationResult, error) {
+	return c.tryConnectingSMTP(smtpDetails, c.SenderEmail, recipientEmail, localName, useTLS, false, time.Time{})
+}
+
+// tryConnectingSMTP does the work for TryConnectingSMTP. When reuse is set, an
+// idle session to the same server is taken from the client's pool if there is
+// one, and the session is reset and returned to the pool afterwards instead of
+// being closed. The conversation is cut short at deadline, unless it is zero.
+// MAIL FROM is sent with sender.
+func (c *Client) tryConnectingSMTP(smtpDetails *SMTPDetails, sender, recipientEmail, localName string, useTLS, reuse bool, deadline time.Time) (*ValidationResult, error) {
 
 	// Create a new validation result. If we are here, we know the domain has MX records.
 	result := &ValidationResult{
@@ -75,10 +91,248 @@ func (c *Client) TryConnectingSMTP(smtpDetails *SMTPDetails, recipientEmail, loc
 		HasMX:   true,
 	}
 
-	// Create a new dialer with a timeout
-	dialer := &net.Dialer{
-		Timeout: 5 * time.Second,
+	key := sessionKey(smtpDetails, useTLS)
+
+	var session *smtpSession
+	if reuse {
+		session = c.sessions.get(key)
+	}
+	pooled := session != nil
+
+	var err error
+	if pooled {
+		session.setDeadline(deadline)
+	} else {
+		session, err = c.openSMTPSession(smtpDetails, localName, useTLS, &result.Timings, deadline)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Record the greeting, it identifies the receiving software more often than not
+	smtpDetails.Banner = session.banner
+	smtpDetails.UsedTLS = session.tls
+	smtpDetails.Extensions = session.ext
+
+	// MAIL FROM and RCPT TO, pipelined if the server supports it
+	mailReply, rcptReplies, err := session.transaction(sender, []string{recipientEmail})
+	result.Timings.Mail = mailReply.took
+	if err == nil && mailReply.err != nil {
+		err = mailReply.err
+	}
+	if err != nil && len(rcptReplies) == 0 && pooled && !errors.Is(err, ErrTarpit) {
+		// The server may have dropped the idle connection, start afresh
+		session.close()
+		return c.tryConnectingSMTP(smtpDetails, sender, recipientEmail, localName, useTLS, false, deadline)
+	}
+	if err != nil && len(rcptReplies) == 0 {
+		session.close()
+		return result, fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+
+	var code int
+	var msg string
+	if len(rcptReplies) > 0 {
+		code, msg, err = rcptReplies[0].code, rcptReplies[0].msg, rcptReplies[0].err
+		result.Timings.Rcpt = rcptReplies[0].took
+	}
+
+	// An accepted recipient may just mean the domain accepts everything
+	var catchAll Confidence
+	var shortfall *budgetShortfall
+	if err == nil && c.catchAllProbes > 0 {
+		domain := emailDomain(recipientEmail)
+		if record, ok := c.CatchAllRecord(domain); ok {
+			catchAll = record.Confidence
+		} else {
+			probes := c.probeAddresses(domain)
+			if shortfall = c.reserveProbes(domain, len(probes)); shortfall == nil {
+				if replies, probeErr := session.addRecipients(probes); probeErr == nil {
+					catchAll = catchAllConfidence(replies)
+					c.rememberCatchAll(domain, catchAll)
+				}
+			}
+		}
+	}
+
+	if reuse && session.reset() == nil {
+		session.setDeadline(time.Time{})
+		c.sessions.put(key, smtpDetails, session)
+	} else {
+		session.quit()
+		session.close()
+	}
+
+	result, err = interpretRcpt(result, code, msg, err)
+	applyCatchAll(result, catchAll)
+	applyBudget(result, shortfall)
+	return result, err
+}
+
+// interpretRcpt fills in result from the reply to RCPT TO. Replies that only
+// defer the recipient are returned along with the error, so the retry policy
+// can try again.
+func interpretRcpt(result *ValidationResult, code int, msg string, err error) (*ValidationResult, error) {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		code, msg = protoErr.Code, protoErr.Msg
 	}
+	status, _ := ParseStatusCode(msg)
+	if code != 0 {
+		result.SMTPReply = &SMTPReply{Code: code, Status: status}
+	}
+	permanent := code >= 500 && code < 600
+
+	// IsValid keeps the value it always had for each reply, the verdict is
+	// what tells an accepted mailbox apart from one that couldn't be checked.
+	switch {
+	case err == nil && code == 252:
+		result.IsValid = true
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusCannotVerify
+		result.ErrorMessage = "Server cannot verify the mailbox but will attempt delivery"
+		return result, nil
+
+	case err == nil:
+		result.IsValid = true
+		result.Verdict = VerdictDeliverable
+		return result, nil
+
+	case code == 450 && status.reason() == SubStatusReverseDNSRequired:
+		result.IsValid = true
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusReverseDNSRequired
+		result.ErrorMessage = "Reverse DNS lookup required but email might be valid"
+		return result, nil
+
+	// Deferrals are returned along with the error, so the retry policy can try
+	// again and the verdict stands if it gives up.
+	case code == 450:
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusGreylisted
+		result.ErrorMessage = "Mailbox temporarily unavailable, the server may be greylisting"
+		result.RetryAfter = retryAfter(msg, greylistRetryAfter)
+		return result, err
+
+	case code == 451:
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusTemporaryFailure
+		result.ErrorMessage = "Server had a local error processing the request, try again later"
+		result.RetryAfter = retryAfter(msg, temporaryRetryAfter)
+		return result, err
+
+	case code == 452 && isOverQuota(status, msg):
+		result.IsMailboxFull = true
+		result.Verdict = VerdictRisky
+		result.SubStatus = SubStatusMailboxFull
+		result.ErrorMessage = "Mailbox is temporarily over quota, try again later"
+		result.RetryAfter = retryAfter(msg, quotaRetryAfter)
+		return result, nil
+
+	case code == 452:
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusInsufficientStorage
+		result.ErrorMessage = "Server has insufficient storage, try again later"
+		result.RetryAfter = retryAfter(msg, storageRetryAfter)
+		return result, err
+
+	case code == 552, permanent && status.reason() == SubStatusMailboxFull:
+		result.IsMailboxFull = true
+		result.Verdict = VerdictRisky
+		result.SubStatus = SubStatusMailboxFull
+		result.ErrorMessage = "Mailbox is full"
+		result.RetryAfter = parseRetryHint(msg)
+		return result, nil
+
+	// A policy rejection (5.7.x), such as a blocklisted IP address or a spam
+	// filter, is about us rather than the mailbox, which was never checked.
+	// It is returned along with the error, so other mail servers are tried.
+	case permanent && code != 530 && status.Subject == 7:
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusPolicyBlocked
+		result.ErrorMessage = "Mailbox not checked, the server refused on policy grounds"
+		return result, err
+
+	case permanent && status.reason() == SubStatusMailboxNotFound:
+		result.Verdict = VerdictUndeliverable
+		result.SubStatus = SubStatusMailboxNotFound
+		result.ErrorMessage = "User doesn't exist"
+		return result, nil
+
+	case permanent && status.reason() == SubStatusMailboxDisabled:
+		result.Verdict = VerdictUndeliverable
+		result.SubStatus = SubStatusMailboxDisabled
+		result.ErrorMessage = "Mailbox is disabled"
+		return result, nil
+
+	// Without an enhanced status code, the reply code and text are all there
+	// is to go on: a bare 550, 551 or 553 is the classic reply for a mailbox
+	// that doesn't exist, unless the text says it is a policy rejection.
+	case permanent && status.IsZero() && isPolicyRejection(msg):
+		result.Verdict = VerdictUnknown
+		result.SubStatus = SubStatusPolicyBlocked
+		result.ErrorMessage = "Mailbox not checked, the server refused on policy grounds"
+		return result, err
+
+	case permanent && status.IsZero() && (code == 550 || code == 551 || code == 553):
+		result.Verdict = VerdictUndeliverable
+		result.SubStatus = SubStatusMailboxNotFound
+		result.ErrorMessage = "User doesn't exist"
+		return result, nil
+
+	case strings.Contains(err.Error(), "250"):
+		result.IsValid = true
+		result.IsCatchAll = true
+		result.Verdict = VerdictRisky
+		result.SubStatus = SubStatusCatchAll
+		return result, nil
+	}
+
+	return result, err
+}
+
+// isOverQuota reports whether a 452 reply is about the mailbox being full
+// rather than the server as a whole running out of storage, by its enhanced
+// status code or failing that its text.
+func isOverQuota(status StatusCode, msg string) bool {
+	if reason := status.reason(); reason != "" {
+		return reason == SubStatusMailboxFull
+	}
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "quota") ||
+		strings.Contains(msg, "mailbox full") ||
+		strings.Contains(msg, "mailbox is full")
+}
+
+// policyMarkers are fragments of the text of replies without an enhanced
+// status code that refuse the check for who is asking rather than because
+// of the mailbox.
+var policyMarkers = []string{"block", "blacklist", "denylist", "spam", "policy", "reputation", "rbl", "not authorized"}
+
+// isPolicyRejection reports whether the text of a permanent reply without an
+// enhanced status code refuses the check on policy grounds.
+func isPolicyRejection(msg string) bool {
+	msg = strings.ToLower(msg)
+	for _, marker := range policyMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// openSMTPSession connects to the SMTP server described by smtpDetails and gets
+// the session ready for MAIL FROM: it reads the greeting, sends EHLO and, if
+// useTLS is set or the client's TLS mode asks for it and the server supports
+// it, upgrades with STARTTLS. The time
+// spent in each stage is recorded in timings. The connection is cut short at
+// deadline, unless it is zero.
+func (c *Client) openSMTPSession(smtpDetails *SMTPDetails, localName string, useTLS bool, timings *Timings, deadline time.Time) (*smtpSession, error) {
+	// Connection timeout, within what is left of the validation's time
+	if expired(deadline) {
+		return nil, fmt.Errorf("connection failed: %w", ErrValidationTimeout)
+	}
+	timeout := within(c.connectTimeout, deadline)
 
 	// Format address based on IP version
 	var address string
@@ -93,82 +347,69 @@ func (c *Client) TryConnectingSMTP(smtpDetails *SMTPDetails, recipientEmail, loc
 
 	// fmt.Printf("Trying to connect to %s\n", address)
 
-	var conn net.Conn
-	var err error
+	stageStart := time.Now()
+	conn, err := c.dial(address, timeout)
+	timings.Connect += time.Since(stageStart)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	conn = c.watchTarpit(conn)
+	conn.SetDeadline(deadline)
 
-	// Handle connection based on port
-	switch smtpDetails.Port {
-	case "465": // SMTPS
-		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+	// Handle connection based on port and TLS mode
+	if c.implicitTLS(smtpDetails.Port) { // SMTPS
+		tlsConn := tls.Client(conn, &tls.Config{
 			InsecureSkipVerify: true,
 			ServerName:         smtpDetails.Server,
 		})
-	default: // Plain or STARTTLS
-		conn, err = dialer.Dial("tcp", address)
-	}
-
-	if err != nil {
-		return result, fmt.Errorf("connection failed: %v", err)
+		tlsConn.SetDeadline(time.Now().Add(timeout))
+		stageStart = time.Now()
+		err = tlsConn.Handshake()
+		timings.TLS += time.Since(stageStart)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("connection failed: %w", err)
+		}
+		tlsConn.SetDeadline(deadline)
+		conn = tlsConn
 	}
-	defer conn.Close()
 
-	client, err := smtp.NewClient(conn, smtpDetails.Server)
+	session, err := newSMTPSession(conn, smtpDetails.Server)
 	if err != nil {
-		return result, fmt.Errorf("SMTP client creation failed: %v", err)
+		conn.Close()
+		return nil, fmt.Errorf("SMTP client creation failed: %w", err)
 	}
-	defer client.Close()
 
 	// HELO/EHLO
-	if err = client.Hello(localName); err != nil {
-		return result, fmt.Errorf("HELO failed: %v", err)
+	stageStart = time.Now()
+	err = session.hello(localName)
+	timings.HELO += time.Since(stageStart)
+	if err != nil {
+		session.close()
+		return nil, fmt.Errorf("HELO failed: %w", err)
 	}
 
 	// STARTTLS if available and not already TLS
-	if smtpDetails.Port != "465" && useTLS {
-		if ok, _ := client.Extension("STARTTLS"); ok {
+	if c.startTLS(smtpDetails.Port, useTLS) {
+		if ok, _ := session.extension("STARTTLS"); !ok && c.tlsMode == TLSStartTLS {
+			session.close()
+			return nil, fmt.Errorf("STARTTLS required but not offered by %s", smtpDetails.Server)
+		} else if ok {
 			config := &tls.Config{
 				InsecureSkipVerify: true,
 				ServerName:         smtpDetails.Server,
 			}
-			if err = client.StartTLS(config); err != nil {
+			stageStart = time.Now()
+			err = session.startTLS(config)
+			timings.TLS += time.Since(stageStart)
+			if err != nil {
 				// fmt.Printf("STARTTLS failed: %v\n", err)
 				fmt.Printf("STARTTLS failed: %v\n", err)
 			}
 		}
 	}
 
-	// MAIL FROM
-	if err = client.Mail(c.SenderEmail); err != nil {
-		return result, fmt.Errorf("MAIL FROM failed: %v", err)
-	}
-
-	// RCPT TO
-	err = client.Rcpt(recipientEmail)
-	client.Quit()
-
-	if err != nil {
-		if strings.Contains(err.Error(), "450 4.7.1") {
-			result.IsValid = true
-			result.ErrorMessage = "Reverse DNS lookup required but email might be valid"
-			return result, nil
-		}
-
-		if strings.Contains(err.Error(), "550 5.1.1") {
-			result.ErrorMessage = "User doesn't exist"
-			return result, nil
-		}
-
-		if strings.Contains(err.Error(), "250") {
-			result.IsValid = true
-			result.IsCatchAll = true
-			return result, nil
-		}
-
-		return result, err
-	}
-
-	result.IsValid = true
-	return result, nil
+	return session, nil
 }
 
 // ValidateEmail validates the recipient's email address by checking its format,
@@ -185,92 +426,425 @@ func (c *Client) TryConnectingSMTP(smtpDetails *SMTPDetails, recipientEmail, loc
 //   - error: An error object if an error occurred during the validation process.
 //
 // The function performs the following steps:
-//  1. Checks if the recipient email contains an "@" symbol and splits it into local
-//     and domain parts.
-//  2. Retrieves the MX records for the domain.
+//  1. Checks the syntax of the recipient email and normalizes it (see NormalizeEmail).
+//     Addresses with a display name, such as "Jane Doe" <jane@example.com>, are accepted (see ParseAddress).
+//  2. Retrieves the MX records for the domain, from the cache if WithMXCache was given.
 //  3. Gets the local hostname for the HELO command.
-//  4. Attempts to connect to each mail server using SMTP, first without TLS and then
-//     with TLS if the initial attempt fails.
+//  4. Attempts to connect to each mail server using SMTP, first without TLS and then,
+//     as the client's RetryPolicy allows, retrying with STARTTLS after a backoff.
 //  5. Returns the validation result and any errors encountered during the process.
+//
+// Every result carries a Timings breakdown of how long each stage took, and
+// flags disposable domains and role accounts. In ModeDNSOnly and ModeOffline
+// the steps that need the network are skipped, see WithMode. Hooks added with
+// WithHooks are run around each stage, and custom stages added with WithStage
+// run in between.
 func (c *Client) ValidateEmail(recipientEmail string) (*ValidationResult, error) {
-	// Basic format validation
-	if !strings.Contains(recipientEmail, "@") {
-		return &ValidationResult{
-			IsValid:      false,
-			ErrorMessage: "Invalid email format",
-		}, nil
+	return c.validate(recipientEmail, &validation{})
+}
+
+// ValidateOptions are the settings of a single validation that override the
+// client's, see ValidateEmailWithOptions. Every field is optional.
+type ValidateOptions struct {
+	// Sender is the address to send MAIL FROM with in place of the client's,
+	// see ValidateEmailFrom.
+	Sender string
+	// MaxAge is the oldest cached result to accept, see ValidateEmailMaxAge.
+	MaxAge time.Duration
+	// Timeout is the most time the validation may take in place of the
+	// client's, see ValidateEmailWithTimeout.
+	Timeout time.Duration
+}
+
+// ValidateEmailWithOptions validates an address like ValidateEmail, with
+// the settings of opts in place of the client's, for callers that need
+// several at once, such as a server validating for a tenant.
+//
+// Parameters:
+//   - recipientEmail: The email address to validate.
+//   - opts: The settings to override.
+//
+// Returns:
+//   - *ValidationResult: The validation result.
+//   - error: A *SenderError if opts.Sender is unusable, or any error
+//     ValidateEmail returns.
+func (c *Client) ValidateEmailWithOptions(recipientEmail string, opts ValidateOptions) (*ValidationResult, error) {
+	v := &validation{maxAge: opts.MaxAge, timeout: opts.Timeout}
+	if opts.Sender != "" {
+		sender, err := c.normalizeSender(opts.Sender)
+		if err != nil {
+			return nil, err
+		}
+		v.sender = sender
 	}
+	return c.validate(recipientEmail, v)
+}
 
-	parts := strings.Split(recipientEmail, "@")
-	if len(parts) != 2 {
-		return &ValidationResult{
+// validation carries the state of a single ValidateEmail call.
+type validation struct {
+	// timings accumulates the time spent in each stage.
+	timings Timings
+	// reuse allows SMTP sessions to be pooled between validations, as done in bulk runs.
+	reuse bool
+	// email is the normalized address, set once it has passed the syntax check.
+	email string
+	// displayName is the display name the address was given with, if any.
+	displayName string
+	// extra holds the fields recorded by custom stages, keyed by stage name.
+	extra map[string]any
+	// mxConsistency compares the MX lookup with other resolvers, if WithMXConsistencyCheck is given.
+	mxConsistency *MXConsistency
+	// timeout is the most time the validation may take, the client's unless set, see WithTimeout.
+	timeout time.Duration
+	// deadline is when the validation's time runs out, zero if it has no limit.
+	deadline time.Time
+	// sender is the MAIL FROM address, the client's unless set, see ValidateEmailFrom.
+	sender string
+	// maxAge is the oldest cached result to accept, see ValidateEmailMaxAge.
+	maxAge time.Duration
+	// cached indicates whether the result was taken from the cache of WithResultCache.
+	cached bool
+	// mailServers are the domain's mail servers, once looked up.
+	mailServers []string
+}
+
+// validate runs a validation and attaches the collected timings and the
+// address classification to the result.
+func (c *Client) validate(recipientEmail string, v *validation) (*ValidationResult, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	start := time.Now()
+	c.startClock(v, start)
+
+	result := c.startHooks(recipientEmail)
+	if result == nil {
+		result = c.cachedResult(recipientEmail, v)
+	}
+	var err error
+	if result == nil {
+		result, err = c.validateEmail(recipientEmail, v)
+	}
+	c.finish(recipientEmail, v, start, result)
+	err = c.redactError(err, recipientEmail, v.email)
+	c.audit(recipientEmail, result, err)
+	return result, err
+}
+
+// finish attaches the collected timings and the address classification to a
+// result, stamps it with when it was validated and how long its verdict
+// holds and how it was derived, redacts the address from its error message as WithPIIMode says,
+// runs the OnResult hooks and keeps it in the cache of WithResultCache.
+func (c *Client) finish(recipientEmail string, v *validation, start time.Time, result *ValidationResult) {
+	if result == nil {
+		return
+	}
+	v.timings.Total = time.Since(start)
+	result.Timings = v.timings
+	if v.email != "" {
+		result.NormalizedEmail = v.email
+		result.DisplayName = v.displayName
+		result.IsRoleAccount = IsRoleAccount(v.email)
+		result.IsDisposable = IsDisposableDomain(emailDomain(v.email))
+	}
+	c.applyConfirmation(v.email, result)
+	if len(v.extra) > 0 {
+		result.Extra = v.extra
+	}
+	if v.mxConsistency != nil {
+		result.MXConsistency = v.mxConsistency
+	}
+	result.Confidence = resultConfidence(result)
+	c.localize(result)
+	result.ErrorMessage = c.redactText(result.ErrorMessage, recipientEmail, v.email)
+	if !v.cached {
+		result.ValidatedAt = time.Now().UTC()
+		result.TTL = c.resultTTLOf(result)
+		stampProvenance(result)
+		if change := c.recordDomain(v, result); change != nil {
+			c.mxChangeHooks(*change)
+		}
+	}
+	c.resultHooks(recipientEmail, result)
+	if !v.cached {
+		c.rememberResult(v.email, result)
+	}
+}
+
+// validateEmail does the work for ValidateEmail, running each stage in turn
+// and giving the hooks a chance to end validation after it. Mailboxes that
+// couldn't be checked from this host are left to the fallback verifiers.
+func (c *Client) validateEmail(recipientEmail string, v *validation) (*ValidationResult, error) {
+	mailServers, result := c.prepare(recipientEmail, v)
+	if result != nil {
+		return c.fallback(v, result), nil
+	}
+
+	stageStart := time.Now()
+	result, err := c.checkMailbox(v.email, mailServers, v)
+	result.Provenance = smtpProvenance(result)
+	return c.fallback(v, c.smtpStageDone(v.email, mailServers, stageStart, result, err)), nil
+}
+
+// prepare runs every stage before the SMTP check. It returns the domain's
+// mail servers, or the result to end validation with if it ends early.
+func (c *Client) prepare(recipientEmail string, v *validation) ([]string, *ValidationResult) {
+	// Syntax validation and normalization
+	stageStart := time.Now()
+	var result *ValidationResult
+	address, err := ParseAddress(recipientEmail)
+	email := address.Email
+	if err != nil {
+		email = recipientEmail
+		result = &ValidationResult{
+			Verdict:      VerdictUndeliverable,
+			SubStatus:    SubStatusInvalidFormat,
 			IsValid:      false,
-			ErrorMessage: "Invalid email format",
-		}, nil
+			ErrorMessage: "Invalid email format: " + err.Error(),
+		}
+	} else {
+		v.email = email
+		v.displayName = address.Name
+	}
+	event := StageEvent{Email: email, Stage: StageSyntax, Duration: time.Since(stageStart), Result: result, Err: err}
+	if result = c.finishStage(event); result != nil {
+		return nil, result
 	}
 
-	domain := parts[1]
+	domain := emailDomain(email)
 	// fmt.Printf("Validating email domain: %s\n", domain)
 
-	// Check MX records
-	mailServers, err := c.GetMailServers(domain)
-	if err != nil {
-		return &ValidationResult{
+	if result = c.runStages(StageMX, email, nil, v); result != nil {
+		return nil, result
+	}
+
+	stageStart = time.Now()
+	mailServers, result, err := c.checkMX(domain, v)
+	v.mailServers = mailServers
+	event = StageEvent{Email: email, Stage: StageMX, Duration: time.Since(stageStart), MailServers: mailServers, Result: result, Err: err}
+	if result = c.finishStage(event); result != nil {
+		return nil, result
+	}
+
+	if result = c.runStages(StageSMTP, email, mailServers, v); result != nil {
+		return nil, result
+	}
+
+	// Protected domains are answered from the cache or rules, whatever the input
+	if result = c.protectedResult(email, domain, v); result != nil {
+		return nil, result
+	}
+
+	// A check of a provider known to accept everyone, or to refuse checks, would mislead
+	if result = c.acceptAllResult(domain, mailServers); result != nil {
+		return nil, result
+	}
+
+	if c.mode != ModeFull {
+		return nil, &ValidationResult{
+			Verdict:      VerdictUnknown,
+			SubStatus:    SubStatusSkipped,
+			IsValid:      false,
+			HasMX:        true,
+			ErrorMessage: fmt.Sprintf("SMTP check skipped in %s mode", c.mode),
+		}
+	}
+	if result = c.budgetResult(domain); result != nil {
+		return nil, result
+	}
+	return mailServers, nil
+}
+
+// smtpStageDone reports the outcome of the SMTP stage to the hooks and
+// returns the result validation ends with, warning about a HELO name
+// without matching forward and reverse DNS.
+func (c *Client) smtpStageDone(email string, mailServers []string, stageStart time.Time, result *ValidationResult, err error) *ValidationResult {
+	if result != nil {
+		if warning := c.heloWarning(); warning != "" {
+			result.Warnings = append(result.Warnings, warning)
+		}
+	}
+	event := StageEvent{Email: email, Stage: StageSMTP, Duration: time.Since(stageStart), MailServers: mailServers, Result: result, Err: err}
+	return c.finishStage(event)
+}
+
+// checkMX looks up the mail servers for domain. If validation should end
+// there, it returns the result to end with, along with the lookup error if any.
+func (c *Client) checkMX(domain string, v *validation) ([]string, *ValidationResult, error) {
+	// Offline, MX records can only come from the cache
+	if c.mode == ModeOffline {
+		if _, _, ok := c.mxCache.get(domain); !ok {
+			return nil, &ValidationResult{
+				Verdict:      VerdictUnknown,
+				SubStatus:    SubStatusSkipped,
+				IsValid:      false,
+				ErrorMessage: "MX records not checked in offline mode",
+			}, nil
+		}
+	}
+
+	// Check MX records, retrying temporary DNS failures
+	var mailServers []string
+	err := c.withRetry(v.deadline, func(attempt int) error {
+		dnsStart := time.Now()
+		var err error
+		mailServers, err = c.GetMailServers(domain)
+		v.timings.DNS += time.Since(dnsStart)
+		return err
+	})
+	if len(c.mxResolvers) > 0 && c.mode != ModeOffline {
+		dnsStart := time.Now()
+		v.mxConsistency = c.mxConsistency(domain, mailServers, err)
+		v.timings.DNS += time.Since(dnsStart)
+	}
+	if err != nil && classifyError(err) == ErrorClassDNS {
+		// The lookup kept failing, which says nothing about the domain itself
+		return nil, &ValidationResult{
+			Verdict:      VerdictUnknown,
+			SubStatus:    SubStatusDNSError,
+			IsValid:      false,
+			HasMX:        false,
+			ErrorMessage: err.Error(),
+		}, err
+	}
+	if err != nil || len(mailServers) == 0 {
+		return nil, &ValidationResult{
+			Verdict:      VerdictUndeliverable,
+			SubStatus:    SubStatusNoMX,
 			IsValid:      false,
 			HasMX:        false,
 			ErrorMessage: "No MX records found",
-		}, nil
+		}, err
 	}
+	return mailServers, nil, nil
+}
+
+// checkMailbox asks the mail servers in turn whether they accept the
+// recipient, adding the time spent in each step to the validation's timings.
+// It returns the outcome, along with the last error if no server gave a
+// definite answer.
+func (c *Client) checkMailbox(recipientEmail string, mailServers []string, v *validation) (*ValidationResult, error) {
+	timings := &v.timings
 
 	// Get hostname for HELO
 	localName, err := c.GetHostname()
 	if err != nil {
 		return &ValidationResult{
+			Verdict:      VerdictUnknown,
+			SubStatus:    SubStatusSMTPError,
 			IsValid:      false,
 			HasMX:        true,
 			ErrorMessage: err.Error(),
-		}, nil
+		}, err
 	}
 	// fmt.Printf("Using hostname for HELO: %s\n", localName)
 
-	// Try each mail server
+	// Try each mail server. A deferral is kept as the answer in case no other
+	// server gives a definite one.
 	var lastErr error
-	for _, mailServer := range mailServers {
-		smtpServer, err := c.GetSMTPServer(mailServer)
-		if err != nil {
-			lastErr = err
+	var deferred *ValidationResult
+	bogons := 0
+	for _, mailServer := range c.orderMailServers(mailServers) {
+		if v.expired() {
+			lastErr = ErrValidationTimeout
+			break
+		}
+		if c.tarpitting(mailServer) {
+			lastErr = ErrTarpit
 			continue
 		}
+		serverStart := time.Now()
+
+		// Bulk runs can skip port discovery while a session to this server is pooled
+		var smtpServer *SMTPDetails
+		if v.reuse {
+			smtpServer = c.sessions.server(mailServer, false)
+		}
+		if smtpServer == nil {
+			err = c.withRetry(v.deadline, func(attempt int) error {
+				var err error
+				smtpServer, err = c.getSMTPServer(mailServer, timings, v.deadline)
+				return err
+			})
+			if errors.Is(err, ErrBogonMX) {
+				bogons++
+			} else if err != nil {
+				c.recordMX(mailServer, false, time.Since(serverStart))
+			}
+			if err != nil {
+				lastErr = err
+				continue
+			}
+		}
 
 		// fmt.Printf("Trying mail server: %s\n", mailServer)
 		// fmt.Printf("SMTP server details: %+v\n", smtpServer)
 
-		// try connecting with TLS
-		result, err := c.TryConnectingSMTP(smtpServer, recipientEmail, localName, false)
-		if err == nil {
-			result.SMTPDetails = smtpServer
-			return result, nil
+		release, err := c.politely(mailServer, v.deadline)
+		if err != nil {
+			lastErr = err
+			continue
 		}
-		// fmt.Printf("Validation attempt without TLS failed for server %s: %v\n", mailServer, err)
-		// fmt.Println("trying to connect with TLS...")
 
-		// Try connecting with TLS
-		result, err = c.TryConnectingSMTP(smtpServer, recipientEmail, localName, true)
+		// The first attempt is made without TLS, retries upgrade with STARTTLS
+		var result *ValidationResult
+		err = c.withRetry(v.deadline, func(attempt int) error {
+			var err error
+			result, err = c.tryConnectingSMTP(smtpServer, c.mailFrom(v, mailServer), recipientEmail, localName, attempt > 1, v.reuse, v.deadline)
+			timings.add(result.Timings)
+			return err
+		})
+		release()
+		c.recordMX(mailServer, err == nil || result.Verdict != "", time.Since(serverStart))
+		if errors.Is(err, ErrTarpit) {
+			c.markTarpit(mailServer)
+		}
 		if err == nil {
 			result.SMTPDetails = smtpServer
 			return result, nil
 		}
+		if result.Verdict != "" {
+			result.SMTPDetails = smtpServer
+			deferred = result
+		}
 
-		// fmt.Printf("Validation attempt with TLS failed for server %s: %v\n", mailServer, err)
+		// fmt.Printf("Validation attempt failed for server %s: %v\n", mailServer, err)
 
 		lastErr = err
 	}
 
+	if deferred != nil {
+		return deferred, lastErr
+	}
+
+	if errors.Is(lastErr, ErrTarpit) {
+		// Waiting out the server would have used up the time for nothing
+		return tarpitResult(), lastErr
+	}
+
+	if v.expired() {
+		// Whatever went wrong last, time ran out before any server answered
+		return timedOutResult(), ErrValidationTimeout
+	}
+
+	if bogons == len(mailServers) {
+		// Nothing on the internet can deliver here, which spam traps rely on
+		return &ValidationResult{
+			Verdict:      VerdictUndeliverable,
+			SubStatus:    SubStatusBogonMX,
+			IsValid:      false,
+			HasMX:        true,
+			ErrorMessage: lastErr.Error(),
+		}, nil
+	}
+
 	return &ValidationResult{
+		Verdict:      VerdictUnknown,
+		SubStatus:    SubStatusSMTPError,
 		IsValid:      false,
 		HasMX:        true,
 		ErrorMessage: lastErr.Error(),
-	}, nil
+	}, lastErr
 }
 
 // Helper function to format validation results
@@ -282,21 +856,58 @@ func (c *Client) ValidateEmail(recipientEmail string) (*ValidationResult, error)
 //
 // Returns:
 //
-//	A formatted string summarizing the validation results, including the email address, validation status,
-//	presence of MX records, catch-all status, and any error message.
+//	A formatted string summarizing the validation results, including the email address, verdict,
+//	presence of MX records, catch-all, disposable and role status, and any error message.
+//	If WithResultTemplate was given, the result is rendered with that template instead,
+//	falling back to the built-in report if the template fails to execute.
 func (c *Client) FormatValidationResult(recipientEmail string, result *ValidationResult) string {
-	status := "INVALID"
-	if result.IsValid {
-		status = "VALID"
-	}
-
-	return fmt.Sprintf(`
-Email Validation Results for %s:
-Status: %s
-Has MX Records: %v
-Catch-All: %v
-Details: %s
-`, recipientEmail, status, result.HasMX, result.IsCatchAll, result.ErrorMessage)
+	if out, err := c.RenderValidationResult(recipientEmail, result); err == nil {
+		return out
+	}
+	return c.defaultValidationResult(recipientEmail, result)
+}
+
+// defaultValidationResult is the built-in English report FormatValidationResult renders.
+func (c *Client) defaultValidationResult(recipientEmail string, result *ValidationResult) string {
+	verdict := string(result.Verdict)
+	if result.SubStatus != "" {
+		verdict += fmt.Sprintf(" (%s)", result.SubStatus)
+	}
+
+	catchAll := fmt.Sprint(result.IsCatchAll)
+	if result.CatchAllConfidence != "" {
+		catchAll += fmt.Sprintf(" (%s confidence)", result.CatchAllConfidence)
+	}
+
+	hasMX := fmt.Sprint(result.HasMX)
+	if result.MXConsistency != nil && !result.MXConsistency.Consistent {
+		hasMX += fmt.Sprintf(" (resolvers disagree, used %s)", result.MXConsistency.Used)
+	}
+
+	out := fmt.Sprintf(`
+%s %s:
+%s: %s
+%s: %s
+%s: %s
+%s: %s
+%s: %v
+%s: %v
+%s: %s
+`, c.translate("Email Validation Results for"), recipientEmail,
+		c.translate("Verdict"), verdict,
+		c.translate("Confidence"), result.Confidence,
+		c.translate("Has MX Records"), hasMX,
+		c.translate("Catch-All"), catchAll,
+		c.translate("Disposable"), result.IsDisposable,
+		c.translate("Role Account"), result.IsRoleAccount,
+		c.translate("Details"), result.ErrorMessage)
+	if result.RetryAfter > 0 {
+		out += fmt.Sprintf("%s: %v\n", c.translate("Retry After"), result.RetryAfter)
+	}
+	for _, warning := range result.Warnings {
+		out += fmt.Sprintf("%s: %s\n", c.translate("Warning"), warning)
+	}
+	return out
 }
 
 // ExtractDomainFromEmailAddress extracts the domain part from the given email address.
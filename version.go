@@ -0,0 +1,85 @@
+package mailify
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// These are set when building releases, e.g.
+//
+//	go build -ldflags "-X github.com/adarsh-jaiss/mailify.version=v1.2.0 \
+//	  -X github.com/adarsh-jaiss/mailify.commit=abc1234 \
+//	  -X github.com/adarsh-jaiss/mailify.date=2024-05-01T12:00:00Z"
+//
+// Left empty, they are read from the build info Go embeds in binaries.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// BuildInfo describes the mailify build that is running.
+type BuildInfo struct {
+	// Version is the mailify version, "(devel)" for local builds.
+	Version string `json:"version"`
+	// Commit is the commit it was built from, if known.
+	Commit string `json:"commit,omitempty"`
+	// Date is when it was built, or else when that commit was made, if known.
+	Date string `json:"date,omitempty"`
+	// GoVersion is the Go version it was built with.
+	GoVersion string `json:"go_version"`
+}
+
+// Version returns the mailify version that is running, as set when building
+// the release or else as recorded in the binary's build info.
+func Version() string {
+	return ReadBuildInfo().Version
+}
+
+// ReadBuildInfo returns the version, commit and build date of the mailify
+// build that is running.
+func ReadBuildInfo() BuildInfo {
+	build := BuildInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		info = nil
+	}
+	if build.Version == "" {
+		build.Version = moduleVersion(info)
+	}
+	// VCS details are those of the main module, so only mailify's own
+	if info != nil && info.Main.Path == modulePath {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && build.Commit == "":
+				build.Commit = setting.Value
+			case setting.Key == "vcs.time" && build.Date == "":
+				build.Date = setting.Value
+			}
+		}
+	}
+	return build
+}
+
+// userAgent identifies mailify in the HTTP requests it makes and the
+// responses it serves.
+func userAgent() string {
+	return "mailify/" + Version()
+}
+
+// moduleVersion returns the version of this module recorded in the running
+// binary's build info, nil if it has none.
+func moduleVersion(info *debug.BuildInfo) string {
+	if info == nil {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
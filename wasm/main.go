@@ -0,0 +1,90 @@
+//go:build js && wasm
+
+// Command wasm exposes mailify's validation to JavaScript, for instant
+// feedback on signup forms with the same checks the server runs. Build it
+// with
+//
+//	GOOS=js GOARCH=wasm go build -o mailify.wasm ./wasm
+//
+// and load it with the wasm_exec.js of the Go release that built it. It
+// sets globalThis.mailify to an object with two functions:
+//
+//	mailify.checkSyntax(email)  {valid, normalized, error}, at once
+//	mailify.validate(email)     a Promise of the validation result, as JSON
+//	                            results are encoded, with the domain's MX
+//	                            records looked up over DNS-over-HTTPS
+//
+// Mailboxes aren't checked, as browsers can't open SMTP connections.
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"time"
+
+	"github.com/adarsh-jaiss/mailify"
+)
+
+func main() {
+	client, err := mailify.NewClient("", mailify.WithoutSenderCheck(), mailify.WithMXCache(time.Hour))
+	if err != nil {
+		panic(err)
+	}
+
+	js.Global().Set("mailify", js.ValueOf(map[string]any{
+		"checkSyntax": js.FuncOf(func(this js.Value, args []js.Value) any {
+			normalized, err := mailify.NormalizeEmail(stringArg(args))
+			if err != nil {
+				return map[string]any{"valid": false, "normalized": "", "error": err.Error()}
+			}
+			return map[string]any{"valid": true, "normalized": normalized, "error": ""}
+		}),
+		"validate": js.FuncOf(func(this js.Value, args []js.Value) any {
+			email := stringArg(args)
+			return newPromise(func() (any, error) {
+				// Lookups block on fetch, which needs the event loop,
+				// so validation can't run in the calling goroutine
+				result, err := client.ValidateEmail(email)
+				if err != nil {
+					return nil, err
+				}
+				encoded, err := json.Marshal(result)
+				if err != nil {
+					return nil, err
+				}
+				return js.Global().Get("JSON").Call("parse", string(encoded)), nil
+			})
+		}),
+	}))
+
+	select {}
+}
+
+// stringArg returns the first argument of a call as a string, empty if
+// there is none.
+func stringArg(args []js.Value) string {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return ""
+	}
+	return args[0].String()
+}
+
+// newPromise returns a JavaScript Promise settled by running fn in a
+// goroutine: resolved with its value, or rejected with an Error of its error.
+func newPromise(fn func() (any, error)) js.Value {
+	var executor js.Func
+	executor = js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			defer executor.Release()
+			value, err := fn()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(value)
+		}()
+		return nil
+	})
+	return js.Global().Get("Promise").New(executor)
+}
@@ -0,0 +1,220 @@
+package mailify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WatchOptions configures WatchDirectory.
+type WatchOptions struct {
+	// OutputDir is where processed files are moved to, with their results.
+	// Required.
+	OutputDir string
+	// Interval is how often the directory is checked for new files. The
+	// default is 5 seconds.
+	Interval time.Duration
+	// Webhook, if set, is a URL that a WatchEvent is POSTed to as JSON after
+	// each file is processed.
+	Webhook string
+	// HTTPClient sends the webhook requests, a client with a 10 second
+	// timeout if nil.
+	HTTPClient *http.Client
+	// Bulk configures how each file is processed, as for
+	// ProcessAndValidateEmailsViaFiles.
+	Bulk BulkOptions
+	// OnProcessed, if set, is called after each file is processed.
+	OnProcessed func(WatchEvent)
+}
+
+// WatchEvent reports on a file processed by WatchDirectory.
+type WatchEvent struct {
+	// File is the path the file was dropped at.
+	File string `json:"file"`
+	// Output is the path of the results: the file itself, moved to the output
+	// directory, or for a ZIP archive the archive written next to it.
+	Output string `json:"output"`
+	// Summary counts the results.
+	Summary BulkSummary `json:"summary"`
+	// Error describes why processing failed, if it did. Archives may fail in
+	// part, and still have a summary.
+	Error string `json:"error,omitempty"`
+	// ProcessedAt is when processing finished.
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// WatchDirectory watches a directory for Excel, CSV and ZIP files dropped into
+// it and processes each, as ProcessAndValidateEmailsViaFiles does, until ctx
+// is done. A file is picked up once its size and modification time have
+// stopped changing between two checks, so files still being copied in are
+// left alone. It is moved to the output directory before it is processed, so
+// the results end up there and the watched directory only ever holds files
+// waiting to be processed. A file already in the output directory under the
+// same name is not overwritten; the new one gets a timestamp in its name.
+//
+// Parameters:
+//   - ctx: Stops watching when done. Files being processed are finished first.
+//   - dir: The directory to watch.
+//   - opts: Where to write the results, and how to process and report on files.
+//
+// Returns:
+//   - error: An error if the directories can't be read or created, otherwise
+//     nil once ctx is done. Files that fail to process are reported in their
+//     WatchEvent instead.
+func (c *Client) WatchDirectory(ctx context.Context, dir string, opts WatchOptions) error {
+	if opts.OutputDir == "" {
+		return errors.New("output directory is required")
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	// seen holds the size and modification time of each file at the last
+	// check, and stuck those of files that couldn't be moved out of the
+	// directory, which are left alone until they change
+	seen := make(map[string]string)
+	stuck := make(map[string]string)
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+
+		current := make(map[string]string)
+		for _, entry := range entries {
+			if entry.IsDir() || !isListFile(entry.Name()) || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			state := fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano())
+			if stuck[path] == state {
+				continue
+			}
+			if seen[path] != state {
+				// New or still changing, check again next time
+				current[path] = state
+				continue
+			}
+
+			event := c.processDropped(path, opts)
+			if event.Output == "" {
+				stuck[path] = state
+			}
+			if opts.OnProcessed != nil {
+				opts.OnProcessed(event)
+			}
+			if opts.Webhook != "" {
+				if err := postWatchEvent(ctx, opts.HTTPClient, opts.Webhook, event); err != nil {
+					fmt.Printf("Warning: webhook for %s failed: %v\n", path, err)
+				}
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+		seen = current
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDropped moves a file dropped into the watched directory to the
+// output directory and processes it there.
+func (c *Client) processDropped(path string, opts WatchOptions) WatchEvent {
+	start := time.Now()
+	event := WatchEvent{File: path}
+	output, err := moveToOutput(path, opts.OutputDir)
+	if err != nil {
+		event.Error = err.Error()
+		event.ProcessedAt = time.Now()
+		return event
+	}
+	event.Output = output
+	if strings.EqualFold(filepath.Ext(output), ".zip") {
+		event.Output = strings.TrimSuffix(output, filepath.Ext(output)) + ".validated.zip"
+	}
+
+	fmt.Printf("\n=== Processing %s ===\n", path)
+	event.Summary, err = c.processFile(output, opts.Bulk)
+	if err != nil {
+		fmt.Printf("ERROR: %s: %v\n", path, err)
+		event.Error = err.Error()
+	}
+	if event.Summary.Files > 0 {
+		event.Summary.print("Summary for " + path)
+	}
+	event.ProcessedAt = time.Now()
+	notify(opts.Bulk.Notifiers, newJobReport("Validation of "+filepath.Base(path), start, event.Summary, event.Output, err))
+	return event
+}
+
+// moveToOutput moves a file into dir, adding a timestamp to its name if a
+// file of the same name is already there.
+func moveToOutput(path, dir string) (string, error) {
+	name := filepath.Base(path)
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(dir, fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102T150405"), ext))
+	}
+
+	if err := os.Rename(path, target); err == nil {
+		return target, nil
+	}
+
+	// The directories may be on different file systems
+	if err := copyFile(path, target); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", path, dir, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", path, dir, err)
+	}
+	return target, nil
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// postWatchEvent POSTs an event to a webhook as JSON.
+func postWatchEvent(ctx context.Context, client *http.Client, url string, event WatchEvent) error {
+	// Report on the file even if watching is being stopped
+	return postJSON(context.WithoutCancel(ctx), client, url, event)
+}